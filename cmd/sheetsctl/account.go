@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	oauth2api "google.golang.org/api/oauth2/v2"
+)
+
+// accountScopes are added to `SCOPES` by `SHOW_ACCOUNT`/`EXPECTED_ACCOUNT`,
+// to be able to look up the authorized account's email.
+var accountScopes = []string{"openid", oauth2api.UserinfoEmailScope}
+
+// withAccountScopes returns the `scopes` along with the `accountScopes` they
+// don't already have.
+func withAccountScopes(scopes []string) []string {
+	for _, accountScope := range accountScopes {
+		found := false
+		for _, scope := range scopes {
+			if scope == accountScope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			scopes = append(scopes, accountScope)
+		}
+	}
+	return scopes
+}
+
+// checkAccount prints the authorized account, and fails the run before any
+// data is accessed when it isn't `EXPECTED_ACCOUNT`.
+func (p Project) checkAccount() {
+	email, err := authorizedAccount(p.client)
+	if err != nil {
+		fatalf("Unable to retrieve the authorized account (a token authorized before SHOW_ACCOUNT/EXPECTED_ACCOUNT lacks the email scope, delete %s to authorize again): %v", tokenFile, err)
+	}
+	p.summary.Account = email
+	fmt.Printf("Authorized account: %s\n", email)
+	if !isExpectedAccount(email, p.config.ExpectedAccount) {
+		fatalf("Authorized as %s, but EXPECTED_ACCOUNT is %s: delete %s to authorize again with %s, or switch to the config/profile meant for %s", email, p.config.ExpectedAccount, tokenFile, p.config.ExpectedAccount, email)
+	}
+}
+
+// isExpectedAccount reports whether `email` is the `expected` account, which
+// is any account when `expected` is empty; emails are case-insensitive.
+func isExpectedAccount(email, expected string) bool {
+	return expected == "" || strings.EqualFold(email, expected)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestWithAccountScopes(t *testing.T) {
+	const email = "https://www.googleapis.com/auth/userinfo.email"
+	const readonly = "https://www.googleapis.com/auth/spreadsheets.readonly"
+	tests := []struct {
+		scopes, want []string
+	}{
+		{[]string{readonly}, []string{readonly, "openid", email}},
+		{nil, []string{"openid", email}},
+		// scopes already there aren't repeated
+		{[]string{email, readonly}, []string{email, readonly, "openid"}},
+		{[]string{"openid", email}, []string{"openid", email}},
+	}
+	for _, tt := range tests {
+		scopes := append([]string(nil), tt.scopes...)
+		if got := withAccountScopes(scopes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withAccountScopes(%q) = %q, want %q", tt.scopes, got, tt.want)
+		}
+	}
+}
+
+func TestIsExpectedAccount(t *testing.T) {
+	tests := []struct {
+		email, expected string
+		want            bool
+	}{
+		{"ann@example.com", "", true},
+		{"ann@example.com", "ann@example.com", true},
+		{"Ann@Example.com", "ann@example.COM", true},
+		{"bob@example.com", "ann@example.com", false},
+		{"", "ann@example.com", false},
+	}
+	for _, tt := range tests {
+		if got := isExpectedAccount(tt.email, tt.expected); got != tt.want {
+			t.Errorf("isExpectedAccount(%q, %q) = %v, want %v", tt.email, tt.expected, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorizedAccount(t *testing.T) {
+	tests := []struct {
+		userinfo cannedResponse
+		want     string
+		wantErr  bool
+	}{
+		{cannedResponse{http.StatusOK, `{"email": "ann@example.com"}`}, "ann@example.com", false},
+		// a token authorized without the email scope
+		{cannedResponse{http.StatusUnauthorized, `{"error": {"code": 401, "message": "Request is missing required authentication credential."}}`}, "", true},
+	}
+	for _, tt := range tests {
+		client := &http.Client{Transport: cannedTransport{"/oauth2/v2/userinfo": tt.userinfo}}
+		got, err := authorizedAccount(client)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("authorizedAccount with userinfo %d = %q, %v, want %q, an error %v", tt.userinfo.status, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
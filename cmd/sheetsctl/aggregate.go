@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// `AGGREGATES` functions; `count` counts rows (or the non-empty cells of its
+// column, when given one), the others only take the numeric cells of their
+// column into account.
+const (
+	aggregateCount = "count"
+	aggregateSum   = "sum"
+	aggregateAvg   = "avg"
+	aggregateMin   = "min"
+	aggregateMax   = "max"
+)
+
+// `AGGREGATE_FORMAT` values.
+const (
+	aggregateFormatTable = "table"
+	aggregateFormatCSV   = "csv"
+	aggregateFormatJSON  = "json"
+)
+
+// aggregate is one of the `AGGREGATES`, e.g. "avg:GPA".
+type aggregate struct {
+	Func   string
+	Column string
+}
+
+// Name is the aggregate's output column, e.g. "avg(GPA)".
+func (a aggregate) Name() string {
+	if a.Column == "" {
+		return a.Func
+	}
+	return fmt.Sprintf("%s(%s)", a.Func, a.Column)
+}
+
+// parseAggregates parses a comma-separated list of "function:column" (the
+// column is optional for `count`), e.g. "count,avg:GPA,sum:Credits".
+func parseAggregates(s string) ([]aggregate, error) {
+	aggregates := []aggregate{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		a := aggregate{Func: strings.ToLower(part)}
+		if i := strings.Index(part, ":"); i != -1 {
+			a = aggregate{Func: strings.ToLower(strings.TrimSpace(part[:i])), Column: strings.TrimSpace(part[i+1:])}
+		}
+		switch a.Func {
+		case aggregateCount:
+		case aggregateSum, aggregateAvg, aggregateMin, aggregateMax:
+			if a.Column == "" {
+				return nil, fmt.Errorf("%q needs a column, e.g. \"%s:GPA\"", part, a.Func)
+			}
+		default:
+			return nil, fmt.Errorf("unknown aggregate %q", part)
+		}
+		aggregates = append(aggregates, a)
+	}
+	return aggregates, nil
+}
+
+// accumulator holds the running values of an aggregate for a group.
+type accumulator struct {
+	count    int
+	sum      float64
+	min, max float64
+}
+
+func (a *accumulator) Add(n float64) {
+	if a.count == 0 || n < a.min {
+		a.min = n
+	}
+	if a.count == 0 || n > a.max {
+		a.max = n
+	}
+	a.count++
+	a.sum += n
+}
+
+// Value returns the accumulated value of the aggregate function `f`, or an
+// empty string when there were no values.
+func (a *accumulator) Value(f string) string {
+	if f == aggregateCount {
+		return strconv.Itoa(a.count)
+	}
+	if a.count == 0 {
+		return ""
+	}
+	var n float64
+	switch f {
+	case aggregateSum:
+		n = a.sum
+	case aggregateAvg:
+		n = a.sum / float64(a.count)
+	case aggregateMin:
+		n = a.min
+	case aggregateMax:
+		n = a.max
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// aggregateGroup is a distinct combination of `GROUP_BY` values.
+type aggregateGroup struct {
+	values       []string
+	accumulators []accumulator
+}
+
+// aggregatingEmitter accumulates the rows by `GROUP_BY` instead of passing
+// them on, and writes a row per group when closed (as porcelain rows with
+// `--porcelain`); memory is proportional to the number of groups.
+type aggregatingEmitter struct {
+	Emitter
+	p          Project
+	groupBy    []string
+	aggregates []aggregate
+	groups     map[string]*aggregateGroup
+}
+
+func newAggregatingEmitter(output Emitter, p Project, groupBy []string, aggregates []aggregate) *aggregatingEmitter {
+	return &aggregatingEmitter{Emitter: output, p: p, groupBy: groupBy, aggregates: aggregates, groups: map[string]*aggregateGroup{}}
+}
+
+// Header is only emitted on close, with the aggregated columns.
+func (e *aggregatingEmitter) Header(columns []string) {}
+
+func (e *aggregatingEmitter) Row(fields map[string]interface{}) error {
+	if e.p.rejects() {
+		// the row is rejected before any of its values is accumulated
+		if errs := e.nonNumeric(fields); len(errs) > 0 {
+			return &rowRejectedError{Errors: errs}
+		}
+	}
+	values := make([]string, len(e.groupBy))
+	for i, column := range e.groupBy {
+		if v, ok := fields[column]; ok && v != nil {
+			values[i] = fmt.Sprint(v)
+		}
+	}
+	key := strings.Join(values, "\x00")
+	group, ok := e.groups[key]
+	if !ok {
+		group = &aggregateGroup{values: values, accumulators: make([]accumulator, len(e.aggregates))}
+		e.groups[key] = group
+	}
+	for i, a := range e.aggregates {
+		if a.Column == "" {
+			group.accumulators[i].count++
+			continue
+		}
+		// NOTE: empty cells may be in `fields` (see `COLUMN_OPTIONS`).
+		v, ok := fields[a.Column]
+		if !ok || v == nil || v == "" {
+			continue
+		}
+		if a.Func == aggregateCount {
+			group.accumulators[i].count++
+			continue
+		}
+		n, ok := e.p.format.ParseNumber(fmt.Sprint(v))
+		if !ok || math.IsNaN(n) {
+			if e.p.config.Strict {
+				return fmt.Errorf("non-numeric value %q in column %q for %s", v, a.Column, a.Name())
+			}
+			e.p.warnAt(Warning{Code: warnNonNumeric, Column: a.Column, Message: fmt.Sprintf("Skipping non-numeric value %q in column %q for %s", v, a.Column, a.Name())})
+			continue
+		}
+		group.accumulators[i].Add(n)
+	}
+	return nil
+}
+
+// nonNumeric returns the errors of the row's values that can't be used by the
+// numeric aggregates.
+func (e *aggregatingEmitter) nonNumeric(fields map[string]interface{}) []string {
+	errs := []string{}
+	for _, a := range e.aggregates {
+		if a.Column == "" || a.Func == aggregateCount {
+			continue
+		}
+		v, ok := fields[a.Column]
+		if !ok || v == nil || v == "" {
+			continue
+		}
+		if n, ok := e.p.format.ParseNumber(fmt.Sprint(v)); !ok || math.IsNaN(n) {
+			errs = append(errs, fmt.Sprintf("non-numeric value %q in column %q for %s", v, a.Column, a.Name()))
+		}
+	}
+	return errs
+}
+
+// Close writes the groups, ordered by their `GROUP_BY` values.
+func (e *aggregatingEmitter) Close() error {
+	groups := make([]*aggregateGroup, 0, len(e.groups))
+	for _, group := range e.groups {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		for k := range groups[i].values {
+			if groups[i].values[k] != groups[j].values[k] {
+				return groups[i].values[k] < groups[j].values[k]
+			}
+		}
+		return false
+	})
+	columns := append([]string{}, e.groupBy...)
+	for _, a := range e.aggregates {
+		columns = append(columns, a.Name())
+	}
+	rows := make([][]string, len(groups))
+	for i, group := range groups {
+		rows[i] = append([]string{}, group.values...)
+		for j, a := range e.aggregates {
+			rows[i] = append(rows[i], group.accumulators[j].Value(a.Func))
+		}
+	}
+	fmt.Printf("\naggregated %d groups\n", len(groups))
+	if e.p.porcelain != nil {
+		e.p.porcelain.Emit(porcelainEvent{Type: eventHeader, Columns: columns})
+		for _, row := range rows {
+			e.p.summary.Rows++
+			e.p.porcelain.Emit(porcelainEvent{Type: eventRow, Fields: aggregateFields(columns, row)})
+		}
+		return e.Emitter.Close()
+	}
+	e.p.summary.Rows += len(rows)
+	if e.p.config.AggregateFormat == aggregateFormatCSV && e.p.config.SanitizeFormulas {
+		rows = e.p.sanitizeRows(columns, rows)
+	}
+	out := e.p.dataOut
+	if out == nil {
+		out = os.Stdout
+	}
+	if err := writeAggregates(out, e.p.config.AggregateFormat, e.p.csvDialect, columns, rows); err != nil {
+		return fmt.Errorf("unable to write aggregates: %w", err)
+	}
+	return e.Emitter.Close()
+}
+
+// aggregateFields maps the `columns` to the `row` values, leaving out the
+// empty ones like the sheet rows.
+func aggregateFields(columns, row []string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for i, column := range columns {
+		if row[i] != "" {
+			fields[column] = row[i]
+		}
+	}
+	return fields
+}
+
+// writeAggregates writes the aggregated `rows` to `out` in the `format`, CSV
+// being written in the `dialect`.
+func writeAggregates(out io.Writer, format string, dialect csvDialect, columns []string, rows [][]string) error {
+	switch format {
+	case aggregateFormatCSV:
+		w, err := dialect.NewWriter(out)
+		if err != nil {
+			return err
+		}
+		w.Write(columns)
+		w.WriteAll(rows)
+		return w.Error()
+	case aggregateFormatJSON:
+		objects := make([]map[string]interface{}, len(rows))
+		for i, row := range rows {
+			objects[i] = aggregateFields(columns, row)
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(objects)
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(columns, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
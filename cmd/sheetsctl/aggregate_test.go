@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAggregates(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    []aggregate
+		wantErr string
+	}{
+		{"count", []aggregate{{Func: "count"}}, ""},
+		{"count, AVG : GPA,sum:Credits", []aggregate{{Func: "count"}, {Func: "avg", Column: "GPA"}, {Func: "sum", Column: "Credits"}}, ""},
+		{"count:Email", []aggregate{{Func: "count", Column: "Email"}}, ""},
+		{"avg", nil, `"avg" needs a column`},
+		{"median:GPA", nil, `unknown aggregate "median:GPA"`},
+		{"", nil, `unknown aggregate ""`},
+	}
+	for _, tt := range tests {
+		got, err := parseAggregates(tt.s)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseAggregates(%q) error = %v, want %q", tt.s, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAggregates(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestAccumulatorValue(t *testing.T) {
+	tests := []struct {
+		values []float64
+		f      string
+		want   string
+	}{
+		{[]float64{3, -1, 4.5}, aggregateCount, "3"},
+		{[]float64{3, -1, 4.5}, aggregateSum, "6.5"},
+		{[]float64{3, -1, 4}, aggregateAvg, "2"},
+		{[]float64{3, -1, 4.5}, aggregateMin, "-1"},
+		{[]float64{3, -1, 4.5}, aggregateMax, "4.5"},
+		// the first value sets the minimum and maximum, even when not 0-bound
+		{[]float64{5}, aggregateMin, "5"},
+		{[]float64{-5}, aggregateMax, "-5"},
+		{nil, aggregateCount, "0"},
+		{nil, aggregateAvg, ""},
+	}
+	for _, tt := range tests {
+		var a accumulator
+		for _, n := range tt.values {
+			a.Add(n)
+		}
+		if got := a.Value(tt.f); got != tt.want {
+			t.Errorf("%s of %v = %q, want %q", tt.f, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestAggregatingEmitter(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"Major": "Math", "GPA": "3.5", "Email": "ann@example.com"},
+		{"Major": "Art", "GPA": "2.5"},
+		{"Major": "Math", "GPA": "n/a", "Email": ""},
+		{"Major": "Math", "GPA": "4.5", "Email": "bob@example.com"},
+		{"GPA": "1"},
+	}
+	aggregates, err := parseAggregates("count,count:Email,avg:GPA,max:GPA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	p := Project{summary: &runSummary{}, porcelain: newPorcelainWriter(&b)}
+	e := newAggregatingEmitter(discardEmitter{}, p, []string{"Major"}, aggregates)
+	for _, fields := range rows {
+		if err := e.Row(fields); err != nil {
+			t.Fatalf("Row(%v): %v", fields, err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// the groups are ordered by their values, the group without a major first
+	want := []string{
+		`{"type":"warning","protocol_version":1,"message":"Skipping non-numeric value \"n/a\" in column \"GPA\" for avg(GPA)","code":"non_numeric","column":"GPA"}`,
+		`{"type":"warning","protocol_version":1,"message":"Skipping non-numeric value \"n/a\" in column \"GPA\" for max(GPA)","code":"non_numeric","column":"GPA"}`,
+		`{"type":"header","protocol_version":1,"columns":["Major","count","count(Email)","avg(GPA)","max(GPA)"]}`,
+		`{"type":"row","protocol_version":1,"fields":{"avg(GPA)":"1","count":"1","count(Email)":"0","max(GPA)":"1"}}`,
+		`{"type":"row","protocol_version":1,"fields":{"Major":"Art","avg(GPA)":"2.5","count":"1","count(Email)":"0","max(GPA)":"2.5"}}`,
+		`{"type":"row","protocol_version":1,"fields":{"Major":"Math","avg(GPA)":"4","count":"3","count(Email)":"2","max(GPA)":"4.5"}}`,
+	}
+	if got := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("events =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if p.summary.Rows != 3 {
+		t.Errorf("summary rows = %d, want 3", p.summary.Rows)
+	}
+}
+
+func TestAggregatingEmitterStrict(t *testing.T) {
+	aggregates, err := parseAggregates("sum:GPA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Project{summary: &runSummary{}}
+	p.config.Strict = true
+	e := newAggregatingEmitter(discardEmitter{}, p, nil, aggregates)
+	if err := e.Row(map[string]interface{}{"GPA": "3.5"}); err != nil {
+		t.Fatalf("Row: %v", err)
+	}
+	err = e.Row(map[string]interface{}{"GPA": "n/a"})
+	if err == nil || !strings.Contains(err.Error(), `non-numeric value "n/a" in column "GPA" for sum(GPA)`) {
+		t.Errorf("Row with a non-numeric value and STRICT error = %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"runtime"
+)
+
+// partialSuffix is appended to the name of a file while it's being written.
+const partialSuffix = ".partial"
+
+// writeFileAtomic writes `data` to `<name>.partial` and only renames it to
+// `name` once it's completely written and closed, so a failed or interrupted
+// run never leaves a truncated `name` behind; the `.partial` file is left in
+// place on failure for debugging.
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	partial := name + partialSuffix
+	f, err := os.OpenFile(partial, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(partial, name); err != nil {
+		// NOTE: renaming over an existing file can fail on Windows (e.g. on
+		// some network shares), so the target is removed and the rename retried.
+		if runtime.GOOS != "windows" {
+			return err
+		}
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return os.Rename(partial, name)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		data     string
+	}{
+		{"new file", "", "a,b\n1,2\n"},
+		{"replaced file", "old contents that are longer", "new"},
+		{"empty data", "old", ""},
+	}
+	for _, tt := range tests {
+		name := filepath.Join(t.TempDir(), "out.csv")
+		if tt.existing != "" {
+			if err := os.WriteFile(name, []byte(tt.existing), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writeFileAtomic(name, []byte(tt.data), 0644); err != nil {
+			t.Errorf("%s: writeFileAtomic: %v", tt.name, err)
+			continue
+		}
+		if got, err := os.ReadFile(name); err != nil || string(got) != tt.data {
+			t.Errorf("%s: contents = %q, %v, want %q", tt.name, got, err, tt.data)
+		}
+		if _, err := os.Stat(name + partialSuffix); !os.IsNotExist(err) {
+			t.Errorf("%s: the %s file is left behind: %v", tt.name, partialSuffix, err)
+		}
+	}
+}
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	// a non-empty directory can't be renamed over
+	name := filepath.Join(t.TempDir(), "out.csv")
+	if err := os.MkdirAll(filepath.Join(name, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(name, []byte("data"), 0644); err == nil {
+		t.Fatalf("writeFileAtomic over a directory succeeded")
+	}
+	// the partial file is kept for debugging
+	if got, err := os.ReadFile(name + partialSuffix); err != nil || string(got) != "data" {
+		t.Errorf("%s file = %q, %v, want the written data", partialSuffix, got, err)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing", "out.csv")
+	if err := writeFileAtomic(missing, []byte("data"), 0644); err == nil {
+		t.Errorf("writeFileAtomic in a missing directory succeeded")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("writeFileAtomic in a missing directory left %s: %v", missing, err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenFile stores the user's access and refresh tokens.
+const tokenFile = "token.json"
+
+// pendingAuthFile stores the state and PKCE verifier of an authorization
+// started with `auth url`, until it's completed with `auth complete`.
+const pendingAuthFile = "auth-pending.json"
+
+// pendingAuth is an authorization started on this machine, whose code is
+// entered later (possibly after authorizing on another machine).
+type pendingAuth struct {
+	State     string    `json:"state"`
+	Verifier  string    `json:"verifier"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// runAuthCommand runs the `auth` subcommands, which split the authorization
+// flow for machines that can't open the browser themselves:
+//   - `auth url` prints the authorization URL and saves the pending
+//     authorization to `auth-pending.json`
+//   - `auth complete --code-file path` (or `--code code`) exchanges the code
+//     (or the whole redirect URL) for a token saved to `token.json`; the
+//     pending authorization is single-use and deleted afterwards
+//   - `auth export --out path` writes `token.json` to an encrypted bundle
+//     (see `exportAuth`), to install on other machines with `auth import
+//     path`, which reads the key from `AUTH_BUNDLE_KEY` or `--key-file`
+func runAuthCommand(config *oauth2.Config, args []string) {
+	if len(args) == 0 {
+		fatalf("Usage: auth url | auth complete --code-file path | auth complete --code code | auth export --out path | auth import [--key-file path] path")
+	}
+	switch args[0] {
+	case "url":
+		authURL, err := startPendingAuth(config)
+		if err != nil {
+			fatalf("Unable to start authorization: %v", err)
+		}
+		fmt.Printf("Go to the following link in your browser, then run `auth complete` with the authorization code (or the whole URL you're redirected to):\n%v\n", authURL)
+	case "complete":
+		flags := flag.NewFlagSet("auth complete", flag.ExitOnError)
+		codeFile := flags.String("code-file", "", "file holding the authorization code or redirect URL")
+		code := flags.String("code", "", "the authorization code or redirect URL")
+		flags.Parse(args[1:])
+		input := *code
+		if *codeFile != "" {
+			b, err := os.ReadFile(*codeFile)
+			if err != nil {
+				fatalf("Unable to read authorization code: %v", err)
+			}
+			input = string(b)
+		}
+		if input == "" {
+			fatalf("Either --code-file or --code is required")
+		}
+		tok, err := completePendingAuth(config, input)
+		if err != nil {
+			fatalf("Unable to complete authorization: %v", err)
+		}
+		saveToken(tokenFile, tok)
+	case "export":
+		flags := flag.NewFlagSet("auth export", flag.ExitOnError)
+		out := flags.String("out", "", "file to write the encrypted token bundle to")
+		flags.Parse(args[1:])
+		if *out == "" {
+			fatalf("--out is required")
+		}
+		exportAuth(config, *out)
+	case "import":
+		flags := flag.NewFlagSet("auth import", flag.ExitOnError)
+		keyFile := flags.String("key-file", "", "file holding the key printed by `auth export` (defaults to AUTH_BUNDLE_KEY)")
+		flags.Parse(args[1:])
+		if flags.NArg() != 1 {
+			fatalf("Usage: auth import [--key-file path] path")
+		}
+		key := os.Getenv("AUTH_BUNDLE_KEY")
+		if *keyFile != "" {
+			b, err := os.ReadFile(*keyFile)
+			if err != nil {
+				fatalf("Unable to read the bundle key: %v", err)
+			}
+			key = string(b)
+		}
+		if key == "" {
+			fatalf("Either AUTH_BUNDLE_KEY or --key-file is required")
+		}
+		importAuth(config, flags.Arg(0), key)
+	default:
+		fatalf("Unknown auth command: %q", args[0])
+	}
+}
+
+// startPendingAuth saves a new pending authorization and returns its
+// authorization URL, which uses PKCE so the code is useless without the
+// saved verifier.
+func startPendingAuth(config *oauth2.Config) (string, error) {
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(pendingAuth{State: state, Verifier: verifier, CreatedAt: time.Now()})
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(pendingAuthFile, b, 0600); err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	return config.AuthCodeURL(state, oauth2.AccessTypeOffline,
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// completePendingAuth exchanges the code in `input` (see `extractAuthCode`)
+// using the saved pending authorization, which is deleted once the exchange
+// has been attempted since neither it nor the code can be reused.
+func completePendingAuth(config *oauth2.Config, input string) (*oauth2.Token, error) {
+	b, err := os.ReadFile(pendingAuthFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no pending authorization, run `auth url` first")
+		}
+		return nil, err
+	}
+	var pending pendingAuth
+	if err := json.Unmarshal(b, &pending); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", pendingAuthFile, err)
+	}
+	code, err := extractAuthCode(input, pending.State)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(pendingAuthFile)
+	return config.Exchange(context.TODO(), code, oauth2.SetAuthURLParam("code_verifier", pending.Verifier))
+}
+
+// randomToken returns 32 random bytes, base64url-encoded (as required of PKCE
+// verifiers).
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/testsupport"
+)
+
+// chdirTemp changes to a new temporary directory for the rest of the test,
+// since `tokenFile` is relative to the working directory.
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestGetClientCacheMiss(t *testing.T) {
+	chdirTemp(t)
+	server := testsupport.NewTokenServer()
+	defer server.Close()
+	server.PendingPolls = 1
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Scopes:       []string{"https://www.googleapis.com/auth/drive.file"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   server.AuthURL(),
+			TokenURL:  server.TokenURL(),
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+
+	if got, err := deviceCodeURL(config); err != nil || got != server.DeviceCodeURL() {
+		t.Fatalf("deviceCodeURL = %q, %v, want %q", got, err, server.DeviceCodeURL())
+	}
+
+	client := getClient(config, time.Minute, []string{authFlowDevice}, 10*time.Second)
+
+	if got := server.Grants("urn:ietf:params:oauth:grant-type:device_code"); got != 1 {
+		t.Errorf("device code grants = %d, want 1", got)
+	}
+	tok, err := readTokenFile(tokenFile)
+	if err != nil {
+		t.Fatalf("readTokenFile: %v", err)
+	}
+	if tok.AccessToken != "access-1" || tok.RefreshToken == "" {
+		t.Errorf("saved token = %+v, want access-1 with a refresh token", tok)
+	}
+
+	var authorization string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer api.Close()
+	resp, err := client.Get(api.URL)
+	if err != nil {
+		t.Fatalf("client.Get: %v", err)
+	}
+	resp.Body.Close()
+	if want := "Bearer access-1"; authorization != want {
+		t.Errorf("Authorization = %q, want %q", authorization, want)
+	}
+
+	// the saved token is used from now on
+	getClient(config, time.Minute, []string{authFlowDevice}, 10*time.Second)
+	if got := server.Issued(); got != 1 {
+		t.Errorf("issued tokens = %d, want 1", got)
+	}
+}
+
+// TestGetClientPasteNoTerminal skips the paste step when stdin isn't a
+// terminal, falling through to the next step rather than waiting for a code.
+func TestGetClientPasteNoTerminal(t *testing.T) {
+	chdirTemp(t)
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stdin := os.Stdin
+	os.Stdin = devNull
+	defer func() { os.Stdin = stdin }()
+	server := testsupport.NewTokenServer()
+	defer server.Close()
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Scopes:       []string{"https://www.googleapis.com/auth/drive.file"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   server.AuthURL(),
+			TokenURL:  server.TokenURL(),
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+
+	getClient(config, time.Minute, []string{authFlowPaste, authFlowDevice}, 10*time.Second)
+	if got := server.Grants("urn:ietf:params:oauth:grant-type:device_code"); got != 1 {
+		t.Errorf("device code grants = %d, want 1", got)
+	}
+}
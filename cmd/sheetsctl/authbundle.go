@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// authBundleMagic starts the encrypted bundles of `auth export`, followed by
+// the AES-GCM nonce and the sealed `authBundle`.
+const authBundleMagic = "sheets-auth-bundle/1\n"
+
+var errAuthBundle = errors.New("invalid auth bundle")
+
+// authBundle is a token exported with `auth export` to be installed on other
+// machines with `auth import`, along with what it's valid for: the refresh
+// token only works with the OAuth client it was issued to.
+type authBundle struct {
+	Token *oauth2.Token `json:"token"`
+	// Scopes are the scopes the token was authorized with
+	Scopes []string `json:"scopes"`
+	// ClientFingerprint is the `fingerprint` of the OAuth client ID
+	ClientFingerprint string    `json:"client_fingerprint"`
+	ExportedAt        time.Time `json:"exported_at"`
+}
+
+// sealAuthBundle encrypts the `bundle` with AES-GCM under the 32-byte `key`.
+func sealAuthBundle(bundle authBundle, key []byte) ([]byte, error) {
+	plaintext, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAuthBundleCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := append([]byte(authBundleMagic), nonce...)
+	return aead.Seal(sealed, nonce, plaintext, []byte(authBundleMagic)), nil
+}
+
+// openAuthBundle decrypts a bundle sealed by `sealAuthBundle`.
+func openAuthBundle(data, key []byte) (authBundle, error) {
+	if !bytes.HasPrefix(data, []byte(authBundleMagic)) {
+		return authBundle{}, fmt.Errorf("%w: not an auth export file", errAuthBundle)
+	}
+	aead, err := newAuthBundleCipher(key)
+	if err != nil {
+		return authBundle{}, err
+	}
+	data = data[len(authBundleMagic):]
+	if len(data) < aead.NonceSize() {
+		return authBundle{}, fmt.Errorf("%w: truncated", errAuthBundle)
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(authBundleMagic))
+	if err != nil {
+		return authBundle{}, fmt.Errorf("%w: wrong key, or the file was modified", errAuthBundle)
+	}
+	var bundle authBundle
+	if err := json.Unmarshal(plaintext, &bundle); err != nil {
+		return authBundle{}, fmt.Errorf("%w: %v", errAuthBundle, err)
+	}
+	if bundle.Token == nil {
+		return authBundle{}, fmt.Errorf("%w: no token", errAuthBundle)
+	}
+	return bundle, nil
+}
+
+func newAuthBundleCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%w: the key must be 32 bytes, got %d", errAuthBundle, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// exportAuth writes the token of `token.json` to the bundle `out`, encrypted
+// with a new random key which is printed (and needed by `auth import`).
+func exportAuth(config *oauth2.Config, out string) {
+	tok, err := readTokenFile(tokenFile)
+	if err != nil {
+		fatalf("Unable to read %s, authorize first: %v", tokenFile, err)
+	}
+	if tok.RefreshToken == "" {
+		log.Printf("WARNING: the token has no refresh token, it won't work on other machines once it expires (%s)", tok.Expiry.Format(time.RFC3339))
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		fatalf("Unable to generate the bundle key: %v", err)
+	}
+	sealed, err := sealAuthBundle(authBundle{Token: tok, Scopes: config.Scopes, ClientFingerprint: fingerprint(config.ClientID), ExportedAt: time.Now()}, key)
+	if err != nil {
+		fatalf("Unable to encrypt the token: %v", err)
+	}
+	if err := writeFileAtomic(out, sealed, 0600); err != nil {
+		fatalf("Unable to write %s: %v", out, err)
+	}
+	fmt.Printf("Exported the token to %s, install it with `auth import %s` and this key (in AUTH_BUNDLE_KEY or a --key-file), which isn't stored anywhere:\n%s\n", out, out, base64.StdEncoding.EncodeToString(key))
+}
+
+// importAuth installs the token of the bundle `path` into `token.json`, once
+// checked it was issued to the OAuth client of the local `credentials.json`.
+func importAuth(config *oauth2.Config, path, encodedKey string) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		fatalf("Unable to decode the bundle key: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fatalf("Unable to read %s: %v", path, err)
+	}
+	bundle, err := openAuthBundle(data, key)
+	if err != nil {
+		fatalf("Unable to open %s: %v", path, err)
+	}
+	if local := fingerprint(config.ClientID); bundle.ClientFingerprint != local {
+		fatalf("Unable to import %s: its token was issued to another OAuth client (%s) than the one of the local credentials file (%s), and its refresh token only works with that client; export it with the same credentials file", path, bundle.ClientFingerprint, local)
+	}
+	for _, scope := range config.Scopes {
+		if indexOf(bundle.Scopes, scope) == -1 {
+			log.Printf("WARNING: the token wasn't authorized with the scope %s, calls needing it will fail", scope)
+		}
+	}
+	saveToken(tokenFile, bundle.Token)
+	fmt.Printf("Imported the token exported at %s\n", bundle.ExportedAt.Format(time.RFC3339))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestAuthBundleRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	bundle := authBundle{
+		Token:             &oauth2.Token{AccessToken: "access", TokenType: "Bearer", RefreshToken: "refresh", Expiry: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)},
+		Scopes:            []string{"https://www.googleapis.com/auth/spreadsheets.readonly"},
+		ClientFingerprint: fingerprint("client-id"),
+		ExportedAt:        time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC),
+	}
+	sealed, err := sealAuthBundle(bundle, key)
+	if err != nil {
+		t.Fatalf("sealAuthBundle: %v", err)
+	}
+	if !bytes.HasPrefix(sealed, []byte(authBundleMagic)) || bytes.Contains(sealed, []byte("refresh")) {
+		t.Errorf("sealed bundle = %q, want the magic and an encrypted token", sealed)
+	}
+	opened, err := openAuthBundle(sealed, key)
+	if err != nil {
+		t.Fatalf("openAuthBundle: %v", err)
+	}
+	if opened.Token.RefreshToken != "refresh" || !opened.Token.Expiry.Equal(bundle.Token.Expiry) || opened.ClientFingerprint != bundle.ClientFingerprint || len(opened.Scopes) != 1 {
+		t.Errorf("opened bundle = %+v, want %+v", opened, bundle)
+	}
+	// every bundle has its own nonce
+	if again, _ := sealAuthBundle(bundle, key); bytes.Equal(again, sealed) {
+		t.Errorf("sealing the bundle twice gave the same bytes")
+	}
+}
+
+func TestOpenAuthBundleErrors(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	sealed, err := sealAuthBundle(authBundle{Token: &oauth2.Token{AccessToken: "access"}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noToken, err := sealAuthBundle(authBundle{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		data    []byte
+		key     []byte
+		wantErr string
+	}{
+		{"wrong key", sealed, bytes.Repeat([]byte{8}, 32), "wrong key, or the file was modified"},
+		{"tampered", tampered, key, "wrong key, or the file was modified"},
+		{"truncated", sealed[:len(authBundleMagic)+4], key, "truncated"},
+		{"token file", []byte(`{"access_token": "access"}`), key, "not an auth export file"},
+		{"short key", sealed, key[:16], "the key must be 32 bytes, got 16"},
+		{"no token", noToken, key, "no token"},
+	}
+	for _, tt := range tests {
+		if _, err := openAuthBundle(tt.data, tt.key); !errors.Is(err, errAuthBundle) || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: openAuthBundle error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestAuthExportImport moves the token of a run to another machine's run
+// with `auth export` and `auth import`.
+func TestAuthExportImport(t *testing.T) {
+	from := newCommandRun(t)
+	stdout, code := from.Run("auth", "export", "--out", "token.bundle")
+	if code != 0 {
+		t.Fatalf("auth export exit code %d", code)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	key := lines[len(lines)-1]
+	bundle := filepath.Join(from.dir, "token.bundle")
+	if info, err := os.Stat(bundle); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("bundle %v, %v, want a 0600 file", info, err)
+	}
+	exported, err := readTokenFile(filepath.Join(from.dir, tokenFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to := newCommandRun(t)
+	if err := os.Remove(filepath.Join(to.dir, tokenFile)); err != nil {
+		t.Fatal(err)
+	}
+	to.Setenv("AUTH_BUNDLE_KEY", key)
+	if _, code := to.Run("auth", "import", bundle); code != 0 {
+		t.Fatalf("auth import exit code %d", code)
+	}
+	imported, err := readTokenFile(filepath.Join(to.dir, tokenFile))
+	if err != nil || imported.RefreshToken != exported.RefreshToken || !imported.Expiry.Equal(exported.Expiry) {
+		t.Errorf("imported token = %+v, %v, want %+v", imported, err, exported)
+	}
+
+	// the refresh token only works with the OAuth client it was issued to
+	other := newCommandRun(t)
+	b, err := os.ReadFile(filepath.Join(other.dir, "credentials.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var credentials map[string]map[string]interface{}
+	if err := json.Unmarshal(b, &credentials); err != nil {
+		t.Fatal(err)
+	}
+	credentials["installed"]["client_id"] = "other-client-id.apps.googleusercontent.com"
+	if b, err = json.Marshal(credentials); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(other.dir, "credentials.json"), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(filepath.Join(other.dir, tokenFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Setenv("AUTH_BUNDLE_KEY", key)
+	if _, code := other.Run("auth", "import", bundle); code == 0 {
+		t.Errorf("auth import for another OAuth client succeeded")
+	}
+	if after, err := os.ReadFile(filepath.Join(other.dir, tokenFile)); err != nil || !bytes.Equal(after, before) {
+		t.Errorf("auth import for another OAuth client replaced the token: %s, %v", after, err)
+	}
+}
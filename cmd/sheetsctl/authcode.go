@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+var errInvalidAuthCode = errors.New("invalid authorization code")
+
+// authCodeAttempts is how many times the authorization code is prompted for
+// when the exchange fails because of a malformed code.
+const authCodeAttempts = 3
+
+// extractAuthCode returns the authorization code from what the user pasted:
+// either the bare code (possibly URL-encoded) or the whole URL they were
+// redirected to, e.g. "http://localhost/?state=...&code=4/...", in which case
+// its `state` must match when present.
+func extractAuthCode(input, state string) (string, error) {
+	input = strings.Trim(strings.TrimSpace(input), "\"'`<>")
+	if input == "" {
+		return "", fmt.Errorf("%w: nothing was entered", errInvalidAuthCode)
+	}
+	if strings.Contains(input, "://") || strings.HasPrefix(input, "?") || strings.Contains(input, "code=") {
+		if !strings.Contains(input, "://") && !strings.HasPrefix(input, "?") {
+			// just the query, e.g. "state=...&code=4/..."
+			input = "?" + input
+		}
+		u, err := url.Parse(input)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", errInvalidAuthCode, err)
+		}
+		query := u.Query()
+		if reason := query.Get("error"); reason != "" {
+			return "", fmt.Errorf("%w: authorization failed: %s", errInvalidAuthCode, reason)
+		}
+		if s := query.Get("state"); s != "" && s != state {
+			return "", fmt.Errorf("%w: the URL's state %q doesn't match %q, it's from another authorization", errInvalidAuthCode, s, state)
+		}
+		code := query.Get("code")
+		if code == "" {
+			return "", fmt.Errorf("%w: the URL has no code parameter", errInvalidAuthCode)
+		}
+		return code, nil
+	}
+	if strings.Contains(input, "%") {
+		code, err := url.QueryUnescape(input)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", errInvalidAuthCode, err)
+		}
+		return code, nil
+	}
+	return input, nil
+}
+
+// isMalformedCodeError reports whether the token exchange failed because the
+// authorization code was wrong (e.g. mistyped or truncated), so it's worth
+// prompting for it again.
+func isMalformedCodeError(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) || retrieveErr.Response == nil || retrieveErr.Response.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	return bytes.Contains(retrieveErr.Body, []byte("invalid_grant")) || bytes.Contains(retrieveErr.Body, []byte("invalid_request"))
+}
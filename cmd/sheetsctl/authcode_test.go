@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestExtractAuthCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   string
+	}{
+		{"4/0AbCdEf", "4/0AbCdEf", ""},
+		{"  '4/0AbCdEf'\n", "4/0AbCdEf", ""},
+		{"4%2F0AbCdEf", "4/0AbCdEf", ""},
+		{"http://localhost:8000/?state=state-1&code=4/0AbCdEf&scope=x", "4/0AbCdEf", ""},
+		{"<http://localhost/?code=4%2F0AbCdEf>", "4/0AbCdEf", ""},
+		{"?code=4/0AbCdEf", "4/0AbCdEf", ""},
+		{"state=state-1&code=4/0AbCdEf", "4/0AbCdEf", ""},
+		// a URL without a state is taken as is
+		{"http://localhost/?code=4/0AbCdEf", "4/0AbCdEf", ""},
+		{"", "", "nothing was entered"},
+		{"http://localhost/?state=state-2&code=4/0AbCdEf", "", `the URL's state "state-2" doesn't match "state-1"`},
+		{"http://localhost/?error=access_denied&state=state-1", "", "authorization failed: access_denied"},
+		{"http://localhost/?state=state-1", "", "the URL has no code parameter"},
+		{"4%ZZ", "", "invalid URL escape"},
+	}
+	for _, tt := range tests {
+		got, err := extractAuthCode(tt.input, "state-1")
+		if tt.err != "" {
+			if !errors.Is(err, errInvalidAuthCode) || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("extractAuthCode(%q) error = %v, want %q", tt.input, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("extractAuthCode(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestIsMalformedCodeError(t *testing.T) {
+	retrieveErr := func(status int, body string) error {
+		return &oauth2.RetrieveError{Response: &http.Response{StatusCode: status}, Body: []byte(body)}
+	}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{retrieveErr(http.StatusBadRequest, `{"error": "invalid_grant", "error_description": "Malformed auth code."}`), true},
+		{retrieveErr(http.StatusBadRequest, `{"error": "invalid_request"}`), true},
+		{retrieveErr(http.StatusBadRequest, `{"error": "invalid_client"}`), false},
+		{retrieveErr(http.StatusUnauthorized, `{"error": "invalid_grant"}`), false},
+		{&oauth2.RetrieveError{}, false},
+		{errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := isMalformedCodeError(tt.err); got != tt.want {
+			t.Errorf("isMalformedCodeError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
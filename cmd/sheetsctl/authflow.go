@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// `AUTH_FLOW` steps, tried in order until one returns a token.
+const (
+	// authFlowLocalhost opens the browser on the authorization URL and
+	// receives the code on a localhost redirect
+	authFlowLocalhost = "localhost"
+	// authFlowDevice shows a code to enter on another device (it requires a
+	// "TVs and Limited Input devices" OAuth client)
+	authFlowDevice = "device"
+	// authFlowPaste prompts for the code (or redirect URL) to be pasted
+	authFlowPaste = "paste"
+)
+
+// deviceCodePath is the OAuth device authorization endpoint, relative to the
+// token endpoint: e.g. https://oauth2.googleapis.com/device/code for Google's.
+const deviceCodePath = "device/code"
+
+// authRequestTimeout bounds each request to the OAuth endpoints.
+const authRequestTimeout = 30 * time.Second
+
+var errAuthFlowSkipped = errors.New("skipped")
+
+// authCapabilities are what the authorization steps need from the machine,
+// probed once so the step selection doesn't depend on the environment.
+type authCapabilities struct {
+	// Browser is whether a browser can be opened, i.e. there's a display
+	Browser bool
+	// Terminal is whether the run is attended, i.e. stdin is a terminal
+	Terminal bool
+}
+
+// probeAuthCapabilities returns the capabilities of this machine.
+func probeAuthCapabilities() authCapabilities {
+	browser := true
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		browser = os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	}
+	return authCapabilities{Browser: browser, Terminal: isInteractive()}
+}
+
+// parseAuthFlow parses `AUTH_FLOW`, a comma-separated list of steps, e.g.
+// "localhost,paste".
+func parseAuthFlow(s string) ([]string, error) {
+	steps := []string{}
+	for _, step := range strings.Split(s, ",") {
+		switch step = strings.TrimSpace(step); step {
+		case authFlowLocalhost, authFlowDevice, authFlowPaste:
+			steps = append(steps, step)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown step %q (want localhost, device, or paste)", step)
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no steps in %q", s)
+	}
+	return steps, nil
+}
+
+// deviceFlowScopes are the only scopes Google grants through the device flow,
+// see https://developers.google.com/identity/protocols/oauth2/limited-input-device#allowedscopes
+// (the Sheets scopes and the Drive ones the example uses aren't).
+var deviceFlowScopes = map[string]bool{
+	"openid":  true,
+	"email":   true,
+	"profile": true,
+	"https://www.googleapis.com/auth/userinfo.email":   true,
+	"https://www.googleapis.com/auth/userinfo.profile": true,
+	"https://www.googleapis.com/auth/drive.appdata":    true,
+	"https://www.googleapis.com/auth/drive.file":       true,
+	"https://www.googleapis.com/auth/youtube":          true,
+	"https://www.googleapis.com/auth/youtube.readonly": true,
+}
+
+// authStepSkipReason returns why the `step` can't run with the `caps` for the
+// `scopes`, or an empty string if it can.
+func authStepSkipReason(step string, caps authCapabilities, scopes []string) string {
+	switch step {
+	case authFlowLocalhost:
+		switch {
+		case !caps.Browser:
+			return "no display to open a browser on"
+		case !caps.Terminal:
+			return "stdin isn't a terminal, nobody is there to use the browser"
+		}
+	case authFlowPaste:
+		if !caps.Terminal {
+			return "stdin isn't a terminal, nobody is there to paste the code"
+		}
+	case authFlowDevice:
+		for _, scope := range scopes {
+			if !deviceFlowScopes[scope] {
+				return fmt.Sprintf("the scope %s can't be granted through the device flow", scope)
+			}
+		}
+	}
+	return ""
+}
+
+// getTokenFromFlow runs the `steps` of the authorization flow in order, each
+// bounded by `timeout`, until one returns a token; why each step failed is
+// logged.
+func getTokenFromFlow(config *oauth2.Config, steps []string, timeout time.Duration) *oauth2.Token {
+	caps := probeAuthCapabilities()
+	for _, step := range steps {
+		var tok *oauth2.Token
+		err := errAuthFlowSkipped
+		if reason := authStepSkipReason(step, caps, config.Scopes); reason != "" {
+			err = fmt.Errorf("%w: %s", errAuthFlowSkipped, reason)
+		} else {
+			switch step {
+			case authFlowLocalhost:
+				tok, err = getTokenFromLocalhost(config, timeout)
+			case authFlowDevice:
+				tok, err = getTokenFromDevice(config, timeout)
+			case authFlowPaste:
+				tok, err = getTokenFromWeb(config, timeout)
+			}
+		}
+		if err == nil {
+			return tok
+		}
+		log.Printf("Authorization step %q failed: %v", step, err)
+	}
+	fatalf("Unable to authorize: every AUTH_FLOW step failed")
+	return nil
+}
+
+// getTokenFromLocalhost opens the browser on the authorization URL, and waits
+// up to `timeout` for the code to be sent to a localhost redirect URL.
+func getTokenFromLocalhost(config *oauth2.Config, timeout time.Duration) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+	redirected := *config
+	redirected.RedirectURL = fmt.Sprintf("http://%s/", listener.Addr())
+	state, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	authURL := redirected.AuthCodeURL(state, oauth2.AccessTypeOffline,
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{Handler: authCallbackHandler(state, codes, errs)}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Printf("Opening the following link in your browser (waiting %s):\n%v\n", timeout, authURL)
+	if err := openBrowser(authURL); err != nil {
+		return nil, fmt.Errorf("unable to open the browser: %w", err)
+	}
+	select {
+	case code := <-codes:
+		return redirected.Exchange(context.TODO(), code, oauth2.SetAuthURLParam("code_verifier", verifier))
+	case err := <-errs:
+		return nil, err
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("no authorization within %s", timeout)
+	}
+}
+
+// authCallbackHandler handles the localhost redirect of the authorization,
+// at "/": the code is sent on `codes`, or the error on `errs` when the
+// redirect is an error or doesn't carry the `state`. Other paths (e.g. the
+// browser's "/favicon.ico") are not found.
+func authCallbackHandler(state string, codes chan<- string, errs chan<- error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		code, err := extractAuthCode("?"+r.URL.RawQuery, state)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			select {
+			case errs <- err:
+			default:
+			}
+			return
+		}
+		fmt.Fprintln(w, "Authorization complete, you can close this window.")
+		select {
+		case codes <- code:
+		default:
+		}
+	})
+}
+
+// openBrowser opens `u` in the default browser.
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	case "darwin":
+		cmd = exec.Command("open", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+// deviceCodeURL returns the device authorization endpoint of `config`, next
+// to its token endpoint so that overriding OAUTH_TOKEN_URL moves both.
+func deviceCodeURL(config *oauth2.Config) (string, error) {
+	tokenURL, err := url.Parse(config.Endpoint.TokenURL)
+	if err != nil || tokenURL.Host == "" {
+		return "", fmt.Errorf("invalid token endpoint %q", config.Endpoint.TokenURL)
+	}
+	return tokenURL.ResolveReference(&url.URL{Path: deviceCodePath}).String(), nil
+}
+
+// deviceCode is the response of the device authorization endpoint.
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// getTokenFromDevice runs the OAuth device flow: the user enters the printed
+// code on another device, while the token endpoint is polled for up to
+// `timeout` (or until the code expires).
+func getTokenFromDevice(config *oauth2.Config, timeout time.Duration) (*oauth2.Token, error) {
+	endpoint, err := deviceCodeURL(config)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: authRequestTimeout}
+	resp, err := client.PostForm(endpoint, url.Values{
+		"client_id": {config.ClientID},
+		"scope":     {strings.Join(config.Scopes, " ")},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return nil, fmt.Errorf("device authorization failed: %s %s (the OAuth client must be of the \"TVs and Limited Input devices\" type)", resp.Status, body.Error)
+	}
+	var code deviceCode
+	if err := json.NewDecoder(resp.Body).Decode(&code); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Go to %s on any device and enter the code %s\n", code.VerificationURL, code.UserCode)
+
+	deadline := time.Now().Add(timeout)
+	if expires := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second); code.ExpiresIn > 0 && expires.Before(deadline) {
+		deadline = expires
+	}
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+		tok, err := config.Exchange(ctx, "",
+			oauth2.SetAuthURLParam("grant_type", "urn:ietf:params:oauth:grant-type:device_code"),
+			oauth2.SetAuthURLParam("device_code", code.DeviceCode),
+		)
+		if err == nil {
+			return tok, nil
+		}
+		var retrieveErr *oauth2.RetrieveError
+		if !errors.As(err, &retrieveErr) {
+			return nil, err
+		}
+		switch {
+		case strings.Contains(string(retrieveErr.Body), "authorization_pending"):
+		case strings.Contains(string(retrieveErr.Body), "slow_down"):
+			interval += 5 * time.Second
+		default:
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no authorization within %s", timeout)
+}
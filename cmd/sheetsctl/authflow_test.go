@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthStepSkipReason(t *testing.T) {
+	attended := authCapabilities{Browser: true, Terminal: true}
+	sheetsScopes := []string{"https://www.googleapis.com/auth/spreadsheets.readonly", "https://www.googleapis.com/auth/drive.readonly"}
+	deviceScopes := []string{"openid", "https://www.googleapis.com/auth/drive.file"}
+	tests := []struct {
+		step   string
+		caps   authCapabilities
+		scopes []string
+		skip   bool
+	}{
+		{authFlowLocalhost, attended, sheetsScopes, false},
+		{authFlowLocalhost, authCapabilities{Terminal: true}, sheetsScopes, true},
+		{authFlowLocalhost, authCapabilities{Browser: true}, sheetsScopes, true},
+		{authFlowDevice, attended, sheetsScopes, true},
+		{authFlowDevice, authCapabilities{}, deviceScopes, false},
+		{authFlowDevice, authCapabilities{}, nil, false},
+		{authFlowPaste, authCapabilities{Terminal: true}, sheetsScopes, false},
+		{authFlowPaste, authCapabilities{Browser: true}, sheetsScopes, true},
+	}
+	for _, tt := range tests {
+		if got := authStepSkipReason(tt.step, tt.caps, tt.scopes); (got != "") != tt.skip {
+			t.Errorf("authStepSkipReason(%q, %+v, %v) = %q, want skipped %v", tt.step, tt.caps, tt.scopes, got, tt.skip)
+		}
+	}
+}
+
+func TestAuthCallbackHandler(t *testing.T) {
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	handler := authCallbackHandler("state", codes, errs)
+	get := func(target string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Code
+	}
+
+	// the browser asks for its icon too
+	if status := get("/favicon.ico"); status != http.StatusNotFound {
+		t.Errorf("GET /favicon.ico = %d, want 404", status)
+	}
+	if status := get("/?code=code&state=other"); status != http.StatusBadRequest {
+		t.Errorf("GET with another state = %d, want 400", status)
+	}
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("error = nil, want the state mismatch")
+		}
+	default:
+		t.Error("no error for the state mismatch")
+	}
+	if status := get("/?code=code&state=state"); status != http.StatusOK {
+		t.Errorf("GET of the redirect = %d, want 200", status)
+	}
+	select {
+	case code := <-codes:
+		if code != "code" {
+			t.Errorf("code = %q, want %q", code, "code")
+		}
+	default:
+		t.Error("no code for the redirect")
+	}
+	if len(errs) != 0 {
+		t.Errorf("%d errors left, want the other paths ignored", len(errs))
+	}
+}
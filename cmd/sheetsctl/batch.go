@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errInvalidBatchPlan = errors.New("invalid batch plan")
+
+// Batch is an inclusive range of sheet rows that's read with a single Values
+// API call.
+type Batch struct {
+	Start int
+	End   int
+}
+
+// PlanBatches splits the rows `firstRow-lastRow` into batches of at most
+// `batchCount` rows; an `errInvalidBatchPlan` error is returned for
+// nonsensical inputs rather than emitting ranges that would fail API-side.
+//
+// NOTE: the math is done with int64 so a huge `batchCount` (or `lastRow`)
+// can't overflow into negative row numbers on 32-bit builds.
+func PlanBatches(firstRow, lastRow, batchCount int) ([]Batch, error) {
+	if batchCount < 1 {
+		return nil, fmt.Errorf("%w: batch count must be at least 1, got %d", errInvalidBatchPlan, batchCount)
+	}
+	if firstRow < 1 {
+		return nil, fmt.Errorf("%w: first row must be at least 1, got %d", errInvalidBatchPlan, firstRow)
+	}
+	if lastRow < 0 {
+		return nil, fmt.Errorf("%w: last row can't be negative, got %d", errInvalidBatchPlan, lastRow)
+	}
+	rows, size := int64(lastRow), int64(batchCount)
+	batches := []Batch{}
+	for start := int64(firstRow); start <= rows; {
+		// clamp the final (short) batch to the last row
+		end := rows
+		if size-1 < rows-start {
+			end = start + size - 1
+		}
+		batches = append(batches, Batch{Start: int(start), End: int(end)})
+		if end == rows {
+			break
+		}
+		start = end + 1
+	}
+	return batches, nil
+}
+
+// clampBatches returns the `batches` cut off at `lastRow`, e.g. when the sheet
+// shrank since they were planned.
+func clampBatches(batches []Batch, lastRow int) []Batch {
+	clamped := []Batch{}
+	for _, batch := range batches {
+		if batch.Start > lastRow {
+			break
+		}
+		if batch.End > lastRow {
+			batch.End = lastRow
+		}
+		clamped = append(clamped, batch)
+	}
+	return clamped
+}
+
+// adaptiveBatchSize returns the number of rows of a batch whose rows have
+// `bytesPerRow` bytes on average, so it stays under `maxBytes`: at least 1, and
+// at most `batchCount`.
+func adaptiveBatchSize(maxBytes int, bytesPerRow float64, batchCount int) int {
+	if bytesPerRow <= 0 || float64(batchCount)*bytesPerRow <= float64(maxBytes) {
+		return batchCount
+	}
+	if size := int(float64(maxBytes) / bytesPerRow); size > 1 {
+		return size
+	}
+	return 1
+}
+
+// resizeBatches plans the rows of the `batches` again, in batches of `size`
+// rows; the rows between them (e.g. the `EXCLUDE_ROWS`) are still left out,
+// each run of adjoining batches being planned on its own.
+func resizeBatches(batches []Batch, size int) []Batch {
+	resized := []Batch{}
+	for i := 0; i < len(batches); {
+		j := i
+		for j+1 < len(batches) && batches[j+1].Start == batches[j].End+1 {
+			j++
+		}
+		planned, _ := PlanBatches(batches[i].Start, batches[j].End, size)
+		resized = append(resized, planned...)
+		i = j + 1
+	}
+	return resized
+}
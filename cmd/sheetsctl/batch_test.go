@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+func TestPlanBatches(t *testing.T) {
+	tests := []struct {
+		name                        string
+		firstRow, lastRow, batchCnt int
+		want                        []Batch
+	}{
+		{"no rows", 2, 1, 3, []Batch{}},
+		{"no rows, empty sheet", 1, 0, 3, []Batch{}},
+		{"one row", 2, 2, 3, []Batch{{2, 2}}},
+		{"batch size minus one", 2, 3, 3, []Batch{{2, 3}}},
+		{"exactly the batch size", 2, 4, 3, []Batch{{2, 4}}},
+		{"batch size plus one", 2, 5, 3, []Batch{{2, 4}, {5, 5}}},
+		{"final partial batch", 2, 9, 3, []Batch{{2, 4}, {5, 7}, {8, 9}}},
+		{"exact multiple", 1, 9, 3, []Batch{{1, 3}, {4, 6}, {7, 9}}},
+		{"batch size of one", 1, 3, 1, []Batch{{1, 1}, {2, 2}, {3, 3}}},
+		{"huge batch size", 2, 10, math.MaxInt32, []Batch{{2, 10}}},
+		{"last row at the int32 limit", math.MaxInt32 - 1, math.MaxInt32, 5, []Batch{{math.MaxInt32 - 1, math.MaxInt32}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PlanBatches(tt.firstRow, tt.lastRow, tt.batchCnt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanBatches(%d, %d, %d) = %v, want %v", tt.firstRow, tt.lastRow, tt.batchCnt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanBatchesInvalid(t *testing.T) {
+	tests := []struct{ firstRow, lastRow, batchCnt int }{
+		{2, 10, 0},
+		{2, 10, -1},
+		{0, 10, 3},
+		{2, -1, 3},
+	}
+	for _, tt := range tests {
+		if _, err := PlanBatches(tt.firstRow, tt.lastRow, tt.batchCnt); !errors.Is(err, errInvalidBatchPlan) {
+			t.Errorf("PlanBatches(%d, %d, %d) error = %v, want errInvalidBatchPlan", tt.firstRow, tt.lastRow, tt.batchCnt, err)
+		}
+	}
+}
+
+func TestClampBatches(t *testing.T) {
+	batches := []Batch{{2, 4}, {5, 7}, {8, 10}}
+	tests := []struct {
+		lastRow int
+		want    []Batch
+	}{
+		{10, []Batch{{2, 4}, {5, 7}, {8, 10}}},
+		{12, []Batch{{2, 4}, {5, 7}, {8, 10}}},
+		{6, []Batch{{2, 4}, {5, 6}}},
+		{7, []Batch{{2, 4}, {5, 7}}},
+		// shrunk to the first row of a batch
+		{5, []Batch{{2, 4}, {5, 5}}},
+		{4, []Batch{{2, 4}}},
+		{2, []Batch{{2, 2}}},
+		// shrunk before the batches, e.g. to the header row
+		{1, []Batch{}},
+		{0, []Batch{}},
+	}
+	for _, tt := range tests {
+		if got := clampBatches(batches, tt.lastRow); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("clampBatches(%d) = %v, want %v", tt.lastRow, got, tt.want)
+		}
+	}
+	// the planned batches are left as they were
+	if want := []Batch{{2, 4}, {5, 7}, {8, 10}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("clampBatches changed the batches to %v", batches)
+	}
+	if got := clampBatches(nil, 10); len(got) != 0 {
+		t.Errorf("clampBatches(nil, 10) = %v, want none", got)
+	}
+}
+
+// planRowBatches plans the batches of a sheet of `rowCount` rows, like
+// `sheetFetcher.Plan` without `EXCLUDE_ROWS`; `startRow`/`endRow` are the
+// `READ_RANGES` rows (0 when it has none).
+func planRowBatches(t *testing.T, rowCount, batchCount, headerRow, startRow, endRow int) (int, []Batch) {
+	t.Helper()
+	p := Project{readRanges: []a1.Range{{StartColumn: 1, EndColumn: 3, StartRow: startRow, EndRow: endRow}}}
+	p.config.HeaderRow = headerRow
+	firstRow, lastRow := p.rowWindow(rowCount)
+	batches, err := PlanBatches(firstRow, lastRow, batchCount)
+	if err != nil {
+		t.Fatalf("rows=%d batch=%d header=%d range=%d-%d: %v", rowCount, batchCount, headerRow, startRow, endRow, err)
+	}
+	return firstRow, batches
+}
+
+func TestPlanRowBatches(t *testing.T) {
+	tests := []struct {
+		name                                        string
+		rowCount, batchCount, headerRow, start, end int
+		want                                        []Batch
+	}{
+		{"whole sheet", 10, 4, 1, 0, 0, []Batch{{1, 4}, {5, 8}, {9, 10}}},
+		{"header row below the top", 10, 4, 3, 0, 0, []Batch{{3, 6}, {7, 10}}},
+		{"header row is the last row", 10, 4, 10, 0, 0, []Batch{{10, 10}}},
+		{"header row past the grid", 10, 4, 12, 0, 0, []Batch{}},
+		{"range within the grid", 20, 5, 1, 3, 12, []Batch{{3, 7}, {8, 12}}},
+		{"range past the grid", 10, 4, 1, 5, 50, []Batch{{5, 8}, {9, 10}}},
+		{"range to the last row", 10, 4, 1, 5, 0, []Batch{{5, 8}, {9, 10}}},
+		{"header row within the range", 20, 5, 4, 2, 12, []Batch{{4, 8}, {9, 12}}},
+		{"header row is the range's end", 20, 5, 12, 2, 12, []Batch{{12, 12}}},
+		{"empty sheet", 0, 4, 1, 0, 0, []Batch{}},
+		{"batch larger than the window", 10, 100, 2, 0, 0, []Batch{{2, 10}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := planRowBatches(t, tt.rowCount, tt.batchCount, tt.headerRow, tt.start, tt.end)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("batches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPlanRowBatchesMatrix checks every combination of small row counts, batch
+// counts, header rows, and `READ_RANGES` rows: the batches cover the rows from
+// the header to the end of the grid or range exactly, without gaps or
+// overlaps (so the header is only read once, by the first batch), and never
+// past the grid or range nor above the header.
+func TestPlanRowBatchesMatrix(t *testing.T) {
+	for rowCount := 0; rowCount <= 12; rowCount++ {
+		for batchCount := 1; batchCount <= 13; batchCount++ {
+			for headerRow := 1; headerRow <= 14; headerRow++ {
+				for start := 0; start <= 15; start++ {
+					// an end of 0 is to the last row
+					ends := []int{0}
+					if start > 0 {
+						for end := start; end <= 15; end++ {
+							ends = append(ends, end)
+						}
+					}
+					for _, end := range ends {
+						// rejected at startup
+						if end != 0 && headerRow > end {
+							continue
+						}
+						checkRowBatches(t, rowCount, batchCount, headerRow, start, end)
+					}
+				}
+			}
+		}
+	}
+}
+
+func checkRowBatches(t *testing.T, rowCount, batchCount, headerRow, start, end int) {
+	t.Helper()
+	firstRow, batches := planRowBatches(t, rowCount, batchCount, headerRow, start, end)
+	lastRow := rowCount
+	if end != 0 && end < lastRow {
+		lastRow = end
+	}
+	if firstRow < headerRow || (start != 0 && firstRow < start) {
+		t.Fatalf("rows=%d batch=%d header=%d range=%d-%d: window starts at %d", rowCount, batchCount, headerRow, start, end, firstRow)
+	}
+	next := firstRow
+	for i, batch := range batches {
+		switch {
+		case batch.Start != next:
+			t.Fatalf("rows=%d batch=%d header=%d range=%d-%d: batch %d %v doesn't start at %d (gap or overlap): %v", rowCount, batchCount, headerRow, start, end, i, batch, next, batches)
+		case batch.End < batch.Start || batch.End-batch.Start+1 > batchCount:
+			t.Fatalf("rows=%d batch=%d header=%d range=%d-%d: batch %d %v has a bad size: %v", rowCount, batchCount, headerRow, start, end, i, batch, batches)
+		case batch.End > lastRow:
+			t.Fatalf("rows=%d batch=%d header=%d range=%d-%d: batch %d %v ends past row %d: %v", rowCount, batchCount, headerRow, start, end, i, batch, lastRow, batches)
+		}
+		next = batch.End + 1
+	}
+	if firstRow <= lastRow && next != lastRow+1 {
+		t.Fatalf("rows=%d batch=%d header=%d range=%d-%d: batches %v stop before row %d", rowCount, batchCount, headerRow, start, end, batches, lastRow)
+	}
+}
+
+func TestAdaptiveBatchSize(t *testing.T) {
+	tests := []struct {
+		maxBytes    int
+		bytesPerRow float64
+		batchCount  int
+		want        int
+	}{
+		{1 << 20, 100, 1000, 1000},
+		{1 << 20, 0, 1000, 1000},
+		{100000, 1000, 1000, 100},
+		{100000, 999.5, 1000, 100},
+		// the batch count exactly
+		{100000, 100, 1000, 1000},
+		// rows larger than the limit are still read, one at a time
+		{100, 5000, 1000, 1},
+	}
+	for _, tt := range tests {
+		if got := adaptiveBatchSize(tt.maxBytes, tt.bytesPerRow, tt.batchCount); got != tt.want {
+			t.Errorf("adaptiveBatchSize(%d, %v, %d) = %d, want %d", tt.maxBytes, tt.bytesPerRow, tt.batchCount, got, tt.want)
+		}
+	}
+}
+
+func TestResizeBatches(t *testing.T) {
+	tests := []struct {
+		batches []Batch
+		size    int
+		want    []Batch
+	}{
+		{[]Batch{{11, 20}, {21, 30}}, 4, []Batch{{11, 14}, {15, 18}, {19, 22}, {23, 26}, {27, 30}}},
+		{[]Batch{{11, 14}, {15, 18}, {19, 20}}, 10, []Batch{{11, 20}}},
+		// the rows between the batches are left out
+		{[]Batch{{11, 14}, {18, 20}, {21, 25}}, 3, []Batch{{11, 13}, {14, 14}, {18, 20}, {21, 23}, {24, 25}}},
+		{nil, 5, []Batch{}},
+	}
+	for _, tt := range tests {
+		if got := resizeBatches(tt.batches, tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("resizeBatches(%v, %d) = %v, want %v", tt.batches, tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// What to do with the empty cells of a column; empty cells are left out of the
+// rows' JSON objects by default.
+const (
+	emptyOmit  = "omit"
+	emptyNull  = "null"
+	emptyEmpty = "empty"
+)
+
+// `COLUMN_OPTIONS` options, overriding `TRIM_CELLS`/`EMPTY_AS_NULL` for a
+// column.
+const (
+	optionTrim         = "trim"
+	optionNoTrim       = "no-trim"
+	optionEmptyAsNull  = "empty-as-null"
+	optionEmptyAsEmpty = "empty-as-empty"
+	optionEmptyOmit    = "empty-omit"
+	optionCheckbox     = "checkbox"
+)
+
+// cellPolicy is how the cells of a column are converted: whether they're
+// trimmed, how they're output when empty (see `emptyOmit`), and whether
+// they're checkboxes output as booleans.
+type cellPolicy struct {
+	Trim  bool
+	Empty string
+	// Checkbox reads the formatted "TRUE"/"FALSE" (or the `LOCALE`'s
+	// literals) of checkbox cells as JSON booleans; other values are kept
+	Checkbox bool
+}
+
+// defaultCellPolicy returns the policy set by `TRIM_CELLS`/`EMPTY_AS_NULL`.
+func (p Project) defaultCellPolicy() cellPolicy {
+	policy := cellPolicy{Trim: p.config.TrimCells, Empty: emptyOmit}
+	if p.config.EmptyAsNull {
+		policy.Empty = emptyNull
+	}
+	return policy
+}
+
+// parseColumnOptions parses the `COLUMN_OPTIONS`, a comma-separated list of
+// columns with their options, e.g.
+// "Notes:no-trim:empty-as-empty,Comments:empty-as-null", into the policies of
+// the columns, starting from the `defaults`.
+func parseColumnOptions(s string, defaults cellPolicy) (map[string]cellPolicy, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	policies := map[string]cellPolicy{}
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Split(part, ":")
+		column := strings.TrimSpace(fields[0])
+		if column == "" || len(fields) < 2 {
+			return nil, fmt.Errorf("%q, expected e.g. \"Notes:no-trim:empty-as-null\"", part)
+		}
+		policy := defaults
+		for _, option := range fields[1:] {
+			switch strings.ToLower(strings.TrimSpace(option)) {
+			case optionTrim:
+				policy.Trim = true
+			case optionNoTrim:
+				policy.Trim = false
+			case optionEmptyAsNull:
+				policy.Empty = emptyNull
+			case optionEmptyAsEmpty:
+				policy.Empty = emptyEmpty
+			case optionEmptyOmit:
+				policy.Empty = emptyOmit
+			case optionCheckbox:
+				policy.Checkbox = true
+			default:
+				return nil, fmt.Errorf("unknown option %q for column %q", option, column)
+			}
+		}
+		policies[column] = policy
+	}
+	return policies, nil
+}
+
+// cellPolicies returns the policy of each of the `headers`, or an error if a
+// column of the `COLUMN_OPTIONS` isn't a header.
+func (p Project) cellPolicies(headers []string) ([]cellPolicy, error) {
+	defaults := p.defaultCellPolicy()
+	policies := make([]cellPolicy, len(headers))
+	found := map[string]bool{}
+	for i, header := range headers {
+		policies[i] = defaults
+		if policy, ok := p.columnOptions[header]; ok {
+			policies[i] = policy
+			found[header] = true
+		}
+	}
+	for column := range p.columnOptions {
+		if !found[column] {
+			return nil, fmt.Errorf("column %q not found in the sheet headers", column)
+		}
+	}
+	return policies, nil
+}
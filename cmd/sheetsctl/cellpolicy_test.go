@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// parseTestRow returns the JSON object of the `row` of a sheet with the
+// `headers`, parsed by the project `p`.
+func parseTestRow(t *testing.T, p Project, headers []interface{}, row []interface{}) map[string]interface{} {
+	t.Helper()
+	if p.summary == nil {
+		p.summary = &runSummary{}
+	}
+	if p.config.NumberMode == "" {
+		p.config.NumberMode = cells.NumberModeString
+	}
+	// so the columns aren't prompted for
+	p.config.Columns = fmt.Sprintf("1-%d", len(headers))
+	parser, err := p.newRowParser(Pipeline{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.reset(p, 1)
+	if err := parser.parseHeader(headers, discardEmitter{}); err != nil {
+		t.Fatalf("parseHeader: %v", err)
+	}
+	fields, err := parser.parseRow(row, 2, time.Now())
+	if err != nil {
+		t.Fatalf("parseRow: %v", err)
+	}
+	return fields
+}
+
+func TestParseColumnOptions(t *testing.T) {
+	defaults := cellPolicy{Trim: true, Empty: emptyOmit}
+	tests := []struct {
+		s       string
+		want    map[string]cellPolicy
+		wantErr string
+	}{
+		{"", nil, ""},
+		{" ", nil, ""},
+		{"Notes:no-trim", map[string]cellPolicy{"Notes": {Trim: false, Empty: emptyOmit}}, ""},
+		{"Notes:no-trim:empty-as-empty, Comments : EMPTY-AS-NULL", map[string]cellPolicy{
+			"Notes":    {Trim: false, Empty: emptyEmpty},
+			"Comments": {Trim: true, Empty: emptyNull},
+		}, ""},
+		// the last option wins
+		{"Notes:empty-as-null:empty-omit", map[string]cellPolicy{"Notes": {Trim: true, Empty: emptyOmit}}, ""},
+		{"Done:checkbox:empty-as-null", map[string]cellPolicy{"Done": {Trim: true, Empty: emptyNull, Checkbox: true}}, ""},
+		{"Notes", nil, `"Notes", expected e.g.`},
+		{":trim", nil, `":trim", expected e.g.`},
+		{"Notes:lowercase", nil, `unknown option "lowercase" for column "Notes"`},
+	}
+	for _, tt := range tests {
+		got, err := parseColumnOptions(tt.s, defaults)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseColumnOptions(%q) error = %v, want %q", tt.s, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseColumnOptions(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestCellPolicies(t *testing.T) {
+	p := Project{}
+	p.config.EmptyAsNull = true
+	var err error
+	if p.columnOptions, err = parseColumnOptions("Notes:empty-omit:trim", p.defaultCellPolicy()); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := p.cellPolicies([]string{"Name", "Notes"})
+	want := []cellPolicy{{Empty: emptyNull}, {Trim: true, Empty: emptyOmit}}
+	if err != nil || !reflect.DeepEqual(policies, want) {
+		t.Errorf("cellPolicies = %v, %v, want %v", policies, err, want)
+	}
+	if _, err := p.cellPolicies([]string{"Name"}); err == nil || !strings.Contains(err.Error(), `column "Notes" not found`) {
+		t.Errorf("cellPolicies without the Notes column error = %v", err)
+	}
+}
+
+func TestParseRowCellPolicy(t *testing.T) {
+	headers := []interface{}{"Name", "Notes", "Email"}
+	tests := []struct {
+		name          string
+		trim, asNull  bool
+		columnOptions string
+		row           []interface{}
+		want          map[string]interface{}
+	}{
+		{"defaults", false, false, "", []interface{}{" Ann ", " ", ""},
+			map[string]interface{}{"Name": " Ann ", "Notes": " "}},
+		{"TRIM_CELLS", true, false, "", []interface{}{" Ann ", " ", ""},
+			map[string]interface{}{"Name": "Ann"}},
+		{"EMPTY_AS_NULL", false, true, "", []interface{}{"Ann"},
+			map[string]interface{}{"Name": "Ann", "Notes": nil, "Email": nil}},
+		{"COLUMN_OPTIONS", true, true, "Notes:no-trim:empty-as-empty,Email:empty-omit", []interface{}{" Ann ", " note ", " "},
+			map[string]interface{}{"Name": "Ann", "Notes": " note "}},
+		{"COLUMN_OPTIONS empty", true, true, "Notes:no-trim:empty-as-empty,Email:empty-omit", []interface{}{" Ann "},
+			map[string]interface{}{"Name": "Ann", "Notes": ""}},
+	}
+	for _, tt := range tests {
+		p := Project{}
+		p.config.TrimCells, p.config.EmptyAsNull = tt.trim, tt.asNull
+		var err error
+		if p.columnOptions, err = parseColumnOptions(tt.columnOptions, p.defaultCellPolicy()); err != nil {
+			t.Fatal(err)
+		}
+		if got := parseTestRow(t, p, headers, tt.row); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseRow(%q) = %q, want %q", tt.name, tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestParseRowCheckbox(t *testing.T) {
+	headers := []interface{}{"Name", "Done"}
+	spanish, ok := (cells.Format{}).WithLocale("es_ES")
+	if !ok {
+		t.Fatal("no es_ES locale")
+	}
+	tests := []struct {
+		format        cells.Format
+		columnOptions string
+		done          interface{}
+		want          interface{}
+	}{
+		{cells.Format{}, "Done:checkbox", "TRUE", true},
+		{cells.Format{}, "Done:checkbox", "FALSE", false},
+		{cells.Format{}, "Done:checkbox", " false ", false},
+		{spanish, "Done:checkbox", "VERDADERO", true},
+		{spanish, "Done:checkbox", "FALSO", false},
+		// values that aren't booleans are kept
+		{cells.Format{}, "Done:checkbox", "n/a", "n/a"},
+		{cells.Format{}, "Done:checkbox", "VERDADERO", "VERDADERO"},
+		// empty cells follow the empty policy
+		{cells.Format{}, "Done:checkbox:empty-as-null", "", nil},
+		// without the option, booleans stay strings
+		{cells.Format{}, "", "TRUE", "TRUE"},
+	}
+	for _, tt := range tests {
+		p := Project{format: tt.format}
+		var err error
+		if p.columnOptions, err = parseColumnOptions(tt.columnOptions, p.defaultCellPolicy()); err != nil {
+			t.Fatal(err)
+		}
+		fields := parseTestRow(t, p, headers, []interface{}{"Ann", tt.done})
+		if got, ok := fields["Done"]; !ok || got != tt.want {
+			t.Errorf("checkbox %q with %q = %#v, want %#v", tt.done, tt.columnOptions, got, tt.want)
+		}
+	}
+}
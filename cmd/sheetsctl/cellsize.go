@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// `MAX_CELL_POLICY` values, for cells larger than `MAX_CELL_BYTES`.
+const (
+	cellPolicyTruncate = "truncate"
+	cellPolicyDrop     = "drop"
+	cellPolicyFail     = "fail"
+)
+
+// truncatedSuffix marks the cells truncated to `MAX_CELL_BYTES`.
+const truncatedSuffix = "…[truncated]"
+
+var errCellTooLarge = errors.New("cell too large")
+
+// cellSize locates a cell of the sheet, and tells its size.
+type cellSize struct {
+	Bytes  int    `json:"bytes"`
+	Row    int    `json:"row"`
+	Column string `json:"column"`
+}
+
+// guardCellSize records the size of the cell of the sheet row `row` and the
+// `column` holding `value`, and applies the `MAX_CELL_POLICY` when it's larger
+// than `MAX_CELL_BYTES`: the value to use instead is returned (empty when the
+// cell is dropped), or an `errCellTooLarge` error.
+func (p Project) guardCellSize(value string, row int, column string) (string, error) {
+	if largest := p.summary.LargestCell; len(value) > 0 && (largest == nil || len(value) > largest.Bytes) {
+		p.summary.LargestCell = &cellSize{Bytes: len(value), Row: row, Column: column}
+	}
+	max := p.config.MaxCellBytes
+	if max <= 0 || len(value) <= max {
+		return value, nil
+	}
+	p.summary.OversizedCells++
+	switch p.config.MaxCellPolicy {
+	case cellPolicyDrop:
+		p.warnAt(Warning{Code: warnCellSize, Row: row, Column: column, Message: fmt.Sprintf("Dropping the cells of column %q larger than MAX_CELL_BYTES (%d)", column, max)})
+		return "", nil
+	case cellPolicyFail:
+		return "", fmt.Errorf("%w: the cell of row %d and column %q has %d bytes, MAX_CELL_BYTES is %d", errCellTooLarge, row, column, len(value), max)
+	}
+	p.warnAt(Warning{Code: warnCellSize, Row: row, Column: column, Message: fmt.Sprintf("Truncating the cells of column %q larger than MAX_CELL_BYTES (%d)", column, max)})
+	return truncateUTF8(value, max) + truncatedSuffix, nil
+}
+
+// truncateUTF8 returns the first `n` bytes of `s` at most, without splitting a
+// character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// printLargestCell tells where the largest cell of the run is, when some cell
+// was larger than `MAX_CELL_BYTES`.
+func (p Project) printLargestCell() {
+	if p.summary.OversizedCells == 0 || p.summary.LargestCell == nil {
+		return
+	}
+	largest := p.summary.LargestCell
+	fmt.Printf("\n%d cells were larger than MAX_CELL_BYTES (%d), the largest has %d bytes (row %d, column %q)\n", p.summary.OversizedCells, p.config.MaxCellBytes, largest.Bytes, largest.Row, largest.Column)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		// the limit exactly
+		{"hello", 5, "hello"},
+		{"hello", 4, "hell"},
+		{"hello", 0, ""},
+		// "\u00e9" is 2 bytes, "\u20ac" 3, and "\U0001f600" 4: a
+		// character cut by the limit is left out whole
+		{"caf\u00e9", 5, "caf\u00e9"},
+		{"caf\u00e9", 4, "caf"},
+		{"\u20ac\u20ac", 5, "\u20ac"},
+		{"\u20ac\u20ac", 3, "\u20ac"},
+		{"\u20ac\u20ac", 2, ""},
+		{"a\U0001f600b", 4, "a"},
+		{"a\U0001f600b", 5, "a\U0001f600"},
+	}
+	for _, tt := range tests {
+		if got := truncateUTF8(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestGuardCellSize(t *testing.T) {
+	tests := []struct {
+		policy string
+		value  string
+		want   string
+		err    bool
+	}{
+		// the limit exactly is kept as is
+		{cellPolicyTruncate, "12345678", "12345678", false},
+		{cellPolicyTruncate, "123456789", "12345678" + truncatedSuffix, false},
+		{"", "123456789", "12345678" + truncatedSuffix, false},
+		// not splitting the 3 bytes of "\u20ac" at the limit
+		{cellPolicyTruncate, "1234567\u20ac", "1234567" + truncatedSuffix, false},
+		{cellPolicyDrop, "123456789", "", false},
+		{cellPolicyFail, "123456789", "", true},
+		{cellPolicyFail, "12345678", "12345678", false},
+	}
+	for _, tt := range tests {
+		p := Project{summary: &runSummary{}}
+		p.config.MaxCellBytes = 8
+		p.config.MaxCellPolicy = tt.policy
+		got, err := p.guardCellSize(tt.value, 3, "Notes")
+		if got != tt.want || (err != nil) != tt.err || (err != nil && !errors.Is(err, errCellTooLarge)) {
+			t.Errorf("%s: guardCellSize(%q) = %q, %v, want %q, error %v", tt.policy, tt.value, got, err, tt.want, tt.err)
+		}
+		oversized := len(tt.value) > 8
+		if (p.summary.OversizedCells == 1) != oversized {
+			t.Errorf("%s: oversized cells of %q = %d", tt.policy, tt.value, p.summary.OversizedCells)
+		}
+		if largest := p.summary.LargestCell; largest == nil || largest.Bytes != len(tt.value) || largest.Row != 3 || largest.Column != "Notes" {
+			t.Errorf("%s: largest cell of %q = %+v", tt.policy, tt.value, largest)
+		}
+		// only the truncated and dropped cells are warned about
+		if warned := len(p.summary.Warnings) == 1 && p.summary.Warnings[0].Code == warnCellSize; warned != (oversized && tt.policy != cellPolicyFail) {
+			t.Errorf("%s: warnings of %q = %+v", tt.policy, tt.value, p.summary.Warnings)
+		}
+	}
+
+	// without MAX_CELL_BYTES, the largest cell is still tracked
+	p := Project{summary: &runSummary{}}
+	for row, value := range []string{"ab", strings.Repeat("x", 100), "abc", ""} {
+		if got, err := p.guardCellSize(value, row+2, "Notes"); got != value || err != nil {
+			t.Errorf("guardCellSize(%q) without a limit = %q, %v", value, got, err)
+		}
+	}
+	if largest := p.summary.LargestCell; largest == nil || largest.Bytes != 100 || largest.Row != 3 || p.summary.OversizedCells != 0 {
+		t.Errorf("largest cell = %+v, oversized %d, want 100 bytes on row 3", largest, p.summary.OversizedCells)
+	}
+}
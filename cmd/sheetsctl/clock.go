@@ -0,0 +1,22 @@
+package main
+
+import "time"
+
+// clock tells the time and waits, so that timing (e.g. the backoff before
+// retries, and how much of the run it took) can be tested deterministically.
+type clock interface {
+	Now() time.Time
+	// Timer is like `time.NewTimer`: the channel receives once `d` has
+	// passed, unless the returned function stops it first
+	Timer(d time.Duration) (<-chan time.Time, func())
+}
+
+// realClock is the `clock` of the runs, i.e. the time package's.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) Timer(d time.Duration) (<-chan time.Time, func()) {
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
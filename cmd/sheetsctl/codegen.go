@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// generateStruct reads the sheet's header (unless it's pinned with `HEADERS`)
+// and up to `GenSampleRows` rows for type inference, and writes a Go source
+// file declaring a struct with a field per column, tagged with the header
+// name, e.g.:
+//
+//	type ClassData struct {
+//		StudentName string `sheet:"Student Name"`
+//	}
+func (p Project) generateStruct() {
+	firstRow, _ := p.rowWindow(0)
+	rows, err := p.fetchRows(firstRow, firstRow+p.config.GenSampleRows)
+	if err != nil {
+		fatalf("Unable to retrieve data from sheet: %v", err)
+	}
+	var headers []interface{}
+	if p.headers != nil {
+		headers = p.fitHeaders(p.headers, rows)
+	} else {
+		if len(rows) == 0 {
+			fatalf("Sheet '%s' has no header row", p.config.SheetName)
+		}
+		headers, rows = rows[0], rows[1:]
+	}
+	samples := make([][]string, len(headers))
+	header := cells.NewHeader(headers)
+	for _, values := range rows {
+		row := header.Row(values, p.format)
+		for i := range headers {
+			if cell, ok := row.Cell(i); ok {
+				samples[i] = append(samples[i], fmt.Sprint(cell))
+			}
+		}
+	}
+	columns := make([]structColumn, len(headers))
+	for i, header := range headers {
+		columns[i] = structColumn{Header: fmt.Sprint(header), Type: p.format.InferColumnType(samples[i])}
+		if p.format.SuggestsDecimalComma(samples[i]) {
+			fmt.Fprintf(os.Stderr, "Column %q looks like decimal-comma numbers (e.g. \"1.234,56\"), set DECIMAL_COMMA=true to read it as numbers\n", header)
+		}
+		if columns[i].Type == cells.TypeTime {
+			// a redacted column's sample value mustn't end up in the struct
+			if !p.isRedacted(fmt.Sprint(header)) {
+				columns[i].AmbiguousDate = p.format.AmbiguousDate(samples[i])
+			}
+			columns[i].DateOrder = p.format.DateOrder()
+			if columns[i].AmbiguousDate != "" {
+				fmt.Fprintf(os.Stderr, "Column %q has ambiguous dates (e.g. %q), read %s\n", header, columns[i].AmbiguousDate, p.format.DateOrder())
+			}
+		}
+	}
+	if p.config.IncludeValidation {
+		// dropdowns are set on the data cells, not the header
+		validations, err := p.columnValidations(firstRow + 1)
+		if err != nil {
+			fatalf("Unable to retrieve data validation from sheet: %v", err)
+		}
+		for i, validation := range validations {
+			if i < len(columns) {
+				columns[i].Validation = validation
+			}
+		}
+	}
+	if p.config.IncludeColumnMetadata {
+		dimensions, err := p.columnDimensions()
+		if err != nil {
+			fatalf("Unable to retrieve column metadata from sheet: %v", err)
+		}
+		for i, dimension := range dimensions {
+			if i < len(columns) {
+				dimension := dimension
+				columns[i].Dimension = &dimension
+			}
+		}
+	}
+	structName := p.config.GenStructName
+	if structName == "" {
+		structName = goIdentifier(p.config.SheetName, "Sheet")
+	}
+	src, err := generateStructSource(p.config.GenPackage, structName, columns)
+	if err != nil {
+		fatalf("Unable to generate struct: %v", err)
+	}
+	if p.config.GenOutput == "" {
+		fmt.Print(string(src))
+		return
+	}
+	if err := writeFileAtomic(p.config.GenOutput, src, 0644); err != nil {
+		fatalf("Unable to write generated struct: %v", err)
+	}
+	fmt.Printf("Generated struct %s written to: %s\n", structName, p.config.GenOutput)
+}
+
+// structColumn is a sheet column to generate a struct field for.
+type structColumn struct {
+	Header     string
+	Type       string
+	Validation columnValidation
+	// AmbiguousDate is a sample value that's a different date in the other
+	// day/month order, if any
+	AmbiguousDate string
+	DateOrder     string
+	// Dimension is set with `INCLUDE_COLUMN_METADATA`
+	Dimension *columnDimension
+}
+
+// generateStructSource returns the gofmt'ed source of a file in package `pkg`
+// declaring the `name` struct with a field per column.
+func generateStructSource(pkg, name string, columns []structColumn) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated from a Google Sheets header; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	for _, column := range columns {
+		if column.Type == cells.TypeTime {
+			b.WriteString("import \"time\"\n\n")
+			break
+		}
+	}
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	used := map[string]int{}
+	for i, column := range columns {
+		field := goIdentifier(column.Header, "Column"+a1.ColumnLetter(i))
+		// header names can collide once converted, e.g. "Name" and "name"
+		used[field]++
+		if used[field] > 1 {
+			field = fmt.Sprintf("%s%d", field, used[field])
+		}
+		switch {
+		case len(column.Validation.Allowed) > 0:
+			quoted := make([]string, len(column.Validation.Allowed))
+			for j, v := range column.Validation.Allowed {
+				quoted[j] = strconv.Quote(v)
+			}
+			fmt.Fprintf(&b, "\t// One of: %s.\n", strings.Join(quoted, ", "))
+		case column.Validation.Unresolved != "":
+			fmt.Fprintf(&b, "\t// NOTE: constrained to the unresolved range %s.\n", column.Validation.Unresolved)
+		}
+		if d := column.Dimension; d != nil {
+			hidden := ""
+			if d.HiddenByUser {
+				hidden = ", hidden"
+			}
+			fmt.Fprintf(&b, "\t// Column %s, %dpx wide%s.\n", d.Column, d.PixelSize, hidden)
+		}
+		if column.AmbiguousDate != "" {
+			fmt.Fprintf(&b, "\t// NOTE: dates like %q are ambiguous, they're read %s.\n", column.AmbiguousDate, column.DateOrder)
+		}
+		fmt.Fprintf(&b, "\t%s %s `sheet:%q`\n", field, column.Type, column.Header)
+	}
+	b.WriteString("}\n")
+	return format.Source(b.Bytes())
+}
+
+// goIdentifier converts `s` into an exported CamelCase Go identifier, e.g.
+// "Student Name" becomes "StudentName"; `fallback` is used when `s` has no
+// letters or digits.
+func goIdentifier(s, fallback string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	id := b.String()
+	if id == "" {
+		return fallback
+	}
+	if unicode.IsDigit([]rune(id)[0]) {
+		return fallback + id
+	}
+	return id
+}
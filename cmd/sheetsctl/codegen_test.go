@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoIdentifier(t *testing.T) {
+	tests := []struct {
+		s, fallback string
+		want        string
+	}{
+		{"Student Name", "Sheet", "StudentName"},
+		{"student_name", "Sheet", "StudentName"},
+		{"Class Data", "Sheet", "ClassData"},
+		{"e-mail (work)", "Sheet", "EMailWork"},
+		{"ñandú count", "Sheet", "ÑandúCount"},
+		// no letters or digits
+		{"", "ColumnA", "ColumnA"},
+		{"#!?", "ColumnB", "ColumnB"},
+		// identifiers can't start with a digit
+		{"2024 Total", "ColumnC", "ColumnC2024Total"},
+	}
+	for _, tt := range tests {
+		if got := goIdentifier(tt.s, tt.fallback); got != tt.want {
+			t.Errorf("goIdentifier(%q, %q) = %q, want %q", tt.s, tt.fallback, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateStructSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []structColumn
+		want    string
+	}{
+		{"fields", []structColumn{
+			{Header: "Student Name", Type: "string"},
+			{Header: "Age", Type: "int64"},
+			{Header: "", Type: "float64"},
+		}, "type ClassData struct {\n" +
+			"\tStudentName string  `sheet:\"Student Name\"`\n" +
+			"\tAge         int64   `sheet:\"Age\"`\n" +
+			"\tColumnC     float64 `sheet:\"\"`\n" +
+			"}\n"},
+		// headers colliding once converted are numbered
+		{"collisions", []structColumn{
+			{Header: "Name", Type: "string"},
+			{Header: "name", Type: "string"},
+			{Header: "NAME!", Type: "string"},
+		}, "type ClassData struct {\n" +
+			"\tName  string `sheet:\"Name\"`\n" +
+			"\tName2 string `sheet:\"name\"`\n" +
+			"\tNAME  string `sheet:\"NAME!\"`\n" +
+			"}\n"},
+		{"time", []structColumn{
+			{Header: "Joined", Type: "time.Time", AmbiguousDate: "01/02/2024", DateOrder: "month-first"},
+		}, "import \"time\"\n\n" +
+			"type ClassData struct {\n" +
+			"\t// NOTE: dates like \"01/02/2024\" are ambiguous, they're read month-first.\n" +
+			"\tJoined time.Time `sheet:\"Joined\"`\n" +
+			"}\n"},
+		{"validation", []structColumn{
+			{Header: "Level", Type: "string", Validation: columnValidation{Allowed: []string{"Junior", "Senior"}}},
+			{Header: "State", Type: "string", Validation: columnValidation{Unresolved: "=Lists!A2:A"}},
+			{Header: "Notes", Type: "string", Dimension: &columnDimension{Column: "C", PixelSize: 120, HiddenByUser: true}},
+		}, "type ClassData struct {\n" +
+			"\t// One of: \"Junior\", \"Senior\".\n" +
+			"\tLevel string `sheet:\"Level\"`\n" +
+			"\t// NOTE: constrained to the unresolved range =Lists!A2:A.\n" +
+			"\tState string `sheet:\"State\"`\n" +
+			"\t// Column C, 120px wide, hidden.\n" +
+			"\tNotes string `sheet:\"Notes\"`\n" +
+			"}\n"},
+	}
+	for _, tt := range tests {
+		src, err := generateStructSource("sheets", "ClassData", tt.columns)
+		if err != nil {
+			t.Errorf("%s: generateStructSource: %v", tt.name, err)
+			continue
+		}
+		want := "// Code generated from a Google Sheets header; DO NOT EDIT.\n\npackage sheets\n\n" + tt.want
+		if string(src) != want {
+			t.Errorf("%s: generateStructSource =\n%s\nwant:\n%s", tt.name, src, want)
+		}
+	}
+
+	if _, err := generateStructSource("sheets", "Class Data", nil); err == nil || !strings.Contains(err.Error(), "expected") {
+		t.Errorf("generateStructSource of an invalid name error = %v, want a syntax error", err)
+	}
+}
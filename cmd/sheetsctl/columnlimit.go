@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// capColumns returns the `ranges` limited to `max` columns in total (0 leaves
+// them as is): ranges past the limit are dropped and the one reaching it is
+// narrowed. The number of columns cut is returned too.
+func capColumns(ranges []a1.Range, max int) ([]a1.Range, int) {
+	if max <= 0 {
+		return ranges, 0
+	}
+	capped, left, cut := []a1.Range{}, max, 0
+	for _, r := range ranges {
+		if left == 0 {
+			cut += r.Width()
+			continue
+		}
+		if r.Width() > left {
+			cut += r.Width() - left
+			r.EndColumn = r.StartColumn + left - 1
+		}
+		left -= r.Width()
+		capped = append(capped, r)
+	}
+	return capped, cut
+}
+
+// readRangesString returns the `ranges` in `READ_RANGES` notation, e.g.
+// "A:C,K:M".
+func readRangesString(ranges []a1.Range) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// printHeaders prints the column letters and names of the sheet's header row,
+// across the whole width of the sheet (up to `MAX_COLUMNS`) rather than just
+// the `READ_RANGES`, so `COLUMNS` or `READ_RANGES` can be set from them. Only
+// the header row is read, with a single request.
+func (p Project) printHeaders() {
+	info, err := p.GetSheetInfo(p.config.SheetName)
+	if err != nil {
+		fatalf("Unable to retrieve the sheet's metadata: %v", err)
+	}
+	width := info.ColumnCount
+	if width == 0 {
+		fatalf("Sheet '%s' has no columns", p.config.SheetName)
+	}
+	if p.config.MaxColumns > 0 && width > p.config.MaxColumns {
+		log.Printf("WARNING: sheet '%s' has %d columns, only listing the first %d (MAX_COLUMNS)", p.config.SheetName, width, p.config.MaxColumns)
+		width = p.config.MaxColumns
+	}
+	headerRow, _ := p.rowWindow(math.MaxInt32)
+	resp, err := p.getValues(a1.Range{StartColumn: 0, EndColumn: width - 1}.Rows(p.config.SheetName, headerRow, headerRow))
+	if err != nil {
+		fatalf("Unable to retrieve the header row: %v", err)
+	}
+	if len(resp.Values) == 0 {
+		fatalf("Header row %d of sheet '%s' is blank", headerRow, p.config.SheetName)
+	}
+	for column, header := range resp.Values[0] {
+		if header != "" {
+			fmt.Printf("%s\t%v\n", a1.ColumnLetter(column), header)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+func TestCapColumns(t *testing.T) {
+	ac := a1.Range{StartColumn: 0, EndColumn: 2}
+	km := a1.Range{StartColumn: 10, EndColumn: 12}
+	tests := []struct {
+		name   string
+		ranges []a1.Range
+		max    int
+		want   []a1.Range
+		cut    int
+	}{
+		{"no limit", []a1.Range{ac, km}, 0, []a1.Range{ac, km}, 0},
+		{"under the limit", []a1.Range{ac, km}, 10, []a1.Range{ac, km}, 0},
+		{"exactly the limit", []a1.Range{ac, km}, 6, []a1.Range{ac, km}, 0},
+		{"narrows the range reaching the limit", []a1.Range{ac, km}, 4, []a1.Range{ac, {StartColumn: 10, EndColumn: 10}}, 2},
+		{"drops the ranges past the limit", []a1.Range{ac, km}, 3, []a1.Range{ac}, 3},
+		{"within the first range", []a1.Range{ac, km}, 1, []a1.Range{{StartColumn: 0, EndColumn: 0}}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cut := capColumns(tt.ranges, tt.max)
+			if !reflect.DeepEqual(got, tt.want) || cut != tt.cut {
+				t.Errorf("capColumns(%v, %d) = %v, %d, want %v, %d", tt.ranges, tt.max, got, cut, tt.want, tt.cut)
+			}
+		})
+	}
+	if got := readRangesString([]a1.Range{ac, {StartColumn: 10, EndColumn: 10}}); got != "A:C,K:K" {
+		t.Errorf("readRangesString = %q, want %q", got, "A:C,K:K")
+	}
+}
+
+// TestHeadersRun lists the header row across the sheet's whole width, skipping
+// its blank cells, and then only up to `MAX_COLUMNS`.
+func TestHeadersRun(t *testing.T) {
+	run := newCommandRun(t)
+	run.sheets.SetValues("spreadsheet-id", "Wide", [][]interface{}{
+		{"Name", "", "Major", "Notes"},
+		{"Ann", "x", "Math", "first"},
+	})
+	run.Setenv("SHEET_NAME", "Wide")
+	run.Setenv("READ_RANGES", "A:B")
+	stdout, code := run.Run("headers")
+	if code != 0 {
+		t.Fatalf("exit code %d, want 0", code)
+	}
+	if want := "A\tName\nC\tMajor\nD\tNotes\n"; !strings.HasSuffix(stdout, want) {
+		t.Errorf("stdout = %q, want it to end with %q", stdout, want)
+	}
+
+	run.Setenv("MAX_COLUMNS", "3")
+	stdout, stderr, code := run.RunStderr("headers")
+	if code != 0 {
+		t.Fatalf("exit code %d, want 0", code)
+	}
+	if want := "A\tName\nC\tMajor\n"; !strings.HasSuffix(stdout, want) {
+		t.Errorf("stdout = %q, want it to end with %q", stdout, want)
+	}
+	if want := "only listing the first 3 (MAX_COLUMNS)"; !strings.Contains(stderr, want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr, want)
+	}
+}
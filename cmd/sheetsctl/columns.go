@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRangePosition is the largest position of a range list, the number of
+// columns of a sheet (up to "ZZZ"), so a range like "1-50000000" is rejected
+// before being expanded.
+const maxRangePosition = 18278
+
+var (
+	errInvalidRangeList = errors.New("invalid range list")
+
+	stdinLines     = make(chan string)
+	stdinLinesOnce sync.Once
+)
+
+// parseRangeList parses a comma-separated list of 1-based positions and
+// inclusive ranges (e.g. "1,3-5,9") and returns the positions in ascending
+// order without duplicates; positions are at most `maxRangePosition`.
+func parseRangeList(s string) ([]int, error) {
+	seen := map[int]bool{}
+	positions := []int{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("%w: %q", errInvalidRangeList, part)
+		}
+		end := start
+		if len(bounds) == 2 {
+			end, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+			if err != nil || end < start {
+				return nil, fmt.Errorf("%w: %q", errInvalidRangeList, part)
+			}
+		}
+		if end > maxRangePosition {
+			return nil, fmt.Errorf("%w: %q is past the %d columns a sheet can have", errInvalidRangeList, part, maxRangePosition)
+		}
+		for n := start; n <= end; n++ {
+			if !seen[n] {
+				seen[n] = true
+				positions = append(positions, n)
+			}
+		}
+	}
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("%w: %q", errInvalidRangeList, s)
+	}
+	sort.Ints(positions)
+	return positions, nil
+}
+
+// columnSelection converts the 1-based `positions` into a set of 0-based
+// header indices, returning an error if a position is outside of the
+// `headerCount` columns.
+func columnSelection(positions []int, headerCount int) (map[int]bool, error) {
+	selected := map[int]bool{}
+	for _, n := range positions {
+		if n > headerCount {
+			return nil, fmt.Errorf("column %d doesn't exist, the sheet has %d columns", n, headerCount)
+		}
+		selected[n-1] = true
+	}
+	return selected, nil
+}
+
+// isInteractive reports whether stdin is attached to a terminal; checking for
+// a character device isn't enough, since /dev/null is one.
+func isInteractive() bool {
+	return isTerminal(int(os.Stdin.Fd()))
+}
+
+// readStdinLine waits up to `timeout` for a line from stdin; `ok` is false if
+// the timeout was reached first.
+//
+// NOTE: stdin is read by a single background goroutine so a prompt that timed
+// out doesn't leave a competing reader behind for the next prompt.
+func readStdinLine(timeout time.Duration) (line string, ok bool) {
+	stdinLinesOnce.Do(func() {
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				stdinLines <- scanner.Text()
+			}
+			close(stdinLines)
+		}()
+	})
+	select {
+	case line, ok = <-stdinLines:
+		return line, ok
+	case <-time.After(timeout):
+		return "", false
+	}
+}
+
+// promptColumnSelection prints the sheet headers with their positions and lets
+// the user type the columns to include (e.g. "1,3-5,9"); an empty answer, or no
+// answer within `ColumnPromptTimeout`, selects all columns (nil).
+func (p Project) promptColumnSelection(headers []interface{}) map[int]bool {
+	fmt.Println("\nAvailable columns:")
+	for i, header := range headers {
+		fmt.Printf("\t%d. %v\n", i+1, header)
+	}
+	for {
+		fmt.Printf("Select the columns to include (e.g. \"1,3-5,9\"), or press Enter for all [%s]: ", p.config.ColumnPromptTimeout)
+		answer, ok := readStdinLine(p.config.ColumnPromptTimeout)
+		if !ok {
+			fmt.Println("\nNo selection made, using all columns.")
+			return nil
+		}
+		if strings.TrimSpace(answer) == "" {
+			return nil
+		}
+		positions, err := parseRangeList(answer)
+		if err == nil {
+			var selected map[int]bool
+			selected, err = columnSelection(positions, len(headers))
+			if err == nil {
+				return selected
+			}
+		}
+		fmt.Printf("Invalid selection: %v\n", err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseRangeList(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []int
+	}{
+		{"1", []int{1}},
+		{"1,3", []int{1, 3}},
+		{"3-5", []int{3, 4, 5}},
+		{"1,3-5,9", []int{1, 3, 4, 5, 9}},
+		{"9,1,3-4", []int{1, 3, 4, 9}},
+		{"2-2", []int{2}},
+		{"1-3,2-4", []int{1, 2, 3, 4}},
+		{"1,1,1", []int{1}},
+		{" 1 , 3 - 4 ", []int{1, 3, 4}},
+		{"1,,2,", []int{1, 2}},
+		{"18278", []int{18278}},
+	}
+	for _, tt := range tests {
+		got, err := parseRangeList(tt.in)
+		if err != nil {
+			t.Errorf("parseRangeList(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseRangeList(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRangeListInvalid(t *testing.T) {
+	for _, in := range []string{"", ",", "0", "-1", "a", "1-a", "5-3", "1,x", "1-2-3"} {
+		if got, err := parseRangeList(in); !errors.Is(err, errInvalidRangeList) {
+			t.Errorf("parseRangeList(%q) = %v, %v, want errInvalidRangeList", in, got, err)
+		}
+	}
+}
+
+// TestParseRangeListOversized rejects ranges past the columns a sheet can have
+// without expanding them first.
+func TestParseRangeListOversized(t *testing.T) {
+	for _, in := range []string{"18279", "1-50000000", "1,2-9223372036854775807"} {
+		allocs := testing.AllocsPerRun(1, func() {
+			if got, err := parseRangeList(in); !errors.Is(err, errInvalidRangeList) {
+				t.Errorf("parseRangeList(%q) = %d positions, %v, want errInvalidRangeList", in, len(got), err)
+			}
+		})
+		if allocs > 20 {
+			t.Errorf("parseRangeList(%q) made %v allocations, want the range left unexpanded", in, allocs)
+		}
+	}
+}
+
+func TestColumnSelection(t *testing.T) {
+	got, err := columnSelection([]int{1, 3}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int]bool{0: true, 2: true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columnSelection = %v, want %v", got, want)
+	}
+	if _, err := columnSelection([]int{4}, 3); err == nil {
+		t.Error("columnSelection accepted a position past the last column")
+	}
+}
+
+// TestIsInteractiveDevNull doesn't take /dev/null, a character device, for a
+// terminal.
+func TestIsInteractiveDevNull(t *testing.T) {
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stdin := os.Stdin
+	os.Stdin = devNull
+	defer func() { os.Stdin = stdin }()
+	if isInteractive() {
+		t.Errorf("isInteractive() with stdin from %s = true, want false", os.DevNull)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Where a config variable comes from, as reported by the `config` subcommand.
+const (
+	sourceDefault = "default"
+	sourceDotEnv  = ".env"
+	sourceEnv     = "env"
+	sourceRemote  = "remote"
+)
+
+// configSources records which layer set each config variable, as the layers
+// are merged: the environment, then the `.env` file (which overrides it), then
+// the `CONFIG_URL` config (which only sets what's still unset).
+type configSources map[string]string
+
+// newConfigSources returns the sources of the variables set in the
+// environment, before any other layer is merged.
+func newConfigSources() configSources {
+	sources := configSources{}
+	for _, kv := range os.Environ() {
+		sources[strings.SplitN(kv, "=", 2)[0]] = sourceEnv
+	}
+	return sources
+}
+
+// Set records the `source` of the variables `keys`.
+func (s configSources) Set(keys []string, source string) {
+	for _, key := range keys {
+		s[key] = source
+	}
+}
+
+// Of returns the source of the variable `key`, `sourceDefault` when no layer
+// set it.
+func (s configSources) Of(key string) string {
+	if source, ok := s[key]; ok {
+		return source
+	}
+	return sourceDefault
+}
+
+// configEntry is a field of the resolved config, printed by the `config`
+// subcommand.
+type configEntry struct {
+	Field    string      `json:"field"`
+	Variable string      `json:"variable"`
+	Value    interface{} `json:"value"`
+	Source   string      `json:"source"`
+}
+
+// printConfig prints the resolved `config` as JSON, with the source of each
+// field; the `secretConfigFields` are replaced by a fingerprint, so two
+// configs can be compared without revealing them.
+func printConfig(config Config, sources configSources) error {
+	entries := []configEntry{}
+	v := reflect.ValueOf(config)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		variable := field.Tag.Get("envconfig")
+		value := v.Field(i).Interface()
+		switch {
+		case secretConfigFields[field.Name]:
+			if !v.Field(i).IsZero() {
+				value = fingerprint(fmt.Sprint(value))
+			}
+		case v.Field(i).Kind() == reflect.String:
+			value = redactSecrets(v.Field(i).String())
+		}
+		entries = append(entries, configEntry{Field: field.Name, Variable: variable, Value: value, Source: sources.Of(variable)})
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// fingerprint returns a short, non-reversible fingerprint of the secret `s`,
+// e.g. "sha256:1a2b3c4d".
+func fingerprint(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])[:8]
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// `CONTROL_SOCKET` commands, sent as one JSON object per line, e.g.
+// {"command":"status"}.
+const (
+	// controlCommandStatus returns the live `controlStatus` of the run
+	controlCommandStatus = "status"
+	// controlCommandPause holds the fetcher before the next batch, until
+	// resumed
+	controlCommandPause  = "pause"
+	controlCommandResume = "resume"
+	// controlCommandStop stops the run before the next batch, as
+	// `MAX_RUN_DURATION` does: the rows read so far are output and the run is
+	// partial
+	controlCommandStop = "stop"
+)
+
+// controlStatus is the live state of the run, as of the last batch boundary.
+type controlStatus struct {
+	Rows    int `json:"rows"`
+	Batches int `json:"batches"`
+	Retries int `json:"retries"`
+	// Range is the batch being read, or the last one read
+	Range   string `json:"range"`
+	Paused  bool   `json:"paused"`
+	Stopped bool   `json:"stopped"`
+}
+
+// controlRequest is a command sent to the `CONTROL_SOCKET`, and controlReply
+// its reply.
+type controlRequest struct {
+	Command string `json:"command"`
+}
+
+type controlReply struct {
+	Status *controlStatus `json:"status,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// controlSocket serves the `CONTROL_SOCKET` commands: the connections are
+// handled in their own goroutines, so the run never waits on a client, and
+// the state they share with the run is guarded by `mu`. Its methods are no-ops
+// when nil, i.e. without `CONTROL_SOCKET`.
+type controlSocket struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	status controlStatus
+	// resumed is closed when the run is resumed (or stopped), waking up the
+	// fetcher held by a pause
+	resumed chan struct{}
+}
+
+var errControlSocketInUse = errors.New("control socket in use")
+
+// listenControlSocket listens on the Unix socket `path`, replacing a stale
+// socket left by a run that exited without removing it, i.e. one nothing
+// accepts connections on; a socket another run listens on is left alone.
+func listenControlSocket(path string) (*controlSocket, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: %s", errControlSocketInUse, path)
+		}
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	c := &controlSocket{listener: listener}
+	go c.serve()
+	return c, nil
+}
+
+func (c *controlSocket) serve() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		go c.handle(conn)
+	}
+}
+
+// handle replies to the commands of the connection `conn`, until it's closed.
+func (c *controlSocket) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var request controlRequest
+		reply := controlReply{}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			reply.Error = fmt.Sprintf("invalid command: %v", err)
+		} else if status, err := c.Do(request.Command); err != nil {
+			reply.Error = err.Error()
+		} else {
+			reply.Status = &status
+		}
+		if err := encoder.Encode(reply); err != nil {
+			return
+		}
+	}
+}
+
+// Do runs the `command`, and returns the resulting status.
+func (c *controlSocket) Do(command string) (controlStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch command {
+	case controlCommandStatus:
+	case controlCommandPause:
+		if !c.status.Paused && !c.status.Stopped {
+			c.status.Paused = true
+			c.resumed = make(chan struct{})
+			log.Printf("Paused through CONTROL_SOCKET")
+		}
+	case controlCommandResume:
+		c.resume()
+	case controlCommandStop:
+		if !c.status.Stopped {
+			c.status.Stopped = true
+			c.resume()
+			log.Printf("Stop requested through CONTROL_SOCKET")
+		}
+	default:
+		return controlStatus{}, fmt.Errorf("unknown command %q (want status, pause, resume, or stop)", command)
+	}
+	return c.status, nil
+}
+
+// resume wakes up the fetcher held by a pause; `mu` must be held.
+func (c *controlSocket) resume() {
+	if c.status.Paused {
+		c.status.Paused = false
+		close(c.resumed)
+	}
+}
+
+// Publish records the live counts of the `summary` and the batch `readRange`
+// for the `status` command.
+func (c *controlSocket) Publish(summary *runSummary, readRange string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.Rows, c.status.Batches, c.status.Retries = summary.Rows, summary.Batches, summary.Retries
+	c.status.Range = readRange
+}
+
+// Wait holds the fetcher while the run is paused, up to the `deadline` (none
+// when zero), and reports whether it was stopped.
+func (c *controlSocket) Wait(deadline time.Time) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	resumed, paused := c.resumed, c.status.Paused
+	c.mu.Unlock()
+	if paused {
+		if deadline.IsZero() {
+			<-resumed
+		} else {
+			timer := time.NewTimer(time.Until(deadline))
+			select {
+			case <-resumed:
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status.Stopped
+}
+
+// Close stops serving, and removes the socket.
+func (c *controlSocket) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.listener.Close()
+}
+
+// stopRequested reports whether the run was stopped through the
+// `CONTROL_SOCKET` (waiting while it's paused), or its `MAX_RUN_DURATION` ran
+// out while paused, in which case the `batch` about to be read is recorded as
+// the first one left unread.
+func (p Project) stopRequested(batch Batch) bool {
+	if p.control.Wait(p.deadline) {
+		p.stopBefore(batch, "Stop requested through CONTROL_SOCKET")
+		return true
+	}
+	return p.timeLimitReached(batch)
+}
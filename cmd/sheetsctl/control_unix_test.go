@@ -0,0 +1,168 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// controlSocketPath returns a path for a control socket, short enough for
+// the length limit of Unix socket paths.
+func controlSocketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "control")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "run.sock")
+}
+
+// controlClient sends commands to a control socket.
+type controlClient struct {
+	t       *testing.T
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+func dialControl(t *testing.T, path string) *controlClient {
+	t.Helper()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &controlClient{t: t, conn: conn, scanner: bufio.NewScanner(conn)}
+}
+
+// Send sends the `command` line and returns the reply.
+func (c *controlClient) Send(command string) controlReply {
+	c.t.Helper()
+	if _, err := c.conn.Write([]byte(command + "\n")); err != nil {
+		c.t.Fatal(err)
+	}
+	if !c.scanner.Scan() {
+		c.t.Fatalf("no reply to %s: %v", command, c.scanner.Err())
+	}
+	var reply controlReply
+	if err := json.Unmarshal(c.scanner.Bytes(), &reply); err != nil {
+		c.t.Fatal(err)
+	}
+	return reply
+}
+
+func TestControlSocket(t *testing.T) {
+	path := controlSocketPath(t)
+	control, err := listenControlSocket(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer control.Close()
+	control.Publish(&runSummary{Rows: 10, Batches: 2, Retries: 1}, "id 'Sheet1'!A1:C10")
+	client := dialControl(t, path)
+
+	reply := client.Send(`{"command":"status"}`)
+	want := controlStatus{Rows: 10, Batches: 2, Retries: 1, Range: "id 'Sheet1'!A1:C10"}
+	if reply.Status == nil || *reply.Status != want {
+		t.Errorf("status = %+v, want %+v", reply.Status, want)
+	}
+
+	if reply := client.Send(`{"command":"pause"}`); reply.Status == nil || !reply.Status.Paused {
+		t.Fatalf("pause = %+v, want paused", reply)
+	}
+	waited := make(chan bool)
+	go func() { waited <- control.Wait(time.Time{}) }()
+	select {
+	case <-waited:
+		t.Fatal("Wait returned while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if reply := client.Send(`{"command":"resume"}`); reply.Status == nil || reply.Status.Paused {
+		t.Fatalf("resume = %+v, want resumed", reply)
+	}
+	if stopped := <-waited; stopped {
+		t.Error("Wait = true after a resume, want false")
+	}
+
+	client.Send(`{"command":"pause"}`)
+	go func() { waited <- control.Wait(time.Time{}) }()
+	if reply := client.Send(`{"command":"stop"}`); reply.Status == nil || !reply.Status.Stopped || reply.Status.Paused {
+		t.Fatalf("stop = %+v, want stopped and no longer paused", reply)
+	}
+	if stopped := <-waited; !stopped {
+		t.Error("Wait = false after a stop, want true")
+	}
+
+	for _, command := range []string{`{"command":"rewind"}`, `not json`} {
+		if reply := client.Send(command); reply.Error == "" || reply.Status != nil {
+			t.Errorf("%s = %+v, want an error", command, reply)
+		}
+	}
+}
+
+func TestControlSocketWaitDeadline(t *testing.T) {
+	control, err := listenControlSocket(controlSocketPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer control.Close()
+	control.Do(controlCommandPause)
+
+	started := time.Now()
+	if stopped := control.Wait(started.Add(50 * time.Millisecond)); stopped {
+		t.Error("Wait = true at the deadline, want false")
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Wait took %s, want it to return at the deadline", elapsed)
+	}
+
+	p := Project{control: control, clock: realClock{}, summary: &runSummary{}, deadline: started.Add(50 * time.Millisecond)}
+	if !p.stopRequested(Batch{Start: 11, End: 20}) || !p.summary.Partial {
+		t.Error("stopRequested = false past the deadline while paused, want the run stopped")
+	}
+}
+
+func TestListenControlSocketExisting(t *testing.T) {
+	path := controlSocketPath(t)
+	live, err := listenControlSocket(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenControlSocket(path); !errors.Is(err, errControlSocketInUse) {
+		t.Errorf("listenControlSocket of a live socket error = %v, want errControlSocketInUse", err)
+	}
+	if reply := dialControl(t, path).Send(`{"command":"status"}`); reply.Status == nil {
+		t.Errorf("status of the live socket = %+v, want it still served", reply)
+	}
+
+	// a run that exited without removing its socket
+	live.listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	live.Close()
+	if _, err := os.Lstat(path); err != nil {
+		t.Fatal(err)
+	}
+	control, err := listenControlSocket(path)
+	if err != nil {
+		t.Fatalf("listenControlSocket of a stale socket: %v", err)
+	}
+	control.Close()
+
+	file := controlSocketPath(t)
+	if err := os.WriteFile(file, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenControlSocket(file); err == nil {
+		t.Error("listenControlSocket of a regular file succeeded, want an error")
+	}
+	if b, err := os.ReadFile(file); err != nil || string(b) != "data" {
+		t.Errorf("regular file = %q, %v, want it left alone", b, err)
+	}
+}
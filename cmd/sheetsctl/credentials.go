@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Kinds of credentials files, see `classifyCredentials`.
+const (
+	credentialsInstalled      = "installed"
+	credentialsWeb            = "web"
+	credentialsServiceAccount = "service_account"
+	credentialsEmpty          = "empty"
+	credentialsHTML           = "html"
+	credentialsInvalidJSON    = "invalid_json"
+	credentialsUnknown        = "unknown"
+)
+
+var errInvalidCredentials = errors.New("invalid credentials file")
+
+// downloadHint tells where to get the right credentials file.
+const downloadHint = "create an OAuth client ID of type \"Desktop app\" in the Cloud Console (APIs & Services > Credentials) and download its JSON"
+
+// credentialsFile is what `classifyCredentials` looks at in a credentials
+// file.
+type credentialsFile struct {
+	Type      string          `json:"type,omitempty"`
+	Installed json.RawMessage `json:"installed,omitempty"`
+	Web       json.RawMessage `json:"web,omitempty"`
+}
+
+// classifyCredentials tells what kind of file the credentials file `b` is: an
+// OAuth client ("installed" takes precedence over "web" when the file has
+// both), a service account key, or one of the usual mis-downloads.
+func classifyCredentials(b []byte) string {
+	b = bytes.TrimSpace(trimBOM(b))
+	if len(b) == 0 {
+		return credentialsEmpty
+	}
+	if b[0] == '<' {
+		return credentialsHTML
+	}
+	var f credentialsFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return credentialsInvalidJSON
+	}
+	switch {
+	case len(f.Installed) > 0 && string(f.Installed) != "null":
+		return credentialsInstalled
+	case len(f.Web) > 0 && string(f.Web) != "null":
+		return credentialsWeb
+	case f.Type == credentialsServiceAccount:
+		return credentialsServiceAccount
+	}
+	return credentialsUnknown
+}
+
+// credentialsConfig returns the OAuth config of the credentials file `name`
+// holding `b`, or an error explaining what's wrong with the file.
+func credentialsConfig(name string, b []byte, scopes ...string) (*oauth2.Config, error) {
+	b = trimBOM(b)
+	switch classifyCredentials(b) {
+	case credentialsEmpty:
+		return nil, fmt.Errorf("%w: %s is empty, %s", errInvalidCredentials, name, downloadHint)
+	case credentialsHTML:
+		return nil, fmt.Errorf("%w: %s is an HTML page (e.g. a sign-in page saved instead of the download), %s", errInvalidCredentials, name, downloadHint)
+	case credentialsInvalidJSON:
+		return nil, fmt.Errorf("%w: %s isn't valid JSON, %s", errInvalidCredentials, name, downloadHint)
+	case credentialsServiceAccount:
+		return nil, fmt.Errorf("%w: %s is a service account key, which can't be used to authorize as a user; %s", errInvalidCredentials, name, downloadHint)
+	case credentialsUnknown:
+		return nil, fmt.Errorf("%w: %s isn't an OAuth client (it has no \"installed\" or \"web\" client, e.g. an API key), %s", errInvalidCredentials, name, downloadHint)
+	case credentialsInstalled:
+		// NOTE: `google.ConfigFromJSON` prefers the "web" client when there's
+		// both.
+		var f credentialsFile
+		json.Unmarshal(b, &f)
+		b, _ = json.Marshal(credentialsFile{Installed: f.Installed})
+	}
+	config, err := google.ConfigFromJSON(b, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errInvalidCredentials, name, err)
+	}
+	return config, nil
+}
+
+// trimBOM strips the byte order mark editors on Windows may add to the file.
+func trimBOM(b []byte) []byte {
+	return bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	installedClient = `{"installed": {"client_id": "installed-id", "client_secret": "secret", "auth_uri": "https://accounts.google.com/o/oauth2/auth", "token_uri": "https://oauth2.googleapis.com/token", "redirect_uris": ["http://localhost"]}}`
+	webClient       = `{"web": {"client_id": "web-id", "client_secret": "secret", "auth_uri": "https://accounts.google.com/o/oauth2/auth", "token_uri": "https://oauth2.googleapis.com/token", "redirect_uris": ["https://example.com/callback"]}}`
+)
+
+func TestClassifyCredentials(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{installedClient, credentialsInstalled},
+		{"\xef\xbb\xbf" + installedClient + "\n", credentialsInstalled},
+		{webClient, credentialsWeb},
+		{`{"web": {"client_id": "web-id"}, "installed": {"client_id": "installed-id"}}`, credentialsInstalled},
+		{`{"installed": null, "web": {"client_id": "web-id"}}`, credentialsWeb},
+		{`{"type": "service_account", "client_email": "bot@project.iam.gserviceaccount.com"}`, credentialsServiceAccount},
+		{"", credentialsEmpty},
+		{"\xef\xbb\xbf \n", credentialsEmpty},
+		{"<!DOCTYPE html><html><title>Sign in</title></html>", credentialsHTML},
+		{`{"installed": `, credentialsInvalidJSON},
+		{`{"key": "AIzaSy..."}`, credentialsUnknown},
+		{`[]`, credentialsInvalidJSON},
+	}
+	for _, tt := range tests {
+		if got := classifyCredentials([]byte(tt.file)); got != tt.want {
+			t.Errorf("classifyCredentials(%.40q) = %s, want %s", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestCredentialsConfig(t *testing.T) {
+	tests := []struct {
+		file     string
+		clientID string
+		wantErr  string
+	}{
+		{installedClient, "installed-id", ""},
+		{"\xef\xbb\xbf" + installedClient, "installed-id", ""},
+		{webClient, "web-id", ""},
+		// the installed client is used even though ConfigFromJSON prefers web
+		{`{"web": {"client_id": "web-id", "redirect_uris": ["https://example.com/callback"]}, "installed": {"client_id": "installed-id", "redirect_uris": ["http://localhost"]}}`, "installed-id", ""},
+		{"", "", "credentials.json is empty"},
+		{"<html></html>", "", "credentials.json is an HTML page"},
+		{"{", "", "credentials.json isn't valid JSON"},
+		{`{"type": "service_account"}`, "", "credentials.json is a service account key"},
+		{`{"key": "AIzaSy..."}`, "", `credentials.json isn't an OAuth client`},
+		// a client without a redirect URI
+		{`{"installed": {"client_id": "installed-id"}}`, "", "credentials.json: "},
+	}
+	for _, tt := range tests {
+		config, err := credentialsConfig("credentials.json", []byte(tt.file), "scope")
+		if tt.wantErr != "" {
+			if !errors.Is(err, errInvalidCredentials) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("credentialsConfig(%.40q) error = %v, want %q", tt.file, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || config.ClientID != tt.clientID || len(config.Scopes) != 1 {
+			t.Errorf("credentialsConfig(%.40q) = %+v, %v, want the client %s", tt.file, config, err, tt.clientID)
+		}
+	}
+}
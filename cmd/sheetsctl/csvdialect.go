@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// utf8BOM is written first by `CSV_BOM`, which Excel needs to read the file
+// as UTF-8.
+const utf8BOM = "\ufeff"
+
+// csvDelimiters are the `CSV_DELIMITER` values.
+var csvDelimiters = map[string]rune{
+	"comma":     ',',
+	"semicolon": ';',
+	"tab":       '\t',
+}
+
+// csvDialect is how the CSV output is written, e.g. semicolon-delimited with
+// a BOM and CRLF line endings for Excel on European locales.
+type csvDialect struct {
+	Comma rune
+	BOM   bool
+	CRLF  bool
+}
+
+// parseCSVDialect returns the dialect of the `CSV_DELIMITER`, `CSV_BOM`, and
+// `CSV_CRLF` options.
+func parseCSVDialect(delimiter string, bom, crlf bool) (csvDialect, error) {
+	comma, ok := csvDelimiters[delimiter]
+	if !ok {
+		return csvDialect{}, fmt.Errorf("unknown delimiter %q (want comma, semicolon, or tab)", delimiter)
+	}
+	return csvDialect{Comma: comma, BOM: bom, CRLF: crlf}, nil
+}
+
+// NewWriter returns a CSV writer to `w` in the dialect, writing the BOM first
+// if needed; fields containing the delimiter are quoted.
+func (d csvDialect) NewWriter(w io.Writer) (*csv.Writer, error) {
+	if d.BOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = d.Comma
+	cw.UseCRLF = d.CRLF
+	return cw, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// dialectRows have fields needing quotes in one dialect or another.
+var dialectRows = [][]string{
+	{"Name", "GPA", "Notes"},
+	{"Ann", "3,5", "likes a;b"},
+	{"Bob \"Bobby\"", "4", "two\nlines"},
+	{"Zo\u00eb", "", "tab\there"},
+}
+
+// writeDialect returns the `rows` written in the `dialect`.
+func writeDialect(t *testing.T, dialect csvDialect, rows [][]string) string {
+	t.Helper()
+	var b bytes.Buffer
+	w, err := dialect.NewWriter(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestParseCSVDialect(t *testing.T) {
+	tests := []struct {
+		delimiter string
+		want      rune
+		ok        bool
+	}{
+		{"comma", ',', true},
+		{"semicolon", ';', true},
+		{"tab", '\t', true},
+		{";", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		dialect, err := parseCSVDialect(tt.delimiter, true, false)
+		if (err == nil) != tt.ok || dialect.Comma != tt.want || (tt.ok && (!dialect.BOM || dialect.CRLF)) {
+			t.Errorf("parseCSVDialect(%q) = %+v, %v, want the delimiter %q", tt.delimiter, dialect, err, tt.want)
+		}
+	}
+}
+
+// TestCSVDialectExcel locks the exact bytes of the semicolon, BOM, and CRLF
+// combination Excel expects on European locales.
+func TestCSVDialectExcel(t *testing.T) {
+	dialect, err := parseCSVDialect("semicolon", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "csv_excel.csv", writeDialect(t, dialect, dialectRows))
+}
+
+func TestCSVDialectQuoting(t *testing.T) {
+	tests := []struct {
+		delimiter string
+		want      string
+	}{
+		{"comma", "Name,GPA,Notes\nAnn,\"3,5\",likes a;b\n\"Bob \"\"Bobby\"\"\",4,\"two\nlines\"\nZo\u00eb,,tab\there\n"},
+		{"semicolon", "Name;GPA;Notes\nAnn;3,5;\"likes a;b\"\n\"Bob \"\"Bobby\"\"\";4;\"two\nlines\"\nZo\u00eb;;tab\there\n"},
+		{"tab", "Name\tGPA\tNotes\nAnn\t3,5\tlikes a;b\n\"Bob \"\"Bobby\"\"\"\t4\t\"two\nlines\"\nZo\u00eb\t\t\"tab\there\"\n"},
+	}
+	for _, tt := range tests {
+		dialect, err := parseCSVDialect(tt.delimiter, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := writeDialect(t, dialect, dialectRows); got != tt.want {
+			t.Errorf("%s: CSV = %q, want %q", tt.delimiter, got, tt.want)
+		}
+	}
+	// the BOM is only written once, first
+	dialect := csvDialect{Comma: ',', BOM: true}
+	if got := writeDialect(t, dialect, dialectRows[:1]); !strings.HasPrefix(got, utf8BOM+"Name") || strings.Count(got, utf8BOM) != 1 {
+		t.Errorf("CSV with a BOM = %q", got)
+	}
+}
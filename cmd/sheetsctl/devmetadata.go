@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// Developer metadata location types.
+const (
+	metadataLocationSpreadsheet = "SPREADSHEET"
+	metadataLocationSheet       = "SHEET"
+	metadataLocationRow         = "ROW"
+	metadataLocationColumn      = "COLUMN"
+)
+
+// metadataTag is the `METADATA_SKIP` developer metadata key and (optional)
+// value, e.g. "export=skip".
+type metadataTag struct {
+	Key   string
+	Value string
+}
+
+// parseMetadataTag parses a "key=value" tag, or just "key" to match any value.
+func parseMetadataTag(s string) (*metadataTag, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(s, "=", 2)
+	tag := &metadataTag{Key: strings.TrimSpace(parts[0])}
+	if tag.Key == "" {
+		return nil, fmt.Errorf("%q, expected e.g. \"export=skip\"", s)
+	}
+	if len(parts) == 2 {
+		tag.Value = strings.TrimSpace(parts[1])
+	}
+	return tag, nil
+}
+
+// searchDeveloperMetadata returns the developer metadata of the configured
+// sheet (the sheet itself, and its rows and columns), only that tagged with
+// the `tag` when it's set.
+func (p Project) searchDeveloperMetadata(tag *metadataTag) ([]*sheets.DeveloperMetadata, error) {
+	info, err := p.GetSheetInfo(p.config.SheetName)
+	if err != nil {
+		return nil, err
+	}
+	lookup := &sheets.DeveloperMetadataLookup{
+		MetadataLocation: &sheets.DeveloperMetadataLocation{
+			SheetId: info.SheetId,
+			// sheet 0 is the first sheet, not an unset ID
+			ForceSendFields: []string{"SheetId"},
+		},
+		LocationMatchingStrategy: "INTERSECTING_LOCATION",
+	}
+	if tag != nil {
+		lookup.MetadataKey, lookup.MetadataValue = tag.Key, tag.Value
+	}
+	resp, err := p.sheetsService.Spreadsheets.DeveloperMetadata.Search(p.config.SpreadsheetId, &sheets.SearchDeveloperMetadataRequest{
+		DataFilters: []*sheets.DataFilter{{DeveloperMetadataLookup: lookup}},
+	}).Do()
+	if err != nil {
+		return nil, err
+	}
+	metadata := []*sheets.DeveloperMetadata{}
+	for _, matched := range resp.MatchedDeveloperMetadata {
+		if matched.DeveloperMetadata != nil && matched.DeveloperMetadata.Location != nil {
+			metadata = append(metadata, matched.DeveloperMetadata)
+		}
+	}
+	return metadata, nil
+}
+
+// metadataExclusions returns the rows and (0-based) sheet columns tagged with
+// the `METADATA_SKIP` tag; when the sheet itself is tagged, all its rows are.
+func (p Project) metadataExclusions() (rowRanges, map[int]bool, error) {
+	metadata, err := p.searchDeveloperMetadata(p.metadataSkip)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, columns := rowRanges{}, map[int]bool{}
+	for _, m := range metadata {
+		location := m.Location
+		switch location.LocationType {
+		case metadataLocationSheet:
+			log.Printf("METADATA_SKIP: sheet '%s' is tagged, leaving out all its rows", p.config.SheetName)
+			rows = append(rows, rowRange{Start: 1})
+		case metadataLocationRow:
+			// dimension ranges are 0-based, their end exclusive
+			rows = append(rows, rowRange{Start: int(location.DimensionRange.StartIndex) + 1, End: int(location.DimensionRange.EndIndex)})
+		case metadataLocationColumn:
+			for column := location.DimensionRange.StartIndex; column < location.DimensionRange.EndIndex; column++ {
+				columns[int(column)] = true
+			}
+		}
+	}
+	return rows, columns, nil
+}
+
+// excludeTaggedColumns leaves the columns tagged with `METADATA_SKIP` out of
+// the `selected` columns of the `headers` (nil meaning all of them).
+func (p Project) excludeTaggedColumns(selected map[int]bool, headers []interface{}) map[int]bool {
+	if len(p.metadataColumns) == 0 {
+		return selected
+	}
+	if selected == nil {
+		selected = map[int]bool{}
+		for i := range headers {
+			selected[i] = true
+		}
+	}
+	for i := range headers {
+		if p.metadataColumns[p.sheetColumn(i)] {
+			delete(selected, i)
+		}
+	}
+	return selected
+}
+
+// developerMetadataEntry is a developer metadata entry printed by the
+// `metadata` subcommand.
+type developerMetadataEntry struct {
+	Id         int64  `json:"id"`
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	Visibility string `json:"visibility"`
+	// Location is e.g. "sheet", "rows 5-7", or "columns C-D"
+	Location string `json:"location"`
+}
+
+// describeMetadataLocation describes the `location` with 1-based rows and
+// column letters, e.g. "rows 5-7".
+func describeMetadataLocation(location *sheets.DeveloperMetadataLocation) string {
+	switch location.LocationType {
+	case metadataLocationSpreadsheet:
+		return "spreadsheet"
+	case metadataLocationSheet:
+		return "sheet"
+	case metadataLocationRow:
+		return fmt.Sprintf("rows %d-%d", location.DimensionRange.StartIndex+1, location.DimensionRange.EndIndex)
+	case metadataLocationColumn:
+		return fmt.Sprintf("columns %s-%s", a1.ColumnLetter(int(location.DimensionRange.StartIndex)), a1.ColumnLetter(int(location.DimensionRange.EndIndex-1)))
+	}
+	return strings.ToLower(location.LocationType)
+}
+
+// printDeveloperMetadata prints the developer metadata of the sheet as JSON,
+// e.g. to pick the `METADATA_SKIP` tag.
+func (p Project) printDeveloperMetadata() {
+	metadata, err := p.searchDeveloperMetadata(nil)
+	if err != nil {
+		fatalf("Unable to search the sheet's developer metadata: %v", err)
+	}
+	entries := make([]developerMetadataEntry, len(metadata))
+	for i, m := range metadata {
+		entries[i] = developerMetadataEntry{Id: m.MetadataId, Key: m.MetadataKey, Value: m.MetadataValue, Visibility: m.Visibility, Location: describeMetadataLocation(m.Location)}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(entries); err != nil {
+		fatalf("Unable to print the developer metadata: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// metadataSearchProject returns a project whose developer metadata search
+// answers the `matched` JSON, recording each search request's lookup.
+func metadataSearchProject(t *testing.T, matched string, lookups *[]map[string]interface{}) Project {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v4/spreadsheets/spreadsheet-id/developerMetadata:search" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			DataFilters []struct {
+				DeveloperMetadataLookup map[string]interface{}
+			}
+		}
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &req); err != nil || len(req.DataFilters) != 1 {
+			t.Errorf("search request %s: %v", b, err)
+		} else {
+			*lookups = append(*lookups, req.DataFilters[0].DeveloperMetadataLookup)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"matchedDeveloperMetadata": `+matched+`}`)
+	}))
+	t.Cleanup(server.Close)
+	service, err := sheets.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Project{sheetsService: service, summary: &runSummary{}, metadata: newMetadataCache(time.Hour, func(spreadsheetId string) (*sheets.Spreadsheet, error) {
+		return &sheets.Spreadsheet{Sheets: []*sheets.Sheet{{Properties: &sheets.SheetProperties{SheetId: 0, Title: "Class Data"}}}}, nil
+	})}
+	p.config.SpreadsheetId = "spreadsheet-id"
+	p.config.SheetName = "Class Data"
+	return p
+}
+
+func TestParseMetadataTag(t *testing.T) {
+	tests := []struct {
+		s    string
+		want *metadataTag
+		ok   bool
+	}{
+		{"", nil, true},
+		{"export=skip", &metadataTag{Key: "export", Value: "skip"}, true},
+		{" export = skip ", &metadataTag{Key: "export", Value: "skip"}, true},
+		{"export", &metadataTag{Key: "export"}, true},
+		{"export=a=b", &metadataTag{Key: "export", Value: "a=b"}, true},
+		{"=skip", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := parseMetadataTag(tt.s)
+		if !reflect.DeepEqual(got, tt.want) || (err == nil) != tt.ok {
+			t.Errorf("parseMetadataTag(%q) = %+v, %v, want %+v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestMetadataExclusions(t *testing.T) {
+	matched := `[
+		{"developerMetadata": {"metadataKey": "export", "metadataValue": "skip", "location": {"locationType": "ROW", "dimensionRange": {"dimension": "ROWS", "startIndex": 4, "endIndex": 7}}}},
+		{"developerMetadata": {"metadataKey": "export", "metadataValue": "skip", "location": {"locationType": "COLUMN", "dimensionRange": {"dimension": "COLUMNS", "startIndex": 2, "endIndex": 4}}}},
+		{"developerMetadata": {"metadataKey": "export", "metadataValue": "skip"}}
+	]`
+	var lookups []map[string]interface{}
+	p := metadataSearchProject(t, matched, &lookups)
+	p.metadataSkip = &metadataTag{Key: "export", Value: "skip"}
+
+	rows, columns, err := p.metadataExclusions()
+	if err != nil {
+		t.Fatalf("metadataExclusions: %v", err)
+	}
+	// rows 5-7, and columns C-D
+	if want := (rowRanges{{Start: 5, End: 7}}); !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+	if want := map[int]bool{2: true, 3: true}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+	// the first sheet's ID 0 is sent
+	want := map[string]interface{}{
+		"metadataKey":              "export",
+		"metadataValue":            "skip",
+		"locationMatchingStrategy": "INTERSECTING_LOCATION",
+		"metadataLocation":         map[string]interface{}{"sheetId": 0.0},
+	}
+	if len(lookups) != 1 || !reflect.DeepEqual(lookups[0], want) {
+		t.Errorf("lookups = %v, want %v", lookups, want)
+	}
+
+	// a tagged sheet leaves out all its rows
+	p = metadataSearchProject(t, `[{"developerMetadata": {"metadataKey": "export", "location": {"locationType": "SHEET", "sheetId": 0}}}]`, &lookups)
+	p.metadataSkip = &metadataTag{Key: "export"}
+	if rows, _, err := p.metadataExclusions(); err != nil || !reflect.DeepEqual(rows, rowRanges{{Start: 1}}) {
+		t.Errorf("metadataExclusions of a tagged sheet = %v, %v, want all rows", rows, err)
+	}
+}
+
+func TestExcludeTaggedColumns(t *testing.T) {
+	headers := []interface{}{"Name", "Major", "GPA", "Email"}
+	tests := []struct {
+		name     string
+		ranges   []a1.Range
+		tagged   map[int]bool
+		selected map[int]bool
+		want     map[int]bool
+	}{
+		{"none tagged", []a1.Range{{StartColumn: 0, EndColumn: 3}}, nil, nil, nil},
+		{"all selected", []a1.Range{{StartColumn: 0, EndColumn: 3}}, map[int]bool{2: true}, nil, map[int]bool{0: true, 1: true, 3: true}},
+		{"some selected", []a1.Range{{StartColumn: 0, EndColumn: 3}}, map[int]bool{2: true}, map[int]bool{1: true, 2: true}, map[int]bool{1: true}},
+		// the tags are on sheet columns, e.g. K:N
+		{"offset range", []a1.Range{{StartColumn: 10, EndColumn: 13}}, map[int]bool{2: true, 11: true}, nil, map[int]bool{0: true, 2: true, 3: true}},
+	}
+	for _, tt := range tests {
+		p := Project{readRanges: tt.ranges, metadataColumns: tt.tagged}
+		if got := p.excludeTaggedColumns(tt.selected, headers); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: excludeTaggedColumns = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeMetadataLocation(t *testing.T) {
+	tests := []struct {
+		location *sheets.DeveloperMetadataLocation
+		want     string
+	}{
+		{&sheets.DeveloperMetadataLocation{LocationType: metadataLocationSpreadsheet}, "spreadsheet"},
+		{&sheets.DeveloperMetadataLocation{LocationType: metadataLocationSheet}, "sheet"},
+		{&sheets.DeveloperMetadataLocation{LocationType: metadataLocationRow, DimensionRange: &sheets.DimensionRange{StartIndex: 4, EndIndex: 7}}, "rows 5-7"},
+		{&sheets.DeveloperMetadataLocation{LocationType: metadataLocationColumn, DimensionRange: &sheets.DimensionRange{StartIndex: 2, EndIndex: 4}}, "columns C-D"},
+		{&sheets.DeveloperMetadataLocation{LocationType: "OTHER"}, "other"},
+	}
+	for _, tt := range tests {
+		if got := describeMetadataLocation(tt.location); got != tt.want {
+			t.Errorf("describeMetadataLocation(%s) = %q, want %q", tt.location.LocationType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+
+// columnMetadataFields is the narrow fields mask used when fetching the column
+// dimensions, which aren't part of the (cached) spreadsheet metadata since
+// they inflate it.
+const columnMetadataFields = "sheets(data(startColumn,columnMetadata(pixelSize,hiddenByUser)))"
+
+// columnDimension is the display metadata of a sheet column.
+type columnDimension struct {
+	// Column is the column's letter, e.g. "C"
+	Column       string
+	PixelSize    int64
+	HiddenByUser bool
+}
+
+// columnDimensions returns the display metadata of the `READ_RANGES` columns,
+// keyed by the column's position in the (stitched) rows.
+func (p Project) columnDimensions() (map[int]columnDimension, error) {
+	firstRow, _ := p.rowWindow(0)
+	readRanges := make([]string, len(p.readRanges))
+	for i, r := range p.readRanges {
+		readRanges[i] = r.Rows(p.config.SheetName, firstRow, firstRow)
+	}
+	resp, err := p.sheetsService.Spreadsheets.Get(p.config.SpreadsheetId).
+		Ranges(readRanges...).
+		Fields(columnMetadataFields).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	dimensions := map[int]columnDimension{}
+	for _, sheet := range resp.Sheets {
+		for _, data := range sheet.Data {
+			for i, metadata := range data.ColumnMetadata {
+				column := int(data.StartColumn) + i
+				position, ok := p.columnPosition(column)
+				if !ok {
+					continue
+				}
+				dimensions[position] = columnDimension{Column: a1.ColumnLetter(column), PixelSize: metadata.PixelSize, HiddenByUser: metadata.HiddenByUser}
+			}
+		}
+	}
+	return dimensions, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/spreadsheet"
+)
+
+// Emitter writes out the batches, header, and rows produced by a `RowParser`;
+// `Close` is called once every batch has been parsed.
+type Emitter interface {
+	BatchStart(batch Batch)
+	BatchEnd(batch Batch, fetched int)
+	Header(columns []string)
+	Row(fields map[string]interface{}) error
+	Close() error
+}
+
+// outputEmitter prints the rows for humans (see `printRow`), as the struct of
+// the schema matching the header if any, or emits them as porcelain events
+// with `--porcelain`.
+type outputEmitter struct {
+	p      Project
+	schema spreadsheet.SchemaMatcher
+}
+
+func (e *outputEmitter) BatchStart(batch Batch) {
+	fmt.Printf("\nfor loop for rows %d-%d\n", batch.Start, batch.End)
+	if e.p.porcelain != nil {
+		e.p.porcelain.Emit(porcelainEvent{Type: eventBatchStart, StartRow: batch.Start, EndRow: batch.End})
+	}
+}
+
+func (e *outputEmitter) BatchEnd(batch Batch, fetched int) {
+	if e.p.porcelain != nil {
+		e.p.porcelain.Emit(porcelainEvent{Type: eventBatchEnd, StartRow: batch.Start, EndRow: batch.End, Rows: &fetched})
+	}
+}
+
+func (e *outputEmitter) Header(columns []string) {
+	e.schema = spreadsheet.MatchSchema(columns)
+	if e.p.porcelain != nil {
+		e.p.porcelain.Emit(porcelainEvent{Type: eventHeader, Columns: columns})
+	}
+}
+
+func (e *outputEmitter) Row(fields map[string]interface{}) error {
+	e.p.printRow(e.schema, fields)
+	return nil
+}
+
+func (e *outputEmitter) Close() error {
+	return nil
+}
+
+// sortingEmitter buffers the rows in a `rowSorter`, and passes them on to the
+// wrapped `Emitter` in `SORT_BY` order when closed.
+type sortingEmitter struct {
+	Emitter
+	p      Project
+	sorter *rowSorter
+}
+
+func (e *sortingEmitter) Row(fields map[string]interface{}) error {
+	if err := e.sorter.Add(fields); err != nil {
+		return fmt.Errorf("unable to buffer row for sorting: %w", err)
+	}
+	return nil
+}
+
+func (e *sortingEmitter) Close() error {
+	fmt.Printf("\nsorted %d rows by %s\n", e.sorter.Len(), e.p.config.SortBy)
+	emit := func(fields map[string]interface{}) error {
+		err := e.Emitter.Row(fields)
+		if rejected, rejectErr := e.p.rejectRow(err, 0, nil, fields); rejected || rejectErr != nil {
+			return rejectErr
+		}
+		return err
+	}
+	if err := e.sorter.Emit(emit); err != nil {
+		return fmt.Errorf("unable to sort rows: %w", err)
+	}
+	if e.sorter.Spilled() {
+		e.p.summary.SortSpilled = true
+		fmt.Printf("\nsort spilled to disk (%d runs)\n", e.sorter.Runs())
+	}
+	return e.Emitter.Close()
+}
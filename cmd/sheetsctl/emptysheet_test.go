@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEmptySheetRun reads a sheet with only a header row under each
+// `EMPTY_SHEET` value, and a sheet with data rows under "fail".
+func TestEmptySheetRun(t *testing.T) {
+	warning := "WARNING: no data rows were emitted from sheet 'Empty'"
+	tests := []struct {
+		name       string
+		sheet      string
+		emptySheet string
+		code       int
+		warned     bool
+	}{
+		{"ok", "Empty", emptySheetOk, 0, false},
+		{"warn", "Empty", emptySheetWarn, 0, true},
+		{"fail", "Empty", emptySheetFail, exitEmptySheet, true},
+		{"fail with data rows", "Class Data", emptySheetFail, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := newCommandRun(t)
+			run.sheets.SetValues("spreadsheet-id", "Empty", [][]interface{}{{"Name", "Major"}})
+			run.Setenv("SHEET_NAME", tt.sheet)
+			run.Setenv("EMPTY_SHEET", tt.emptySheet)
+			stdout, code := run.Run("-porcelain")
+			if code != tt.code {
+				t.Errorf("exit code %d, want %d", code, tt.code)
+			}
+			if warned := strings.Contains(stdout, `"code":"`+warnEmptySheet+`"`); warned != tt.warned {
+				t.Errorf("empty_sheet warning emitted = %v, want %v in %q", warned, tt.warned, stdout)
+			}
+			if tt.warned && !strings.Contains(stdout, warning) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout, warning)
+			}
+		})
+	}
+}
+
+func TestEmptySheetInvalid(t *testing.T) {
+	run := newCommandRun(t)
+	run.Setenv("EMPTY_SHEET", "ignore")
+	_, stderr, code := run.RunStderr()
+	if code == 0 {
+		t.Errorf("exit code 0, want the run to fail")
+	}
+	if want := `Unknown EMPTY_SHEET: "ignore"`; !strings.Contains(stderr, want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr, want)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rowRange is a range of absolute sheet row numbers; `End` is 0 when the range
+// is open-ended (e.g. "9000-").
+type rowRange struct {
+	Start, End int
+}
+
+// rowRanges are the `EXCLUDE_ROWS`.
+type rowRanges []rowRange
+
+// parseRowRanges parses a comma-separated list of row numbers and ranges, e.g.
+// "5000-5100,7020,9000-"; the overlapping and adjoining ranges are merged (see
+// `mergeRowRanges`), so a batch spanning several of them is still left out.
+func parseRowRanges(s string) (rowRanges, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	ranges := rowRanges{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("%w: %q", errInvalidRangeList, part)
+		}
+		r := rowRange{Start: start, End: start}
+		if len(bounds) == 2 {
+			if end := strings.TrimSpace(bounds[1]); end == "" {
+				r.End = 0
+			} else if r.End, err = strconv.Atoi(end); err != nil || r.End < start {
+				return nil, fmt.Errorf("%w: %q", errInvalidRangeList, part)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("%w: %q", errInvalidRangeList, s)
+	}
+	return mergeRowRanges(ranges), nil
+}
+
+// mergeRowRanges sorts the `ranges` by their start, merging the ones that
+// overlap or adjoin.
+func mergeRowRanges(ranges rowRanges) rowRanges {
+	if len(ranges) == 0 {
+		return ranges
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	merged := rowRanges{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		switch {
+		case last.End == 0:
+			// open-ended, so it covers all the following ranges
+		case r.Start <= last.End+1:
+			if r.End == 0 || r.End > last.End {
+				last.End = r.End
+			}
+		default:
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// Excludes returns whether the `row` is in one of the ranges.
+func (rs rowRanges) Excludes(row int) bool {
+	for _, r := range rs {
+		if row >= r.Start && (r.End == 0 || row <= r.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludesAll returns whether the rows `start-end` are all in one of the
+// ranges.
+func (rs rowRanges) ExcludesAll(start, end int) bool {
+	for _, r := range rs {
+		if start >= r.Start && (r.End == 0 || end <= r.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeBatches leaves out the `batches` lying entirely within the
+// `EXCLUDE_ROWS`, saving their API calls, except for the one holding the
+// `headerRow`; the number of rows left out is also returned.
+func (p Project) excludeBatches(batches []Batch, headerRow int) ([]Batch, int) {
+	if p.excludedRows == nil {
+		return batches, 0
+	}
+	kept, excluded := []Batch{}, 0
+	for _, batch := range batches {
+		if p.excludedRows.ExcludesAll(batch.Start, batch.End) && (headerRow < batch.Start || headerRow > batch.End) {
+			excluded += batch.End - batch.Start + 1
+			continue
+		}
+		kept = append(kept, batch)
+	}
+	return kept, excluded
+}
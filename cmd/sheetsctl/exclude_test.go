@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseRowRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want rowRanges
+	}{
+		{"unset", "", nil},
+		{"blank", "  ", nil},
+		{"single rows", "7020, 12", rowRanges{{12, 12}, {7020, 7020}}},
+		{"range", "5000-5100", rowRanges{{5000, 5100}}},
+		{"one-row range", "5-5", rowRanges{{5, 5}}},
+		{"open-ended", "9000-", rowRanges{{9000, 0}}},
+		{"spaces and empty parts", " 5 - 10 ,, 20 ", rowRanges{{5, 10}, {20, 20}}},
+		{"overlapping", "5-10,8-20", rowRanges{{5, 20}}},
+		{"contained", "5-20,8-10", rowRanges{{5, 20}}},
+		{"adjoining", "11-20,5-10", rowRanges{{5, 20}}},
+		{"adjoining row", "5-10,11", rowRanges{{5, 11}}},
+		{"gap of a row", "5-10,12-20", rowRanges{{5, 10}, {12, 20}}},
+		{"duplicated", "7,7", rowRanges{{7, 7}}},
+		{"overlapping an open-ended range", "9000-,9500-9600,8000-9000", rowRanges{{8000, 0}}},
+		{"after an open-ended range", "10-,20-30,40-", rowRanges{{10, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRowRanges(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRowRanges(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRowRangesInvalid(t *testing.T) {
+	for _, s := range []string{
+		"10-5", // reversed
+		"0",
+		"-5",
+		"a",
+		"5-b",
+		"5-10-20",
+		",",
+	} {
+		if _, err := parseRowRanges(s); !errors.Is(err, errInvalidRangeList) {
+			t.Errorf("parseRowRanges(%q) error = %v, want errInvalidRangeList", s, err)
+		}
+	}
+}
+
+func TestExcludeBatches(t *testing.T) {
+	batches := []Batch{{1, 10}, {11, 20}, {21, 30}, {31, 40}}
+	tests := []struct {
+		name      string
+		exclude   string
+		headerRow int
+		want      []Batch
+		excluded  int
+	}{
+		{"no exclusions", "", 1, batches, 0},
+		{"a whole batch", "11-20", 1, []Batch{{1, 10}, {21, 30}, {31, 40}}, 10},
+		{"split by an exclusion", "13-17", 1, batches, 0},
+		{"across batches", "15-25", 1, batches, 0},
+		{"overlapping ranges covering a batch", "11-15,14-20", 1, []Batch{{1, 10}, {21, 30}, {31, 40}}, 10},
+		{"adjoining ranges covering a batch", "21-25,26-30", 1, []Batch{{1, 10}, {11, 20}, {31, 40}}, 10},
+		{"reversed order", "31-40,11-20", 1, []Batch{{1, 10}, {21, 30}}, 20},
+		{"open-ended", "21-", 1, []Batch{{1, 10}, {11, 20}}, 20},
+		{"the header batch is kept", "1-20", 1, []Batch{{1, 10}, {21, 30}, {31, 40}}, 10},
+		{"header row below the exclusion", "1-20", 25, []Batch{{21, 30}, {31, 40}}, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Project
+			var err error
+			if p.excludedRows, err = parseRowRanges(tt.exclude); err != nil {
+				t.Fatal(err)
+			}
+			got, excluded := p.excludeBatches(batches, tt.headerRow)
+			if !reflect.DeepEqual(got, tt.want) || excluded != tt.excluded {
+				t.Errorf("excludeBatches(%q) = %v, %d, want %v, %d", tt.exclude, got, excluded, tt.want, tt.excluded)
+			}
+		})
+	}
+}
+
+func TestRowRangesExcludes(t *testing.T) {
+	rs := rowRanges{{5, 10}, {20, 0}}
+	for row, want := range map[int]bool{4: false, 5: true, 10: true, 11: false, 19: false, 20: true, 1000000: true} {
+		if got := rs.Excludes(row); got != want {
+			t.Errorf("Excludes(%d) = %v, want %v", row, got, want)
+		}
+	}
+}
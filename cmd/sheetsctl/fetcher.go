@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// fetchedBatch is a batch of rows retrieved by a `Fetcher`; `SoftDeleted` holds
+// the absolute row numbers of the batch that are soft-deleted.
+type fetchedBatch struct {
+	Batch
+	Rows        [][]interface{}
+	SoftDeleted map[int]bool
+	FetchedAt   time.Time
+}
+
+// Fetcher plans the batches of rows to read and retrieves them.
+type Fetcher interface {
+	Plan() ([]Batch, error)
+	Fetch(batch Batch) (fetchedBatch, error)
+}
+
+// sheetFetcher fetches the `READ_RANGES` of the configured sheet, of which
+// there are `rowCount` rows, in `BATCH_COUNT` sized batches.
+type sheetFetcher struct {
+	p              Project
+	rowCount       int
+	skipBackground *rgbColor
+}
+
+// Plan splits the row window of the sheet into batches, leaving out those
+// lying entirely within the `EXCLUDE_ROWS`.
+func (f sheetFetcher) Plan() ([]Batch, error) {
+	firstRow, lastRow := f.p.rowWindow(f.rowCount)
+	batches, err := PlanBatches(firstRow, lastRow, f.p.config.BatchCount)
+	if err != nil {
+		return nil, err
+	}
+	headerRow := firstRow
+	if f.p.headers != nil {
+		headerRow = 0
+	}
+	batches, excluded := f.p.excludeBatches(batches, headerRow)
+	f.p.summary.ExcludedRows += excluded
+	return batches, nil
+}
+
+// Fetch retrieves the rows of the `batch`, along with which of them are
+// soft-deleted when `SKIP_STRIKETHROUGH`/`SKIP_BACKGROUND_COLOR` are set.
+func (f sheetFetcher) Fetch(batch Batch) (fetchedBatch, error) {
+	// Example range: "'Sheet Name'!A1:Z10"
+	rows, err := f.p.fetchRows(batch.Start, batch.End)
+	if err != nil {
+		return fetchedBatch{}, err
+	}
+	fetched := fetchedBatch{Batch: batch, Rows: rows, FetchedAt: time.Now()}
+	if f.p.config.SkipStrikethrough || f.skipBackground != nil {
+		fetched.SoftDeleted, err = f.p.softDeletedRows(batch, f.skipBackground)
+		if err != nil {
+			return fetchedBatch{}, fmt.Errorf("unable to retrieve soft-deleted rows: %w", err)
+		}
+	}
+	return fetched, nil
+}
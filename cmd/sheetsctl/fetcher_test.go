@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+func TestSheetFetcher(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Sheet1", [][]interface{}{
+		{"id", "name"},
+		{"1", "one"},
+		{},
+		{"3", "three"},
+		{"4"},
+	})
+	p := fakeSheetsProject(t, server, "Sheet1")
+	p.readRanges = []a1.Range{{StartColumn: 0, EndColumn: 1}}
+	p.config.BatchCount = 2
+	p.excludedRows = rowRanges{{Start: 3, End: 4}}
+
+	fetcher := sheetFetcher{p: p, rowCount: 7}
+	batches, err := fetcher.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	want := []Batch{{1, 2}, {5, 6}, {7, 7}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("Plan = %v, want %v without the excluded rows", batches, want)
+	}
+	if p.summary.ExcludedRows != 2 {
+		t.Errorf("ExcludedRows = %d, want 2", p.summary.ExcludedRows)
+	}
+
+	tests := []struct {
+		batch Batch
+		rows  [][]interface{}
+	}{
+		{Batch{1, 2}, [][]interface{}{{"id", "name"}, {"1", "one"}}},
+		{Batch{2, 5}, [][]interface{}{{"1", "one"}, {}, {"3", "three"}, {"4"}}},
+		// past the values, the API leaves out the rows altogether
+		{Batch{6, 7}, nil},
+	}
+	for _, tt := range tests {
+		fetched, err := fetcher.Fetch(tt.batch)
+		if err != nil {
+			t.Fatalf("Fetch(%v): %v", tt.batch, err)
+		}
+		if fetched.Batch != tt.batch || !reflect.DeepEqual(fetched.Rows, tt.rows) {
+			t.Errorf("Fetch(%v) = %v, %v, want %v", tt.batch, fetched.Batch, fetched.Rows, tt.rows)
+		}
+	}
+}
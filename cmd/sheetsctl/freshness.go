@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// sourceFile is how fresh a spreadsheet read by the run is, from its Drive
+// file; `Unavailable` says why the other fields are missing (e.g. no Drive
+// scope), rather than failing the run.
+type sourceFile struct {
+	ModifiedTime      string `json:"modified_time,omitempty"`
+	Version           string `json:"version,omitempty"`
+	LastModifyingUser string `json:"last_modifying_user,omitempty"`
+	// Revision is the `REVISION_ID`/`AS_OF` revision read, whose
+	// `ModifiedTime` is reported instead of the file's
+	Revision    string `json:"revision,omitempty"`
+	Unavailable string `json:"unavailable,omitempty"`
+}
+
+// fetchSourceFiles records, once per run, the Drive `modifiedTime`, version,
+// and last modifying user of every spreadsheet read in the summary.
+func (p Project) fetchSourceFiles() {
+	p.summary.SourceFiles = map[string]*sourceFile{}
+	for _, spreadsheetId := range p.spreadsheetIds() {
+		file := &sourceFile{}
+		p.summary.SourceFiles[spreadsheetId] = file
+		if !hasDriveScope(p.config.Scopes) {
+			file.Unavailable = "no Drive scope in SCOPES"
+			continue
+		}
+		if err := p.fetchSourceFile(spreadsheetId, file); err != nil {
+			file.Unavailable = err.Error()
+			p.warn(warnSourceFile, "WARNING: unable to read the Drive metadata of spreadsheet %s: %v", spreadsheetId, err)
+			continue
+		}
+		if p.revision != nil {
+			file.Revision, file.ModifiedTime = p.revision.Id, p.revision.ModifiedTime
+		}
+	}
+}
+
+// fetchSourceFile fills the `file` from the Drive file of the spreadsheet.
+func (p Project) fetchSourceFile(spreadsheetId string, file *sourceFile) error {
+	driveService, err := drive.NewService(context.Background(), option.WithHTTPClient(p.client))
+	if err != nil {
+		return err
+	}
+	f, err := driveService.Files.Get(spreadsheetId).
+		Fields("modifiedTime,version,lastModifyingUser(displayName,emailAddress)").
+		SupportsAllDrives(true).
+		Do()
+	if err != nil {
+		return err
+	}
+	file.ModifiedTime = f.ModifiedTime
+	if f.Version != 0 {
+		file.Version = strconv.FormatInt(f.Version, 10)
+	}
+	if user := f.LastModifyingUser; user != nil {
+		// the email is only visible to accounts the user shares it with
+		file.LastModifyingUser = user.EmailAddress
+		if file.LastModifyingUser == "" {
+			file.LastModifyingUser = user.DisplayName
+		}
+	}
+	return nil
+}
+
+// sourceModifiedAt returns the modified time of the spreadsheet recorded by
+// `fetchSourceFiles`, or an empty string if it's unavailable.
+func (p Project) sourceModifiedAt(spreadsheetId string) string {
+	if file := p.summary.SourceFiles[spreadsheetId]; file != nil {
+		return file.ModifiedTime
+	}
+	return ""
+}
+
+// printSourceFiles prints how fresh the spreadsheets read are.
+func (p Project) printSourceFiles() {
+	fmt.Printf("\nsource files:\n")
+	for _, spreadsheetId := range p.spreadsheetIds() {
+		file := p.summary.SourceFiles[spreadsheetId]
+		if file.Unavailable != "" {
+			fmt.Printf("\t%s: unavailable (%s)\n", spreadsheetId, file.Unavailable)
+			continue
+		}
+		fmt.Printf("\t%s: modified %s (version %s) by %s\n", spreadsheetId, file.ModifiedTime, file.Version, file.LastModifyingUser)
+	}
+}
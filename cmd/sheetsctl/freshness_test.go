@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFetchSourceFiles(t *testing.T) {
+	const driveScope = "https://www.googleapis.com/auth/drive.metadata.readonly"
+	files := cannedTransport{
+		"/drive/v3/files/a": {http.StatusOK, `{"modifiedTime": "2024-03-01T10:00:00.000Z", "version": "42", "lastModifyingUser": {"displayName": "Ann", "emailAddress": "ann@example.com"}}`},
+		// the email isn't shared with the account
+		"/drive/v3/files/b": {http.StatusOK, `{"modifiedTime": "2024-03-02T09:00:00.000Z", "version": "7", "lastModifyingUser": {"displayName": "Bob"}}`},
+	}
+	tests := []struct {
+		name     string
+		scopes   []string
+		revision *revisionSheet
+		want     map[string]*sourceFile
+		warnings int
+	}{
+		{"drive scope", []string{driveScope}, nil, map[string]*sourceFile{
+			"a": {ModifiedTime: "2024-03-01T10:00:00.000Z", Version: "42", LastModifyingUser: "ann@example.com"},
+			"b": {ModifiedTime: "2024-03-02T09:00:00.000Z", Version: "7", LastModifyingUser: "Bob"},
+			"c": {Unavailable: "googleapi: Error 404: Not Found"},
+		}, 1},
+		{"revision", []string{driveScope}, &revisionSheet{Id: "3", ModifiedTime: "2024-02-01T08:00:00.000Z"}, map[string]*sourceFile{
+			"a": {ModifiedTime: "2024-02-01T08:00:00.000Z", Version: "42", LastModifyingUser: "ann@example.com", Revision: "3"},
+			"b": {ModifiedTime: "2024-02-01T08:00:00.000Z", Version: "7", LastModifyingUser: "Bob", Revision: "3"},
+			"c": {Unavailable: "googleapi: Error 404: Not Found"},
+		}, 1},
+		{"no drive scope", []string{"https://www.googleapis.com/auth/spreadsheets.readonly"}, nil, map[string]*sourceFile{
+			"a": {Unavailable: "no Drive scope in SCOPES"},
+			"b": {Unavailable: "no Drive scope in SCOPES"},
+			"c": {Unavailable: "no Drive scope in SCOPES"},
+		}, 0},
+	}
+	for _, tt := range tests {
+		p := Project{client: &http.Client{Transport: files}, summary: &runSummary{}, revision: tt.revision}
+		p.config.Scopes = tt.scopes
+		p.config.SpreadsheetIds = []string{"a", "b", "c"}
+		p.fetchSourceFiles()
+		if !reflect.DeepEqual(p.summary.SourceFiles, tt.want) {
+			t.Errorf("%s: source files = %+v, want %+v", tt.name, p.summary.SourceFiles, tt.want)
+		}
+		if got := len(p.Warnings()); got != tt.warnings {
+			t.Errorf("%s: %d warnings, want %d", tt.name, got, tt.warnings)
+		}
+		if got := p.sourceModifiedAt("a"); got != tt.want["a"].ModifiedTime {
+			t.Errorf("%s: sourceModifiedAt(a) = %q, want %q", tt.name, got, tt.want["a"].ModifiedTime)
+		}
+	}
+	if got := (Project{summary: &runSummary{}}).sourceModifiedAt("a"); got != "" {
+		t.Errorf("sourceModifiedAt without source files = %q, want none", got)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// rowHasher computes a stable `HASH_COLUMN` value per row, usable as an
+// idempotency key by downstream loads.
+//
+// The canonical serialization is frozen, changing it changes every hash. The
+// hashed columns are `HASH_COLUMNS` in the configured order, or every header in
+// sheet order when not configured. Each column is serialized as the byte length
+// of its header name, ":", and the name, followed by:
+//   - "M" when the cell is missing (the API omits trailing empty cells)
+//   - "V", the byte length of the value, ":", and the value otherwise, so an
+//     empty cell is "V0:"
+//
+// The lengths are decimal, and the hash is the lower-case hex SHA-256 of the
+// concatenated columns.
+//
+// The values are hashed as redacted by `REDACT_COLUMNS`, and dropped columns
+// are left out, so the hash can't be used to brute-force a redacted value.
+type rowHasher struct {
+	names    []string
+	indexes  []int
+	redactor *redactor
+	// buf is reused between rows, so a hasher isn't safe for concurrent use
+	buf []byte
+}
+
+// newRowHasher returns a hasher over the `columns` of `headers` (all headers
+// but the dropped ones when `columns` is empty), redacted by the optional
+// `redactor`, or an error if a column isn't a header or is dropped.
+func newRowHasher(headers []interface{}, columns []string, redactor *redactor) (*rowHasher, error) {
+	h := &rowHasher{redactor: redactor}
+	if len(columns) == 0 {
+		for i, header := range headers {
+			if redactor != nil && redactor.Dropped(i) {
+				continue
+			}
+			h.names = append(h.names, fmt.Sprint(header))
+			h.indexes = append(h.indexes, i)
+		}
+		return h, nil
+	}
+	for _, column := range columns {
+		column = strings.TrimSpace(column)
+		index := -1
+		for i, header := range headers {
+			if fmt.Sprint(header) == column {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return nil, fmt.Errorf("hash column %q not found in the sheet headers", column)
+		}
+		if redactor != nil && redactor.Dropped(index) {
+			return nil, fmt.Errorf("hash column %q is dropped by REDACT_COLUMNS", column)
+		}
+		h.names = append(h.names, column)
+		h.indexes = append(h.indexes, index)
+	}
+	return h, nil
+}
+
+// Hash returns the hex SHA-256 of the canonical serialization of the `row`.
+func (h *rowHasher) Hash(row cells.Row) string {
+	b := h.buf[:0]
+	for i, name := range h.names {
+		b = strconv.AppendInt(b, int64(len(name)), 10)
+		b = append(b, ':')
+		b = append(b, name...)
+		cell, ok := row.Cell(h.indexes[i])
+		if !ok {
+			b = append(b, 'M')
+			continue
+		}
+		value, isString := cell.(string)
+		if !isString {
+			value = fmt.Sprint(cell)
+		}
+		if h.redactor != nil {
+			value = h.redactor.Redact(h.indexes[i], value)
+		}
+		b = append(b, 'V')
+		b = strconv.AppendInt(b, int64(len(value)), 10)
+		b = append(b, ':')
+		b = append(b, value...)
+	}
+	h.buf = b
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
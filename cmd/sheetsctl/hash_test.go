@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+func TestRowHasher(t *testing.T) {
+	headers := []interface{}{"Name", "Age"}
+	// the hashes of the frozen canonical serialization, e.g. "4:NameV3:Ann3:AgeV2:42"
+	tests := []struct {
+		columns []string
+		values  []interface{}
+		want    string
+	}{
+		{nil, []interface{}{"Ann", "42"}, "1a2d24656c90f42add0fd05d8213be2687c633d1d6feb04561d9d7b7cc66e63b"},
+		// non-string cells hash as they print
+		{nil, []interface{}{"Ann", 42}, "1a2d24656c90f42add0fd05d8213be2687c633d1d6feb04561d9d7b7cc66e63b"},
+		// missing and empty cells hash differently
+		{nil, []interface{}{"Ann"}, "2831774eff1f32241632c9871d565132d1ba707235ba93dc0b7db4dc83b4ff69"},
+		{nil, []interface{}{"Ann", ""}, "6717956029a592f38018ba9b1da24a0b274ff0076854c91414d726578e8dbb71"},
+		// HASH_COLUMNS order
+		{[]string{"Age", " Name "}, []interface{}{"Ann", "42"}, "eb0375eff1fb0946c0e1914b36740f9bad0ddab60500d6120c9ae8fdac6a42ef"},
+		// the lengths keep shifted values apart
+		{nil, []interface{}{"An", "n42"}, "fa5060cd01980d227b325ab06feb11266e572188903849b9afc8b640709b91fe"},
+	}
+	for _, tt := range tests {
+		h, err := newRowHasher(headers, tt.columns, nil)
+		if err != nil {
+			t.Errorf("newRowHasher(%q): %v", tt.columns, err)
+			continue
+		}
+		row := cells.NewRow(headers, tt.values, cells.Format{})
+		if got := h.Hash(row); got != tt.want {
+			t.Errorf("Hash(%q) with columns %q = %s, want %s", tt.values, tt.columns, got, tt.want)
+		}
+		// reusing the buffer doesn't change the hash
+		if got := h.Hash(row); got != tt.want {
+			t.Errorf("second Hash(%q) with columns %q = %s, want %s", tt.values, tt.columns, got, tt.want)
+		}
+	}
+
+	if _, err := newRowHasher(headers, []string{"Name", "Email"}, nil); err == nil || !strings.Contains(err.Error(), `"Email"`) {
+		t.Errorf("newRowHasher with a missing column error = %v, want it named", err)
+	}
+}
+
+func TestRowHasherRedacted(t *testing.T) {
+	headers := []interface{}{"Name", "SSN", "Email"}
+	p := Project{
+		config: Config{HashColumn: "_hash", RedactSalt: "pepper"},
+		redactions: []redaction{
+			{Column: "Name", Strategy: redactMask},
+			{Column: "SSN", Strategy: redactDrop},
+			{Column: "Email", Strategy: redactHash},
+		},
+	}
+	hash := func(row ...interface{}) interface{} {
+		return parseTestRow(t, p, headers, row)["_hash"]
+	}
+	want := hash("Ann", "123-45-6789", "ann@example.com")
+	// the dropped column isn't hashed, and the masked one only as masked
+	if got := hash("Ann", "987-65-4321", "ann@example.com"); got != want {
+		t.Errorf("hash with another dropped SSN = %v, want %v", got, want)
+	}
+	if got := hash("Ann", nil, "ann@example.com"); got != want {
+		t.Errorf("hash with no dropped SSN = %v, want %v", got, want)
+	}
+	if got := hash("Aon", "123-45-6789", "ann@example.com"); got != want {
+		t.Errorf("hash with another masked Name = %v, want %v", got, want)
+	}
+	// the hashed column still tells rows apart
+	if got := hash("Ann", "123-45-6789", "bob@example.com"); got == want {
+		t.Errorf("hash with another Email = %v, want it changed", got)
+	}
+
+	// the raw value of a redacted column can't be recomputed from the hash
+	h, err := newRowHasher(headers, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := h.Hash(cells.NewRow(headers, []interface{}{"Ann", "123-45-6789", "ann@example.com"}, cells.Format{})); raw == want {
+		t.Errorf("redacted hash = %v, want it to differ from the raw one", want)
+	}
+
+	r, err := newRedactor(headers, p.redactions, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newRowHasher(headers, []string{"Name", "SSN"}, r); err == nil || !strings.Contains(err.Error(), `"SSN"`) {
+		t.Errorf("newRowHasher with a dropped column error = %v, want it named", err)
+	}
+}
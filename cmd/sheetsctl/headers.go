@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// loadHeaders returns the `HEADERS` (or the `HEADERS_FILE` lines) to use
+// instead of reading the header from the sheet; nil when neither is set.
+func loadHeaders(list []string, file string) ([]interface{}, error) {
+	if len(list) > 0 && file != "" {
+		return nil, fmt.Errorf("only one of HEADERS and HEADERS_FILE can be set")
+	}
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		list = strings.Split(strings.TrimRight(string(b), "\r\n"), "\n")
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	headers := make([]interface{}, len(list))
+	for i, header := range list {
+		headers[i] = strings.TrimSpace(header)
+	}
+	return headers, nil
+}
+
+// fitHeaders matches the pinned `headers` to the width of the widest of the
+// `rows`, warning about any mismatch: extra sheet columns are named after their
+// column letter (e.g. "Column F"), and extra headers are always empty.
+func (p Project) fitHeaders(headers []interface{}, rows [][]interface{}) []interface{} {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	switch {
+	case width > len(headers):
+		p.warn(warnHeaderCount, "WARNING: the sheet has %d columns but only %d HEADERS, naming the extra columns after their letter", width, len(headers))
+		fitted := append([]interface{}{}, headers...)
+		for i := len(headers); i < width; i++ {
+			fitted = append(fitted, "Column "+a1.ColumnLetter(p.sheetColumn(i)))
+		}
+		return fitted
+	case width < len(headers) && width > 0:
+		p.warn(warnHeaderCount, "WARNING: the sheet has %d columns but %d HEADERS, the extra headers will always be empty", width, len(headers))
+	}
+	return headers
+}
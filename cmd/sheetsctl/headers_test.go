@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+func TestLoadHeaders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "headers.txt")
+	if err := os.WriteFile(file, []byte(" Name \r\nMajor\r\n\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		list []string
+		file string
+		want []interface{}
+	}{
+		{"neither", nil, "", nil},
+		{"HEADERS", []string{" Name", "Major "}, "", []interface{}{"Name", "Major"}},
+		{"HEADERS_FILE", nil, file, []interface{}{"Name", "Major"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadHeaders(tt.list, tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadHeaders(%q, %q) = %q, want %q", tt.list, tt.file, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadHeaders([]string{"Name"}, file); err == nil {
+		t.Errorf("loadHeaders with both HEADERS and HEADERS_FILE succeeded, want an error")
+	}
+	if _, err := loadHeaders(nil, filepath.Join(t.TempDir(), "missing.txt")); !os.IsNotExist(err) {
+		t.Errorf("loadHeaders of a missing HEADERS_FILE error = %v, want it not to exist", err)
+	}
+}
+
+func TestFitHeaders(t *testing.T) {
+	headers := []interface{}{"Name", "Major"}
+	tests := []struct {
+		name     string
+		rows     [][]interface{}
+		want     []interface{}
+		warnings int
+	}{
+		{"no rows", nil, headers, 0},
+		{"same width", [][]interface{}{{"Ann", "Math"}, {"Bob"}}, headers, 0},
+		{"extra columns", [][]interface{}{{"Ann"}, {"Bob", "Art", "x", "y"}}, []interface{}{"Name", "Major", "Column D", "Column E"}, 1},
+		{"extra headers", [][]interface{}{{"Ann"}}, headers, 1},
+		{"blank rows", [][]interface{}{{}, {}}, headers, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the sheet's columns start at B
+			p := Project{summary: &runSummary{}, readRanges: []a1.Range{{StartColumn: 1, EndColumn: 2}}}
+			got := p.fitHeaders(headers, tt.rows)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fitHeaders = %q, want %q", got, tt.want)
+			}
+			if len(p.summary.Warnings) != tt.warnings {
+				t.Errorf("fitHeaders warnings = %v, want %d", p.summary.Warnings, tt.warnings)
+			}
+			for _, w := range p.summary.Warnings {
+				if w.Code != warnHeaderCount {
+					t.Errorf("fitHeaders warning code = %q, want %q", w.Code, warnHeaderCount)
+				}
+			}
+		})
+	}
+	if len(headers) != 2 {
+		t.Errorf("fitHeaders changed the pinned headers to %q", headers)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build integration
+// +build integration
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/testsupport"
+)
+
+// liveProject returns a project reading the live `sheet`.
+func liveProject(sheet *testsupport.LiveSheet) Project {
+	p := Project{sheetsService: sheet.Service, summary: &runSummary{}}
+	p.config.SpreadsheetId = sheet.SpreadsheetID
+	p.config.SheetName = sheet.Title
+	return p
+}
+
+func TestLiveFetchRows(t *testing.T) {
+	sheet := testsupport.NewLiveSheet(t, [][]interface{}{
+		{"Name", "Score", "Passed", "Note"},
+		{"Ann", "10", "TRUE", "first"},
+		{},
+		{"Bob", "7.5", "", "last"},
+	})
+	tests := []struct {
+		name           string
+		readRanges     []a1.Range
+		colsPerRequest int
+		want           [][]interface{}
+	}{
+		{"one range", []a1.Range{{StartColumn: 0, EndColumn: 3}}, 0, [][]interface{}{
+			{"Name", "Score", "Passed", "Note"},
+			{"Ann", "10", "TRUE", "first"},
+			{},
+			{"Bob", "7.5", "", "last"},
+		}},
+		{"batch", []a1.Range{{StartColumn: 0, EndColumn: 0}, {StartColumn: 2, EndColumn: 3}}, 0, [][]interface{}{
+			{"Name", "Passed", "Note"},
+			{"Ann", "TRUE", "first"},
+			{},
+			{"Bob", "", "last"},
+		}},
+		{"tiles", []a1.Range{{StartColumn: 0, EndColumn: 3}}, 1, [][]interface{}{
+			{"Name", "Score", "Passed", "Note"},
+			{"Ann", "10", "TRUE", "first"},
+			{},
+			{"Bob", "7.5", "", "last"},
+		}},
+	}
+	for _, tt := range tests {
+		p := liveProject(sheet)
+		p.readRanges = tt.readRanges
+		p.config.ColsPerRequest = tt.colsPerRequest
+		rows, err := p.fetchRows(1, 4)
+		if err != nil {
+			t.Errorf("%s: fetchRows: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rows, tt.want) {
+			t.Errorf("%s: fetchRows = %v, want %v", tt.name, rows, tt.want)
+		}
+		if warnings := p.Warnings(); len(warnings) != 0 {
+			t.Errorf("%s: warnings = %+v, want none", tt.name, warnings)
+		}
+	}
+}
+
+func TestLiveRunLogAppend(t *testing.T) {
+	sheet := testsupport.NewLiveSheet(t, [][]interface{}{
+		{"Name", "Note"},
+		{"Ann", "first"},
+	})
+	l := newRunLogger(sheet.Service, sheet.SpreadsheetID, sheet.Title, time.Now())
+	for _, row := range [][]interface{}{{"Bob", "second"}, {"Cy", "third"}} {
+		if err := l.append(row); err != nil {
+			t.Fatalf("append(%v): %v", row, err)
+		}
+	}
+
+	p := liveProject(sheet)
+	p.readRanges = []a1.Range{{StartColumn: 0, EndColumn: 1}}
+	rows, err := p.fetchRows(1, 10)
+	if err != nil {
+		t.Fatalf("fetchRows: %v", err)
+	}
+	want := [][]interface{}{{"Name", "Note"}, {"Ann", "first"}, {"Bob", "second"}, {"Cy", "third"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
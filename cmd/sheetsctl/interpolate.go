@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// now returns the current time; it's a variable so the date tokens can be
+// evaluated against a fixed clock.
+var now = time.Now
+
+// interpolateConfig resolves `${...}` references (see `interpolate`) in the
+// fields naming what's read and written: `SheetName`, `ReadRanges`, and the
+// output paths. It returns an error listing any variables that couldn't be
+// resolved. Other fields are used as is, so a "$" in e.g. a secret or a
+// command is never mangled.
+func interpolateConfig(c *Config) error {
+	unresolved := map[string]bool{}
+	t := now()
+	for _, field := range []*string{&c.SheetName, &c.ReadRanges, &c.GenOutput, &c.OutputFile, &c.RejectFile, &c.LogFile} {
+		*field = interpolate(*field, t, unresolved)
+	}
+	if len(unresolved) > 0 {
+		names := make([]string, 0, len(unresolved))
+		for name := range unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unresolved variables: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// interpolate replaces the following references in `s`, adding the names of
+// any it can't resolve to `unresolved`:
+//   - ${VAR}: the VAR environment variable
+//   - ${YYYY}, ${MM}, ${DD}: the current year, month, and day
+//   - ${YESTERDAY:layout}: yesterday's date formatted with the Go time `layout`,
+//     e.g. ${YESTERDAY:2006-01-02}
+//
+// References can be nested, e.g. "${DATA_${MM}}", and "$$" is a literal "$".
+//
+// NOTE: godotenv expands `${VAR}` in unquoted and double-quoted `.env` values
+// when loading them, so use single quotes there, e.g. SHEET_NAME='Data ${MM}'.
+func interpolate(s string, t time.Time, unresolved map[string]bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := closingBrace(s, i+2)
+			if end == -1 {
+				// unterminated, leave as-is
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			name := interpolate(s[i+2:end], t, unresolved)
+			b.WriteString(resolveVariable(name, t, unresolved))
+			i = end
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// closingBrace returns the index of the "}" closing the reference whose name
+// starts at `start`, accounting for nested references, or -1 if there's none.
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// resolveVariable returns the value of the date token or environment variable
+// `name`.
+func resolveVariable(name string, t time.Time, unresolved map[string]bool) string {
+	token, layout := name, ""
+	if i := strings.Index(name, ":"); i != -1 {
+		token, layout = name[:i], name[i+1:]
+	}
+	switch {
+	case token == "YYYY" && layout == "":
+		return t.Format("2006")
+	case token == "MM" && layout == "":
+		return t.Format("01")
+	case token == "DD" && layout == "":
+		return t.Format("02")
+	case token == "YESTERDAY" && layout != "":
+		return t.AddDate(0, 0, -1).Format(layout)
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	unresolved[name] = true
+	return ""
+}
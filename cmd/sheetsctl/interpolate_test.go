@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("REGION", "emea")
+	t.Setenv("DATA_03", "March data")
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		s, want    string
+		unresolved string
+	}{
+		{"Data ${YYYY}-${MM}-${DD}", "Data 2024-03-01", ""},
+		{"${YESTERDAY:2006-01-02}", "2024-02-29", ""},
+		{"${YESTERDAY:Jan 2}.csv", "Feb 29.csv", ""},
+		{"${REGION}/${YYYY}", "emea/2024", ""},
+		{"${DATA_${MM}}", "March data", ""},
+		{"$$5 ${REGION}", "$5 emea", ""},
+		{"$5 and $", "$5 and $", ""},
+		{"${UNSET_VARIABLE} sheet", " sheet", "UNSET_VARIABLE"},
+		{"${YESTERDAY}", "", "YESTERDAY"},
+		{"unterminated ${MM", "unterminated ${MM", ""},
+	}
+	for _, tt := range tests {
+		unresolved := map[string]bool{}
+		if got := interpolate(tt.s, date, unresolved); got != tt.want {
+			t.Errorf("interpolate(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+		if tt.unresolved != "" && !unresolved[tt.unresolved] || tt.unresolved == "" && len(unresolved) > 0 {
+			t.Errorf("interpolate(%q) unresolved = %v, want %q", tt.s, unresolved, tt.unresolved)
+		}
+	}
+}
+
+func TestInterpolateConfig(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC) }
+
+	c := Config{
+		SheetName:   "Data ${YYYY}-${MM}",
+		ReadRanges:  "A:C",
+		GenOutput:   "out/${YESTERDAY:2006-01-02}.go",
+		RejectFile:  "rejects-${DD}.jsonl",
+		LogFile:     "run-$${MM}.log",
+		PostCommand: "echo ${HOME} $$",
+		RedactSalt:  "s4lt${YYYY}",
+	}
+	if err := interpolateConfig(&c); err != nil {
+		t.Fatalf("interpolateConfig: %v", err)
+	}
+	want := Config{
+		SheetName:   "Data 2024-01",
+		ReadRanges:  "A:C",
+		GenOutput:   "out/2023-12-31.go",
+		RejectFile:  "rejects-01.jsonl",
+		LogFile:     "run-${MM}.log",
+		PostCommand: "echo ${HOME} $$",
+		RedactSalt:  "s4lt${YYYY}",
+	}
+	if c.SheetName != want.SheetName || c.ReadRanges != want.ReadRanges || c.GenOutput != want.GenOutput ||
+		c.RejectFile != want.RejectFile || c.LogFile != want.LogFile {
+		t.Errorf("interpolated fields = %q, %q, %q, %q, %q, want %q, %q, %q, %q, %q",
+			c.SheetName, c.ReadRanges, c.GenOutput, c.RejectFile, c.LogFile,
+			want.SheetName, want.ReadRanges, want.GenOutput, want.RejectFile, want.LogFile)
+	}
+	if c.PostCommand != want.PostCommand || c.RedactSalt != want.RedactSalt {
+		t.Errorf("other fields = %q, %q, want them as is", c.PostCommand, c.RedactSalt)
+	}
+
+	c = Config{SheetName: "${UNSET_B}", ReadRanges: "${UNSET_A}"}
+	if err := interpolateConfig(&c); err == nil || !strings.Contains(err.Error(), "UNSET_A, UNSET_B") {
+		t.Errorf("interpolateConfig error = %v, want the unresolved variables listed", err)
+	}
+}
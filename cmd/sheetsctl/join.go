@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// joinSpec is the `JOIN_*` config: the rows are enriched with the
+// `JOIN_COLUMNS` of the `JOIN_SHEET` row whose `JOIN_KEY` is the rows'
+// `JOIN_FOREIGN_KEY` value.
+type joinSpec struct {
+	Sheet      string
+	Key        string
+	ForeignKey string
+	// Columns are the lookup columns added to the rows (all but the key when
+	// empty), named with `Prefix` when they collide with the rows' columns
+	Columns []string
+	Prefix  string
+
+	// lookup is the `Columns` values of the lookup rows by key, set by `Load`
+	lookup map[string][]string
+	// budget is the share of `MEMORY_BUDGET_MB` the lookup is held in, and
+	// reserved how much of it the lookup holds
+	budget   *memoryBudget
+	reserved int64
+}
+
+// Load reads the lookup sheet of the configured spreadsheet into an
+// in-memory index, warning when it has more than `JOIN_WARN_ROWS` rows and
+// about duplicate keys (the first row of a key is used).
+func (j *joinSpec) Load(p Project) error {
+	resp, err := p.getValues(a1.QuoteSheetName(j.Sheet))
+	if err != nil {
+		return err
+	}
+	if len(resp.Values) == 0 {
+		return fmt.Errorf("sheet '%s' is empty", j.Sheet)
+	}
+	sheetHeader := cells.NewHeader(resp.Values[0])
+	header := sheetHeader.Names()
+	keyIndex := indexOf(header, j.Key)
+	if keyIndex == -1 {
+		return fmt.Errorf("JOIN_KEY %q isn't a column of sheet '%s'", j.Key, j.Sheet)
+	}
+	if len(j.Columns) == 0 {
+		for _, column := range header {
+			if column != j.Key && column != "" {
+				j.Columns = append(j.Columns, column)
+			}
+		}
+	}
+	indexes := make([]int, len(j.Columns))
+	for i, column := range j.Columns {
+		if indexes[i] = indexOf(header, column); indexes[i] == -1 {
+			return fmt.Errorf("JOIN_COLUMNS column %q isn't a column of sheet '%s'", column, j.Sheet)
+		}
+	}
+	rows := resp.Values[1:]
+	if p.config.JoinWarnRows > 0 && len(rows) > p.config.JoinWarnRows {
+		p.warn(warnJoinSize, "WARNING: JOIN_SHEET '%s' has %d rows, all held in memory", j.Sheet, len(rows))
+	}
+	j.lookup = make(map[string][]string, len(rows))
+	for n, values := range rows {
+		row := sheetHeader.Row(values, p.format)
+		key, _ := row.CellString(keyIndex)
+		if key == "" {
+			continue
+		}
+		if _, ok := j.lookup[key]; ok {
+			p.warnAt(Warning{Code: warnJoinDuplicate, Row: n + 2, Column: j.Key, Message: fmt.Sprintf("Duplicate JOIN_KEY %q in sheet '%s', using its first row", key, j.Sheet)})
+			continue
+		}
+		values := make([]string, len(indexes))
+		size := int64(len(key))
+		for i, index := range indexes {
+			values[i], _ = row.CellString(index)
+			size += int64(len(values[i]))
+		}
+		j.lookup[key] = values
+		// the lookup is needed whole for every row, so it can't be spilled
+		j.reserved += size
+		if j.budget.Reserve(size) {
+			return fmt.Errorf("sheet '%s' doesn't fit in its share of MEMORY_BUDGET_MB=%d", j.Sheet, p.config.MemoryBudgetMB)
+		}
+	}
+	return nil
+}
+
+// Release drops the lookup, and its memory from the budget, once every row
+// has been joined.
+func (j *joinSpec) Release() {
+	j.lookup = nil
+	j.budget.Release(j.reserved)
+	j.reserved = 0
+}
+
+// indexOf returns the index of `s` in `values`, or -1.
+func indexOf(values []string, s string) int {
+	for i, v := range values {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// joiningEmitter adds the lookup columns of the `joinSpec` to the rows before
+// passing them on.
+type joiningEmitter struct {
+	Emitter
+	p     Project
+	join  *joinSpec
+	names []string
+}
+
+// Header names the added columns, prefixing those colliding with the rows'
+// `columns`.
+func (e *joiningEmitter) Header(columns []string) {
+	e.names = make([]string, len(e.join.Columns))
+	for i, column := range e.join.Columns {
+		e.names[i] = column
+		if indexOf(columns, column) != -1 {
+			e.names[i] = e.join.Prefix + column
+		}
+	}
+	e.Emitter.Header(columns)
+}
+
+func (e *joiningEmitter) Row(fields map[string]interface{}) error {
+	key := ""
+	if v, ok := fields[e.join.ForeignKey]; ok && v != nil {
+		key = fmt.Sprint(v)
+	}
+	values, ok := e.join.lookup[key]
+	if !ok {
+		if e.p.config.Strict {
+			return fmt.Errorf("no row of JOIN_SHEET '%s' has the %s %q", e.join.Sheet, e.join.Key, key)
+		}
+		if e.p.rejects() {
+			return &rowRejectedError{Errors: []string{fmt.Sprintf("no row of JOIN_SHEET '%s' has the %s %q", e.join.Sheet, e.join.Key, key)}}
+		}
+		// the lookup columns are left empty, i.e. out of the row
+		return e.Emitter.Row(fields)
+	}
+	for i, value := range values {
+		if value != "" {
+			fields[e.names[i]] = value
+		}
+	}
+	return e.Emitter.Row(fields)
+}
+
+// Close releases the lookup (see `joinSpec.Release`) before closing the
+// stages after the join.
+func (e *joiningEmitter) Close() error {
+	e.join.Release()
+	return e.Emitter.Close()
+}
+
+// String describes the join, e.g. "Users on User ID=ID (Name,Email)".
+func (j *joinSpec) String() string {
+	columns := "all"
+	if len(j.Columns) > 0 {
+		columns = strings.Join(j.Columns, ",")
+	}
+	return fmt.Sprintf("%s on %s=%s (%s)", j.Sheet, j.ForeignKey, j.Key, columns)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+// recordingEmitter keeps the rows it's given.
+type recordingEmitter struct {
+	discardEmitter
+	rows []map[string]interface{}
+}
+
+func (e *recordingEmitter) Row(fields map[string]interface{}) error {
+	e.rows = append(e.rows, fields)
+	return nil
+}
+
+// joinProject returns a project of a fake spreadsheet with a "Dept's Majors"
+// lookup sheet, where "Art" is duplicated.
+func joinProject(t *testing.T) Project {
+	t.Helper()
+	server := sheetstest.NewServer()
+	t.Cleanup(server.Close)
+	server.SetValues("spreadsheet-id", "Dept's Majors", [][]interface{}{
+		{"Major", "Department", "Name"},
+		{"Art", "Humanities", "Fine Arts"},
+		{"Math", "Science"},
+		{"", "Nowhere"},
+		{"Art", "Design"},
+	})
+	return fakeSheetsProject(t, server, "Class Data")
+}
+
+func TestJoinLoad(t *testing.T) {
+	p := joinProject(t)
+	j := &joinSpec{Sheet: "Dept's Majors", Key: "Major", ForeignKey: "Major"}
+	if err := j.Load(p); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	// every column but the key, and the rows without a key are left out
+	if want := []string{"Department", "Name"}; !reflect.DeepEqual(j.Columns, want) {
+		t.Errorf("columns = %q, want %q", j.Columns, want)
+	}
+	want := map[string][]string{"Art": {"Humanities", "Fine Arts"}, "Math": {"Science", ""}}
+	if !reflect.DeepEqual(j.lookup, want) {
+		t.Errorf("lookup = %q, want %q", j.lookup, want)
+	}
+	if w := p.Warnings(); len(w) != 1 || w[0].Code != warnJoinDuplicate || w[0].Row != 5 {
+		t.Errorf("warnings = %+v, want the duplicate Art of row 5", w)
+	}
+
+	tests := []struct {
+		join    joinSpec
+		wantErr string
+	}{
+		{joinSpec{Sheet: "Dept's Majors", Key: "Code"}, `JOIN_KEY "Code" isn't a column`},
+		{joinSpec{Sheet: "Dept's Majors", Key: "Major", Columns: []string{"Building"}}, `JOIN_COLUMNS column "Building" isn't a column`},
+		{joinSpec{Sheet: "Minors", Key: "Major"}, "Unable to parse range"},
+	}
+	for _, tt := range tests {
+		if err := tt.join.Load(p); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Load of %s error = %v, want %q", tt.join.String(), err, tt.wantErr)
+		}
+	}
+}
+
+func TestJoiningEmitter(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"Name": "Ann", "Major": "Art"},
+		{"Name": "Bob", "Major": "Math"},
+		{"Name": "Cy", "Major": "History"},
+		{"Name": "Dee"},
+	}
+	p := joinProject(t)
+	j := &joinSpec{Sheet: "Dept's Majors", Key: "Major", ForeignKey: "Major", Prefix: "major_"}
+	if err := j.Load(p); err != nil {
+		t.Fatal(err)
+	}
+	output := &recordingEmitter{}
+	e := &joiningEmitter{Emitter: output, p: p, join: j}
+	e.Header([]string{"Name", "Major"})
+	for _, fields := range rows {
+		if err := e.Row(fields); err != nil {
+			t.Fatalf("Row(%v): %v", fields, err)
+		}
+	}
+	// the colliding Name column is prefixed, and the rows without a match
+	// are passed on as they are
+	want := []map[string]interface{}{
+		{"Name": "Ann", "Major": "Art", "Department": "Humanities", "major_Name": "Fine Arts"},
+		{"Name": "Bob", "Major": "Math", "Department": "Science"},
+		{"Name": "Cy", "Major": "History"},
+		{"Name": "Dee"},
+	}
+	if !reflect.DeepEqual(output.rows, want) {
+		t.Errorf("rows = %v, want %v", output.rows, want)
+	}
+	if err := e.Close(); err != nil || j.lookup != nil {
+		t.Errorf("Close = %v, lookup %v, want it released", err, j.lookup)
+	}
+
+	p.config.Strict = true
+	e = &joiningEmitter{Emitter: output, p: p, join: j}
+	if err := j.Load(p); err != nil {
+		t.Fatal(err)
+	}
+	e.Header([]string{"Name", "Major"})
+	if err := e.Row(map[string]interface{}{"Name": "Cy", "Major": "History"}); err == nil || !strings.Contains(err.Error(), `no row of JOIN_SHEET 'Dept's Majors' has the Major "History"`) {
+		t.Errorf("Row without a match with STRICT error = %v", err)
+	}
+}
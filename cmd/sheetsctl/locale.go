@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// resolveCellFormat returns the `cells.Format` of the `LOCALE` (defaulting to
+// the spreadsheet's own locale) with any `TRUE_LITERALS`/`FALSE_LITERALS`
+// overrides applied.
+func (p Project) resolveCellFormat() (cells.Format, error) {
+	f := cells.Format{DecimalComma: p.config.DecimalComma, Locale: p.config.Locale}
+	if f.Locale == "" {
+		resp, err := p.metadata.Spreadsheet(p.config.SpreadsheetId)
+		if err != nil {
+			return cells.Format{}, err
+		}
+		if resp.Properties != nil {
+			f.Locale = resp.Properties.Locale
+		}
+	}
+	if f.Locale != "" {
+		var ok bool
+		if f, ok = f.WithLocale(f.Locale); !ok {
+			fmt.Printf("Unknown locale %q, reading booleans and dates as en_US\n", f.Locale)
+		}
+	}
+	if len(p.config.TrueLiterals) > 0 {
+		f.TrueLiterals = p.config.TrueLiterals
+	}
+	if len(p.config.FalseLiterals) > 0 {
+		f.FalseLiterals = p.config.FalseLiterals
+	}
+	return f, nil
+}
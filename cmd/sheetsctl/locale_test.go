@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestResolveCellFormat(t *testing.T) {
+	tests := []struct {
+		name                string
+		sheetLocale, locale string
+		trueLiterals        []string
+		wantLocale          string
+		wantTrue            []string
+		wantDayFirst        bool
+		// the spreadsheet's locale is only looked up without LOCALE
+		lookups int
+	}{
+		{"spreadsheet's locale", "de_DE", "", nil, "de_DE", []string{"wahr"}, true, 1},
+		{"LOCALE", "de_DE", "en_US", nil, "en_US", nil, false, 0},
+		{"TRUE_LITERALS", "es_ES", "", []string{"sí"}, "es_ES", []string{"sí"}, true, 1},
+		{"no locale", "", "", nil, "", nil, false, 1},
+	}
+	for _, tt := range tests {
+		var lookups int
+		p := Project{metadata: newMetadataCache(time.Hour, func(spreadsheetId string) (*sheets.Spreadsheet, error) {
+			lookups++
+			return &sheets.Spreadsheet{Properties: &sheets.SpreadsheetProperties{Locale: tt.sheetLocale}}, nil
+		})}
+		p.config.Locale = tt.locale
+		p.config.TrueLiterals = tt.trueLiterals
+		p.config.DecimalComma = true
+
+		f, err := p.resolveCellFormat()
+		if err != nil {
+			t.Errorf("%s: resolveCellFormat: %v", tt.name, err)
+			continue
+		}
+		if f.Locale != tt.wantLocale || !reflect.DeepEqual(f.TrueLiterals, tt.wantTrue) || f.DayFirst != tt.wantDayFirst || !f.DecimalComma {
+			t.Errorf("%s: resolveCellFormat = %+v, want LOCALE %q, true literals %q, DayFirst %v", tt.name, f, tt.wantLocale, tt.wantTrue, tt.wantDayFirst)
+		}
+		if lookups != tt.lookups {
+			t.Errorf("%s: spreadsheet lookups = %d, want %d", tt.name, lookups, tt.lookups)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logBackupLayout is the timestamp suffix of the rotated log files.
+const logBackupLayout = "20060102T150405.000000000"
+
+// rotatingFile is the `LOG_FILE`: once it's grown past `maxSize` bytes, it's
+// renamed with a timestamp suffix and a new file is started, keeping the
+// `maxBackups` most recent renamed files. Writes are serialized, so the log
+// lines of concurrent writers are never split across files.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens (appending to) the log file `path`.
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	file, size, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f.file, f.size = file, size
+	return f, nil
+}
+
+// openLogFile opens the log file `path` for appending, and returns its size.
+func openLogFile(path string) (*os.File, int64, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+func (f *rotatingFile) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == os.Stderr {
+		// back to the file as soon as it can be opened again
+		if file, size, err := openLogFile(f.path); err == nil {
+			f.file, f.size = file, size
+		}
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(b)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to rotate LOG_FILE: %v\n", err)
+		}
+	}
+	n, err := f.file.Write(b)
+	if f.file != os.Stderr {
+		f.size += int64(n)
+	}
+	return n, err
+}
+
+// rotate renames the file with a timestamp suffix, starts a new one, and
+// deletes the backups beyond `maxBackups`; `mu` must be held. The renamed file
+// stays open until the new one is, so failing to open it doesn't stop the
+// logging.
+func (f *rotatingFile) rotate() error {
+	backup := f.path + "." + time.Now().Format(logBackupLayout)
+	if err := os.Rename(f.path, backup); err != nil {
+		return f.rotateClosed(backup)
+	}
+	file, size, err := openLogFile(f.path)
+	if err != nil {
+		// keep logging to the same file, rotating it on a later write
+		return fmt.Errorf("%v (restoring %s: %v)", err, f.path, os.Rename(backup, f.path))
+	}
+	f.file.Close()
+	f.file, f.size = file, size
+	return f.prune()
+}
+
+// rotateClosed rotates the file where it can't be renamed while open (i.e. on
+// Windows): it's closed first, so the log goes to stderr if the new file can't
+// be opened, until it can.
+func (f *rotatingFile) rotateClosed(backup string) error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	renameErr := os.Rename(f.path, backup)
+	file, size, err := openLogFile(f.path)
+	if err != nil {
+		f.file, f.size = os.Stderr, 0
+		return err
+	}
+	f.file, f.size = file, size
+	if renameErr != nil {
+		// logging to the same file
+		return renameErr
+	}
+	return f.prune()
+}
+
+// prune deletes the oldest backups beyond `maxBackups` (0 keeps them all).
+func (f *rotatingFile) prune() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+	backups := []string{}
+	for _, match := range matches {
+		if _, err := time.Parse(logBackupLayout, strings.TrimPrefix(match, f.path+".")); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	// the timestamp suffixes sort chronologically
+	sort.Strings(backups)
+	for len(backups) > f.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Reopen reopens the file at `path`, e.g. after logrotate renamed it; the
+// current file is kept when the new one can't be opened.
+func (f *rotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, size, err := openLogFile(f.path)
+	if err != nil {
+		return err
+	}
+	if f.file != os.Stderr {
+		f.file.Close()
+	}
+	f.file, f.size = file, size
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// logBackups returns the rotated backups of the log file `path`.
+func logBackups(t *testing.T, path string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(path + ".2*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.log")
+	f, err := openRotatingFile(path, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.file.Close()
+	line := strings.Repeat("x", 39) + "\n"
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2 lines fit in 100 bytes, so the 5 lines are in 3 files
+	backups := logBackups(t, path)
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want 2", backups)
+	}
+	for _, name := range append(backups, path) {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) > 100 || len(b)%len(line) != 0 {
+			t.Errorf("%s has %d bytes, want whole lines up to 100", name, len(b))
+		}
+	}
+	if b, _ := os.ReadFile(path); string(b) != line {
+		t.Errorf("current file = %q, want the last line", b)
+	}
+}
+
+func TestRotatingFilePrune(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.log")
+	other := filepath.Join(dir, "run.log.old")
+	if err := os.WriteFile(other, []byte("kept"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.file.Close()
+	for i := 0; i < 6; i++ {
+		if _, err := f.Write([]byte(strings.Repeat(string(rune('a'+i)), 9) + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups := logBackups(t, path)
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want the 2 most recent", backups)
+	}
+	for i, want := range []string{"ddddddddd\n", "eeeeeeeee\n"} {
+		if b, _ := os.ReadFile(backups[i]); string(b) != want {
+			t.Errorf("backup %s = %q, want %q", backups[i], b, want)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("%s isn't a backup, want it kept: %v", other, err)
+	}
+}
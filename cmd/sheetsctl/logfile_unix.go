@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// reopenOnHangup reopens the `LOG_FILE` on SIGHUP, as logrotate expects after
+// renaming it.
+func reopenOnHangup(f *rotatingFile) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			if err := f.Reopen(); err != nil {
+				log.Printf("Unable to reopen LOG_FILE: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRotatingFileOpenFailure removes the directory of the open log file,
+// which Windows doesn't allow.
+func TestRotatingFileOpenFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "run.log")
+	f, err := openRotatingFile(path, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// the current file is kept when the new one can't be opened
+	current := f.file
+	if err := f.Reopen(); err == nil {
+		t.Error("Reopen without the directory succeeded, want an error")
+	}
+	if f.file != current {
+		t.Error("Reopen failing replaced the current file")
+	}
+
+	// rotating falls back to stderr, then back to the file once it can be
+	// opened again
+	if _, err := f.Write([]byte("second\n")); err != nil {
+		t.Errorf("Write while the file can't be opened: %v", err)
+	}
+	if f.file != os.Stderr {
+		t.Errorf("file = %v, want stderr while the file can't be opened", f.file.Name())
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("third\n")); err != nil {
+		t.Fatal(err)
+	}
+	defer f.file.Close()
+	if b, err := os.ReadFile(path); err != nil || string(b) != "third\n" {
+		t.Errorf("file = %q, %v, want the logging back to it", b, err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build windows
+// +build windows
+
+package main
+
+// reopenOnHangup is a no-op on Windows, which has no SIGHUP.
+func reopenOnHangup(f *rotatingFile) {}
@@ -0,0 +1,1201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/kelseyhightower/envconfig"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/spreadsheet"
+)
+
+// Code originally pulled from the following, and then modified for my own
+// demonstration:
+//   - Google Sheets API - Golang Quickstart:
+//     https://developers.google.com/sheets/api/quickstart/go#step_3_set_up_the_sample
+
+type Config struct {
+	// `Mode` selects what the program does:
+	//  - "" (default): read and print the sheet's rows
+	//  - "gen-struct": generate a Go struct from the sheet's header
+	//  - "aggregate": output the `Aggregates` of the rows per `GroupBy` group
+	Mode                string `envconfig:"MODE"`
+	BatchCount          int    `envconfig:"BATCH_COUNT" required:"true" default:"1000"`
+	CredentialsFileName string `envconfig:"CREDENTIALS_FILE_NAME" required:"true" default:"credentials.json"`
+	// The `SpreadsheetId`/`SheetName` defaults are for a Google Sheets API sample
+	// spreadsheet:
+	//  - https://docs.google.com/spreadsheets/d/1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms/edit
+	//
+	// `SpreadsheetId` can also be a spreadsheet URL (see
+	// `ParseSpreadsheetRef`); when it links to a tab with "#gid=" and
+	// `SHEET_NAME` isn't set, that tab is read.
+	SpreadsheetId string   `envconfig:"SPREADSHEET_ID" required:"true" default:"1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms"`
+	SheetName     string   `envconfig:"SHEET_NAME" required:"true" default:"Class Data"`
+	Scopes        []string `envconfig:"SCOPES" required:"true" default:"https://www.googleapis.com/auth/drive.readonly"`
+	// `Columns` is a comma-separated list of 1-based column positions and ranges
+	// (e.g. "1,3-5,9") to include in the output. When empty and running
+	// interactively, the user is prompted for a selection once the headers are
+	// known; the prompt defaults to all columns after `ColumnPromptTimeout`.
+	Columns             string        `envconfig:"COLUMNS"`
+	ColumnPromptTimeout time.Duration `envconfig:"COLUMN_PROMPT_TIMEOUT" default:"30s"`
+	// `OAuthAuthURL`/`OAuthTokenURL` override the OAuth endpoints read from
+	// `credentials.json`, e.g. for a corporate OAuth proxy or a local test
+	// server; the device flow's endpoint is "device/code" next to the token
+	// endpoint.
+	OAuthAuthURL  string `envconfig:"OAUTH_AUTH_URL"`
+	OAuthTokenURL string `envconfig:"OAUTH_TOKEN_URL"`
+	// `SortBy` orders the output by one or more columns, e.g.
+	// "Home State asc,Student Name desc"; rows are buffered in memory up to
+	// `SortMemoryMB` and then spilled to temporary files.
+	SortBy              string `envconfig:"SORT_BY"`
+	SortCaseInsensitive bool   `envconfig:"SORT_CASE_INSENSITIVE" default:"false"`
+	SortMemoryMB        int    `envconfig:"SORT_MEMORY_MB" default:"64"`
+	// `MemoryBudgetMB` caps the (estimated) memory held by all the stages
+	// buffering data together (0 disables it): the sort spills to temporary
+	// files when it's exceeded, and the run fails if the `JOIN_SHEET` lookup
+	// alone doesn't fit in it. With both, each gets half of it.
+	MemoryBudgetMB int `envconfig:"MEMORY_BUDGET_MB" default:"0"`
+	// `Provenance` appends synthetic columns to every row recording where it
+	// came from; the column names start with `ProvenancePrefix`.
+	Provenance       bool   `envconfig:"PROVENANCE" default:"false"`
+	ProvenancePrefix string `envconfig:"PROVENANCE_PREFIX" default:"_"`
+	// `ProvenanceModifiedAt` adds the Drive `modifiedTime` of the spreadsheet
+	// to the provenance columns (empty when it's unavailable).
+	ProvenanceModifiedAt bool `envconfig:"PROVENANCE_MODIFIED_AT" default:"false"`
+	// Rows "deleted" by striking them through (`SkipStrikethrough`) or by
+	// coloring them (`SkipBackgroundColor`, e.g. "#d9d9d9") are excluded from
+	// the output; only `SoftDeleteColumn` (e.g. "A") is checked when set, else
+	// any cell in the row.
+	SkipStrikethrough   bool   `envconfig:"SKIP_STRIKETHROUGH" default:"false"`
+	SkipBackgroundColor string `envconfig:"SKIP_BACKGROUND_COLOR"`
+	SoftDeleteColumn    string `envconfig:"SOFT_DELETE_COLUMN"`
+	// `Gen*` configure the "gen-struct" `Mode`; the struct is printed to stdout
+	// when `GenOutput` is empty, and named after the sheet when `GenStructName`
+	// is empty.
+	GenPackage    string `envconfig:"GEN_PACKAGE" default:"main"`
+	GenStructName string `envconfig:"GEN_STRUCT_NAME"`
+	GenOutput     string `envconfig:"GEN_OUTPUT"`
+	GenSampleRows int    `envconfig:"GEN_SAMPLE_ROWS" default:"100"`
+	// `IncludeValidation` documents the allowed values of dropdown columns on
+	// the generated struct's fields.
+	IncludeValidation bool `envconfig:"INCLUDE_VALIDATION"`
+	// `IncludeColumnMetadata` documents each column's letter, pixel width, and
+	// whether it's hidden on the generated struct's fields.
+	IncludeColumnMetadata bool `envconfig:"INCLUDE_COLUMN_METADATA"`
+	// `MetadataTTL` is how long spreadsheet metadata (sheet titles, grid sizes)
+	// is cached before being fetched again.
+	MetadataTTL time.Duration `envconfig:"METADATA_TTL" default:"5m"`
+	// `HashColumn` names a column appended to every row holding a stable
+	// SHA-256 of the row's `HashColumns` (all columns when empty); see
+	// `rowHasher` for the canonicalization rules.
+	HashColumn  string   `envconfig:"HASH_COLUMN"`
+	HashColumns []string `envconfig:"HASH_COLUMNS"`
+	// `Preflight` checks access to the spreadsheet with a minimal metadata call
+	// before doing anything else; disable it to save the extra API call.
+	Preflight bool `envconfig:"PREFLIGHT" default:"true"`
+	// `Cache` ("off", "read", or "readwrite") serves fetched ranges from
+	// `CacheDir` while the spreadsheet's Drive `modifiedTime` is unchanged; this
+	// requires a Drive scope.
+	Cache      string `envconfig:"CACHE" default:"off"`
+	CacheDir   string `envconfig:"CACHE_DIR" default:".cache"`
+	CacheMaxMB int    `envconfig:"CACHE_MAX_MB" default:"100"`
+	// `ReadRanges` is a comma-separated list of the sheet's A1 ranges to read,
+	// e.g. "A:C,K:M"; several ranges are fetched together and joined into a
+	// single row. Ranges can limit the rows read too, e.g. "A2:C50,K2:M50".
+	ReadRanges string `envconfig:"READ_RANGES" default:"A:Z"`
+	// `ColsPerRequest` splits the `ReadRanges` of very wide sheets into tiles
+	// of up to that many columns, fetched with a request each (or a single
+	// `Values.BatchGet` with `TileBatchGet`) and stitched back into rows.
+	ColsPerRequest int  `envconfig:"COLS_PER_REQUEST"`
+	TileBatchGet   bool `envconfig:"TILE_BATCH_GET"`
+	// `MaxColumns` caps the number of columns read from the `ReadRanges`
+	// (e.g. "A:ZZZ"), with a warning, before any batches are planned (0
+	// disables it); the `headers` subcommand lists the header row's columns.
+	MaxColumns int `envconfig:"MAX_COLUMNS" default:"1000"`
+	// `TokenMinValidity` forces a refresh of a cached token that expires within
+	// it before any data is read, so a bad refresh token is discovered up front
+	// rather than mid-run.
+	TokenMinValidity time.Duration `envconfig:"TOKEN_MIN_VALIDITY" default:"30m"`
+	// `AuthFlow` are the steps tried in order to authorize when there's no
+	// cached token (see `getTokenFromFlow`), each for up to `AuthTimeout`:
+	// "localhost" opens the browser, "device" shows a code to enter on another
+	// device (only for the scopes Google grants that way, which the Sheets ones
+	// aren't), and "paste" prompts for the code.
+	AuthFlow    string        `envconfig:"AUTH_FLOW" default:"localhost,paste"`
+	AuthTimeout time.Duration `envconfig:"AUTH_TIMEOUT" default:"3m"`
+	// `SheetsEndpoint` overrides the Sheets API base URL, e.g. to point at a
+	// local emulator or echo server.
+	SheetsEndpoint string `envconfig:"SHEETS_ENDPOINT"`
+	// `DebugDumpDir` writes every API request (method/URL/headers) and its raw
+	// response body to numbered files in the directory, with credentials
+	// redacted.
+	DebugDumpDir string `envconfig:"DEBUG_DUMP_DIR"`
+	// `DecimalComma` reads numbers with swapped thousand/decimal separators,
+	// e.g. "1.234,56", as used by European locales.
+	DecimalComma bool `envconfig:"DECIMAL_COMMA" default:"false"`
+	// `Locale` drives the boolean literals and day/month order of dates, e.g.
+	// "es_ES" reads "VERDADERO" and "31/01/2024"; the spreadsheet's own locale
+	// is used when empty. `TrueLiterals`/`FalseLiterals` override the locale's
+	// boolean literals.
+	Locale        string   `envconfig:"LOCALE"`
+	TrueLiterals  []string `envconfig:"TRUE_LITERALS"`
+	FalseLiterals []string `envconfig:"FALSE_LITERALS"`
+	// `RevisionId` reads the sheet as of a past Drive revision of the
+	// spreadsheet, or `AsOf` as of the latest revision at or before that time
+	// (RFC 3339); this requires a Drive scope. The revision is exported to
+	// .xlsx, so values are the stored ones rather than formatted (e.g. dates
+	// are serial numbers), and features that need the live spreadsheet's
+	// formatting or metadata can't be used.
+	RevisionId string    `envconfig:"REVISION_ID"`
+	AsOf       time.Time `envconfig:"AS_OF"`
+	// `EmptySheet` is what to do when no data rows are emitted (after soft
+	// deletes are skipped): "ok" finishes as usual, "warn" also prints a
+	// warning, and "fail" exits with `exitEmptySheet`.
+	EmptySheet string `envconfig:"EMPTY_SHEET" default:"ok"`
+	// `HeaderRow` is the row of the header, rows above it are never read;
+	// when the header is pinned with `Headers` (or `HeadersFile`, one name per
+	// line), it's the first data row instead.
+	HeaderRow   int      `envconfig:"HEADER_ROW" default:"1"`
+	Headers     []string `envconfig:"HEADERS"`
+	HeadersFile string   `envconfig:"HEADERS_FILE"`
+	// `SpreadsheetIds` reads the `SheetName` sheet of each of the spreadsheets
+	// (instead of `SpreadsheetId`) and outputs the union of their rows, with a
+	// `_source_spreadsheet_id` column; the headers must match.
+	SpreadsheetIds []string `envconfig:"SPREADSHEET_IDS"`
+	// `DryRun` prints the pipeline stages and the planned batches, without
+	// reading any rows.
+	DryRun bool `envconfig:"DRY_RUN"`
+	// `FieldsMask` limits the values responses to the values and their range;
+	// turn it off to see the full responses, e.g. with `DebugDumpDir`.
+	FieldsMask bool `envconfig:"FIELDS_MASK" default:"true"`
+	// `MaxRetries` is how many times a failed API call (network error, 429,
+	// or 5xx) is retried, as long as the run's `RetryBudget` lasts.
+	MaxRetries  int `envconfig:"MAX_RETRIES" default:"5"`
+	RetryBudget int `envconfig:"RETRY_BUDGET" default:"50"`
+	// `CircuitBreakerThreshold` is how many consecutive failures of the same
+	// kind stop the run (with exit code 4); 0 disables the circuit breaker.
+	CircuitBreakerThreshold int `envconfig:"CIRCUIT_BREAKER_THRESHOLD" default:"5"`
+	// `QuotaPressureWarnPct` warns when more than this percentage of the run
+	// was spent backing off before retries (0 disables it).
+	QuotaPressureWarnPct float64 `envconfig:"QUOTA_PRESSURE_WARN_PCT" default:"25"`
+	// `RedactColumns` redacts columns before they're output, e.g.
+	// "Email:hash,SSN:drop,Name:mask" (see `redactHash`, `redactMask`, and
+	// `redactDrop`); `RedactSalt` salts the hashes.
+	RedactColumns string `envconfig:"REDACT_COLUMNS"`
+	RedactSalt    string `envconfig:"REDACT_SALT"`
+	// `DebugBodies` includes the API response bodies in the
+	// `--support-bundle`, which otherwise only has the calls' status and
+	// timing.
+	DebugBodies bool `envconfig:"DEBUG_BODIES"`
+	// `ShowAccount` prints the authorized account's email, adding the email
+	// scope to `Scopes`; `ExpectedAccount` also fails the run (before any data
+	// is accessed) when it's another account.
+	ShowAccount     bool   `envconfig:"SHOW_ACCOUNT"`
+	ExpectedAccount string `envconfig:"EXPECTED_ACCOUNT"`
+	// `GroupBy` are the columns `MODE=aggregate` groups the rows by (all rows
+	// are a single group when empty), and `Aggregates` what's computed for each
+	// group, e.g. "count,avg:GPA,sum:Credits" (see `parseAggregates`); the
+	// result is written as a "table", "csv", or "json" (`AggregateFormat`),
+	// the last two alone on stdout.
+	GroupBy         []string `envconfig:"GROUP_BY"`
+	Aggregates      string   `envconfig:"AGGREGATES" default:"count"`
+	AggregateFormat string   `envconfig:"AGGREGATE_FORMAT" default:"table"`
+	// The CSV output is delimited by `CSVDelimiter` ("comma", "semicolon", or
+	// "tab"), starts with a UTF-8 BOM with `CSVBOM`, and ends its lines with
+	// CRLF with `CSVCRLF`; e.g. Excel on European locales expects
+	// "semicolon", a BOM, and CRLF.
+	CSVDelimiter string `envconfig:"CSV_DELIMITER" default:"comma"`
+	CSVBOM       bool   `envconfig:"CSV_BOM" default:"false"`
+	CSVCRLF      bool   `envconfig:"CSV_CRLF" default:"false"`
+	// `Strict` fails the run on values that can't be used as expected (e.g. a
+	// non-numeric cell in a numeric aggregate) instead of warning about them,
+	// and on one of several `ReadRanges` the API rejects instead of leaving its
+	// columns blank.
+	Strict bool `envconfig:"STRICT"`
+	// `RejectFile` lands the rows that can't be used as expected (a value a
+	// stage can't use, an unmatched `JoinForeignKey`, a cell over
+	// `MaxCellBytes` with `MaxCellPolicy=fail`) in this JSONL file instead of
+	// using them as is or failing the run, unless `Strict` is set; the run
+	// exits with code 7 (`exitRejectThreshold`) when more rows than
+	// `RejectThreshold` ("100", or "5%" of the rows read) are rejected.
+	RejectFile      string `envconfig:"REJECT_FILE"`
+	RejectThreshold string `envconfig:"REJECT_THRESHOLD"`
+	// `SlowRangeThreshold` warns about batches taking longer to fetch, as
+	// they're fetched (0 disables it); the `SlowestBatches` batches of the run
+	// are reported in the summary.
+	SlowRangeThreshold time.Duration `envconfig:"SLOW_RANGE_THRESHOLD"`
+	SlowestBatches     int           `envconfig:"SLOWEST_BATCHES" default:"5"`
+	// `ExcludeRows` is a comma-separated list of absolute sheet row numbers and
+	// ranges to leave out, e.g. "5000-5100,7020,9000-" ("9000-" meaning row
+	// 9000 onwards); batches lying entirely within them aren't fetched.
+	ExcludeRows string `envconfig:"EXCLUDE_ROWS"`
+	// `MetadataSkip` leaves out the rows and columns (or the whole sheet)
+	// tagged with this developer metadata, e.g. "export=skip", or just
+	// "export" for any value; the `metadata` subcommand lists the sheet's.
+	MetadataSkip string `envconfig:"METADATA_SKIP"`
+	// `MaxCellBytes` limits the size of the cells (0 means no limit); larger
+	// cells are truncated, dropped, or fail the run depending on
+	// `MaxCellPolicy` (see `cellPolicyTruncate`, `cellPolicyDrop`, and
+	// `cellPolicyFail`).
+	MaxCellBytes  int    `envconfig:"MAX_CELL_BYTES"`
+	MaxCellPolicy string `envconfig:"MAX_CELL_POLICY" default:"truncate"`
+	// `MaxBatchBytes` adapts the size of the batches to the bytes per row of
+	// the previous batch, so they stay under it (0 keeps `BatchCount` rows);
+	// e.g. a column of large cells makes the following batches smaller.
+	MaxBatchBytes int `envconfig:"MAX_BATCH_BYTES"`
+	// `OutputFile` writes the export (the rows, the `--porcelain` protocol, or
+	// the CSV/JSON aggregates) to the file rather than stdout, e.g. for the
+	// `PostCommand` to upload it.
+	OutputFile string `envconfig:"OUTPUT_FILE"`
+	// `PostCommand` is run after a successful export, e.g. to compress and
+	// upload it (see `runPostCommand`); a failure, or taking longer than
+	// `PostCommandTimeout`, fails the run with `exitPostCommand`.
+	PostCommand        string        `envconfig:"POST_COMMAND"`
+	PostCommandTimeout time.Duration `envconfig:"POST_COMMAND_TIMEOUT" default:"5m"`
+	// `TrimCells` trims the whitespace around the cells' values, and
+	// `EmptyAsNull` outputs the empty cells as nulls rather than leaving them
+	// out of the rows; `ColumnOptions` overrides them per column, e.g.
+	// "Notes:no-trim:empty-as-empty" (see `parseColumnOptions`).
+	TrimCells     bool   `envconfig:"TRIM_CELLS"`
+	EmptyAsNull   bool   `envconfig:"EMPTY_AS_NULL"`
+	ColumnOptions string `envconfig:"COLUMN_OPTIONS"`
+	// `NumberMode` is how numeric cells are output: "string" keeps their
+	// formatted strings, "float" outputs them as numbers, and "auto" as
+	// numbers too except for the integers beyond 2^53 (e.g. IDs), which
+	// would lose precision (see `numberValue`).
+	NumberMode string `envconfig:"NUMBER_MODE" default:"string"`
+	// `RawSideChannel` adds a "<header>__raw" field with the cell as returned
+	// by the API next to the converted value (trimmed, a boolean, a number,
+	// truncated, ...) of the `RawColumns` (all when empty), for debugging the
+	// conversions; redacted columns never get one.
+	RawSideChannel bool     `envconfig:"RAW_SIDE_CHANNEL"`
+	RawColumns     []string `envconfig:"RAW_COLUMNS"`
+	// `RunLogSpreadsheetId` records every run as a row of its `RunLogSheet`
+	// sheet (see `runLogger`), adding the spreadsheets scope to `Scopes`.
+	RunLogSpreadsheetId string `envconfig:"RUN_LOG_SPREADSHEET_ID"`
+	RunLogSheet         string `envconfig:"RUN_LOG_SHEET" default:"Runs"`
+	// `SanitizeFormulas` protects the CSV output against formula injection:
+	// values starting like a formula (e.g. "=HYPERLINK(...)") are prefixed
+	// with a single quote, except in the `SanitizeFormulasAllow` columns.
+	SanitizeFormulas      bool     `envconfig:"SANITIZE_FORMULAS"`
+	SanitizeFormulasAllow []string `envconfig:"SANITIZE_FORMULAS_ALLOW"`
+	// `ReadOnly` guarantees the run can't modify spreadsheets: features that
+	// write are rejected, the `Scopes` are replaced by their read-only
+	// variants, and any API request but a GET fails before it's sent.
+	ReadOnly bool `envconfig:"READ_ONLY" default:"false"`
+	// `ConfigURL` is an https:// or gs:// URL of a shared env-format config,
+	// whose variables are used when they aren't set by the `.env` file or the
+	// environment; it's cached in `ConfigCacheDir` in case it can't be
+	// fetched. Only the `remoteConfigKeys` can be set that way (see
+	// `loadRemoteConfig`).
+	ConfigURL      string `envconfig:"CONFIG_URL"`
+	ConfigCacheDir string `envconfig:"CONFIG_CACHE_DIR" default:".cache"`
+	// `MaxRunDuration` stops reading batches once the run has lasted that long
+	// (0 disables it): the rows read so far are output and the summary marked
+	// as partial, and the run exits with code 6 (`exitTimeLimit`).
+	MaxRunDuration time.Duration `envconfig:"MAX_RUN_DURATION"`
+	// `ControlSocket` is the path of a Unix socket controlling the run while
+	// it's going: `status` returns its live counts, `pause`/`resume` hold the
+	// fetcher between batches, and `stop` stops it as `MaxRunDuration` does
+	// (see control.go).
+	ControlSocket string `envconfig:"CONTROL_SOCKET"`
+	// `LogFile` also writes the log to this file, rotated once it's over
+	// `LogMaxSizeMB` (0 disables rotation), keeping the `LogMaxBackups` most
+	// recent rotated files (0 keeps them all); it's reopened on SIGHUP for
+	// logrotate.
+	LogFile       string `envconfig:"LOG_FILE"`
+	LogMaxSizeMB  int    `envconfig:"LOG_MAX_SIZE_MB" default:"100"`
+	LogMaxBackups int    `envconfig:"LOG_MAX_BACKUPS" default:"5"`
+	// `JoinSheet` enriches every row with the `JoinColumns` (all when empty)
+	// of the row of this sheet whose `JoinKey` column is the row's
+	// `JoinForeignKey` value; the columns colliding with the rows' are named
+	// with the `JoinPrefix`. The sheet is held in memory, with a warning past
+	// `JoinWarnRows` rows. Unmatched rows are left without the columns, or
+	// fail the run with `Strict`.
+	JoinSheet      string   `envconfig:"JOIN_SHEET"`
+	JoinKey        string   `envconfig:"JOIN_KEY"`
+	JoinForeignKey string   `envconfig:"JOIN_FOREIGN_KEY"`
+	JoinColumns    []string `envconfig:"JOIN_COLUMNS"`
+	JoinPrefix     string   `envconfig:"JOIN_PREFIX" default:"join_"`
+	JoinWarnRows   int      `envconfig:"JOIN_WARN_ROWS" default:"50000"`
+}
+
+type Project struct {
+	config        Config
+	client        *http.Client
+	sheetsService *sheets.Service
+	metadata      *metadataCache
+	cache         *valueCache
+	readRanges    []a1.Range
+	format        cells.Format
+	provenance    provenance
+	summary       *runSummary
+	meter         *apiMeter
+	// porcelain is set by the `--porcelain` flag
+	porcelain *porcelainWriter
+	// dataOut is the original stdout with `AGGREGATE_FORMAT=csv`/`json`, when
+	// anything printed for humans goes to stderr instead
+	dataOut io.Writer
+	// revision is set by `REVISION_ID`/`AS_OF`
+	revision *revisionSheet
+	// redactions is set by `REDACT_COLUMNS`
+	redactions []redaction
+	// headers is set by `HEADERS`/`HEADERS_FILE`
+	headers []interface{}
+	// excludedRows is set by `EXCLUDE_ROWS`
+	excludedRows rowRanges
+	// columnOptions is set by `COLUMN_OPTIONS`
+	columnOptions map[string]cellPolicy
+	// csvDialect is set by `CSV_DELIMITER`, `CSV_BOM`, and `CSV_CRLF`
+	csvDialect csvDialect
+	// deadline is set by `MAX_RUN_DURATION`
+	deadline time.Time
+	// authFlow is set by `AUTH_FLOW`
+	authFlow []string
+	// memory is set by `MEMORY_BUDGET_MB`
+	memory *memoryBudget
+	// rejectFile is set by `REJECT_FILE`
+	rejectFile *rejectWriter
+	// started is when the run started, by the `clock`
+	started time.Time
+	clock   clock
+	// postCommand is set by `POST_COMMAND`
+	postCommand []string
+	// control is set by `CONTROL_SOCKET`
+	control *controlSocket
+	// metadataSkip is set by `METADATA_SKIP`, and metadataColumns are the
+	// (0-based) sheet columns it tags in the spreadsheet being read
+	metadataSkip    *metadataTag
+	metadataColumns map[int]bool
+}
+
+const (
+	modeGenStruct = "gen-struct"
+	modeAggregate = "aggregate"
+
+	// `EmptySheet` values
+	emptySheetOk   = "ok"
+	emptySheetWarn = "warn"
+	emptySheetFail = "fail"
+	// exitEmptySheet is the exit code of `EMPTY_SHEET=fail` runs without any
+	// data rows, so it can be told apart from other failures (which exit 1)
+	exitEmptySheet = 3
+
+	// valueRenderOption is how values are rendered by the Values API.
+	valueRenderOption = "FORMATTED_VALUE"
+)
+
+var (
+	project Project
+
+	errSheetNotFound = errors.New("sheetTitle not found")
+)
+
+// main initializes the project by reading the local `.env`/`credentials.json`
+// files and triggering the OAuth authorization if needed; and then prints the
+// names and majors of students from a sample spreadsheet.
+//
+// Edited from original:
+// https://developers.google.com/sheets/api/quickstart/go#step_3_set_up_the_sample
+func main() {
+	porcelain := flag.Bool("porcelain", false, "write a stable, versioned JSON lines protocol to stdout and everything else to stderr")
+	supportBundle := flag.String("support-bundle", "", "write a zip of the (redacted) config, batch plan, API calls, summary, and logs of the run to `path`")
+	showVersion := flag.Bool("version", false, "print the build's version, commit, and date, and exit")
+	flag.Parse()
+	if *showVersion || flag.Arg(0) == "version" {
+		printVersion()
+		return
+	}
+	if *porcelain {
+		project.porcelain = newPorcelainWriter(os.Stdout)
+		// anything printed for humans now goes to stderr, keeping stdout for the
+		// protocol only
+		os.Stdout = os.Stderr
+	}
+	project.clock = realClock{}
+	started := project.clock.Now()
+	project.started = started
+	project.summary = &runSummary{Build: buildVersion()}
+	project.meter = &apiMeter{}
+	if *supportBundle != "" {
+		bundle = newSupportBundle(*supportBundle, project.summary)
+		log.SetOutput(io.MultiWriter(os.Stderr, bundle))
+		// failed runs write the bundle in `exit`
+		defer func() {
+			if err := bundle.Close(0); err != nil {
+				log.Printf("Unable to write the support bundle: %v", err)
+			}
+		}()
+	}
+
+	var c Config
+	ctx := context.Background()
+	sources := newConfigSources()
+	// Load ENV config
+	if err := godotenv.Overload(); err != nil {
+		// don't care if there is no .env file as we have defaults set.
+		if !os.IsNotExist(err) {
+			fatalf("Unable to load ENV: %v", err)
+		}
+	} else if dotenv, err := godotenv.Read(); err == nil {
+		for key := range dotenv {
+			sources[key] = sourceDotEnv
+		}
+	}
+	remoteKeys, err := loadRemoteConfig()
+	if err != nil {
+		fatalf("Unable to load CONFIG_URL: %v", err)
+	}
+	sources.Set(remoteKeys, sourceRemote)
+	err = envconfig.Process("", &c)
+	if err != nil {
+		fatalf("Unable to get Config: %v", err)
+	}
+	if err := interpolateConfig(&c); err != nil {
+		fatalf("Unable to interpolate Config: %v", err)
+	}
+	if flag.Arg(0) == "config" {
+		if err := printConfig(c, sources); err != nil {
+			fatalf("Unable to print the config: %v", err)
+		}
+		return
+	}
+	project.config = c
+	bundle.SetConfig(&project.config)
+	if project.config.MaxRunDuration > 0 {
+		project.deadline = started.Add(project.config.MaxRunDuration)
+	}
+	if project.config.LogFile != "" {
+		logFile, err := openRotatingFile(project.config.LogFile, int64(project.config.LogMaxSizeMB)<<20, project.config.LogMaxBackups)
+		if err != nil {
+			fatalf("Unable to open LOG_FILE: %v", err)
+		}
+		log.SetOutput(io.MultiWriter(log.Writer(), logFile))
+		reopenOnHangup(logFile)
+	}
+	project.memory = newMemoryBudget(int64(project.config.MemoryBudgetMB) << 20)
+	if project.config.ControlSocket != "" {
+		project.control, err = listenControlSocket(project.config.ControlSocket)
+		if err != nil {
+			fatalf("Unable to listen on CONTROL_SOCKET: %v", err)
+		}
+		defer project.control.Close()
+	}
+	// spreadsheets can be given as IDs or as URLs; the gid of a URL to a tab
+	// picks the sheet when `SHEET_NAME` isn't set
+	ref, err := ParseSpreadsheetRef(project.config.SpreadsheetId)
+	if err != nil {
+		fatalf("Unable to parse SPREADSHEET_ID: %v", err)
+	}
+	project.config.SpreadsheetId = ref.Id
+	for i, spreadsheetId := range project.config.SpreadsheetIds {
+		idsRef, err := ParseSpreadsheetRef(spreadsheetId)
+		if err != nil {
+			fatalf("Unable to parse SPREADSHEET_IDS: %v", err)
+		}
+		project.config.SpreadsheetIds[i] = idsRef.Id
+		if i == 0 {
+			ref = idsRef
+		}
+	}
+	if project.config.RunLogSpreadsheetId != "" {
+		runLogRef, err := ParseSpreadsheetRef(project.config.RunLogSpreadsheetId)
+		if err != nil {
+			fatalf("Unable to parse RUN_LOG_SPREADSHEET_ID: %v", err)
+		}
+		project.config.RunLogSpreadsheetId = runLogRef.Id
+	}
+	project.readRanges, err = parseReadRanges(project.config.ReadRanges)
+	if err != nil {
+		fatalf("Unable to parse READ_RANGES: %v", err)
+	}
+	if capped, cut := capColumns(project.readRanges, project.config.MaxColumns); cut > 0 {
+		log.Printf("WARNING: READ_RANGES %q spans %d columns more than MAX_COLUMNS (%d), only reading %s; run the headers subcommand to list the sheet's columns", project.config.ReadRanges, cut, project.config.MaxColumns, readRangesString(capped))
+		project.readRanges = capped
+	}
+	// NOTE: rows above `HEADER_ROW` are never read, so a header past the
+	// ranges' rows would silently leave nothing to read.
+	if r := project.readRanges[0]; r.EndRow != 0 && project.config.HeaderRow > r.EndRow {
+		fatalf("HEADER_ROW %d is past the rows of READ_RANGES (%d-%d)", project.config.HeaderRow, r.StartRow, r.EndRow)
+	}
+	project.excludedRows, err = parseRowRanges(project.config.ExcludeRows)
+	if err != nil {
+		fatalf("Unable to parse EXCLUDE_ROWS: %v", err)
+	}
+	project.metadataSkip, err = parseMetadataTag(project.config.MetadataSkip)
+	if err != nil {
+		fatalf("Unable to parse METADATA_SKIP: %v", err)
+	}
+	if _, _, err := parseRejectThreshold(project.config.RejectThreshold); err != nil {
+		fatalf("Unable to parse REJECT_THRESHOLD: %v", err)
+	}
+	project.postCommand, err = parsePostCommand(project.config.PostCommand)
+	if err != nil {
+		fatalf("Unable to parse POST_COMMAND: %v", err)
+	}
+	if project.postCommand != nil && project.config.PostCommandTimeout <= 0 {
+		fatalf("POST_COMMAND_TIMEOUT must be positive, got %s", project.config.PostCommandTimeout)
+	}
+	switch project.config.Mode {
+	case "", modeGenStruct, modeAggregate:
+	default:
+		fatalf("Unknown MODE: %q", project.config.Mode)
+	}
+	if project.config.Mode == modeAggregate && project.porcelain == nil && (project.config.AggregateFormat == aggregateFormatCSV || project.config.AggregateFormat == aggregateFormatJSON) {
+		// keep stdout for the CSV/JSON data only, like `--porcelain`
+		project.dataOut = os.Stdout
+		os.Stdout = os.Stderr
+	}
+	if project.config.OutputFile != "" {
+		project.redirectOutput()
+	}
+	if project.config.ReadOnly {
+		project.checkReadOnlyConfig()
+	}
+	project.authFlow, err = parseAuthFlow(project.config.AuthFlow)
+	if err != nil {
+		fatalf("Unable to parse AUTH_FLOW: %v", err)
+	}
+	project.csvDialect, err = parseCSVDialect(project.config.CSVDelimiter, project.config.CSVBOM, project.config.CSVCRLF)
+	if err != nil {
+		fatalf("Unable to parse CSV_DELIMITER: %v", err)
+	}
+	project.columnOptions, err = parseColumnOptions(project.config.ColumnOptions, project.defaultCellPolicy())
+	if err != nil {
+		fatalf("Unable to parse COLUMN_OPTIONS: %v", err)
+	}
+	switch project.config.MaxCellPolicy {
+	case cellPolicyTruncate, cellPolicyDrop, cellPolicyFail:
+	default:
+		fatalf("Unknown MAX_CELL_POLICY: %q", project.config.MaxCellPolicy)
+	}
+	project.redactions, err = parseRedactColumns(project.config.RedactColumns)
+	if err != nil {
+		fatalf("Unable to parse REDACT_COLUMNS: %v", err)
+	}
+	if project.redactions != nil {
+		// NOTE: the dumps hold the raw responses, redacted values included.
+		if project.config.DebugDumpDir != "" {
+			fatalf("DEBUG_DUMP_DIR can't be used with REDACT_COLUMNS")
+		}
+		if bundle != nil && project.config.DebugBodies {
+			fatalf("DEBUG_BODIES can't be used with REDACT_COLUMNS")
+		}
+		project.summary.Redacted = map[string]string{}
+		for _, redaction := range project.redactions {
+			project.summary.Redacted[redaction.Column] = redaction.Strategy
+		}
+	}
+	project.headers, err = loadHeaders(project.config.Headers, project.config.HeadersFile)
+	if err != nil {
+		fatalf("Unable to load HEADERS: %v", err)
+	}
+	if len(project.config.SpreadsheetIds) > 0 {
+		if project.config.RevisionId != "" || !project.config.AsOf.IsZero() {
+			fatalf("REVISION_ID/AS_OF can't be used with SPREADSHEET_IDS")
+		}
+		// the metadata (e.g. the locale) comes from the first spreadsheet
+		project.config.SpreadsheetId = project.config.SpreadsheetIds[0]
+	}
+	switch project.config.EmptySheet {
+	case emptySheetOk, emptySheetWarn, emptySheetFail:
+	default:
+		fatalf("Unknown EMPTY_SHEET: %q", project.config.EmptySheet)
+	}
+	switch project.config.NumberMode {
+	case cells.NumberModeString, cells.NumberModeFloat, cells.NumberModeAuto:
+	default:
+		fatalf("Unknown NUMBER_MODE: %q", project.config.NumberMode)
+	}
+	project.provenance = newProvenance(project.config.ProvenancePrefix, project.config.ProvenanceModifiedAt)
+	b, err := os.ReadFile(project.config.CredentialsFileName)
+	if err != nil {
+		fatalf("Unable to read client secret file: %v", err)
+	}
+
+	if project.config.ShowAccount || project.config.ExpectedAccount != "" {
+		project.config.Scopes = withAccountScopes(project.config.Scopes)
+	}
+	if project.config.RunLogSpreadsheetId != "" {
+		project.config.Scopes = append(project.config.Scopes, runLogScope)
+	}
+	if project.config.ReadOnly {
+		project.config.Scopes = readonlyScopesOf(project.config.Scopes)
+	}
+	scopes, dropped := normalizeScopes(project.config.Scopes)
+	for _, scope := range dropped {
+		log.Printf("Ignoring the scope %s, implied by a broader one of SCOPES", scope)
+	}
+	project.config.Scopes = scopes
+	fmt.Println("\nThe following scopes will be used:")
+	for _, scope := range project.config.Scopes {
+		fmt.Println("\t• " + scope)
+	}
+	fmt.Println()
+	// NOTE: if you modify the scopes, delete your previously saved `token.json`
+	// file.
+	config, err := credentialsConfig(project.config.CredentialsFileName, b, project.config.Scopes...)
+	if err != nil {
+		fatalf("Unable to parse client secret file to config: %v", err)
+	}
+	if project.config.OAuthAuthURL != "" {
+		config.Endpoint.AuthURL = project.config.OAuthAuthURL
+	}
+	if project.config.OAuthTokenURL != "" {
+		config.Endpoint.TokenURL = project.config.OAuthTokenURL
+	}
+	if flag.Arg(0) == "auth" {
+		runAuthCommand(config, flag.Args()[1:])
+		return
+	}
+	project.client = getClient(config, project.config.TokenMinValidity, project.authFlow, project.config.AuthTimeout)
+	// the run log is written even when the run failed because of the API, so
+	// it doesn't go through the retry transport's circuit breaker
+	runLogClient := &http.Client{Transport: bundle.Transport(newUserAgentTransport(project.client.Transport))}
+	// NOTE: the transports wrapping the client must not set `Accept-Encoding`
+	// themselves: when it's unset, `net/http` requests gzip responses and
+	// transparently decompresses them, which matters for large values reads.
+	if project.config.DebugDumpDir != "" {
+		transport, err := newDumpTransport(project.client.Transport, project.config.DebugDumpDir)
+		if err != nil {
+			fatalf("Unable to set up DEBUG_DUMP_DIR: %v", err)
+		}
+		project.client = &http.Client{Transport: transport}
+	}
+	project.client = &http.Client{Transport: newRetryTransport(project.meter.Transport(bundle.Transport(newUserAgentTransport(project.client.Transport))), project.config.MaxRetries, project.config.RetryBudget, project.config.CircuitBreakerThreshold, project.summary, project.clock)}
+	if project.config.ReadOnly {
+		project.client = &http.Client{Transport: newReadOnlyTransport(project.client.Transport)}
+	}
+	if project.config.ShowAccount || project.config.ExpectedAccount != "" {
+		project.checkAccount()
+	}
+
+	serviceOptions := []option.ClientOption{option.WithHTTPClient(project.client)}
+	if project.config.SheetsEndpoint != "" {
+		serviceOptions = append(serviceOptions, option.WithEndpoint(project.config.SheetsEndpoint))
+	}
+	project.sheetsService, err = sheets.NewService(ctx, serviceOptions...)
+	if err != nil {
+		fatalf("Unable to retrieve Sheets client: %v", err)
+	}
+	if project.config.RunLogSpreadsheetId != "" {
+		serviceOptions[0] = option.WithHTTPClient(runLogClient)
+		runLogService, err := sheets.NewService(ctx, serviceOptions...)
+		if err != nil {
+			fatalf("Unable to retrieve Sheets client: %v", err)
+		}
+		runLog = newRunLogger(runLogService, project.config.RunLogSpreadsheetId, project.config.RunLogSheet, started)
+		// failed runs are recorded in `exit`
+		defer runLog.Record(0)
+	}
+	project.metadata = newMetadataCache(project.config.MetadataTTL, func(spreadsheetId string) (*sheets.Spreadsheet, error) {
+		return project.sheetsService.Spreadsheets.Get(spreadsheetId).Do()
+	})
+	if _, ok := os.LookupEnv("SHEET_NAME"); ref.HasGid && !ok {
+		project.config.SheetName, err = project.sheetTitleByGid(ref.Gid)
+		if err != nil {
+			fatalf("Unable to resolve the sheet of gid %d: %v", ref.Gid, err)
+		}
+	}
+	project.format, err = project.resolveCellFormat()
+	if err != nil {
+		fatalf("Unable to resolve LOCALE: %v", err)
+	}
+
+	if project.config.Cache != cacheOff {
+		if !hasDriveScope(project.config.Scopes) {
+			fatalf("CACHE requires a Drive scope in SCOPES to detect spreadsheet changes")
+		}
+		project.cache, err = newValueCache(project.config.CacheDir, project.config.Cache, int64(project.config.CacheMaxMB)<<20, func(spreadsheetId string) (string, error) {
+			return driveModifiedTime(project.client, spreadsheetId)
+		})
+		if err != nil {
+			fatalf("Unable to set up the cache: %v", err)
+		}
+	}
+	if project.config.Preflight {
+		for _, spreadsheetId := range project.spreadsheetIds() {
+			source := project
+			source.config.SpreadsheetId = spreadsheetId
+			source.preflight()
+		}
+	}
+	if project.config.RevisionId != "" || !project.config.AsOf.IsZero() {
+		project.checkRevisionConfig()
+		project.revision, err = project.loadRevision()
+		if err != nil {
+			fatalf("Unable to read spreadsheet revision: %v", err)
+		}
+		fmt.Printf("Reading revision %s (modified %s)\n", project.revision.Id, project.revision.ModifiedTime)
+	}
+	if flag.Arg(0) == "headers" {
+		project.printHeaders()
+		return
+	}
+	if flag.Arg(0) == "metadata" {
+		project.printDeveloperMetadata()
+		return
+	}
+	if project.config.Mode == modeGenStruct {
+		project.generateStruct()
+		return
+	}
+	// Prints the names and majors of students from the sample spreadsheet
+	// project.printFromSampleSpreadsheet()
+	project.parseFromSampleSpreadsheet()
+}
+
+// getSpreadsheetSheetRowCount will return the row count of the `spreadsheetId`
+// `sheetTitle` if found; else an `errSheetNotFound` error.
+//
+// NOTE: the returned row count doesn't account for blank rows; when looping
+// through the spreadsheets rows, watch for `len(resp.Values) == 0` to know when
+// you're working with a blank row.
+func (p Project) getSpreadsheetSheetRowCount() (int, error) {
+	if p.revision != nil {
+		return p.revision.RowCount(), nil
+	}
+	info, err := p.GetSheetInfo(p.config.SheetName)
+	if err != nil {
+		return 0, err
+	}
+	return info.RowCount, nil
+}
+
+// rowWindow returns the first and last rows to read from a sheet with
+// `rowCount` rows, limited by the row window of the `READ_RANGES` if they have
+// one, and starting no earlier than `HEADER_ROW`.
+func (p Project) rowWindow(rowCount int) (firstRow, lastRow int) {
+	firstRow, lastRow = 1, rowCount
+	if r := p.readRanges[0]; r.StartRow != 0 {
+		firstRow = r.StartRow
+		if r.EndRow != 0 && r.EndRow < lastRow {
+			lastRow = r.EndRow
+		}
+	}
+	if p.config.HeaderRow > firstRow {
+		firstRow = p.config.HeaderRow
+	}
+	return firstRow, lastRow
+}
+
+// printFromSampleSpreadsheet prints the names and majors of students from the
+// Google Sheets API sample spreadsheet:
+//  - https://docs.google.com/spreadsheets/d/1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms/edit
+func (p Project) printFromSampleSpreadsheet() {
+	readRange := "Class Data!A2:Z"
+	resp, err := p.sheetsService.Spreadsheets.Values.Get("1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms", readRange).Do()
+	if err != nil {
+		fatalf("Unable to retrieve data from sheet: %v", err)
+	}
+	if len(resp.Values) == 0 {
+		fmt.Println("No data found.")
+	} else {
+		fmt.Println("Name, Major:")
+		for _, row := range resp.Values {
+			// Print columns A and E, which correspond to indices 0 and 4.
+			fmt.Printf("%s, %s\n", row[0], row[4])
+		}
+	}
+}
+
+// ExampleStudent is the structure for the Google API Sample Spreadsheet:
+// https://docs.google.com/spreadsheets/d/1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms/edit
+//
+// NOTE: parsing to a struct is only possible when we know the Spreadsheet
+// structure ahead of time; this wouldn't work if the `spreadsheetId` and
+// `sheetTitle` are provided externally.
+type ExampleStudent struct {
+	StudentName             string
+	Gender                  string
+	ClassLevel              string
+	HomeState               string
+	Major                   string
+	ExtracurricularActivity string
+}
+
+// parseFromSampleSpreadsheet shows how to parse records from a sample
+// spreadsheet, using the header (first row) as the keys to map to the
+// `ExampleStudent` struct (if found), as well as in a JSON object for when the
+// structure isn't known ahead of time.
+func (p Project) parseFromSampleSpreadsheet() {
+	pipeline, err := p.newPipeline()
+	if err != nil {
+		fatalf("Unable to set up the pipeline: %v", err)
+	}
+	if p.config.DryRun {
+		p.dryRun(pipeline)
+		return
+	}
+	if p.config.RejectFile != "" {
+		p.rejectFile, err = newRejectWriter(p.config.RejectFile)
+		if err != nil {
+			fatalf("Unable to create REJECT_FILE: %v", err)
+		}
+	}
+	p.fetchSourceFiles()
+	if pipeline.join != nil {
+		if err := pipeline.join.Load(p); err != nil {
+			fatalf("Unable to load JOIN_SHEET: %v", err)
+		}
+	}
+	emitter := pipeline.Emitter(p, &outputEmitter{p: p})
+	var skipBackground *rgbColor
+	if p.config.SkipBackgroundColor != "" {
+		color, err := parseHexColor(p.config.SkipBackgroundColor)
+		if err != nil {
+			fatalf("Unable to parse SKIP_BACKGROUND_COLOR: %v", err)
+		}
+		skipBackground = &color
+	}
+	parser, err := p.newRowParser(pipeline)
+	if err != nil {
+		fatalf("Unable to parse COLUMNS: %v", err)
+	}
+	// with `SPREADSHEET_IDS`, the rows of every spreadsheet are streamed into
+	// the same emitter
+	for _, spreadsheetId := range p.spreadsheetIds() {
+		source := p
+		source.config.SpreadsheetId = spreadsheetId
+		source.readSpreadsheet(parser, emitter, skipBackground)
+		if p.summary.Partial {
+			break
+		}
+	}
+	if p.config.SkipStrikethrough || skipBackground != nil {
+		fmt.Printf("\nskipped %d soft-deleted rows\n", p.summary.SoftDeleted)
+	}
+	if p.cache != nil {
+		p.summary.CacheHits, p.summary.CacheMisses = p.cache.hits, p.cache.misses
+		fmt.Printf("\ncache: %d hits, %d misses\n", p.cache.hits, p.cache.misses)
+	}
+	if err := emitter.Close(); err != nil {
+		fatalf("Unable to emit rows: %v", err)
+	}
+	if p.rejectFile != nil {
+		if err := p.rejectFile.Close(); err != nil {
+			fatalf("Unable to write REJECT_FILE: %v", err)
+		}
+		if p.summary.Rejected > 0 {
+			fmt.Printf("\nrejected %d rows to %s\n", p.summary.Rejected, p.config.RejectFile)
+		}
+	}
+	rejectThresholdExceeded := p.rejectThresholdExceeded()
+	p.memory.Report(p.summary)
+	if p.memory != nil {
+		fmt.Printf("\npeak memory: %d bytes (estimated), %d spills\n", p.summary.PeakMemoryBytes, p.summary.Spills)
+	}
+	p.printSlowestBatches()
+	p.printLargestCell()
+	p.printSourceFiles()
+	p.checkQuotaPressure()
+	p.printWarnings()
+	if len(p.config.SpreadsheetIds) > 0 {
+		fmt.Printf("\nrows per spreadsheet:\n")
+		for _, spreadsheetId := range p.config.SpreadsheetIds {
+			fmt.Printf("\t%s: %d\n", spreadsheetId, p.summary.Sources[spreadsheetId])
+		}
+	}
+	emptySheet := p.summary.Rows == 0 && p.config.EmptySheet != emptySheetOk
+	if emptySheet {
+		p.warn(warnEmptySheet, "WARNING: no data rows were emitted from sheet '%s'", p.config.SheetName)
+	}
+	if p.porcelain != nil {
+		p.porcelain.Emit(porcelainEvent{Type: eventSummary, Summary: p.summary})
+	}
+	if p.summary.Partial {
+		exit(exitTimeLimit)
+	}
+	if rejectThresholdExceeded {
+		exit(exitRejectThreshold)
+	}
+	if emptySheet && p.config.EmptySheet == emptySheetFail {
+		exit(exitEmptySheet)
+	}
+	if p.postCommand != nil {
+		if err := p.runPostCommand(); err != nil {
+			log.Printf("POST_COMMAND failed: %v", err)
+			exit(exitPostCommand)
+		}
+	}
+	fmt.Printf("\n\nfinished\n\n")
+}
+
+// readSpreadsheet reads the configured sheet of the spreadsheet in batches,
+// passing them through the `parser` to the `emitter`.
+func (p Project) readSpreadsheet(parser *sheetRowParser, emitter Emitter, skipBackground *rgbColor) {
+	rowCount, err := p.getSpreadsheetSheetRowCount()
+	if err != nil {
+		if errors.Is(err, errSheetNotFound) {
+			fatalf("Sheet '%s' not found in spreadsheet %s", p.config.SheetName, p.config.SpreadsheetId)
+		}
+		p.exitIfCircuitOpen(err)
+		fatalf("Unable to retrieve sheet row count: %v", err)
+	}
+	fmt.Printf("spreadsheetId: %s\n", p.config.SpreadsheetId)
+	fmt.Printf("sheetName: %s\n", p.config.SheetName)
+	fmt.Printf("rowCount: %d\n", rowCount)
+	if p.metadataSkip != nil {
+		rows, columns, err := p.metadataExclusions()
+		if err != nil {
+			p.exitIfCircuitOpen(err)
+			fatalf("Unable to search the sheet's developer metadata: %v", err)
+		}
+		p.excludedRows = mergeRowRanges(append(append(rowRanges{}, p.excludedRows...), rows...))
+		p.metadataColumns = columns
+	}
+	var fetcher Fetcher = sheetFetcher{p: p, rowCount: rowCount, skipBackground: skipBackground}
+	// the sheet's ID is kept to follow the sheet if it's renamed during the
+	// run, or its grid shrinks
+	info, infoErr := p.GetSheetInfo(p.config.SheetName)
+	followSheet := p.revision == nil && infoErr == nil
+	batches, err := fetcher.Plan()
+	if err != nil {
+		fatalf("Unable to plan batches: %v", err)
+	}
+	bundle.AddPlan(p.config.SpreadsheetId, batches)
+	headerRow, _ := p.rowWindow(rowCount)
+	parser.reset(p, headerRow)
+	// Loop through all the rows in batches of `batchCount`
+	batchSize := p.config.BatchCount
+	for i := 0; i < len(batches); i++ {
+		batch := batches[i]
+		if p.timeLimitReached(batch) || p.stopRequested(batch) {
+			break
+		}
+		emitter.BatchStart(batch)
+		p.summary.Batches++
+		p.control.Publish(p.summary, p.config.SpreadsheetId+" "+p.batchRanges(batch))
+		start, bytes := time.Now(), p.meter.Bytes()
+		fetched, err := fetcher.Fetch(batch)
+		if err != nil && followSheet && isSheetRangeError(err) {
+			title, titleErr := p.renamedSheetTitle(info.SheetId)
+			if errors.Is(titleErr, errSheetNotFound) {
+				fatalf("Sheet '%s' not found in spreadsheet %s", p.config.SheetName, p.config.SpreadsheetId)
+			}
+			if titleErr == nil && title != p.config.SheetName {
+				log.Printf("Sheet '%s' was renamed to '%s', continuing with the new title", p.config.SheetName, title)
+				p.config.SheetName = title
+				parser.p.config.SheetName = title
+				fetcher = sheetFetcher{p: p, rowCount: rowCount, skipBackground: skipBackground}
+				fetched, err = fetcher.Fetch(batch)
+			}
+		}
+		if err != nil && followSheet && isGridLimitError(err) {
+			p.metadata.Invalidate(p.config.SpreadsheetId)
+			if shrunk, infoErr := p.GetSheetInfo(p.config.SheetName); infoErr == nil && shrunk.RowCount < batch.End {
+				log.Printf("Sheet '%s' shrank to %d rows during the run, clamping the remaining batches", p.config.SheetName, shrunk.RowCount)
+				if shrunk.RowCount < batch.Start-1 {
+					p.warnAt(Warning{Code: warnGridShrunk, Row: shrunk.RowCount + 1, Message: fmt.Sprintf("Sheet '%s' shrank to %d rows during the run, after rows up to %d were read", p.config.SheetName, shrunk.RowCount, batch.Start-1)})
+				}
+				batches = append(batches[:i], clampBatches(batches[i:], shrunk.RowCount)...)
+				if i == len(batches) {
+					emitter.BatchEnd(batch, 0)
+					break
+				}
+				batch = batches[i]
+				fetched, err = fetcher.Fetch(batch)
+			}
+		}
+		if err != nil {
+			p.exitIfCircuitOpen(err)
+			fatalf("Unable to retrieve data from sheet: %v", err)
+		}
+		p.recordBatchTiming(batch, time.Since(start), p.meter.Bytes()-bytes)
+		p.summary.FetchedRows += len(fetched.Rows)
+		if p.config.MaxBatchBytes > 0 && len(fetched.Rows) > 0 && i+1 < len(batches) {
+			bytesPerRow := float64(p.meter.Bytes()-bytes) / float64(len(fetched.Rows))
+			if size := adaptiveBatchSize(p.config.MaxBatchBytes, bytesPerRow, p.config.BatchCount); size != batchSize {
+				log.Printf("Reading batches of %d rows (%.0f bytes per row, MAX_BATCH_BYTES is %d)", size, bytesPerRow, p.config.MaxBatchBytes)
+				batchSize = size
+				batches = append(batches[:i+1], resizeBatches(batches[i+1:], size)...)
+			}
+		}
+		if err := parser.Parse(fetched, emitter); err != nil {
+			fatalf("Unable to parse rows: %v", err)
+		}
+		emitter.BatchEnd(batch, len(fetched.Rows))
+		p.control.Publish(p.summary, p.config.SpreadsheetId+" "+p.batchRanges(batch))
+	}
+}
+
+// dryRun prints the `pipeline` stages and the batches that would be read from
+// each spreadsheet.
+func (p Project) dryRun(pipeline Pipeline) {
+	fmt.Printf("pipeline: %s\n", pipeline)
+	for _, spreadsheetId := range p.spreadsheetIds() {
+		source := p
+		source.config.SpreadsheetId = spreadsheetId
+		rowCount, err := source.getSpreadsheetSheetRowCount()
+		if err != nil {
+			fatalf("Unable to retrieve sheet row count: %v", err)
+		}
+		batches, err := sheetFetcher{p: source, rowCount: rowCount}.Plan()
+		if err != nil {
+			fatalf("Unable to plan batches: %v", err)
+		}
+		fmt.Printf("\nspreadsheetId: %s\nsheetName: %s\nrowCount: %d\n", spreadsheetId, p.config.SheetName, rowCount)
+		for _, batch := range batches {
+			fmt.Printf("\tbatch: %s\n", p.batchRanges(batch))
+		}
+	}
+}
+
+// printRow prints the row as the struct of the `schema` (e.g. `ExampleStudent`
+// for the Google Sheets API sample spreadsheet) when the sheet matches one,
+// else as a JSON object.
+//
+// NOTE: the struct has no room for the `HASH_COLUMN`/`PROVENANCE` columns, so
+// they're printed on their own lines after it.
+//
+// With `--porcelain`, the row is emitted as a `row` event instead.
+func (p Project) printRow(schema spreadsheet.SchemaMatcher, json map[string]interface{}) {
+	p.summary.Rows++
+	if p.porcelain != nil {
+		p.porcelain.Emit(porcelainEvent{Type: eventRow, Fields: json})
+		return
+	}
+	var record interface{}
+	if schema != nil {
+		record = schema.Record(json)
+	}
+	if record != nil {
+		fmt.Printf("%s struct:\t%#v\n", schema.Name(), record)
+		if p.config.HashColumn != "" {
+			fmt.Printf("\t\t hash:\t%s\n", json[p.config.HashColumn])
+		}
+		if p.config.Provenance {
+			fmt.Printf("\t   provenance:\t%#v\n", p.provenance.Values(json))
+		}
+		if len(p.config.SpreadsheetIds) > 0 {
+			fmt.Printf("\t       source:\t%s\n", json[sourceSpreadsheetColumn])
+		}
+	} else {
+		fmt.Printf("\t\t json:\t%#v\n\n", json)
+	}
+}
+
+// getClient retrieve `token.json` if exists, else runs the `authFlow` steps
+// (see `getTokenFromFlow`) to save `token.json`, then returns the generated
+// client.
+//
+// A cached token that expires within `minValidity` is refreshed (and saved)
+// right away, so a revoked or expired refresh token fails the run before any
+// data is fetched instead of part-way through it.
+//
+// `token.json` is read and written under a lock (see `lockFile`), since
+// several processes can share it; tokens refreshed during the run are saved
+// too (see `fileTokenSource`).
+//
+// https://developers.google.com/sheets/api/quickstart/go#step_3_set_up_the_sample
+func getClient(config *oauth2.Config, minValidity time.Duration, authFlow []string, authTimeout time.Duration) *http.Client {
+	// The file `token.json` stores the user's access and refresh tokens, and is
+	// created automatically when the authorization flow completes for the first
+	// time.
+	tokFile := tokenFile
+	tok, err := readTokenFile(tokFile)
+	if errors.Is(err, errLocked) {
+		fatalf("Unable to read the cached token: %v", err)
+	}
+	if err != nil {
+		tok = getTokenFromFlow(config, authFlow, authTimeout)
+		saveToken(tokFile, tok)
+	}
+	// refreshed tokens are saved for the other processes sharing the file,
+	// also when refreshed during the run
+	source := newFileTokenSource(config, tokFile, tok)
+	if tok.RefreshToken != "" {
+		tok, refreshed, err := source.Refresh(minValidity)
+		if err != nil {
+			fatalf("Unable to refresh the cached token, delete %s to authorize again: %v", tokFile, err)
+		}
+		if refreshed {
+			fmt.Printf("Refreshed token, expires at: %s\n", tok.Expiry.Format(time.RFC3339))
+		}
+	}
+	return oauth2.NewClient(context.Background(), source)
+}
+
+// getTokenFromWeb request a token from the web, waiting up to `timeout` for
+// the code to be typed, then returns the retrieved token.
+//
+// The user can paste either the code or the whole redirect URL (see
+// `extractAuthCode`), and is prompted again if the code turns out malformed.
+//
+// https://developers.google.com/sheets/api/quickstart/go#step_3_set_up_the_sample
+func getTokenFromWeb(config *oauth2.Config, timeout time.Duration) (*oauth2.Token, error) {
+	state := "state-token"
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	fmt.Printf("Go to the following link in your browser then type the authorization code (or paste the whole URL you're redirected to, waiting %s): \n%v\n", timeout, authURL)
+
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		var input string
+		for input == "" {
+			line, ok := readStdinLine(time.Until(deadline))
+			if !ok {
+				return nil, fmt.Errorf("no authorization code within %s", timeout)
+			}
+			input = strings.TrimSpace(line)
+		}
+		authCode, err := extractAuthCode(input, state)
+		if err == nil {
+			var tok *oauth2.Token
+			tok, err = config.Exchange(context.TODO(), authCode)
+			if err == nil {
+				return tok, nil
+			}
+			if !isMalformedCodeError(err) {
+				return nil, err
+			}
+		}
+		if attempt == authCodeAttempts {
+			return nil, err
+		}
+		fmt.Printf("%v\nPlease try again (%d attempts left): ", err, authCodeAttempts-attempt)
+	}
+}
+
+// tokenFromFile retrieves a token from a local file.
+//
+// https://developers.google.com/sheets/api/quickstart/go#step_3_set_up_the_sample
+func tokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tok := &oauth2.Token{}
+	err = json.NewDecoder(f).Decode(tok)
+	return tok, err
+}
+
+// saveToken saves a token to a file path.
+//
+// https://developers.google.com/sheets/api/quickstart/go#step_3_set_up_the_sample
+func saveToken(path string, token *oauth2.Token) {
+	fmt.Printf("Saving credential file to: %s\n", path)
+	unlock, err := lockFile(tokenLockFile(path), tokenLockTimeout)
+	if err != nil {
+		fatalf("Unable to cache oauth token: %v", err)
+	}
+	defer unlock()
+	if err := writeTokenFile(path, token); err != nil {
+		fatalf("Unable to cache oauth token: %v", err)
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/testsupport"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+// runMainEnv is set in the environment of the test binary re-run as the
+// command by `commandRun.Run`.
+const runMainEnv = "SHEETS_EXAMPLE_RUN_MAIN"
+
+var update = flag.Bool("update", false, "update the golden files of testdata/golden")
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// sampleSheet returns the values of the sample spreadsheet's "Class Data"
+// sheet, as recorded in testdata/class_data.json.
+func sampleSheet(t *testing.T) [][]interface{} {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", "class_data.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		Values [][]interface{} `json:"values"`
+	}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Values
+}
+
+// commandRun is a run of the command against fake Google endpoints, from a
+// directory with a `credentials.json` and a cached `token.json`.
+type commandRun struct {
+	t      *testing.T
+	dir    string
+	sheets *sheetstest.Server
+	env    []string
+}
+
+// newCommandRun returns a run reading the "Class Data" sheet of the
+// "spreadsheet-id" spreadsheet of a fake Sheets API, seeded with the sample
+// spreadsheet's values.
+func newCommandRun(t *testing.T) *commandRun {
+	t.Helper()
+	tokens := testsupport.NewTokenServer()
+	t.Cleanup(tokens.Close)
+	sheets := sheetstest.NewServer()
+	t.Cleanup(sheets.Close)
+	sheets.SetValues("spreadsheet-id", "Class Data", sampleSheet(t))
+
+	dir := t.TempDir()
+	credentials := map[string]interface{}{"installed": map[string]interface{}{
+		"client_id":     "client-id.apps.googleusercontent.com",
+		"client_secret": "client-secret",
+		"auth_uri":      tokens.AuthURL(),
+		"token_uri":     tokens.TokenURL(),
+		"redirect_uris": []string{"http://localhost"},
+	}}
+	b, err := json.Marshal(credentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "credentials.json"), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	token := &oauth2.Token{AccessToken: "access", TokenType: "Bearer", RefreshToken: tokens.NewRefreshToken(), Expiry: time.Now().Add(24 * time.Hour)}
+	if err := writeTokenFile(filepath.Join(dir, tokenFile), token); err != nil {
+		t.Fatal(err)
+	}
+	return &commandRun{t: t, dir: dir, sheets: sheets, env: []string{
+		"SPREADSHEET_ID=spreadsheet-id",
+		"SHEET_NAME=Class Data",
+		"SHEETS_ENDPOINT=" + sheets.Endpoint(),
+		"AUTH_FLOW=paste",
+		// without a Drive scope, nothing is read from the Drive API
+		"SCOPES=https://www.googleapis.com/auth/spreadsheets.readonly",
+	}}
+}
+
+// Setenv sets the environment variable `key` of the run.
+func (r *commandRun) Setenv(key, value string) {
+	r.env = append(r.env, key+"="+value)
+}
+
+// Run runs the command with the `args`, and returns its stdout and exit code.
+func (r *commandRun) Run(args ...string) (string, int) {
+	r.t.Helper()
+	stdout, _, code := r.RunStderr(args...)
+	return stdout, code
+}
+
+// RunStderr runs the command with the `args`, and returns its stdout, stderr,
+// and exit code.
+func (r *commandRun) RunStderr(args ...string) (string, string, int) {
+	r.t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(commandEnv(), append([]string{runMainEnv + "=1"}, r.env...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		r.t.Fatal(err)
+	}
+	if cmd.ProcessState.ExitCode() != 0 || testing.Verbose() {
+		r.t.Logf("stderr:\n%s", stderr.String())
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+// commandEnv returns the environment of the tests without the command's
+// config, so the runs only see what they set.
+func commandEnv() []string {
+	env := []string{}
+	for _, kv := range os.Environ() {
+		key := kv[:strings.Index(kv, "=")]
+		if key == "PATH" || key == "HOME" || key == "TMPDIR" || key == "SYSTEMROOT" {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// variableOutput are the parts of the output changing from run to run (e.g.
+// the fake servers' ports), and what they're replaced with in golden files.
+var variableOutput = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`127\.0\.0\.1:[0-9]+`), "127.0.0.1:PORT"},
+	{regexp.MustCompile(`"_fetched_at":"[^"]*"`), `"_fetched_at":"TIME"`},
+	{regexp.MustCompile(`"duration_ms":[0-9]+`), `"duration_ms":0`},
+}
+
+// checkGolden compares the `output` with the golden file `name` of
+// testdata/golden, or updates it with -update.
+func checkGolden(t *testing.T, name, output string) {
+	t.Helper()
+	for _, v := range variableOutput {
+		output = v.re.ReplaceAllString(output, v.replacement)
+	}
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if output != string(want) {
+		t.Errorf("output differs from %s (run with -update to accept it):\n%s", path, lineDiff(string(want), output))
+	}
+}
+
+// lineDiff lists the lines of `got` that differ from `want`, by line number.
+func lineDiff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			fmt.Fprintf(&b, "line %d:\n- %s\n+ %s\n", i+1, w, g)
+		}
+	}
+	return b.String()
+}
+
+func TestSampleRun(t *testing.T) {
+	run := newCommandRun(t)
+	stdout, code := run.Run()
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	checkGolden(t, "sample.txt", stdout)
+}
+
+// TestPasteAuthRun fails right away without a cached token when stdin isn't a
+// terminal to paste the code in, rather than waiting for AUTH_TIMEOUT.
+func TestPasteAuthRun(t *testing.T) {
+	run := newCommandRun(t)
+	if err := os.Remove(filepath.Join(run.dir, tokenFile)); err != nil {
+		t.Fatal(err)
+	}
+	run.Setenv("AUTH_TIMEOUT", "1m")
+	started := time.Now()
+	if _, code := run.Run(); code == 0 {
+		t.Errorf("exit code 0, want the authorization to fail")
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Errorf("the run took %s, want it to fail without waiting for AUTH_TIMEOUT", elapsed)
+	}
+}
+
+// TestBlankRowsRun reads a sheet with blank and short rows in several
+// batches, some of them entirely blank.
+func TestBlankRowsRun(t *testing.T) {
+	run := newCommandRun(t)
+	run.sheets.SetValues("spreadsheet-id", "Scores", [][]interface{}{
+		{"Name", "Score", "Notes"},
+		{"Ann", "10", "first"},
+		{},
+		{"Bob", "7"},
+		{},
+		{},
+		{},
+		{},
+		{"Cy", "", "no score"},
+		{"Dee"},
+	})
+	run.sheets.SetGrid("spreadsheet-id", "Scores", 12, 26)
+	run.Setenv("SHEET_NAME", "Scores")
+	run.Setenv("BATCH_COUNT", "4")
+	stdout, code := run.Run()
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	checkGolden(t, "blank_rows.txt", stdout)
+}
+
+// TestAggregateRun checks that stdout only holds the data with
+// AGGREGATE_FORMAT=csv and json, so e.g. the CSV BOM comes first.
+func TestAggregateRun(t *testing.T) {
+	run := newCommandRun(t)
+	run.Setenv("MODE", "aggregate")
+	run.Setenv("GROUP_BY", "Class Level")
+	run.Setenv("AGGREGATES", "count")
+	run.Setenv("AGGREGATE_FORMAT", "csv")
+	run.Setenv("CSV_BOM", "true")
+	stdout, code := run.Run()
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	checkGolden(t, "aggregate.csv", stdout)
+
+	run.Setenv("AGGREGATE_FORMAT", "json")
+	stdout, code = run.Run()
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	checkGolden(t, "aggregate.json", stdout)
+}
+
+// TestPipelineRun enables most of the pipeline stages at once, and checks the
+// rows they output.
+func TestPipelineRun(t *testing.T) {
+	run := newCommandRun(t)
+	run.sheets.SetValues("spreadsheet-id", "Majors", [][]interface{}{
+		{"Major", "Department", "Building"},
+		{"Art", "Humanities", "North"},
+		{"English", "Humanities", "North"},
+		{"Math", "Science", "East"},
+		{"Physics", "Science", "West"},
+	})
+	run.Setenv("COLUMNS", "1,2,3,5")
+	run.Setenv("REDACT_COLUMNS", "Gender:drop,Student Name:mask")
+	run.Setenv("SORT_BY", "Major,Class Level desc")
+	run.Setenv("HASH_COLUMN", "_hash")
+	run.Setenv("PROVENANCE", "true")
+	run.Setenv("JOIN_SHEET", "Majors")
+	run.Setenv("JOIN_KEY", "Major")
+	run.Setenv("JOIN_FOREIGN_KEY", "Major")
+	run.Setenv("JOIN_COLUMNS", "Department")
+
+	stdout, code := run.Run("-porcelain")
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	checkGolden(t, "pipeline.jsonl", stdout)
+
+	run.Setenv("DRY_RUN", "true")
+	stdout, code = run.Run()
+	if code != 0 {
+		t.Fatalf("dry run exit code %d", code)
+	}
+	checkGolden(t, "pipeline_dry_run.txt", stdout)
+}
@@ -0,0 +1,83 @@
+package main
+
+import "sync"
+
+// memoryBudget is the `MEMORY_BUDGET_MB` shared by the stages buffering data
+// (sort, join): each one accounts for the (estimated) bytes it holds, and
+// spills to disk when the total is over the budget. Its methods are no-ops
+// when nil, i.e. without a budget.
+type memoryBudget struct {
+	limit int64
+	// parent is the budget this one is a share of (see `Share`)
+	parent *memoryBudget
+
+	mu     sync.Mutex
+	used   int64
+	peak   int64
+	spills int
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &memoryBudget{limit: limit}
+}
+
+// Share returns a budget of `fraction` of this one's limit, so that a stage
+// holding memory it can't spill (the join) doesn't leave none to the others;
+// what's held in the share still counts in this budget's peak and spills.
+func (b *memoryBudget) Share(fraction float64) *memoryBudget {
+	if b == nil {
+		return nil
+	}
+	return &memoryBudget{limit: int64(float64(b.limit) * fraction), parent: b}
+}
+
+// Reserve accounts for `n` more bytes, and reports whether the budget is now
+// exceeded.
+func (b *memoryBudget) Reserve(n int64) bool {
+	if b == nil {
+		return false
+	}
+	b.parent.Reserve(n)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used += n
+	if b.used > b.peak {
+		b.peak = b.used
+	}
+	return b.used > b.limit
+}
+
+// Release accounts for `n` bytes no longer held, e.g. once spilled.
+func (b *memoryBudget) Release(n int64) {
+	if b == nil {
+		return
+	}
+	b.parent.Release(n)
+	b.mu.Lock()
+	b.used -= n
+	b.mu.Unlock()
+}
+
+// Spilled counts a spill to disk.
+func (b *memoryBudget) Spilled() {
+	if b == nil {
+		return
+	}
+	b.parent.Spilled()
+	b.mu.Lock()
+	b.spills++
+	b.mu.Unlock()
+}
+
+// Report records the peak memory and the number of spills in the `summary`.
+func (b *memoryBudget) Report(summary *runSummary) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	summary.PeakMemoryBytes, summary.Spills = b.peak, b.spills
+}
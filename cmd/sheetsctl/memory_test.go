@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestMemoryBudgetShare(t *testing.T) {
+	budget := newMemoryBudget(100)
+	join, sort := budget.Share(0.5), budget.Share(0.5)
+
+	if join.Reserve(50) {
+		t.Errorf("join.Reserve(50) is over its share of 50")
+	}
+	// the join holding its whole share leaves the sort's untouched
+	if sort.Reserve(40) {
+		t.Errorf("sort.Reserve(40) is over its share with the join holding its own")
+	}
+	if !sort.Reserve(20) {
+		t.Errorf("sort.Reserve(20) isn't over its share of 50 at 60")
+	}
+	sort.Spilled()
+	sort.Release(60)
+	join.Release(50)
+
+	summary := &runSummary{}
+	budget.Report(summary)
+	if summary.PeakMemoryBytes != 110 || summary.Spills != 1 {
+		t.Errorf("peak, spills = %d, %d, want 110, 1", summary.PeakMemoryBytes, summary.Spills)
+	}
+	if budget.used != 0 {
+		t.Errorf("used = %d after releasing everything, want 0", budget.used)
+	}
+}
+
+func TestMemoryBudgetNil(t *testing.T) {
+	var budget *memoryBudget
+	if share := budget.Share(0.5); share != nil {
+		t.Errorf("Share of no budget = %v, want nil", share)
+	}
+	if budget.Reserve(1 << 40) {
+		t.Errorf("Reserve without a budget is over it")
+	}
+}
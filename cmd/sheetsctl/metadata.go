@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+)
+
+// SheetInfo is the metadata of a single sheet (tab) of a spreadsheet.
+type SheetInfo struct {
+	SheetId     int64
+	Title       string
+	RowCount    int
+	ColumnCount int
+}
+
+// metadataCache caches spreadsheet metadata for `ttl`, and collapses
+// concurrent requests for the same spreadsheet into a single
+// `Spreadsheets.Get` call.
+type metadataCache struct {
+	ttl   time.Duration
+	fetch func(spreadsheetId string) (*sheets.Spreadsheet, error)
+	group singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]metadataEntry
+}
+
+type metadataEntry struct {
+	spreadsheet *sheets.Spreadsheet
+	expires     time.Time
+}
+
+func newMetadataCache(ttl time.Duration, fetch func(spreadsheetId string) (*sheets.Spreadsheet, error)) *metadataCache {
+	return &metadataCache{ttl: ttl, fetch: fetch, entries: map[string]metadataEntry{}}
+}
+
+// Spreadsheet returns the (possibly cached) metadata of the spreadsheet.
+func (c *metadataCache) Spreadsheet(spreadsheetId string) (*sheets.Spreadsheet, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[spreadsheetId]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.spreadsheet, nil
+	}
+	v, err, _ := c.group.Do(spreadsheetId, func() (interface{}, error) {
+		spreadsheet, err := c.fetch(spreadsheetId)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.entries[spreadsheetId] = metadataEntry{spreadsheet: spreadsheet, expires: time.Now().Add(c.ttl)}
+		c.mu.Unlock()
+		return spreadsheet, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*sheets.Spreadsheet), nil
+}
+
+// Invalidate drops the cached metadata of the spreadsheet, e.g. when a range
+// error suggests the grid changed since it was fetched.
+func (c *metadataCache) Invalidate(spreadsheetId string) {
+	c.mu.Lock()
+	delete(c.entries, spreadsheetId)
+	c.mu.Unlock()
+}
+
+// GetSheetInfo returns the metadata of the `sheetName` sheet of the configured
+// spreadsheet if found; else an `errSheetNotFound` error.
+func (p Project) GetSheetInfo(sheetName string) (SheetInfo, error) {
+	resp, err := p.metadata.Spreadsheet(p.config.SpreadsheetId)
+	if err != nil {
+		return SheetInfo{}, err
+	}
+	for _, sheet := range resp.Sheets {
+		if sheet.Properties.Title == sheetName {
+			info := SheetInfo{SheetId: sheet.Properties.SheetId, Title: sheet.Properties.Title}
+			if grid := sheet.Properties.GridProperties; grid != nil {
+				info.RowCount = int(grid.RowCount)
+				info.ColumnCount = int(grid.ColumnCount)
+			}
+			return info, nil
+		}
+	}
+	return SheetInfo{}, errSheetNotFound
+}
+
+// isSheetRangeError returns whether `err` is the API rejecting a range, which
+// is what it does when the range's sheet title doesn't exist (anymore).
+func isSheetRangeError(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest && strings.Contains(apiErr.Message, "Unable to parse range")
+}
+
+// isGridLimitError returns whether `err` is the API rejecting a range past the
+// sheet's grid, e.g. because rows were deleted since the batches were planned.
+func isGridLimitError(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest && strings.Contains(apiErr.Message, "exceeds grid limits")
+}
+
+// renamedSheetTitle re-resolves the configured sheet by its `sheetId`, which
+// unlike its title is stable, after fresh metadata: it returns the sheet's new
+// title when it was renamed (e.g. by a colleague during the run), its current
+// one when it wasn't, or an `errSheetNotFound` error when it's gone.
+func (p Project) renamedSheetTitle(sheetId int64) (string, error) {
+	p.metadata.Invalidate(p.config.SpreadsheetId)
+	return p.sheetTitleByGid(sheetId)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+func TestMetadataCache(t *testing.T) {
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		invalidate bool
+		fetches    int32
+	}{
+		{"cached", time.Hour, false, 1},
+		{"expired", 0, false, 2},
+		{"invalidated", time.Hour, true, 2},
+	}
+	for _, tt := range tests {
+		var fetches int32
+		c := newMetadataCache(tt.ttl, func(spreadsheetId string) (*sheets.Spreadsheet, error) {
+			atomic.AddInt32(&fetches, 1)
+			return &sheets.Spreadsheet{SpreadsheetId: spreadsheetId}, nil
+		})
+		for i := 0; i < 2; i++ {
+			if i == 1 && tt.invalidate {
+				c.Invalidate("id")
+			}
+			spreadsheet, err := c.Spreadsheet("id")
+			if err != nil || spreadsheet.SpreadsheetId != "id" {
+				t.Errorf("%s: Spreadsheet = %v, %v, want the spreadsheet", tt.name, spreadsheet, err)
+			}
+		}
+		if fetches != tt.fetches {
+			t.Errorf("%s: fetches = %d, want %d", tt.name, fetches, tt.fetches)
+		}
+	}
+}
+
+func TestMetadataCacheError(t *testing.T) {
+	var fetches int32
+	failure := errors.New("unavailable")
+	c := newMetadataCache(time.Hour, func(spreadsheetId string) (*sheets.Spreadsheet, error) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			return nil, failure
+		}
+		return &sheets.Spreadsheet{SpreadsheetId: spreadsheetId}, nil
+	})
+	if _, err := c.Spreadsheet("id"); !errors.Is(err, failure) {
+		t.Errorf("Spreadsheet error = %v, want %v", err, failure)
+	}
+	// errors aren't cached
+	if spreadsheet, err := c.Spreadsheet("id"); err != nil || spreadsheet == nil {
+		t.Errorf("Spreadsheet after an error = %v, %v, want the spreadsheet", spreadsheet, err)
+	}
+}
+
+func TestMetadataCacheConcurrent(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	c := newMetadataCache(time.Hour, func(spreadsheetId string) (*sheets.Spreadsheet, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return &sheets.Spreadsheet{SpreadsheetId: spreadsheetId}, nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Spreadsheet("id"); err != nil {
+				t.Errorf("Spreadsheet: %v", err)
+			}
+		}()
+	}
+	// let the callers pile up on the first fetch
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if fetches != 1 {
+		t.Errorf("fetches = %d, want concurrent requests collapsed into 1", fetches)
+	}
+}
+
+func TestRenamedSheetTitle(t *testing.T) {
+	// the sheet's title on each metadata fetch, "" once it's deleted
+	titles := []string{"Class Data", "Class Data", "Students", ""}
+	fetches := 0
+	p := Project{metadata: newMetadataCache(time.Hour, func(spreadsheetId string) (*sheets.Spreadsheet, error) {
+		spreadsheet := &sheets.Spreadsheet{Sheets: []*sheets.Sheet{{Properties: &sheets.SheetProperties{SheetId: 1, Title: "Notes"}}}}
+		if title := titles[fetches]; title != "" {
+			spreadsheet.Sheets = append(spreadsheet.Sheets, &sheets.Sheet{Properties: &sheets.SheetProperties{SheetId: 7, Title: title}})
+		}
+		fetches++
+		return spreadsheet, nil
+	})}
+	p.config.SpreadsheetId = "id"
+	if _, err := p.metadata.Spreadsheet("id"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		want  string
+		found bool
+	}{
+		{"Class Data", true},
+		{"Students", true},
+		{"", false},
+	}
+	for i, tt := range tests {
+		got, err := p.renamedSheetTitle(7)
+		if got != tt.want || (err == nil) != tt.found || (err != nil && !errors.Is(err, errSheetNotFound)) {
+			t.Errorf("renamedSheetTitle after %d fetches = %q, %v, want %q", i+1, got, err, tt.want)
+		}
+	}
+	// the metadata is fetched again every time
+	if fetches != 4 {
+		t.Errorf("fetches = %d, want 4", fetches)
+	}
+}
+
+func TestIsSheetRangeError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&googleapi.Error{Code: http.StatusBadRequest, Message: "Unable to parse range: 'Class Data'!A1:E10"}, true},
+		{fmt.Errorf("batch 2-11: %w", &googleapi.Error{Code: http.StatusBadRequest, Message: "Unable to parse range: Sheet1!A1"}), true},
+		{&googleapi.Error{Code: http.StatusForbidden, Message: "Unable to parse range: Sheet1!A1"}, false},
+		{&googleapi.Error{Code: http.StatusBadRequest, Message: "Invalid value"}, false},
+		{errors.New("Unable to parse range: Sheet1!A1"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isSheetRangeError(tt.err); got != tt.want {
+			t.Errorf("isSheetRangeError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsGridLimitError(t *testing.T) {
+	// the fake's answer to a read past a shrunk sheet's grid
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Sheet1", [][]interface{}{{"Name"}, {"Ann"}})
+	server.SetGrid("spreadsheet-id", "Sheet1", 2, 26)
+	p := fakeSheetsProject(t, server, "Sheet1")
+	_, pastGrid := p.sheetsService.Spreadsheets.Values.Get("spreadsheet-id", "Sheet1!A3:Z10").Do()
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{pastGrid, true},
+		{fmt.Errorf("batch 3-10: %w", pastGrid), true},
+		{&googleapi.Error{Code: http.StatusBadRequest, Message: "Unable to parse range: Sheet1!A1"}, false},
+		{&googleapi.Error{Code: http.StatusInternalServerError, Message: "exceeds grid limits"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isGridLimitError(tt.err); got != tt.want {
+			t.Errorf("isGridLimitError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+)
+
+// outputStdout is the `outputPath` of exports written to stdout.
+const outputStdout = "-"
+
+// redirectOutput sends the export to the `OUTPUT_FILE` rather than stdout: the
+// `--porcelain` protocol, the CSV/JSON aggregates, or otherwise everything
+// printed for humans, like redirecting stdout would.
+func (p *Project) redirectOutput() {
+	f, err := os.Create(p.config.OutputFile)
+	if err != nil {
+		fatalf("Unable to create OUTPUT_FILE: %v", err)
+	}
+	switch {
+	case p.porcelain != nil:
+		p.porcelain = newPorcelainWriter(f)
+	case p.dataOut != nil:
+		p.dataOut = f
+	default:
+		os.Stdout = f
+	}
+}
+
+// outputPath is where the export is written: the `OUTPUT_FILE`, or
+// `outputStdout`.
+func (p Project) outputPath() string {
+	if p.config.OutputFile == "" {
+		return outputStdout
+	}
+	return p.config.OutputFile
+}
+
+// exportFormat names the format of the export: "porcelain" with
+// `--porcelain`, the `AGGREGATE_FORMAT` in the aggregate `MODE`, or "text" for
+// the rows printed for humans.
+func (p Project) exportFormat() string {
+	switch {
+	case p.porcelain != nil:
+		return "porcelain"
+	case p.config.Mode == modeAggregate:
+		return p.config.AggregateFormat
+	default:
+		return "text"
+	}
+}
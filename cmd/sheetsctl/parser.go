@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// RowParser turns fetched batches into rows for the `Emitter`: the first row
+// of the sheet is the header, and blank and soft-deleted rows are skipped.
+type RowParser interface {
+	Parse(batch fetchedBatch, emitter Emitter) error
+}
+
+// rawSuffix names the `RAW_SIDE_CHANNEL` field of a column, after its header.
+const rawSuffix = "__raw"
+
+// sheetRowParser maps rows to a JSON object keyed by the header, limited to the
+// `COLUMNS` selection.
+type sheetRowParser struct {
+	p        Project
+	pipeline Pipeline
+	// headerRow is the absolute row number of the header, or 0 when the header
+	// is pinned by `pinnedHeaders` instead
+	headerRow       int
+	pinnedHeaders   []interface{}
+	columnPositions []int
+
+	headers []interface{}
+	// headerSource is the spreadsheet `headers` were read from; the headers of
+	// the following `SPREADSHEET_IDS` must match them
+	headerSource string
+	// selectedColumns holds the 0-based header indices to include in the
+	// output; nil means all columns.
+	selectedColumns map[int]bool
+	redactor        *redactor
+	hasher          *rowHasher
+	// header is shared by the parsed rows, and fieldCount the number of fields
+	// of their JSON objects at most
+	header     *cells.Header
+	fieldCount int
+	// policies are the `cellPolicy` of each header
+	policies []cellPolicy
+	// raw is whether each header has a `RAW_SIDE_CHANNEL` column
+	raw []bool
+}
+
+// newRowParser returns a parser of the rows of the configured sheet; `reset`
+// must be called before parsing each spreadsheet.
+func (p Project) newRowParser(pipeline Pipeline) (*sheetRowParser, error) {
+	parser := &sheetRowParser{p: p, pipeline: pipeline, headers: []interface{}{}}
+	if p.config.Columns != "" {
+		positions, err := parseRangeList(p.config.Columns)
+		if err != nil {
+			return nil, err
+		}
+		parser.columnPositions = positions
+	}
+	return parser, nil
+}
+
+// reset prepares the parser for the sheet of the spreadsheet `p`, whose header
+// is on row `headerRow` unless it's pinned with `HEADERS`.
+func (r *sheetRowParser) reset(p Project, headerRow int) {
+	r.p, r.headerRow, r.pinnedHeaders = p, headerRow, nil
+	if p.headers != nil {
+		r.headerRow, r.pinnedHeaders = 0, p.headers
+	}
+}
+
+// Parse passes the header and the data rows of the `batch` to the `emitter`.
+func (r *sheetRowParser) Parse(batch fetchedBatch, emitter Emitter) error {
+	if r.pinnedHeaders != nil {
+		// the first batch tells how wide the sheet is
+		headers := r.p.fitHeaders(r.pinnedHeaders, batch.Rows)
+		r.pinnedHeaders = nil
+		if err := r.parseHeader(headers, emitter); err != nil {
+			return err
+		}
+	}
+	// NOTE: this doesn't necessarily mean the end of the sheet has been
+	// reached; it's possible there's some blank rows spread throughout the
+	// values (as well as blank rows in-between valid rows that also needs to
+	// be caught down below while looping through `rows`).
+	if len(batch.Rows) == 0 {
+		fmt.Printf("No data found in rows %d-%d.\n", batch.Start, batch.End)
+	}
+	// The API leaves out the trailing blank rows of the batch, and the whole
+	// `Values` when every row is blank (which isn't an error), so the rows
+	// missing at the end are blank rows too.
+	if omitted := batch.End - batch.Start + 1 - len(batch.Rows); omitted > 0 {
+		r.p.summary.BlankRows += omitted
+	}
+	for ii, row := range batch.Rows {
+		// there might be a blank row in-between valid rows, skip to next row if
+		// this is blank:
+		if len(row) == 0 {
+			fmt.Println("Blank row found.")
+			r.p.summary.BlankRows++
+			continue
+		}
+		rowNumber := batch.Start + ii
+		if rowNumber == r.headerRow {
+			if err := r.parseHeader(row, emitter); err != nil {
+				return err
+			}
+			continue
+		}
+		if r.p.excludedRows.Excludes(rowNumber) {
+			r.p.summary.ExcludedRows++
+			continue
+		}
+		if batch.SoftDeleted[rowNumber] {
+			r.p.summary.SoftDeleted++
+			continue
+		}
+		fields, err := r.parseRow(row, rowNumber, batch.FetchedAt)
+		if err == nil {
+			err = emitter.Row(fields)
+		}
+		if err != nil {
+			rejected, rejectErr := r.p.rejectRow(err, rowNumber, r.redactor.RedactCells(row), fields)
+			if rejectErr != nil {
+				return rejectErr
+			}
+			if !rejected {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseHeader sets up the column selection (prompting for it when interactive
+// and `COLUMNS` isn't set) and the extra columns, and emits the header.
+func (r *sheetRowParser) parseHeader(row []interface{}, emitter Emitter) error {
+	p := r.p
+	if r.headerSource != "" {
+		// another of the `SPREADSHEET_IDS`, whose rows keep the first one's keys
+		if diff := headerDiff(r.headers, row); len(diff) > 0 {
+			return fmt.Errorf("the header of spreadsheet %s differs from spreadsheet %s's:\n\t%s", p.config.SpreadsheetId, r.headerSource, strings.Join(diff, "\n\t"))
+		}
+		return nil
+	}
+	r.headers, r.headerSource = row, p.config.SpreadsheetId
+	var err error
+	if r.columnPositions != nil {
+		r.selectedColumns, err = columnSelection(r.columnPositions, len(r.headers))
+		if err != nil {
+			return fmt.Errorf("invalid COLUMNS: %w", err)
+		}
+	} else if isInteractive() {
+		r.selectedColumns = p.promptColumnSelection(r.headers)
+	}
+	r.selectedColumns = p.excludeTaggedColumns(r.selectedColumns, r.headers)
+	if p.redactions != nil {
+		r.redactor, err = newRedactor(r.headers, p.redactions, p.config.RedactSalt)
+		if err != nil {
+			return fmt.Errorf("invalid REDACT_COLUMNS: %w", err)
+		}
+	}
+	if p.config.Provenance {
+		if err := p.provenance.CheckCollisions(r.headers); err != nil {
+			return fmt.Errorf("invalid PROVENANCE columns: %w", err)
+		}
+	}
+	if len(p.config.SpreadsheetIds) > 0 {
+		for _, header := range r.headers {
+			if fmt.Sprint(header) == sourceSpreadsheetColumn {
+				return fmt.Errorf("%s collides with a sheet header", sourceSpreadsheetColumn)
+			}
+		}
+	}
+	if p.config.HashColumn != "" {
+		for _, header := range r.headers {
+			if fmt.Sprint(header) == p.config.HashColumn {
+				return fmt.Errorf("HASH_COLUMN %q collides with a sheet header", p.config.HashColumn)
+			}
+		}
+		r.hasher, err = newRowHasher(r.headers, p.config.HashColumns, r.redactor)
+		if err != nil {
+			return fmt.Errorf("invalid HASH_COLUMNS: %w", err)
+		}
+	}
+	if err := r.prepareRawColumns(); err != nil {
+		return fmt.Errorf("invalid RAW_COLUMNS: %w", err)
+	}
+	columns := r.prepareHeader()
+	r.policies, err = p.cellPolicies(r.header.Names())
+	if err != nil {
+		return fmt.Errorf("invalid COLUMN_OPTIONS: %w", err)
+	}
+	if err := r.pipeline.CheckHeader(columns); err != nil {
+		return err
+	}
+	emitter.Header(columns)
+	return nil
+}
+
+// prepareRawColumns sets up the `RAW_SIDE_CHANNEL` columns of the `RAW_COLUMNS`
+// headers (all when empty), leaving out the redacted ones so their values
+// can't leak; an error is returned for a column that isn't a header, or whose
+// raw column would collide with a header.
+func (r *sheetRowParser) prepareRawColumns() error {
+	headers := cells.NewHeader(r.headers)
+	r.raw = make([]bool, len(headers.Names()))
+	if !r.p.config.RawSideChannel {
+		return nil
+	}
+	listed := map[string]bool{}
+	for _, column := range r.p.config.RawColumns {
+		column = strings.TrimSpace(column)
+		if _, ok := headers.Index(column); !ok {
+			return fmt.Errorf("%q isn't a header", column)
+		}
+		listed[column] = true
+	}
+	for i, header := range headers.Names() {
+		if header == "" || (r.redactor != nil && r.redactor.Redacts(i)) {
+			continue
+		}
+		if len(listed) > 0 && !listed[header] {
+			continue
+		}
+		if _, ok := headers.Index(header + rawSuffix); ok {
+			return fmt.Errorf("%q collides with a header", header+rawSuffix)
+		}
+		r.raw[i] = true
+	}
+	return nil
+}
+
+// fieldValue returns the `valueString` of the cell `i` of the `row` as a JSON
+// field value, reusing the cell when it already holds that string, since boxing
+// it again would allocate.
+func (r *sheetRowParser) fieldValue(row cells.Row, i int, valueString string) interface{} {
+	if cell, ok := row.Cell(i); ok {
+		if s, ok := cell.(string); ok && s == valueString {
+			return cell
+		}
+	}
+	return valueString
+}
+
+// prepareHeader sets up the `header` shared by the rows, and returns the
+// output columns.
+func (r *sheetRowParser) prepareHeader() []string {
+	r.header = cells.NewHeader(r.headers)
+	columns := []string{}
+	for i, header := range r.header.Names() {
+		if (r.selectedColumns == nil || r.selectedColumns[i]) && (r.redactor == nil || !r.redactor.Dropped(i)) {
+			columns = append(columns, header)
+			if r.raw[i] {
+				columns = append(columns, header+rawSuffix)
+			}
+		}
+	}
+	r.fieldCount = len(columns)
+	if r.hasher != nil {
+		r.fieldCount++
+	}
+	if r.p.config.Provenance {
+		r.fieldCount += len(r.p.provenance.Columns())
+	}
+	if len(r.p.config.SpreadsheetIds) > 0 {
+		r.fieldCount++
+	}
+	return columns
+}
+
+// parseRow maps the sheet row `rowNumber` to a JSON object, along with the
+// `HASH_COLUMN`/`PROVENANCE` columns; an error is returned for cells larger
+// than `MAX_CELL_BYTES` with `MAX_CELL_POLICY=fail`.
+//
+// NOTE: the JSON object works for any sheet, since the header strings are used
+// as the keys; mapping rows to a struct is left to the output (see
+// `spreadsheet.SchemaMatcher`).
+func (r *sheetRowParser) parseRow(row []interface{}, rowNumber int, fetchedAt time.Time) (map[string]interface{}, error) {
+	p := r.p
+	json := make(map[string]interface{}, r.fieldCount)
+	sheetRow := r.header.Row(row, p.format)
+	for i, keyString := range sheetRow.Names() {
+		if r.selectedColumns != nil && !r.selectedColumns[i] {
+			continue
+		}
+		if r.redactor != nil && r.redactor.Dropped(i) {
+			continue
+		}
+		// NOTE: the cell is looked up by position rather than by name, since
+		// headers can be duplicated.
+		valueString, _ := sheetRow.CellString(i)
+		policy := r.policies[i]
+		if policy.Trim {
+			valueString = strings.TrimSpace(valueString)
+		}
+		valueString, err := p.guardCellSize(valueString, rowNumber, keyString)
+		if err != nil {
+			return nil, err
+		}
+		if r.redactor != nil {
+			valueString = r.redactor.Redact(i, valueString)
+		}
+		if keyString == "" {
+			continue
+		}
+		switch {
+		case valueString != "" && policy.Checkbox:
+			if checked, ok := p.format.ParseBool(valueString); ok {
+				json[keyString] = checked
+			} else {
+				json[keyString] = r.fieldValue(sheetRow, i, valueString)
+			}
+		case valueString != "" && p.config.NumberMode != cells.NumberModeString:
+			if number, ok := p.format.NumberValue(valueString, p.config.NumberMode); ok {
+				json[keyString] = number
+			} else {
+				json[keyString] = r.fieldValue(sheetRow, i, valueString)
+			}
+		case valueString != "":
+			json[keyString] = r.fieldValue(sheetRow, i, valueString)
+		case policy.Empty == emptyNull:
+			json[keyString] = nil
+		case policy.Empty == emptyEmpty:
+			json[keyString] = ""
+		}
+		if cell, ok := sheetRow.Cell(i); r.raw[i] && ok && cell != "" {
+			json[keyString+rawSuffix] = cell
+		}
+	}
+	if r.hasher != nil {
+		json[p.config.HashColumn] = r.hasher.Hash(sheetRow)
+	}
+	if p.config.Provenance {
+		p.provenance.Annotate(json, p.config.SpreadsheetId, p.config.SheetName, rowNumber, fetchedAt, p.sourceModifiedAt(p.config.SpreadsheetId))
+	}
+	if len(p.config.SpreadsheetIds) > 0 {
+		json[sourceSpreadsheetColumn] = p.config.SpreadsheetId
+		if p.summary.Sources == nil {
+			p.summary.Sources = map[string]int{}
+		}
+		p.summary.Sources[p.config.SpreadsheetId]++
+	}
+	return json, nil
+}
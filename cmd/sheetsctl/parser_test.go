@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// discardEmitter drops everything it's given.
+type discardEmitter struct{}
+
+func (discardEmitter) BatchStart(batch Batch)                  {}
+func (discardEmitter) BatchEnd(batch Batch, fetched int)       {}
+func (discardEmitter) Header(columns []string)                 {}
+func (discardEmitter) Row(fields map[string]interface{}) error { return nil }
+func (discardEmitter) Close() error                            { return nil }
+
+// benchmarkRowParser returns a parser of a 20-column sheet with `HASH_COLUMN`
+// set, and one of its rows.
+func benchmarkRowParser(tb testing.TB) (*sheetRowParser, []interface{}) {
+	tb.Helper()
+	p := Project{summary: &runSummary{}}
+	p.config.HashColumn = "_hash"
+	p.config.NumberMode = cells.NumberModeString
+	// so the columns aren't prompted for
+	p.config.Columns = "1-20"
+	parser, err := p.newRowParser(Pipeline{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	parser.reset(p, 1)
+	headers := make([]interface{}, 20)
+	row := make([]interface{}, 20)
+	for i := range headers {
+		headers[i] = fmt.Sprintf("Column %d", i+1)
+		row[i] = fmt.Sprintf("value %d", i+1)
+	}
+	if err := parser.parseHeader(headers, discardEmitter{}); err != nil {
+		tb.Fatal(err)
+	}
+	return parser, row
+}
+
+func BenchmarkParseRow(b *testing.B) {
+	parser, row := benchmarkRowParser(b)
+	now := time.Now()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.parseRow(row, i+2, now); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// maxParseRowAllocs is a bit over the allocations of `benchmarkRowParser`'s
+// rows (7): the row's map and its hash, none per cell.
+const maxParseRowAllocs = 10
+
+// TestParseRowAllocs guards the row conversion against allocating per cell
+// again: the header keys are shared and the cell values reused, so what's left
+// is the row's map and its hash.
+func TestParseRowAllocs(t *testing.T) {
+	parser, row := benchmarkRowParser(t)
+	now := time.Now()
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := parser.parseRow(row, 2, now); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > maxParseRowAllocs {
+		t.Errorf("parseRow allocates %v times per row, want at most %d", allocs, maxParseRowAllocs)
+	}
+}
+
+// countingEmitter counts the rows it's given.
+type countingEmitter struct {
+	discardEmitter
+	rows int
+}
+
+func (e *countingEmitter) Row(fields map[string]interface{}) error {
+	e.rows++
+	return nil
+}
+
+func TestParseBlankRows(t *testing.T) {
+	tests := []struct {
+		name      string
+		batch     Batch
+		rows      [][]interface{}
+		blankRows int
+		dataRows  int
+	}{
+		// the API leaves out Values when every row is blank
+		{"nil values", Batch{2, 11}, nil, 10, 0},
+		{"no values", Batch{2, 11}, [][]interface{}{}, 10, 0},
+		{"trailing blank rows", Batch{2, 11}, [][]interface{}{{"Ann"}, {"Bob"}}, 8, 2},
+		{"blank rows in-between", Batch{2, 6}, [][]interface{}{{"Ann"}, {}, {}, {"Bob"}}, 3, 2},
+		{"full batch", Batch{2, 4}, [][]interface{}{{"Ann"}, {"Bob"}, {"Cid"}}, 0, 3},
+		// the header row isn't a blank row
+		{"header only", Batch{1, 3}, [][]interface{}{{"Name"}}, 2, 0},
+	}
+	for _, tt := range tests {
+		p := Project{summary: &runSummary{}}
+		p.config.NumberMode = cells.NumberModeString
+		p.config.Columns = "1"
+		parser, err := p.newRowParser(Pipeline{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.reset(p, 1)
+		if tt.batch.Start > 1 {
+			if err := parser.parseHeader([]interface{}{"Name"}, discardEmitter{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		emitter := &countingEmitter{}
+		if err := parser.Parse(fetchedBatch{Batch: tt.batch, Rows: tt.rows}, emitter); err != nil {
+			t.Errorf("%s: Parse: %v", tt.name, err)
+			continue
+		}
+		if p.summary.BlankRows != tt.blankRows || emitter.rows != tt.dataRows {
+			t.Errorf("%s: blank rows, rows = %d, %d, want %d, %d", tt.name, p.summary.BlankRows, emitter.rows, tt.blankRows, tt.dataRows)
+		}
+	}
+}
+
+// TestParseRowRaw checks the `RAW_SIDE_CHANNEL` fields: the API's cell next to
+// the coerced value, for the `RAW_COLUMNS` only, and never for the empty or
+// redacted cells, or in the hash.
+func TestParseRowRaw(t *testing.T) {
+	headers := []interface{}{"Name", "Score", "Notes", "SSN"}
+	row := []interface{}{"Ann", "1234.5", "", "123-45-6789"}
+	p := Project{redactions: []redaction{{Column: "SSN", Strategy: redactMask}}}
+	p.config.NumberMode = cells.NumberModeFloat
+	p.config.HashColumn = "_hash"
+	plain := parseTestRow(t, p, headers, row)
+
+	p.config.RawSideChannel = true
+	got := parseTestRow(t, p, headers, row)
+	want := map[string]interface{}{
+		"Name": "Ann", "Name__raw": "Ann",
+		"Score": 1234.5, "Score__raw": "1234.5",
+		"SSN": plain["SSN"], "_hash": plain["_hash"],
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRow with RAW_SIDE_CHANNEL = %v, want %v", got, want)
+	}
+
+	p.config.RawColumns = []string{" Score"}
+	got = parseTestRow(t, p, headers, row)
+	if _, ok := got["Name__raw"]; ok || got["Score__raw"] != "1234.5" {
+		t.Errorf("parseRow with RAW_COLUMNS=Score = %v, want only Score__raw", got)
+	}
+}
+
+func TestPrepareRawColumnsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []interface{}
+		columns []string
+	}{
+		{"not a header", []interface{}{"Name", "Score"}, []string{"Age"}},
+		{"collides with a header", []interface{}{"Score", "Score__raw"}, nil},
+	}
+	for _, tt := range tests {
+		p := Project{summary: &runSummary{}}
+		p.config.RawSideChannel = true
+		p.config.RawColumns = tt.columns
+		p.config.Columns = fmt.Sprintf("1-%d", len(tt.headers))
+		parser, err := p.newRowParser(Pipeline{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.reset(p, 1)
+		if err := parser.parseHeader(tt.headers, discardEmitter{}); err == nil {
+			t.Errorf("%s: parseHeader succeeded, want an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// payloadServer serves a canned values response of wide text columns, gzipped
+// when the client accepts it and limited to the values with `valuesFields`,
+// counting the bytes it writes on the wire.
+type payloadServer struct {
+	*httptest.Server
+	wire    int64
+	gzipped int64
+}
+
+func newPayloadServer(tb testing.TB) *payloadServer {
+	tb.Helper()
+	words := strings.Fields("the quick brown fox jumps over a lazy dog while students write long notes about their majors and activities")
+	random := rand.New(rand.NewSource(1))
+	values := make([][]interface{}, 200)
+	for i := range values {
+		values[i] = make([]interface{}, 20)
+		for j := range values[i] {
+			var cell strings.Builder
+			for cell.Len() < 200 {
+				cell.WriteString(words[random.Intn(len(words))])
+				cell.WriteByte(' ')
+			}
+			values[i][j] = cell.String()
+		}
+	}
+	s := &payloadServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := map[string]interface{}{"range": "'Sheet1'!A1:T200", "majorDimension": "ROWS", "values": values}
+		if r.URL.Query().Get("fields") == valuesFields {
+			delete(resp, "majorDimension")
+		}
+		body, err := json.Marshal(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		counted := &countingWriter{w: w}
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			atomic.AddInt64(&s.gzipped, 1)
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(counted)
+			gz.Write(body)
+			gz.Close()
+		} else {
+			counted.Write(body)
+		}
+		atomic.AddInt64(&s.wire, counted.n)
+	}))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// payloadProject returns a project reading from the `server` through the
+// transports of the runs, optionally without gzip and the fields mask.
+func payloadProject(tb testing.TB, server *payloadServer, compress, mask bool) Project {
+	tb.Helper()
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.DisableCompression = !compress
+	summary := &runSummary{}
+	meter := &apiMeter{}
+	client := &http.Client{Transport: newRetryTransport(meter.Transport(newUserAgentTransport(base)), 0, 0, 0, summary, realClock{})}
+	service, err := sheets.NewService(context.Background(), option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	p := Project{sheetsService: service, summary: summary, meter: meter}
+	p.config.SpreadsheetId = "spreadsheet-id"
+	p.config.FieldsMask = mask
+	return p
+}
+
+// BenchmarkGetValuesPayload reports the bytes on the wire of a wide values
+// read ("wire-B/op"), with and without gzip and the fields mask.
+func BenchmarkGetValuesPayload(b *testing.B) {
+	for _, bb := range []struct {
+		name           string
+		compress, mask bool
+	}{
+		{"plain", false, false},
+		{"fields", false, true},
+		{"gzip", true, false},
+		{"gzip+fields", true, true},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			server := newPayloadServer(b)
+			p := payloadProject(b, server, bb.compress, bb.mask)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.getValues("'Sheet1'!A1:T200"); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(&server.wire))/float64(b.N), "wire-B/op")
+			b.ReportMetric(float64(p.meter.Bytes())/float64(b.N), "body-B/op")
+		})
+	}
+}
+
+// TestGetValuesGzip guards the run's transports against dropping gzip: the
+// values are requested gzipped, and decompressed transparently.
+func TestGetValuesGzip(t *testing.T) {
+	server := newPayloadServer(t)
+	p := payloadProject(t, server, true, true)
+	resp, err := p.getValues("'Sheet1'!A1:T200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.gzipped != 1 {
+		t.Errorf("gzipped responses = %d, want 1", server.gzipped)
+	}
+	if len(resp.Values) != 200 || resp.MajorDimension != "" {
+		t.Errorf("values = %d rows, major dimension %q, want 200 rows without the major dimension", len(resp.Values), resp.MajorDimension)
+	}
+	if wire, body := server.wire, p.meter.Bytes(); wire*2 > body {
+		t.Errorf("wire bytes = %d, body bytes = %d, want the wire at most half the body", wire, body)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errInvalidPipeline = errors.New("invalid pipeline")
+
+// Pipeline stages, in the fixed order rows go through them:
+//
+//  1. projection: only the `COLUMNS` selection is kept
+//  2. redact: the `REDACT_COLUMNS` are hashed, masked, or dropped
+//  3. filter: soft-deleted rows (`SKIP_STRIKETHROUGH`/`SKIP_BACKGROUND_COLOR`)
+//     are dropped
+//  4. sort: rows are ordered by `SORT_BY`
+//  5. hash, provenance, source: the `HASH_COLUMN`, `PROVENANCE`, and
+//     `_source_spreadsheet_id` columns are added
+//  6. join: the `JOIN_COLUMNS` of the `JOIN_SHEET` row matching the row's
+//     `JOIN_FOREIGN_KEY` are added
+//  7. aggregate: with `MODE=aggregate`, rows are grouped by `GROUP_BY`, and
+//     only the groups are output
+//  8. output: rows are printed (as the struct of the
+//     `spreadsheet.SchemaMatcher` matching the header, if any), or written as
+//     porcelain events
+//
+// So each stage only sees what the previous ones left, e.g. `SORT_BY` can
+// only use the projected sheet columns, not the added or dropped ones, and
+// nothing after redaction sees the raw values of the redacted columns.
+//
+// NOTE: the added columns are computed while parsing, since they need the
+// whole row and its position in the sheet, but no stage before them can
+// refer to them.
+const (
+	stageProjection = "projection"
+	stageRedact     = "redact"
+	stageFilter     = "filter"
+	stageSort       = "sort"
+	stageHash       = "hash"
+	stageProvenance = "provenance"
+	stageSource     = "source"
+	stageJoin       = "join"
+	stageAggregate  = "aggregate"
+	stageOutput     = "output"
+)
+
+// pipelineStage is an enabled stage of the `Pipeline`, with a description of
+// how it's configured.
+type pipelineStage struct {
+	Name   string
+	Detail string
+}
+
+// Pipeline is the list of stages enabled by the config, in processing order.
+type Pipeline struct {
+	Stages     []pipelineStage
+	sortKeys   []sortKey
+	groupBy    []string
+	aggregates []aggregate
+	join       *joinSpec
+	// sortBudget is the share of `MEMORY_BUDGET_MB` the sort buffers rows in
+	sortBudget *memoryBudget
+	// added are the columns added after projection
+	added map[string]bool
+}
+
+// newPipeline returns the stages enabled by the config, or an
+// `errInvalidPipeline` error if they don't make sense together.
+func (p Project) newPipeline() (Pipeline, error) {
+	pl := Pipeline{}
+	add := func(name, detail string) {
+		pl.Stages = append(pl.Stages, pipelineStage{Name: name, Detail: detail})
+	}
+	if p.config.Columns != "" {
+		add(stageProjection, "COLUMNS="+p.config.Columns)
+	}
+	if p.redactions != nil {
+		add(stageRedact, "REDACT_COLUMNS="+p.config.RedactColumns)
+	}
+	if p.config.SkipStrikethrough {
+		add(stageFilter, "SKIP_STRIKETHROUGH")
+	}
+	if p.config.SkipBackgroundColor != "" {
+		add(stageFilter, "SKIP_BACKGROUND_COLOR="+p.config.SkipBackgroundColor)
+	}
+	added := map[string]bool{}
+	if p.config.HashColumn != "" {
+		added[p.config.HashColumn] = true
+	}
+	if p.config.Provenance {
+		for _, column := range p.provenance.Columns() {
+			added[column] = true
+		}
+	}
+	if len(p.config.SpreadsheetIds) > 0 {
+		added[sourceSpreadsheetColumn] = true
+	}
+	if p.config.JoinSheet != "" {
+		if p.config.JoinKey == "" || p.config.JoinForeignKey == "" {
+			return Pipeline{}, fmt.Errorf("%w: JOIN_SHEET requires JOIN_KEY and JOIN_FOREIGN_KEY", errInvalidPipeline)
+		}
+		pl.join = &joinSpec{Sheet: p.config.JoinSheet, Key: p.config.JoinKey, ForeignKey: p.config.JoinForeignKey, Prefix: p.config.JoinPrefix, budget: p.memory}
+		for _, column := range p.config.JoinColumns {
+			column = strings.TrimSpace(column)
+			pl.join.Columns = append(pl.join.Columns, column)
+			added[column], added[p.config.JoinPrefix+column] = true, true
+		}
+	}
+	if p.config.SortBy != "" {
+		keys, err := parseSortBy(p.config.SortBy)
+		if err != nil {
+			return Pipeline{}, err
+		}
+		for _, key := range keys {
+			if added[key.Column] {
+				return Pipeline{}, fmt.Errorf("%w: SORT_BY can't use %q, it's added after sorting", errInvalidPipeline, key.Column)
+			}
+		}
+		if p.config.Mode == modeAggregate {
+			return Pipeline{}, fmt.Errorf("%w: SORT_BY can't be used with MODE=aggregate, groups are output in GROUP_BY order", errInvalidPipeline)
+		}
+		pl.sortKeys = keys
+		pl.sortBudget = p.memory
+		if pl.join != nil {
+			// the join lookup is held while the sort buffers every row, so
+			// each gets half of the budget, or a large lookup would make the
+			// sort spill all the time
+			pl.join.budget, pl.sortBudget = p.memory.Share(0.5), p.memory.Share(0.5)
+		}
+		add(stageSort, "SORT_BY="+p.config.SortBy)
+	}
+	if p.config.HashColumn != "" {
+		add(stageHash, "HASH_COLUMN="+p.config.HashColumn)
+	}
+	if p.config.Provenance {
+		add(stageProvenance, strings.Join(p.provenance.Columns(), ","))
+	}
+	if len(p.config.SpreadsheetIds) > 0 {
+		add(stageSource, sourceSpreadsheetColumn)
+	}
+	if pl.join != nil {
+		add(stageJoin, pl.join.String())
+	}
+	if p.config.Mode == modeAggregate {
+		aggregates, err := parseAggregates(p.config.Aggregates)
+		if err != nil {
+			return Pipeline{}, fmt.Errorf("%w: invalid AGGREGATES: %v", errInvalidPipeline, err)
+		}
+		switch p.config.AggregateFormat {
+		case aggregateFormatTable, aggregateFormatCSV, aggregateFormatJSON:
+		default:
+			return Pipeline{}, fmt.Errorf("%w: unknown AGGREGATE_FORMAT %q", errInvalidPipeline, p.config.AggregateFormat)
+		}
+		for _, column := range p.config.GroupBy {
+			pl.groupBy = append(pl.groupBy, strings.TrimSpace(column))
+		}
+		pl.aggregates = aggregates
+		add(stageAggregate, fmt.Sprintf("GROUP_BY=%s AGGREGATES=%s", strings.Join(pl.groupBy, ","), p.config.Aggregates))
+	}
+	pl.added = added
+	if p.porcelain != nil {
+		add(stageOutput, "porcelain")
+	} else {
+		add(stageOutput, "stdout")
+	}
+	return pl, nil
+}
+
+// CheckHeader returns an `errInvalidPipeline` error if a stage refers to a
+// column that's not in the projected `columns` (or, after they're added, one
+// of the added columns).
+func (pl Pipeline) CheckHeader(columns []string) error {
+	projected := map[string]bool{}
+	for _, column := range columns {
+		projected[column] = true
+	}
+	if pl.join != nil && !projected[pl.join.ForeignKey] && !pl.added[pl.join.ForeignKey] {
+		return fmt.Errorf("%w: JOIN_FOREIGN_KEY column %q isn't a (selected) column", errInvalidPipeline, pl.join.ForeignKey)
+	}
+	for _, key := range pl.sortKeys {
+		if !projected[key.Column] {
+			return fmt.Errorf("%w: SORT_BY column %q isn't a (selected) sheet column", errInvalidPipeline, key.Column)
+		}
+	}
+	for _, column := range pl.groupBy {
+		if !projected[column] && !pl.added[column] {
+			return fmt.Errorf("%w: GROUP_BY column %q isn't a (selected) column", errInvalidPipeline, column)
+		}
+	}
+	for _, a := range pl.aggregates {
+		if a.Column != "" && !projected[a.Column] && !pl.added[a.Column] {
+			return fmt.Errorf("%w: AGGREGATES column %q isn't a (selected) column", errInvalidPipeline, a.Column)
+		}
+	}
+	return nil
+}
+
+// Emitter returns the `Emitter` chain of the stages after parsing, ending in
+// `output`.
+func (pl Pipeline) Emitter(p Project, output Emitter) Emitter {
+	if pl.aggregates != nil {
+		output = newAggregatingEmitter(output, p, pl.groupBy, pl.aggregates)
+	}
+	if pl.join != nil {
+		output = &joiningEmitter{Emitter: output, p: p, join: pl.join}
+	}
+	if pl.sortKeys != nil {
+		return &sortingEmitter{Emitter: output, p: p, sorter: newRowSorter(pl.sortKeys, p.config.SortCaseInsensitive, p.format, int64(p.config.SortMemoryMB)<<20, pl.sortBudget)}
+	}
+	return output
+}
+
+// String describes the stages, e.g. "projection (COLUMNS=1-3) → sort
+// (SORT_BY=Major) → output (stdout)".
+func (pl Pipeline) String() string {
+	stages := make([]string, len(pl.Stages))
+	for i, stage := range pl.Stages {
+		stages[i] = fmt.Sprintf("%s (%s)", stage.Name, stage.Detail)
+	}
+	return strings.Join(stages, " → ")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewPipelineOrder(t *testing.T) {
+	p := Project{provenance: newProvenance("_", false)}
+	p.config.SortBy = "Major"
+	p.config.Provenance = true
+	p.config.HashColumn = "_hash"
+	p.config.Columns = "1-3"
+	p.config.SkipStrikethrough = true
+	p.config.JoinSheet, p.config.JoinKey, p.config.JoinForeignKey = "Majors", "Major", "Major"
+	p.config.SpreadsheetIds = []string{"a", "b"}
+
+	pl, err := p.newPipeline()
+	if err != nil {
+		t.Fatalf("newPipeline: %v", err)
+	}
+	want := []string{stageProjection, stageFilter, stageSort, stageHash, stageProvenance, stageSource, stageJoin, stageOutput}
+	if len(pl.Stages) != len(want) {
+		t.Fatalf("stages = %s, want %v", pl, want)
+	}
+	for i, stage := range pl.Stages {
+		if stage.Name != want[i] {
+			t.Errorf("stage %d = %s, want %s", i, stage.Name, want[i])
+		}
+	}
+}
+
+func TestNewPipelineInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(c *Config)
+	}{
+		{"sort with aggregate", func(c *Config) { c.SortBy, c.Mode = "Major", modeAggregate }},
+		{"sort by an added column", func(c *Config) { c.SortBy, c.HashColumn = "_hash", "_hash" }},
+		{"sort by a joined column", func(c *Config) {
+			c.SortBy, c.JoinSheet, c.JoinKey, c.JoinForeignKey, c.JoinColumns = "Department", "Majors", "Major", "Major", []string{"Department"}
+		}},
+		{"join without a key", func(c *Config) { c.JoinSheet, c.JoinForeignKey = "Majors", "Major" }},
+		{"unknown aggregate format", func(c *Config) { c.Mode, c.Aggregates, c.AggregateFormat = modeAggregate, "count", "xml" }},
+	}
+	for _, tt := range tests {
+		p := Project{}
+		tt.setup(&p.config)
+		if _, err := p.newPipeline(); !errors.Is(err, errInvalidPipeline) {
+			t.Errorf("%s: newPipeline error = %v, want errInvalidPipeline", tt.name, err)
+		}
+	}
+}
+
+func TestPipelineCheckHeader(t *testing.T) {
+	p := Project{}
+	p.config.HashColumn = "_hash"
+	p.config.Mode, p.config.Aggregates, p.config.AggregateFormat = modeAggregate, "count", aggregateFormatTable
+	p.config.GroupBy = []string{"_hash"}
+	pl, err := p.newPipeline()
+	if err != nil {
+		t.Fatalf("newPipeline: %v", err)
+	}
+	if err := pl.CheckHeader([]string{"Major"}); err != nil {
+		t.Errorf("CheckHeader grouping by an added column: %v", err)
+	}
+
+	p.config.GroupBy = []string{"Gender"}
+	if pl, err = p.newPipeline(); err != nil {
+		t.Fatalf("newPipeline: %v", err)
+	}
+	if err := pl.CheckHeader([]string{"Major"}); !errors.Is(err, errInvalidPipeline) {
+		t.Errorf("CheckHeader grouping by an unselected column error = %v, want errInvalidPipeline", err)
+	}
+}
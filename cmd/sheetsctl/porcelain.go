@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// porcelainProtocolVersion is bumped whenever an event's shape changes, rather
+// than changing the shape under consumers of an existing version.
+const porcelainProtocolVersion = 1
+
+// Porcelain event types.
+const (
+	eventHeader     = "header"
+	eventRow        = "row"
+	eventBatchStart = "batch_start"
+	eventBatchEnd   = "batch_end"
+	eventWarning    = "warning"
+	eventSummary    = "summary"
+)
+
+// porcelainEvent is a single line of the `--porcelain` protocol; only the
+// fields relevant to the event's `Type` are set:
+//   - header: columns
+//   - row: fields
+//   - batch_start: start_row, end_row
+//   - batch_end: start_row, end_row, rows (the number of rows fetched)
+//   - warning: message, code, and the row/column it's about when known
+//   - summary: summary
+type porcelainEvent struct {
+	Type            string                 `json:"type"`
+	ProtocolVersion int                    `json:"protocol_version"`
+	Columns         []string               `json:"columns,omitempty"`
+	Fields          map[string]interface{} `json:"fields,omitempty"`
+	StartRow        int                    `json:"start_row,omitempty"`
+	EndRow          int                    `json:"end_row,omitempty"`
+	Rows            *int                   `json:"rows,omitempty"`
+	Message         string                 `json:"message,omitempty"`
+	Code            string                 `json:"code,omitempty"`
+	Row             int                    `json:"row,omitempty"`
+	Column          string                 `json:"column,omitempty"`
+	Summary         *runSummary            `json:"summary,omitempty"`
+}
+
+// porcelainWriter writes the `--porcelain` protocol: one JSON object per line,
+// for tools wrapping this program that need output which doesn't change with
+// human-oriented tweaks.
+type porcelainWriter struct {
+	enc *json.Encoder
+}
+
+func newPorcelainWriter(w io.Writer) *porcelainWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &porcelainWriter{enc: enc}
+}
+
+// Emit writes the event, stamped with the protocol version.
+func (w *porcelainWriter) Emit(event porcelainEvent) {
+	event.ProtocolVersion = porcelainProtocolVersion
+	if err := w.enc.Encode(event); err != nil {
+		fatalf("Unable to write porcelain output: %v", err)
+	}
+}
+
+// warn prints a warning with the `code`, and also emits it as a porcelain
+// `warning` event when `--porcelain` is enabled; see `warnAt`.
+func (p Project) warn(code, format string, a ...interface{}) {
+	p.warnAt(Warning{Code: code, Message: fmt.Sprintf(format, a...)})
+}
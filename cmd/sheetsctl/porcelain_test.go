@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPorcelainWriter(t *testing.T) {
+	zero, three := 0, 3
+	tests := []struct {
+		event porcelainEvent
+		want  string
+	}{
+		{porcelainEvent{Type: eventHeader, Columns: []string{"Name", "Major"}},
+			`{"type":"header","protocol_version":1,"columns":["Name","Major"]}`},
+		// HTML isn't escaped
+		{porcelainEvent{Type: eventRow, Fields: map[string]interface{}{"Name": "Ann & <Bob>", "Age": 42}},
+			`{"type":"row","protocol_version":1,"fields":{"Age":42,"Name":"Ann & <Bob>"}}`},
+		{porcelainEvent{Type: eventBatchStart, StartRow: 2, EndRow: 11},
+			`{"type":"batch_start","protocol_version":1,"start_row":2,"end_row":11}`},
+		{porcelainEvent{Type: eventBatchEnd, StartRow: 2, EndRow: 11, Rows: &three},
+			`{"type":"batch_end","protocol_version":1,"start_row":2,"end_row":11,"rows":3}`},
+		// an empty batch still has its row count
+		{porcelainEvent{Type: eventBatchEnd, StartRow: 12, EndRow: 21, Rows: &zero},
+			`{"type":"batch_end","protocol_version":1,"start_row":12,"end_row":21,"rows":0}`},
+		{porcelainEvent{Type: eventWarning, Message: "Row 5 has more cells than headers", Code: warnHeaderCount, Row: 5},
+			`{"type":"warning","protocol_version":1,"message":"Row 5 has more cells than headers","code":"header_count","row":5}`},
+		// the version is stamped over whatever the event has
+		{porcelainEvent{Type: eventWarning, ProtocolVersion: 7, Message: "Sheet is empty", Code: warnEmptySheet},
+			`{"type":"warning","protocol_version":1,"message":"Sheet is empty","code":"empty_sheet"}`},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		newPorcelainWriter(&b).Emit(tt.event)
+		if got := b.String(); got != tt.want+"\n" {
+			t.Errorf("Emit(%s) = %q, want %q", tt.event.Type, got, tt.want+"\n")
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// exitPostCommand is the exit code of runs whose `POST_COMMAND` failed, so it
+// can be told apart from a failed export (which exits 1).
+const exitPostCommand = 5
+
+var (
+	errPostCommandTimeout = errors.New("timed out")
+	errInvalidPostCommand = errors.New("invalid POST_COMMAND")
+)
+
+// parsePostCommand splits the `POST_COMMAND` into its arguments like a shell
+// would, though without running one: they're separated by whitespace, and can
+// be quoted with single quotes (taken literally) or double quotes (where a
+// backslash escapes a `"` or a `\`), or have a character escaped with a
+// backslash. nil is returned when it isn't set, and an `errInvalidPostCommand`
+// error when it's only whitespace or has an unterminated quote.
+func parsePostCommand(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var (
+		args  []string
+		arg   strings.Builder
+		inArg bool
+		quote rune
+	)
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(c)
+			}
+		case quote == '"':
+			switch {
+			case c == '"':
+				quote = 0
+			case c == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\'):
+				i++
+				arg.WriteRune(runes[i])
+			default:
+				arg.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("%w: %q ends with a backslash", errInvalidPostCommand, s)
+			}
+			i++
+			arg.WriteRune(runes[i])
+			inArg = true
+		case unicode.IsSpace(c):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: %q has an unterminated %c quote", errInvalidPostCommand, s, quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: %q has no command", errInvalidPostCommand, s)
+	}
+	return args, nil
+}
+
+// runPostCommand runs the `POST_COMMAND` after a successful export. The
+// command is split into its arguments (see `parsePostCommand`) before the
+// {output}, {format}, {rows}, {spreadsheet_id}, and {sheet_name} placeholders
+// are replaced, so a value with spaces or quotes stays a single argument; the
+// same values, along with the run summary as JSON, are passed as the
+// `EXPORT_OUTPUT`, `EXPORT_FORMAT`, `EXPORT_ROWS`, `EXPORT_SPREADSHEET_ID`,
+// `EXPORT_SHEET_NAME`, and `EXPORT_SUMMARY` environment variables.
+//
+// The command's output is logged once it exits; it's killed along with the
+// processes it started after `POST_COMMAND_TIMEOUT`.
+func (p Project) runPostCommand() error {
+	summary, err := json.Marshal(p.summary)
+	if err != nil {
+		return err
+	}
+	path, format := p.outputPath(), p.exportFormat()
+	rows := strconv.Itoa(p.summary.Rows)
+	replacer := strings.NewReplacer(
+		"{output}", path,
+		"{format}", format,
+		"{rows}", rows,
+		"{spreadsheet_id}", p.config.SpreadsheetId,
+		"{sheet_name}", p.config.SheetName,
+	)
+	args := make([]string, len(p.postCommand))
+	for i, arg := range p.postCommand {
+		args[i] = replacer.Replace(arg)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"EXPORT_OUTPUT="+path,
+		"EXPORT_FORMAT="+format,
+		"EXPORT_ROWS="+rows,
+		"EXPORT_SPREADSHEET_ID="+p.config.SpreadsheetId,
+		"EXPORT_SHEET_NAME="+p.config.SheetName,
+		"EXPORT_SUMMARY="+string(summary),
+	)
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	setProcessGroup(cmd)
+	log.Printf("Running POST_COMMAND: %q", args)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	timer := time.NewTimer(p.config.PostCommandTimeout)
+	defer timer.Stop()
+	select {
+	case err = <-done:
+	case <-timer.C:
+		killProcessGroup(cmd)
+		<-done
+		err = fmt.Errorf("%w after %s", errPostCommandTimeout, p.config.PostCommandTimeout)
+	}
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		log.Printf("POST_COMMAND: %s", scanner.Text())
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// postCommandProject returns a project whose `POST_COMMAND` is a shell script
+// with the `script` body, followed by the `args`.
+func postCommandProject(t *testing.T, script string, args ...string) Project {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "post.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	p := Project{summary: &runSummary{Rows: 42}}
+	p.config.SpreadsheetId = "spreadsheet-id"
+	p.config.SheetName = "Sheet1"
+	p.config.PostCommandTimeout = 10 * time.Second
+	p.postCommand = append([]string{path}, args...)
+	return p
+}
+
+func TestParsePostCommand(t *testing.T) {
+	if args, err := parsePostCommand(""); args != nil || err != nil {
+		t.Errorf("parsePostCommand(\"\") = %v, %v, want nil, nil", args, err)
+	}
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"  gzip  -k {sheet_name}.json ", []string{"gzip", "-k", "{sheet_name}.json"}},
+		{`./upload.sh 'my bucket' "{output}"`, []string{"./upload.sh", "my bucket", "{output}"}},
+		{`sh -c 'gzip -k "$EXPORT_OUTPUT"'`, []string{"sh", "-c", `gzip -k "$EXPORT_OUTPUT"`}},
+		{`echo "say \"hi\" \\ \n"`, []string{"echo", `say "hi" \ \n`}},
+		{`echo a\ b c\'d`, []string{"echo", "a b", "c'd"}},
+		{`echo '' ""`, []string{"echo", "", ""}},
+		{`echo x"y z"'w'`, []string{"echo", "xy zw"}},
+	}
+	for _, tt := range tests {
+		args, err := parsePostCommand(tt.s)
+		if err != nil || strings.Join(args, "|") != strings.Join(tt.want, "|") || len(args) != len(tt.want) {
+			t.Errorf("parsePostCommand(%q) = %q, %v, want %q", tt.s, args, err, tt.want)
+		}
+	}
+	for _, s := range []string{" ", "\t\n", `echo 'x`, `echo "x`, `echo x\`} {
+		if _, err := parsePostCommand(s); !errors.Is(err, errInvalidPostCommand) {
+			t.Errorf("parsePostCommand(%q) error = %v, want errInvalidPostCommand", s, err)
+		}
+	}
+}
+
+func TestRunPostCommandEnv(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	p := postCommandProject(t, `echo "$1 $EXPORT_ROWS $EXPORT_SPREADSHEET_ID $EXPORT_SHEET_NAME $EXPORT_SUMMARY" > "$2"`, "{rows}/{sheet_name}", out)
+	if err := p.runPostCommand(); err != nil {
+		t.Fatalf("runPostCommand: %v", err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	if want := "42/Sheet1 42 spreadsheet-id Sheet1 {"; !strings.HasPrefix(got, want) {
+		t.Errorf("output = %q, want it to start with %q", got, want)
+	}
+	if !strings.Contains(got, `"rows":42`) {
+		t.Errorf("output = %q, want EXPORT_SUMMARY with the rows", got)
+	}
+}
+
+// TestRunPostCommandOutput checks the {output} and {format} placeholders and
+// variables, and that a value with spaces stays a single argument.
+func TestRunPostCommandOutput(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	p := postCommandProject(t, `printf '%s\n' "$#" "$1" "$2" "$EXPORT_OUTPUT" "$EXPORT_FORMAT" > "$3"`, "{output}", "{format}:{sheet_name}", out)
+	p.config.OutputFile = filepath.Join(dir, "my export.csv")
+	p.config.Mode = modeAggregate
+	p.config.AggregateFormat = aggregateFormatCSV
+	p.config.SheetName = "Class Data"
+	if err := p.runPostCommand(); err != nil {
+		t.Fatalf("runPostCommand: %v", err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{"3", p.config.OutputFile, "csv:Class Data", p.config.OutputFile, "csv", ""}, "\n")
+	if got := string(b); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	p.config.OutputFile, p.config.Mode = "", ""
+	if path, format := p.outputPath(), p.exportFormat(); path != outputStdout || format != "text" {
+		t.Errorf("outputPath, exportFormat = %q, %q, want %q, %q", path, format, outputStdout, "text")
+	}
+	p.porcelain = newPorcelainWriter(io.Discard)
+	if format := p.exportFormat(); format != "porcelain" {
+		t.Errorf("exportFormat with --porcelain = %q, want %q", format, "porcelain")
+	}
+}
+
+// TestPostCommandRun exports to an `OUTPUT_FILE`, which the `POST_COMMAND`
+// copies once the run is done.
+func TestPostCommandRun(t *testing.T) {
+	run := newCommandRun(t)
+	run.Setenv("OUTPUT_FILE", "export.jsonl")
+	run.Setenv("POST_COMMAND", `sh -c 'cp "$EXPORT_OUTPUT" copy.jsonl && echo "$EXPORT_FORMAT {rows}" > format'`)
+	stdout, code := run.Run("-porcelain")
+	if code != 0 {
+		t.Fatalf("exit code %d, want 0", code)
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, want the export in OUTPUT_FILE", stdout)
+	}
+	export, err := os.ReadFile(filepath.Join(run.dir, "export.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(export), `"type":"summary"`) {
+		t.Errorf("OUTPUT_FILE = %q, want the porcelain protocol up to the summary", export)
+	}
+	copied, err := os.ReadFile(filepath.Join(run.dir, "copy.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(copied, export) {
+		t.Errorf("POST_COMMAND copied %q, want the OUTPUT_FILE %q", copied, export)
+	}
+	format, err := os.ReadFile(filepath.Join(run.dir, "format"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(format), "porcelain 30\n"; got != want {
+		t.Errorf("POST_COMMAND got %q, want %q", got, want)
+	}
+}
+
+func TestRunPostCommandExitCode(t *testing.T) {
+	p := postCommandProject(t, "exit 3")
+	err := p.runPostCommand()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("runPostCommand error = %v, want exit status 3", err)
+	}
+}
+
+func TestRunPostCommandTimeout(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	p := postCommandProject(t, `sleep 30 & echo $! > "$1"; wait`, pidFile)
+	p.config.PostCommandTimeout = 200 * time.Millisecond
+
+	started := time.Now()
+	if err := p.runPostCommand(); !errors.Is(err, errPostCommandTimeout) {
+		t.Fatalf("runPostCommand error = %v, want errPostCommandTimeout", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("runPostCommand took %s, want it killed after the timeout", elapsed)
+	}
+	b, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the command's child is killed along with it (it can linger as a zombie
+	// until reaped by init)
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("child process %d still running after the timeout", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// processAlive reports whether the process `pid` exists and isn't a zombie.
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
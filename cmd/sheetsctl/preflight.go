@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	oauth2api "google.golang.org/api/oauth2/v2"
+	"google.golang.org/api/option"
+)
+
+// driveScopePrefix matches every Drive scope that allows reading a file's
+// metadata (drive, drive.readonly, drive.file, drive.metadata...).
+const driveScopePrefix = "https://www.googleapis.com/auth/drive"
+
+// preflight performs the cheapest possible metadata call so that missing
+// access is reported before any batches are planned, rather than at batch 1.
+//
+// On a 403/404, the Drive API is used (when the scopes allow it) to tell
+// whether the file is visible to the authorized account at all, and the
+// account's email is looked up so the user knows who to share the file with.
+func (p Project) preflight() {
+	_, err := p.sheetsService.Spreadsheets.Get(p.config.SpreadsheetId).Fields("spreadsheetId").Do()
+	if err == nil {
+		return
+	}
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || (apiErr.Code != http.StatusForbidden && apiErr.Code != http.StatusNotFound) {
+		fatalf("Preflight check failed: %v", err)
+	}
+	account := accountEmail(p.client)
+	if account == "" {
+		account = "the authorized account"
+	}
+	if !hasDriveScope(p.config.Scopes) {
+		fatalf("Preflight check failed: spreadsheet %s doesn't exist or isn't shared with %s (add a Drive scope to SCOPES for more details): %v", p.config.SpreadsheetId, account, err)
+	}
+	fatalf("Preflight check failed: %s", p.driveAccessReport(account, err))
+}
+
+// driveAccessReport explains the Sheets API `sheetsErr` using what the Drive
+// API can see of the spreadsheet file.
+func (p Project) driveAccessReport(account string, sheetsErr error) string {
+	driveService, err := drive.NewService(context.Background(), option.WithHTTPClient(p.client))
+	if err != nil {
+		return fmt.Sprintf("unable to access spreadsheet %s as %s: %v", p.config.SpreadsheetId, account, sheetsErr)
+	}
+	file, err := driveService.Files.Get(p.config.SpreadsheetId).Fields("id,name,mimeType,capabilities(canEdit)").SupportsAllDrives(true).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return fmt.Sprintf("spreadsheet %s either doesn't exist or isn't shared with %s, ask the owner to share it with that account", p.config.SpreadsheetId, account)
+		}
+		return fmt.Sprintf("unable to access spreadsheet %s as %s: %v (Drive API: %v)", p.config.SpreadsheetId, account, sheetsErr, err)
+	}
+	if file.MimeType != "application/vnd.google-apps.spreadsheet" {
+		return fmt.Sprintf("file %s (%q) is shared with %s but isn't a Google Sheets spreadsheet (%s)", file.Id, file.Name, account, file.MimeType)
+	}
+	return fmt.Sprintf("spreadsheet %s (%q) is shared with %s, but the Sheets API denied access (check the Sheets API is enabled for the project): %v", file.Id, file.Name, account, sheetsErr)
+}
+
+// accountEmail returns the email of the authorized account, or an empty string
+// if it can't be retrieved (e.g. the email scope wasn't granted).
+func accountEmail(client *http.Client) string {
+	email, _ := authorizedAccount(client)
+	return email
+}
+
+// authorizedAccount returns the email of the authorized account from the
+// userinfo endpoint, which requires the email scope.
+func authorizedAccount(client *http.Client) (string, error) {
+	service, err := oauth2api.NewService(context.Background(), option.WithHTTPClient(client))
+	if err != nil {
+		return "", err
+	}
+	info, err := service.Userinfo.Get().Do()
+	if err != nil {
+		return "", err
+	}
+	return info.Email, nil
+}
+
+// hasDriveScope reports whether any of the `scopes` grants Drive access.
+func hasDriveScope(scopes []string) bool {
+	for _, scope := range scopes {
+		if strings.HasPrefix(scope, driveScopePrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// driveModifiedTime returns the Drive `modifiedTime` of the spreadsheet file.
+func driveModifiedTime(client *http.Client, spreadsheetId string) (string, error) {
+	driveService, err := drive.NewService(context.Background(), option.WithHTTPClient(client))
+	if err != nil {
+		return "", err
+	}
+	file, err := driveService.Files.Get(spreadsheetId).Fields("modifiedTime").SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", err
+	}
+	return file.ModifiedTime, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// cannedTransport answers requests with the status and JSON body of their URL
+// path, and 404s otherwise, standing in for the Google APIs called directly
+// (i.e. without an endpoint option) like Drive's and the userinfo endpoint.
+type cannedTransport map[string]cannedResponse
+
+type cannedResponse struct {
+	status int
+	body   string
+}
+
+func (t cannedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, ok := t[req.URL.Path]
+	if !ok {
+		resp = cannedResponse{http.StatusNotFound, `{"error": {"code": 404, "message": "Not Found"}}`}
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	return &http.Response{StatusCode: resp.status, Header: header, Body: io.NopCloser(strings.NewReader(resp.body)), Request: req}, nil
+}
+
+func TestHasDriveScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		want   bool
+	}{
+		{[]string{"https://www.googleapis.com/auth/spreadsheets.readonly"}, false},
+		{[]string{"https://www.googleapis.com/auth/spreadsheets", "https://www.googleapis.com/auth/drive.readonly"}, true},
+		{[]string{"https://www.googleapis.com/auth/drive.metadata.readonly"}, true},
+		{[]string{"https://www.googleapis.com/auth/drive"}, true},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := hasDriveScope(tt.scopes); got != tt.want {
+			t.Errorf("hasDriveScope(%q) = %v, want %v", tt.scopes, got, tt.want)
+		}
+	}
+}
+
+func TestDriveAccessReport(t *testing.T) {
+	sheetsErr := errors.New("googleapi: Error 403: The caller does not have permission")
+	tests := []struct {
+		name string
+		file cannedResponse
+		want string
+	}{
+		{"not visible", cannedResponse{http.StatusNotFound, `{"error": {"code": 404, "message": "File not found: id."}}`},
+			"spreadsheet id either doesn't exist or isn't shared with ann@example.com, ask the owner to share it with that account"},
+		{"not a spreadsheet", cannedResponse{http.StatusOK, `{"id": "id", "name": "Report.pdf", "mimeType": "application/pdf"}`},
+			`file id ("Report.pdf") is shared with ann@example.com but isn't a Google Sheets spreadsheet (application/pdf)`},
+		{"shared", cannedResponse{http.StatusOK, `{"id": "id", "name": "Class Data", "mimeType": "application/vnd.google-apps.spreadsheet"}`},
+			`spreadsheet id ("Class Data") is shared with ann@example.com, but the Sheets API denied access (check the Sheets API is enabled for the project): ` + sheetsErr.Error()},
+		{"drive error", cannedResponse{http.StatusInternalServerError, `{"error": {"code": 500, "message": "Backend Error"}}`},
+			"unable to access spreadsheet id as ann@example.com: " + sheetsErr.Error() + " (Drive API: googleapi: Error 500: Backend Error"},
+	}
+	for _, tt := range tests {
+		p := Project{client: &http.Client{Transport: cannedTransport{"/drive/v3/files/id": tt.file}}}
+		p.config.SpreadsheetId = "id"
+		if got := p.driveAccessReport("ann@example.com", sheetsErr); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("%s: driveAccessReport = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAccountEmail(t *testing.T) {
+	tests := []struct {
+		userinfo cannedResponse
+		want     string
+	}{
+		{cannedResponse{http.StatusOK, `{"email": "ann@example.com"}`}, "ann@example.com"},
+		// without the email scope
+		{cannedResponse{http.StatusUnauthorized, `{"error": {"code": 401, "message": "Request is missing required authentication credential."}}`}, ""},
+	}
+	for _, tt := range tests {
+		client := &http.Client{Transport: cannedTransport{"/oauth2/v2/userinfo": tt.userinfo}}
+		if got := accountEmail(client); got != tt.want {
+			t.Errorf("accountEmail with userinfo %d = %q, want %q", tt.userinfo.status, got, tt.want)
+		}
+	}
+}
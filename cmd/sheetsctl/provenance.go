@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// provenance holds the names of the synthetic `PROVENANCE` columns appended to
+// every row so exported rows can be traced back to where they came from.
+type provenance struct {
+	SpreadsheetId string
+	SheetName     string
+	RowNumber     string
+	FetchedAt     string
+	// SourceModifiedAt is only set with `PROVENANCE_MODIFIED_AT`
+	SourceModifiedAt string
+}
+
+// newProvenance returns the provenance column names using `prefix` (e.g. "_"
+// results in "_spreadsheet_id", "_sheet_name", "_row_number", "_fetched_at"),
+// and "_source_modified_at" when `modifiedAt` is set.
+func newProvenance(prefix string, modifiedAt bool) provenance {
+	pv := provenance{
+		SpreadsheetId: prefix + "spreadsheet_id",
+		SheetName:     prefix + "sheet_name",
+		RowNumber:     prefix + "row_number",
+		FetchedAt:     prefix + "fetched_at",
+	}
+	if modifiedAt {
+		pv.SourceModifiedAt = prefix + "source_modified_at"
+	}
+	return pv
+}
+
+// Columns returns the provenance column names in output order.
+func (pv provenance) Columns() []string {
+	columns := []string{pv.SpreadsheetId, pv.SheetName, pv.RowNumber, pv.FetchedAt}
+	if pv.SourceModifiedAt != "" {
+		columns = append(columns, pv.SourceModifiedAt)
+	}
+	return columns
+}
+
+// CheckCollisions returns an error if any sheet header has the same name as a
+// provenance column; use `PROVENANCE_PREFIX` to pick non-colliding names.
+func (pv provenance) CheckCollisions(headers []interface{}) error {
+	for _, column := range pv.Columns() {
+		for _, header := range headers {
+			if fmt.Sprint(header) == column {
+				return fmt.Errorf("provenance column %q collides with a sheet header, set a different PROVENANCE_PREFIX", column)
+			}
+		}
+	}
+	return nil
+}
+
+// Annotate adds the provenance values to the `fields` of the row at the
+// absolute sheet `rowNumber`, fetched at `fetchedAt` from a spreadsheet last
+// modified at `sourceModifiedAt`.
+func (pv provenance) Annotate(fields map[string]interface{}, spreadsheetId, sheetName string, rowNumber int, fetchedAt time.Time, sourceModifiedAt string) {
+	fields[pv.SpreadsheetId] = spreadsheetId
+	fields[pv.SheetName] = sheetName
+	fields[pv.RowNumber] = strconv.Itoa(rowNumber)
+	fields[pv.FetchedAt] = fetchedAt.Format(time.RFC3339)
+	if pv.SourceModifiedAt != "" {
+		fields[pv.SourceModifiedAt] = sourceModifiedAt
+	}
+}
+
+// Values returns the provenance fields of `fields`.
+func (pv provenance) Values(fields map[string]interface{}) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, column := range pv.Columns() {
+		if v, ok := fields[column]; ok {
+			values[column] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProvenance(t *testing.T) {
+	fetchedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		prefix     string
+		modifiedAt bool
+		want       map[string]interface{}
+	}{
+		{"_", false, map[string]interface{}{
+			"_spreadsheet_id": "id", "_sheet_name": "Class Data", "_row_number": "12", "_fetched_at": "2024-03-01T10:00:00Z",
+		}},
+		{"src.", true, map[string]interface{}{
+			"src.spreadsheet_id": "id", "src.sheet_name": "Class Data", "src.row_number": "12", "src.fetched_at": "2024-03-01T10:00:00Z",
+			"src.source_modified_at": "2024-02-28T09:00:00.000Z",
+		}},
+	}
+	for _, tt := range tests {
+		pv := newProvenance(tt.prefix, tt.modifiedAt)
+		fields := map[string]interface{}{"Name": "Ann"}
+		pv.Annotate(fields, "id", "Class Data", 12, fetchedAt, "2024-02-28T09:00:00.000Z")
+		if got := pv.Values(fields); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("prefix %q: provenance = %v, want %v", tt.prefix, got, tt.want)
+		}
+		if fields["Name"] != "Ann" || len(fields) != len(tt.want)+1 {
+			t.Errorf("prefix %q: annotated fields = %v", tt.prefix, fields)
+		}
+		if got := len(pv.Columns()); got != len(tt.want) {
+			t.Errorf("prefix %q: %d columns, want %d", tt.prefix, got, len(tt.want))
+		}
+	}
+}
+
+func TestProvenanceCheckCollisions(t *testing.T) {
+	tests := []struct {
+		headers []interface{}
+		wantErr string
+	}{
+		{[]interface{}{"Name", "spreadsheet_id"}, ""},
+		{[]interface{}{"Name", "_row_number"}, `provenance column "_row_number" collides`},
+		{[]interface{}{"_source_modified_at"}, `provenance column "_source_modified_at" collides`},
+	}
+	pv := newProvenance("_", true)
+	for _, tt := range tests {
+		err := pv.CheckCollisions(tt.headers)
+		if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("CheckCollisions(%v) = %v, want %q", tt.headers, err, tt.wantErr)
+		}
+	}
+	// without PROVENANCE_MODIFIED_AT, the column isn't there to collide
+	if err := newProvenance("_", false).CheckCollisions([]interface{}{"_source_modified_at"}); err != nil {
+		t.Errorf("CheckCollisions without the modified time column = %v", err)
+	}
+}
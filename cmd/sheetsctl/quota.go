@@ -0,0 +1,25 @@
+package main
+
+import "time"
+
+// quotaPressure returns the percentage of the `elapsed` wall time spent
+// sleeping in `backoff`.
+func quotaPressure(backoff, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(backoff) / float64(elapsed) * 100
+}
+
+// checkQuotaPressure records how much of the run was spent backing off from
+// throttled or failing API calls, and warns when it's over
+// `QUOTA_PRESSURE_WARN_PCT`: the configured rates don't fit the quota, or
+// another consumer shares it.
+func (p Project) checkQuotaPressure() {
+	elapsed := p.clock.Now().Sub(p.started)
+	p.summary.BackoffPct = quotaPressure(time.Duration(p.summary.BackoffSeconds*float64(time.Second)), elapsed)
+	if p.config.QuotaPressureWarnPct <= 0 || p.summary.BackoffPct < p.config.QuotaPressureWarnPct {
+		return
+	}
+	p.warn(warnQuotaPressure, "WARNING: %.0f%% of the run (%.1fs of %s) was spent backing off from throttled API calls; raise BATCH_COUNT for fewer calls, check whether another consumer shares the project's quota, or request a quota increase", p.summary.BackoffPct, p.summary.BackoffSeconds, elapsed.Round(time.Second))
+}
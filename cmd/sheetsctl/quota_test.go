@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuotaPressure(t *testing.T) {
+	tests := []struct {
+		backoff, elapsed time.Duration
+		want             float64
+	}{
+		{0, time.Minute, 0},
+		{15 * time.Second, time.Minute, 25},
+		{time.Minute, time.Minute, 100},
+		{time.Second, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := quotaPressure(tt.backoff, tt.elapsed); got != tt.want {
+			t.Errorf("quotaPressure(%s, %s) = %v, want %v", tt.backoff, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+// TestCheckQuotaPressure runs the retries through the fake clock, so the
+// backoff and the run's duration are exact.
+func TestCheckQuotaPressure(t *testing.T) {
+	tests := []struct {
+		name     string
+		warnPct  float64
+		wantWarn bool
+	}{
+		{"over the threshold", 50, true},
+		{"under the threshold", 80, false},
+		{"disabled", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			p := Project{summary: &runSummary{}, clock: clock, started: clock.Now()}
+			p.config.QuotaPressureWarnPct = tt.warnPct
+
+			// 0.5s + 1s + 2s of backoff, in a run of 5s
+			base := &scriptedTransport{statuses: []int{503, 503, 503, 200}}
+			if _, err := getThrough(t, newRetryTransport(base, 5, 50, 0, p.summary, clock)); err != nil {
+				t.Fatal(err)
+			}
+			clock.Advance(1500 * time.Millisecond)
+			p.checkQuotaPressure()
+
+			if p.summary.BackoffSeconds != 3.5 || p.summary.BackoffPct != 70 {
+				t.Errorf("backoff = %vs, %v%%, want 3.5s, 70%%", p.summary.BackoffSeconds, p.summary.BackoffPct)
+			}
+			warned := len(p.summary.Warnings) == 1 && p.summary.Warnings[0].Code == warnQuotaPressure
+			if warned != tt.wantWarn {
+				t.Errorf("warnings = %v, want a quota pressure warning: %v", p.summary.Warnings, tt.wantWarn)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// valuesFields/batchValuesFields are the fields masks of the values reads,
+// which leave out the echoed `majorDimension`; the `range` is kept for
+// `alignValues`.
+const (
+	valuesFields      = "range,values"
+	batchValuesFields = "valueRanges(range,values)"
+)
+
+// parseReadRanges parses `READ_RANGES`, a comma-separated list of A1 ranges of
+// the configured sheet, e.g. "A:C,K:M". Ranges must not overlap, and must
+// share the same row window if they have one (e.g. "A2:C50,K2:M50", or
+// "A2:C,K2:M" to the last row).
+func parseReadRanges(s string) ([]a1.Range, error) {
+	ranges := []a1.Range{}
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		r, err := a1.ParseRange(part)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range ranges {
+			if r.StartRow != other.StartRow || r.EndRow != other.EndRow {
+				return nil, fmt.Errorf("%w: %q doesn't cover the same rows as the other ranges", a1.ErrInvalidRange, part)
+			}
+			if r.StartColumn <= other.EndColumn && other.StartColumn <= r.EndColumn {
+				return nil, fmt.Errorf("%w: %q overlaps the columns of another range", a1.ErrInvalidRange, part)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("%w: no ranges in %q", a1.ErrInvalidRange, s)
+	}
+	return ranges, nil
+}
+
+// fetchRows returns the rows `start-end` of the configured `READ_RANGES`. When
+// there are several ranges, they're fetched with a single `Values.BatchGet`
+// and each row is stitched together from the ranges' sub-rows, so the result
+// looks like a single range of the concatenated columns. With
+// `COLS_PER_REQUEST`, the ranges are split into tiles stitched the same way,
+// but fetched with a request each (unless `TILE_BATCH_GET` is set).
+//
+// A leading byte order mark or zero-width characters are stripped from the
+// first cell of every row.
+func (p Project) fetchRows(start, end int) ([][]interface{}, error) {
+	rows, err := p.fetchRawRows(start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if len(row) > 0 {
+			if s, ok := row[0].(string); ok {
+				row[0] = cells.StripInvisiblePrefix(s)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// fetchRawRows returns the rows `start-end` of the `READ_RANGES` as returned by
+// the API; see `fetchRows`.
+func (p Project) fetchRawRows(start, end int) ([][]interface{}, error) {
+	if p.revision != nil {
+		return p.revision.Rows(p.readRanges, start, end), nil
+	}
+	ranges := p.requestRanges()
+	if len(ranges) == 1 {
+		resp, err := p.getValues(ranges[0].Rows(p.config.SheetName, start, end))
+		if err != nil {
+			return nil, err
+		}
+		return alignValues(ranges[0], start, resp)
+	}
+	readRanges := make([]string, len(ranges))
+	for i, r := range ranges {
+		readRanges[i] = r.Rows(p.config.SheetName, start, end)
+	}
+	segments := make([][][]interface{}, len(ranges))
+	if len(ranges) > len(p.readRanges) && !p.config.TileBatchGet {
+		// the point of tiling is keeping the responses small, so the tiles are
+		// fetched one by one
+		for i, readRange := range readRanges {
+			resp, err := p.getValues(readRange)
+			if err != nil {
+				return nil, err
+			}
+			if segments[i], err = alignValues(ranges[i], start, resp); err != nil {
+				return nil, err
+			}
+		}
+		return zipRows(ranges, segments), nil
+	}
+	resps, err := p.batchGetValues(readRanges)
+	var failed rangeErrors
+	if errors.As(err, &failed) && len(failed) < len(readRanges) && !p.config.Strict {
+		// the other ranges' columns are still worth reading
+		for _, f := range failed {
+			p.warnAt(Warning{Code: warnRangeRead, Row: start, Message: fmt.Sprintf("Unable to read the range %s, leaving its columns blank: %v", f.Range, f.Err)})
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	for i, resp := range resps {
+		if resp == nil {
+			continue
+		}
+		if segments[i], err = alignValues(ranges[i], start, resp); err != nil {
+			return nil, err
+		}
+	}
+	return zipRows(ranges, segments), nil
+}
+
+// alignValues returns the values of `resp`, read from the `requested` range's
+// columns starting at row `start`, padded with blank leading rows and cells
+// if the API trimmed them (its echoed range then starts past the requested
+// one), so `row[i]` always lines up with `header[i]`.
+func alignValues(requested a1.Range, start int, resp *sheets.ValueRange) ([][]interface{}, error) {
+	if resp.Range == "" {
+		return resp.Values, nil
+	}
+	echoed := resp.Range[strings.LastIndex(resp.Range, "!")+1:]
+	column, row, err := a1.ParseCell(strings.Split(echoed, ":")[0])
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the range %q of the response: %w", resp.Range, err)
+	}
+	leadingCells, leadingRows := column-requested.StartColumn, 0
+	if row != 0 {
+		leadingRows = row - start
+	}
+	if leadingCells <= 0 && leadingRows <= 0 {
+		return resp.Values, nil
+	}
+	values := make([][]interface{}, 0, leadingRows+len(resp.Values))
+	for i := 0; i < leadingRows; i++ {
+		values = append(values, []interface{}{})
+	}
+	for _, row := range resp.Values {
+		if leadingCells > 0 && len(row) > 0 {
+			padded := make([]interface{}, leadingCells, leadingCells+len(row))
+			for j := range padded {
+				padded[j] = ""
+			}
+			row = append(padded, row...)
+		}
+		values = append(values, row)
+	}
+	return values, nil
+}
+
+// requestRanges returns the `READ_RANGES` as requested, i.e. split into tiles
+// of up to `COLS_PER_REQUEST` columns when set.
+func (p Project) requestRanges() []a1.Range {
+	return tileColumns(p.readRanges, p.config.ColsPerRequest)
+}
+
+// tileColumns splits the `ranges` into consecutive ranges of up to `width`
+// columns each (0 leaves them as is); stitching the tiles back together with
+// `zipRows` gives the rows of the `ranges`.
+func tileColumns(ranges []a1.Range, width int) []a1.Range {
+	if width <= 0 {
+		return ranges
+	}
+	tiles := []a1.Range{}
+	for _, r := range ranges {
+		for column := r.StartColumn; column <= r.EndColumn; column += width {
+			tile := r
+			tile.StartColumn = column
+			if tile.EndColumn = column + width - 1; tile.EndColumn > r.EndColumn {
+				tile.EndColumn = r.EndColumn
+			}
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles
+}
+
+// zipRows joins the sub-rows of each range's `segments` by row number into
+// complete rows. Short sub-rows are padded with empty cells so the following
+// ranges' values stay aligned with their headers, and rows that are blank in
+// every range stay blank.
+func zipRows(ranges []a1.Range, segments [][][]interface{}) [][]interface{} {
+	rowCount := 0
+	for _, values := range segments {
+		if len(values) > rowCount {
+			rowCount = len(values)
+		}
+	}
+	rows := make([][]interface{}, rowCount)
+	for i := range rows {
+		row := []interface{}{}
+		for s, values := range segments {
+			if i < len(values) && len(values[i]) > 0 {
+				// pad the columns skipped by blank sub-rows before this one
+				for len(row) < offsetOf(ranges, s) {
+					row = append(row, "")
+				}
+				row = append(row, values[i]...)
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// offsetOf returns the position of the first column of the `index` range in
+// the stitched rows.
+func offsetOf(ranges []a1.Range, index int) int {
+	offset := 0
+	for _, r := range ranges[:index] {
+		offset += r.Width()
+	}
+	return offset
+}
+
+// batchGetValues returns the values of each of the `readRanges`, served from
+// the `CACHE_DIR` cache when all of them are cached. When the API rejects the
+// BatchGet, the ranges are read one by one: the error is then `rangeErrors`
+// of the ones that couldn't be, returned along with the others' values.
+func (p Project) batchGetValues(readRanges []string) ([]*sheets.ValueRange, error) {
+	if p.cache != nil {
+		cached := make([]*sheets.ValueRange, 0, len(readRanges))
+		for _, readRange := range readRanges {
+			resp, ok := p.cache.Get(p.config.SpreadsheetId, readRange, valueRenderOption)
+			if !ok {
+				break
+			}
+			cached = append(cached, resp)
+		}
+		if len(cached) == len(readRanges) {
+			return cached, nil
+		}
+	}
+	call := p.sheetsService.Spreadsheets.Values.BatchGet(p.config.SpreadsheetId).Ranges(readRanges...).ValueRenderOption(valueRenderOption)
+	if p.config.FieldsMask {
+		call.Fields(batchValuesFields)
+	}
+	resp, err := call.Do()
+	if err != nil && len(readRanges) > 1 && isBadRequest(err) {
+		log.Printf("BatchGet of %d ranges rejected (%v), reading them one by one", len(readRanges), err)
+		return p.getValuesEach(readRanges)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.ValueRanges) != len(readRanges) {
+		return nil, fmt.Errorf("expected %d value ranges, got %d", len(readRanges), len(resp.ValueRanges))
+	}
+	if p.cache != nil {
+		for i, readRange := range readRanges {
+			if err := p.cache.Put(p.config.SpreadsheetId, readRange, valueRenderOption, resp.ValueRanges[i]); err != nil {
+				p.warn(warnCacheWrite, "Unable to cache values: %v", err)
+			}
+		}
+	}
+	return resp.ValueRanges, nil
+}
+
+// getValuesEach reads each of the `readRanges` on its own, in order, after a
+// BatchGet of them was rejected as a whole: the ranges the API accepts are
+// returned, with nil for the others, which are attributed their error in the
+// returned `rangeErrors`.
+func (p Project) getValuesEach(readRanges []string) ([]*sheets.ValueRange, error) {
+	resps := make([]*sheets.ValueRange, len(readRanges))
+	var failed rangeErrors
+	for i, readRange := range readRanges {
+		resp, err := p.getValues(readRange)
+		if err != nil {
+			failed = append(failed, rangeError{Range: readRange, Err: err})
+			continue
+		}
+		resps[i] = resp
+	}
+	if failed != nil {
+		return resps, failed
+	}
+	return resps, nil
+}
+
+// rangeError is the error of reading one of the ranges of a batch.
+type rangeError struct {
+	Range string
+	Err   error
+}
+
+// rangeErrors are the errors of the ranges of a batch that couldn't be read,
+// in the batch's order. It unwraps to the first one, e.g. to tell whether the
+// API rejected it.
+type rangeErrors []rangeError
+
+func (e rangeErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, f := range e {
+		messages[i] = fmt.Sprintf("range %s: %v", f.Range, f.Err)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e rangeErrors) Unwrap() error {
+	return e[0].Err
+}
+
+// isBadRequest returns whether `err` is the API rejecting the request itself
+// (e.g. an invalid range), which retrying wouldn't fix.
+func isBadRequest(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest
+}
+
+// sheetColumn returns the 0-based sheet column at `position` in the rows
+// stitched from the `READ_RANGES`; it's the inverse of `columnPosition`.
+func (p Project) sheetColumn(position int) int {
+	for _, r := range p.readRanges {
+		if position < r.Width() {
+			return r.StartColumn + position
+		}
+		position -= r.Width()
+	}
+	// past the last range, assume the columns carry on
+	last := p.readRanges[len(p.readRanges)-1]
+	return last.EndColumn + 1 + position
+}
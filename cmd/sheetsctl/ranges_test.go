@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+// fakeSheetsProject returns a project reading the `sheetName` sheet of the
+// "spreadsheet-id" spreadsheet of the fake `server`.
+func fakeSheetsProject(t *testing.T, server *sheetstest.Server, sheetName string) Project {
+	t.Helper()
+	service, err := sheets.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.Endpoint()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Project{sheetsService: service, summary: &runSummary{}}
+	p.config.SpreadsheetId = "spreadsheet-id"
+	p.config.SheetName = sheetName
+	return p
+}
+
+func TestFetchRowsRejectedRange(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Sheet1", [][]interface{}{
+		{"a1", "b1", "c1", "d1", "e1", "f1"},
+		{"a2", "b2", "c2", "d2", "e2", "f2"},
+		{"a3", "", "c3", "d3", "e3", "f3"},
+	})
+	// the API rejects the second range, and so any BatchGet of it
+	bad := "'Sheet1'!C1:D3"
+	server.Fail = func(r *http.Request, ranges []string) (int, string) {
+		for _, readRange := range ranges {
+			if readRange == bad {
+				return http.StatusBadRequest, "Unable to parse range: " + readRange
+			}
+		}
+		return 0, ""
+	}
+	p := fakeSheetsProject(t, server, "Sheet1")
+	p.readRanges = []a1.Range{{StartColumn: 0, EndColumn: 1}, {StartColumn: 2, EndColumn: 3}, {StartColumn: 4, EndColumn: 5}}
+
+	rows, err := p.fetchRows(1, 3)
+	if err != nil {
+		t.Fatalf("fetchRows: %v", err)
+	}
+	want := [][]interface{}{
+		{"a1", "b1", "", "", "e1", "f1"},
+		{"a2", "b2", "", "", "e2", "f2"},
+		{"a3", "", "", "", "e3", "f3"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+	warnings := p.Warnings()
+	if len(warnings) != 1 || warnings[0].Code != warnRangeRead || !strings.Contains(warnings[0].Message, bad) {
+		t.Errorf("warnings = %+v, want one %s warning about %s", warnings, warnRangeRead, bad)
+	}
+	if requests := server.Requests(); len(requests) != 4 {
+		t.Errorf("requests = %q, want the BatchGet and a Get of each range", requests)
+	}
+
+	p.config.Strict = true
+	_, err = p.fetchRows(1, 3)
+	var failed rangeErrors
+	if !errors.As(err, &failed) || len(failed) != 1 || failed[0].Range != bad || !isSheetRangeError(err) {
+		t.Errorf("fetchRows error with STRICT = %v, want the rejected range's", err)
+	}
+}
+
+func TestGetValuesEach(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Sheet1", [][]interface{}{{"a1", "b1"}, {"a2", "b2"}})
+	p := fakeSheetsProject(t, server, "Sheet1")
+
+	resps, err := p.getValuesEach([]string{"'Sheet1'!A1:A2", "'Missing'!A1:A2", "'Sheet1'!B1:B2"})
+	var failed rangeErrors
+	if !errors.As(err, &failed) || len(failed) != 1 || failed[0].Range != "'Missing'!A1:A2" {
+		t.Fatalf("getValuesEach error = %v, want the missing sheet's range", err)
+	}
+	if resps[1] != nil {
+		t.Errorf("resps[1] = %v, want nil for the failed range", resps[1])
+	}
+	if resps[0] == nil || resps[2] == nil || resps[0].Values[1][0] != "a2" || resps[2].Values[1][0] != "b2" {
+		t.Errorf("resps = %v, %v, want the other ranges in order", resps[0], resps[2])
+	}
+}
+
+func TestFetchRowsQuotedSheetName(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Bob's Data", [][]interface{}{{"a1", "b1"}, {"a2", "b2"}})
+	p := fakeSheetsProject(t, server, "Bob's Data")
+	p.readRanges = []a1.Range{{StartColumn: 0, EndColumn: 0}, {StartColumn: 1, EndColumn: 1}}
+
+	rows, err := p.fetchRows(1, 2)
+	if err != nil {
+		t.Fatalf("fetchRows: %v", err)
+	}
+	if want := [][]interface{}{{"a1", "b1"}, {"a2", "b2"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
+
+func TestFetchRowsStripsInvisiblePrefix(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Sheet1", [][]interface{}{
+		{"\ufeffName", "\u200bNote"},
+		{"\u200b\u200dAnn", "\ufefffirst"},
+		{},
+	})
+	p := fakeSheetsProject(t, server, "Sheet1")
+	p.readRanges = []a1.Range{{StartColumn: 0, EndColumn: 1}}
+
+	rows, err := p.fetchRows(1, 3)
+	if err != nil {
+		t.Fatalf("fetchRows: %v", err)
+	}
+	// only the first cell of each row
+	want := [][]interface{}{{"Name", "\u200bNote"}, {"Ann", "\ufefffirst"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestTileColumns(t *testing.T) {
+	ranges, err := parseReadRanges("A2:E9,K2:L9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{0, "A:E,K:L"},
+		{1, "A:A,B:B,C:C,D:D,E:E,K:K,L:L"},
+		{2, "A:B,C:D,E:E,K:L"},
+		{3, "A:C,D:E,K:L"},
+		{10, "A:E,K:L"},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, tile := range tileColumns(ranges, tt.width) {
+			got = append(got, a1.ColumnLetter(tile.StartColumn)+":"+a1.ColumnLetter(tile.EndColumn))
+			// the tiles keep their range's rows
+			if tile.StartRow != ranges[0].StartRow || tile.EndRow != ranges[0].EndRow {
+				t.Errorf("tileColumns(A2:E9,K2:L9, %d) has a tile of rows %d-%d", tt.width, tile.StartRow, tile.EndRow)
+			}
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("tileColumns(A2:E9,K2:L9, %d) = %s, want %s", tt.width, strings.Join(got, ","), tt.want)
+		}
+	}
+}
+
+func TestZipRows(t *testing.T) {
+	ranges, err := parseReadRanges("A:B,C:C,D:E")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		segments [][][]interface{}
+		want     [][]interface{}
+	}{
+		{"complete", [][][]interface{}{{{"a1", "b1"}}, {{"c1"}}, {{"d1", "e1"}}},
+			[][]interface{}{{"a1", "b1", "c1", "d1", "e1"}}},
+		{"short sub-rows are padded", [][][]interface{}{{{"a1"}}, {{}}, {{"d1"}}},
+			[][]interface{}{{"a1", "", "", "d1"}}},
+		{"blank in every range", [][][]interface{}{{{"a1", "b1"}, {}, {"a3", "b3"}}, {{"c1"}}, {}},
+			[][]interface{}{{"a1", "b1", "c1"}, {}, {"a3", "b3"}}},
+		{"only in a later range", [][][]interface{}{{}, {{}, {"c2"}}, {}},
+			[][]interface{}{{}, {"", "", "c2"}}},
+		{"no rows", [][][]interface{}{{}, {}, {}}, [][]interface{}{}},
+	}
+	for _, tt := range tests {
+		if got := zipRows(ranges, tt.segments); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: zipRows = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFetchRowsTiled(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Sheet1", [][]interface{}{
+		{"Name", "Major", "GPA", "Year", "Email"},
+		{"Ann", "", "3.5"},
+		{},
+		{"", "", "", "", "dan@example.com"},
+	})
+	p := fakeSheetsProject(t, server, "Sheet1")
+	p.readRanges = []a1.Range{{StartColumn: 0, EndColumn: 4}}
+	untiled, err := p.fetchRows(1, 4)
+	if err != nil {
+		t.Fatalf("fetchRows: %v", err)
+	}
+	for _, width := range []int{1, 2, 3} {
+		p.config.ColsPerRequest = width
+		rows, err := p.fetchRows(1, 4)
+		if err != nil {
+			t.Errorf("fetchRows with COLS_PER_REQUEST %d: %v", width, err)
+			continue
+		}
+		if !reflect.DeepEqual(rows, untiled) {
+			t.Errorf("fetchRows with COLS_PER_REQUEST %d = %q, want %q", width, rows, untiled)
+		}
+	}
+}
+
+func TestAlignValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		start     int
+		echoed    string
+		values    [][]interface{}
+		want      [][]interface{}
+	}{
+		{"as requested", "A:C", 2, "Sheet1!A2:C4", [][]interface{}{{"a2", "b2"}, {"a3"}},
+			[][]interface{}{{"a2", "b2"}, {"a3"}}},
+		{"leading blank rows", "A:C", 2, "Sheet1!A4:C6", [][]interface{}{{"a4"}},
+			[][]interface{}{{}, {}, {"a4"}}},
+		{"leading blank column", "A:C", 2, "Sheet1!B2:C4", [][]interface{}{{"b2", "c2"}, {}, {"b4"}},
+			[][]interface{}{{"", "b2", "c2"}, {}, {"", "b4"}}},
+		{"both", "K:M", 10, "'Class Data'!M11:M12", [][]interface{}{{"m11"}},
+			[][]interface{}{{}, {"", "", "m11"}}},
+		// an entirely blank range isn't echoed
+		{"no range", "A:C", 2, "", nil, nil},
+		{"whole columns", "A:C", 2, "Sheet1!B:C", [][]interface{}{{"b2"}},
+			[][]interface{}{{"", "b2"}}},
+	}
+	for _, tt := range tests {
+		requested, err := a1.ParseRange(tt.requested)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := alignValues(requested, tt.start, &sheets.ValueRange{Range: tt.echoed, Values: tt.values})
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: alignValues = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := alignValues(a1.Range{}, 1, &sheets.ValueRange{Range: "Sheet1!1A"}); err == nil {
+		t.Errorf("alignValues of an unparsable range succeeded")
+	}
+}
+
+func TestFetchRowsLeadingBlanks(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Sheet1", [][]interface{}{
+		{"", "Name", "Major"},
+		{},
+		{"", "Ann", "Math"},
+	})
+	p := fakeSheetsProject(t, server, "Sheet1")
+	p.readRanges = []a1.Range{{StartColumn: 0, EndColumn: 2}}
+
+	rows, err := p.fetchRows(1, 3)
+	if err != nil {
+		t.Fatalf("fetchRows: %v", err)
+	}
+	want := [][]interface{}{{"", "Name", "Major"}, {}, {"", "Ann", "Math"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
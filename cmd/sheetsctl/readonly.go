@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// googleScopePrefix is the prefix of the Google API scopes.
+const googleScopePrefix = "https://www.googleapis.com/auth/"
+
+// readonlyScopes maps the write-capable scopes to the read-only scope
+// `READ_ONLY` replaces them with.
+var readonlyScopes = map[string]string{
+	googleScopePrefix + "spreadsheets":   googleScopePrefix + "spreadsheets.readonly",
+	googleScopePrefix + "drive":          googleScopePrefix + "drive.readonly",
+	googleScopePrefix + "drive.file":     googleScopePrefix + "drive.readonly",
+	googleScopePrefix + "drive.metadata": googleScopePrefix + "drive.metadata.readonly",
+}
+
+// checkReadOnlyConfig exits if a feature that writes to a spreadsheet is
+// enabled along with `READ_ONLY`.
+func (p Project) checkReadOnlyConfig() {
+	if p.config.RunLogSpreadsheetId != "" {
+		fatalf("RUN_LOG_SPREADSHEET_ID writes to a spreadsheet and can't be used with READ_ONLY")
+	}
+}
+
+// readonlyScopesOf returns the `scopes` with the write-capable ones replaced
+// by their read-only variant; Google scopes that have none are dropped.
+func readonlyScopesOf(scopes []string) []string {
+	var readonly []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		switch {
+		case readonlyScopes[scope] != "":
+			log.Printf("READ_ONLY: using the scope %s instead of %s", readonlyScopes[scope], scope)
+			scope = readonlyScopes[scope]
+		case strings.HasPrefix(scope, googleScopePrefix) && !strings.HasSuffix(scope, readonlySuffix) && !isAccountScope(scope):
+			log.Printf("READ_ONLY: dropping the scope %s, which has no read-only variant", scope)
+			continue
+		}
+		readonly = append(readonly, scope)
+	}
+	return readonly
+}
+
+// isAccountScope reports whether the `scope` is one of the `accountScopes`,
+// which only read the authorized account's profile.
+func isAccountScope(scope string) bool {
+	for _, accountScope := range accountScopes {
+		if scope == accountScope {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnlyTransport rejects every request that could modify data (anything
+// but GET and HEAD, and the POST calls that only read) before it's sent, as a
+// backstop to `READ_ONLY`.
+type readOnlyTransport struct {
+	base http.RoundTripper
+}
+
+func newReadOnlyTransport(base http.RoundTripper) *readOnlyTransport {
+	return &readOnlyTransport{base: base}
+}
+
+func (t *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead && !isReadOnlyPost(req) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("READ_ONLY: refusing to send %s %s", req.Method, redactURL(req.URL))
+	}
+	return t.base.RoundTrip(req)
+}
+
+// isReadOnlyPost reports whether the `req` is one of the POST calls that only
+// read, e.g. the developer metadata search of `METADATA_SKIP`.
+func isReadOnlyPost(req *http.Request) bool {
+	return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/developerMetadata:search")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+func TestReadonlyScopesOf(t *testing.T) {
+	tests := []struct {
+		scopes, want []string
+	}{
+		{[]string{googleScopePrefix + "spreadsheets"}, []string{googleScopePrefix + "spreadsheets.readonly"}},
+		{[]string{" " + googleScopePrefix + "drive.file", googleScopePrefix + "drive.metadata"}, []string{googleScopePrefix + "drive.readonly", googleScopePrefix + "drive.metadata.readonly"}},
+		{[]string{googleScopePrefix + "spreadsheets.readonly", googleScopePrefix + "drive.readonly"}, []string{googleScopePrefix + "spreadsheets.readonly", googleScopePrefix + "drive.readonly"}},
+		// the account scopes only read
+		{[]string{"openid", googleScopePrefix + "userinfo.email"}, []string{"openid", googleScopePrefix + "userinfo.email"}},
+		// Google scopes without a read-only variant are dropped
+		{[]string{googleScopePrefix + "script.projects", googleScopePrefix + "spreadsheets"}, []string{googleScopePrefix + "spreadsheets.readonly"}},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := readonlyScopesOf(tt.scopes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("readonlyScopesOf(%q) = %q, want %q", tt.scopes, got, tt.want)
+		}
+	}
+}
+
+func TestReadOnlyTransport(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	values := [][]interface{}{{"Name"}, {"Ann"}}
+	server.SetValues("spreadsheet-id", "Sheet1", values)
+	client := &http.Client{Transport: newReadOnlyTransport(server.Client().Transport)}
+	service, err := sheets.NewService(context.Background(), option.WithHTTPClient(client), option.WithEndpoint(server.Endpoint()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Spreadsheets.Values.Get("spreadsheet-id", "Sheet1!A1:A2").Do(); err != nil {
+		t.Errorf("Get: %v", err)
+	}
+	// a POST that only reads isn't refused, though the fake doesn't serve it
+	search := &sheets.SearchDeveloperMetadataRequest{DataFilters: []*sheets.DataFilter{{DeveloperMetadataLookup: &sheets.DeveloperMetadataLookup{MetadataKey: "skip"}}}}
+	if _, err := service.Spreadsheets.DeveloperMetadata.Search("spreadsheet-id", search).Do(); err != nil && strings.Contains(err.Error(), "READ_ONLY") {
+		t.Errorf("developer metadata search: %v", err)
+	}
+
+	writes := []struct {
+		name string
+		do   func() error
+	}{
+		{"update", func() error {
+			_, err := service.Spreadsheets.Values.Update("spreadsheet-id", "Sheet1!A2", &sheets.ValueRange{Values: [][]interface{}{{"Bob"}}}).ValueInputOption("RAW").Do()
+			return err
+		}},
+		{"append", func() error {
+			_, err := service.Spreadsheets.Values.Append("spreadsheet-id", "Sheet1!A1", &sheets.ValueRange{Values: [][]interface{}{{"Bob"}}}).ValueInputOption("RAW").Do()
+			return err
+		}},
+		{"clear", func() error {
+			_, err := service.Spreadsheets.Values.Clear("spreadsheet-id", "Sheet1!A1:A2", &sheets.ClearValuesRequest{}).Do()
+			return err
+		}},
+	}
+	for _, tt := range writes {
+		if err := tt.do(); err == nil || !strings.Contains(err.Error(), "READ_ONLY: refusing to send") {
+			t.Errorf("%s error = %v, want it refused", tt.name, err)
+		}
+	}
+	if got := server.Values("spreadsheet-id", "Sheet1"); !reflect.DeepEqual(got, values) {
+		t.Errorf("values after the refused writes = %v, want %v", got, values)
+	}
+	for _, request := range server.Requests() {
+		if !strings.HasPrefix(request, http.MethodGet) && !strings.Contains(request, "developerMetadata:search") {
+			t.Errorf("request %s reached the server", request)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// `REDACT_COLUMNS` strategies:
+//   - hash: the hex SHA-256 of `REDACT_SALT` followed by the value
+//   - mask: the first and last characters are kept, the others replaced by "*"
+//   - drop: the column is removed from the output, header included
+const (
+	redactHash = "hash"
+	redactMask = "mask"
+	redactDrop = "drop"
+)
+
+// redaction is a column of `REDACT_COLUMNS` and its strategy.
+type redaction struct {
+	Column   string
+	Strategy string
+}
+
+// parseRedactColumns parses a comma-separated list of "column:strategy", e.g.
+// "Email:hash,SSN:drop,Name:mask".
+func parseRedactColumns(s string) ([]redaction, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	redactions := []redaction{}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ",") {
+		i := strings.LastIndex(part, ":")
+		if i == -1 {
+			return nil, fmt.Errorf("%q isn't \"column:strategy\"", part)
+		}
+		column, strategy := strings.TrimSpace(part[:i]), strings.ToLower(strings.TrimSpace(part[i+1:]))
+		switch strategy {
+		case redactHash, redactMask, redactDrop:
+		default:
+			return nil, fmt.Errorf("unknown strategy %q for column %q", strategy, column)
+		}
+		if column == "" {
+			return nil, fmt.Errorf("%q has no column", part)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("column %q is redacted twice", column)
+		}
+		seen[column] = true
+		redactions = append(redactions, redaction{Column: column, Strategy: strategy})
+	}
+	return redactions, nil
+}
+
+// redactor redacts the cells of a sheet's `REDACT_COLUMNS`, by header index.
+type redactor struct {
+	strategies map[int]string
+	salt       string
+}
+
+// newRedactor returns a redactor of the `redactions` columns of `headers`, or
+// an error if a column isn't a header (so a renamed column can't silently
+// leak).
+func newRedactor(headers []interface{}, redactions []redaction, salt string) (*redactor, error) {
+	r := &redactor{strategies: map[int]string{}, salt: salt}
+	for _, redaction := range redactions {
+		found := false
+		for i, header := range headers {
+			if fmt.Sprint(header) == redaction.Column {
+				r.strategies[i] = redaction.Strategy
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("column %q not found in the sheet headers", redaction.Column)
+		}
+	}
+	return r, nil
+}
+
+// Dropped returns whether the column at `index` is left out of the output.
+func (r *redactor) Dropped(index int) bool {
+	return r.strategies[index] == redactDrop
+}
+
+// Redacts returns whether the column at `index` is redacted in any way.
+func (r *redactor) Redacts(index int) bool {
+	return r.strategies[index] != ""
+}
+
+// Redact returns the `value` of the column at `index`, redacted if needed;
+// empty values are kept empty.
+func (r *redactor) Redact(index int, value string) string {
+	if value == "" {
+		return value
+	}
+	switch r.strategies[index] {
+	case redactHash:
+		sum := sha256.Sum256([]byte(r.salt + value))
+		return hex.EncodeToString(sum[:])
+	case redactMask:
+		runes := []rune(value)
+		if len(runes) <= 2 {
+			return strings.Repeat("*", len(runes))
+		}
+		return string(runes[0]) + strings.Repeat("*", len(runes)-2) + string(runes[len(runes)-1])
+	case redactDrop:
+		return ""
+	}
+	return value
+}
+
+// RedactCells returns a copy of the row `cells` with its columns redacted, and
+// the dropped ones null, e.g. for the `REJECT_FILE`; the `cells` are returned as
+// is without a redactor.
+func (r *redactor) RedactCells(cells []interface{}) []interface{} {
+	if r == nil || cells == nil {
+		return cells
+	}
+	redacted := make([]interface{}, len(cells))
+	for i, cell := range cells {
+		switch {
+		case r.Dropped(i):
+		case r.Redacts(i):
+			redacted[i] = r.Redact(i, fmt.Sprint(cell))
+		default:
+			redacted[i] = cell
+		}
+	}
+	return redacted
+}
+
+// isRedacted returns whether the `column` is one of the `REDACT_COLUMNS`.
+func (p Project) isRedacted(column string) bool {
+	for _, redaction := range p.redactions {
+		if redaction.Column == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRedactColumns(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []redaction
+		err  string
+	}{
+		{"", nil, ""},
+		{"Email:hash, SSN:DROP ,Name:mask", []redaction{{"Email", redactHash}, {"SSN", redactDrop}, {"Name", redactMask}}, ""},
+		// the strategy follows the last colon
+		{"Time: start:mask", []redaction{{"Time: start", redactMask}}, ""},
+		{"Email", nil, `"Email" isn't "column:strategy"`},
+		{"Email:encrypt", nil, `unknown strategy "encrypt" for column "Email"`},
+		{":hash", nil, `":hash" has no column`},
+		{"Email:hash,Email:mask", nil, `column "Email" is redacted twice`},
+	}
+	for _, tt := range tests {
+		got, err := parseRedactColumns(tt.s)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("parseRedactColumns(%q) error = %v, want %q", tt.s, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseRedactColumns(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	headers := []interface{}{"Name", "Email", "SSN", "Notes"}
+	r, err := newRedactor(headers, []redaction{{"Name", redactMask}, {"Email", redactHash}, {"SSN", redactDrop}}, "pepper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		index int
+		value string
+		want  string
+	}{
+		{0, "Alexandra", "A*******a"},
+		{0, "Ñandú", "Ñ***ú"},
+		{0, "Al", "**"},
+		{0, "A", "*"},
+		// the hex SHA-256 of the salt and the value
+		{1, "ann@example.com", "831740710d6a8225c6ad8e71610353afdfcf9f6a3caa448dc11ab17a513081c2"},
+		{2, "123-45-6789", ""},
+		{3, "kept", "kept"},
+		// empty values stay empty
+		{0, "", ""},
+		{1, "", ""},
+	}
+	for _, tt := range tests {
+		if got := r.Redact(tt.index, tt.value); got != tt.want {
+			t.Errorf("Redact(%s, %q) = %q, want %q", headers[tt.index], tt.value, got, tt.want)
+		}
+	}
+	for i, want := range []bool{false, false, true, false} {
+		if got := r.Dropped(i); got != want {
+			t.Errorf("Dropped(%s) = %v, want %v", headers[i], got, want)
+		}
+	}
+	for i, want := range []bool{true, true, true, false} {
+		if got := r.Redacts(i); got != want {
+			t.Errorf("Redacts(%s) = %v, want %v", headers[i], got, want)
+		}
+	}
+
+	// a renamed column can't silently leak
+	if _, err := newRedactor(headers, []redaction{{"E-mail", redactHash}}, ""); err == nil || !strings.Contains(err.Error(), `"E-mail"`) {
+		t.Errorf("newRedactor of a missing column error = %v, want it named", err)
+	}
+	unsalted, _ := newRedactor(headers, []redaction{{"Email", redactHash}}, "")
+	if got, want := unsalted.Redact(1, "ann@example.com"), "71d4f55f72fa128dfb468a1a3901507c804b74316488744d769d7f4b16696476"; got != want {
+		t.Errorf("Redact without a salt = %q, want %q", got, want)
+	}
+}
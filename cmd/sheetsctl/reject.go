@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// exitRejectThreshold is the exit code of runs rejecting more rows than
+// `REJECT_THRESHOLD`: the good rows were output, but too many were rejected.
+const exitRejectThreshold = 7
+
+// rowRejectedError is returned by the stages for a row they can't handle (e.g.
+// a non-numeric value to aggregate) when it's to be written to the
+// `REJECT_FILE` rather than fail the run or be used as is.
+type rowRejectedError struct {
+	Errors []string
+}
+
+func (e *rowRejectedError) Error() string {
+	return strings.Join(e.Errors, "; ")
+}
+
+// rejectedRow is a line of the `REJECT_FILE`: the row's cells (redacted as by
+// `REDACT_COLUMNS`) and number when rejected while parsing, its fields when rejected by a later stage, and
+// why it was rejected.
+type rejectedRow struct {
+	Row    int                    `json:"row,omitempty"`
+	Cells  []interface{}          `json:"cells,omitempty"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	Errors []string               `json:"errors"`
+}
+
+// rejectWriter writes the rejected rows to the `REJECT_FILE` as JSONL.
+type rejectWriter struct {
+	file *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+}
+
+func newRejectWriter(path string) (*rejectWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &rejectWriter{file: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+func (r *rejectWriter) Write(row rejectedRow) error {
+	return r.enc.Encode(row)
+}
+
+func (r *rejectWriter) Close() error {
+	if err := r.w.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// rejects reports whether rows are to be rejected rather than fail the run or
+// be used as is, i.e. with `REJECT_FILE` and without `STRICT`.
+func (p Project) rejects() bool {
+	return p.rejectFile != nil && !p.config.Strict
+}
+
+// rejectRow writes the row to the `REJECT_FILE` when `err` is a rejection
+// (either a `rowRejectedError` or a cell too large to keep), and reports
+// whether it did; `rowNumber` and `cells` are zero once past parsing.
+func (p Project) rejectRow(err error, rowNumber int, cells []interface{}, fields map[string]interface{}) (bool, error) {
+	if !p.rejects() {
+		return false, nil
+	}
+	var rejected *rowRejectedError
+	switch {
+	case errors.As(err, &rejected):
+	case errors.Is(err, errCellTooLarge):
+		rejected = &rowRejectedError{Errors: []string{err.Error()}}
+	default:
+		return false, nil
+	}
+	p.summary.Rejected++
+	if err := p.rejectFile.Write(rejectedRow{Row: rowNumber, Cells: cells, Fields: fields, Errors: rejected.Errors}); err != nil {
+		return false, fmt.Errorf("unable to write to REJECT_FILE: %w", err)
+	}
+	return true, nil
+}
+
+// parseRejectThreshold parses `REJECT_THRESHOLD`, a number of rows or a
+// percentage of the rows read, e.g. "100" or "5%".
+func parseRejectThreshold(s string) (count int, percent float64, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+	if strings.HasSuffix(s, "%") {
+		percent, err = strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || percent < 0 {
+			return 0, 0, fmt.Errorf("%q, expected e.g. \"100\" or \"5%%\"", s)
+		}
+		return 0, percent, nil
+	}
+	count, err = strconv.Atoi(s)
+	if err != nil || count < 0 {
+		return 0, 0, fmt.Errorf("%q, expected e.g. \"100\" or \"5%%\"", s)
+	}
+	return count, 0, nil
+}
+
+// rejectThresholdExceeded reports whether more rows were rejected than
+// `REJECT_THRESHOLD` allows, warning about it.
+func (p Project) rejectThresholdExceeded() bool {
+	if p.summary.Rejected == 0 || strings.TrimSpace(p.config.RejectThreshold) == "" {
+		return false
+	}
+	count, percent, _ := parseRejectThreshold(p.config.RejectThreshold)
+	read := p.summary.Rows + p.summary.Rejected
+	exceeded := p.summary.Rejected > count
+	if percent > 0 {
+		exceeded = float64(p.summary.Rejected)/float64(read)*100 > percent
+	}
+	if exceeded {
+		p.warn(warnRejectThreshold, "WARNING: %d of the %d rows read were rejected to %s, more than REJECT_THRESHOLD (%s)", p.summary.Rejected, read, p.config.RejectFile, p.config.RejectThreshold)
+	}
+	return exceeded
+}
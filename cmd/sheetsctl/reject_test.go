@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// rejectProject returns a project rejecting rows to a `REJECT_FILE` in a
+// temporary directory, and the file's path.
+func rejectProject(t *testing.T) (Project, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rejects.jsonl")
+	rejectFile, err := newRejectWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Project{summary: &runSummary{}, rejectFile: rejectFile}
+	p.config.RejectFile = path
+	return p, path
+}
+
+// readRejects closes the project's `REJECT_FILE`, and returns its lines.
+func readRejects(t *testing.T, p Project, path string) []string {
+	t.Helper()
+	if err := p.rejectFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+func TestRejectRow(t *testing.T) {
+	p, path := rejectProject(t)
+	tests := []struct {
+		err      error
+		row      int
+		cells    []interface{}
+		fields   map[string]interface{}
+		rejected bool
+	}{
+		{&rowRejectedError{Errors: []string{"non-numeric value", "no join row"}}, 0, nil, map[string]interface{}{"Name": "Ann"}, true},
+		{fmt.Errorf("row 7: %w", errCellTooLarge), 7, []interface{}{"Bob", "xxx"}, nil, true},
+		// other errors still fail the run
+		{errors.New("unable to write"), 8, nil, nil, false},
+	}
+	for _, tt := range tests {
+		rejected, err := p.rejectRow(tt.err, tt.row, tt.cells, tt.fields)
+		if rejected != tt.rejected || err != nil {
+			t.Errorf("rejectRow(%v) = %v, %v, want %v", tt.err, rejected, err, tt.rejected)
+		}
+	}
+	want := []string{
+		`{"fields":{"Name":"Ann"},"errors":["non-numeric value","no join row"]}`,
+		`{"row":7,"cells":["Bob","xxx"],"errors":["row 7: cell too large"]}`,
+	}
+	if got := readRejects(t, p, path); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("REJECT_FILE =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if p.summary.Rejected != 2 {
+		t.Errorf("rejected = %d, want 2", p.summary.Rejected)
+	}
+
+	// STRICT fails the run instead
+	p.config.Strict = true
+	if rejected, _ := p.rejectRow(&rowRejectedError{Errors: []string{"non-numeric value"}}, 0, nil, nil); rejected {
+		t.Errorf("rejectRow with STRICT rejected the row")
+	}
+}
+
+// TestParseRejects rejects the rows a later stage can't handle while parsing,
+// passing on the others.
+func TestParseRejects(t *testing.T) {
+	p, path := rejectProject(t)
+	p.config.NumberMode = cells.NumberModeString
+	p.config.Columns = "1-2"
+	aggregates, err := parseAggregates("sum:GPA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser, err := p.newRowParser(Pipeline{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.reset(p, 1)
+	output := &countingEmitter{}
+	rows := [][]interface{}{{"Name", "GPA"}, {"Ann", "3.5"}, {"Bob", "n/a"}, {"Cy", "4"}}
+	emitter := newAggregatingEmitter(output, p, nil, aggregates)
+	if err := parser.Parse(fetchedBatch{Batch: Batch{1, 4}, Rows: rows}, emitter); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got := readRejects(t, p, path)
+	want := `{"row":3,"cells":["Bob","n/a"],"fields":{"GPA":"n/a","Name":"Bob"},"errors":["non-numeric value \"n/a\" in column \"GPA\" for sum(GPA)"]}`
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("REJECT_FILE = %q, want %q", got, want)
+	}
+	// the rejected row's value wasn't summed
+	if value := emitter.groups[""].accumulators[0].Value(aggregateSum); value != "7.5" {
+		t.Errorf("sum(GPA) = %s, want 7.5", value)
+	}
+}
+
+func TestParseRejectThreshold(t *testing.T) {
+	tests := []struct {
+		s       string
+		count   int
+		percent float64
+		ok      bool
+	}{
+		{"", 0, 0, true},
+		{"100", 100, 0, true},
+		{" 0 ", 0, 0, true},
+		{"5%", 0, 5, true},
+		{"0.5%", 0, 0.5, true},
+		{"-1", 0, 0, false},
+		{"-5%", 0, 0, false},
+		{"five", 0, 0, false},
+		{"5 %", 0, 0, false},
+	}
+	for _, tt := range tests {
+		count, percent, err := parseRejectThreshold(tt.s)
+		if count != tt.count || percent != tt.percent || (err == nil) != tt.ok {
+			t.Errorf("parseRejectThreshold(%q) = %d, %v, %v, want %d, %v", tt.s, count, percent, err, tt.count, tt.percent)
+		}
+	}
+}
+
+func TestRejectThresholdExceeded(t *testing.T) {
+	tests := []struct {
+		threshold      string
+		rows, rejected int
+		want           bool
+	}{
+		{"", 90, 10, false},
+		{"10", 90, 10, false},
+		{"9", 90, 10, true},
+		{"0", 99, 1, true},
+		{"10%", 90, 10, false},
+		{"9.5%", 90, 10, true},
+		// nothing rejected never exceeds it
+		{"0", 100, 0, false},
+	}
+	for _, tt := range tests {
+		p := Project{summary: &runSummary{Rows: tt.rows, Rejected: tt.rejected}}
+		p.config.RejectThreshold = tt.threshold
+		if got := p.rejectThresholdExceeded(); got != tt.want {
+			t.Errorf("%d of %d rows rejected with REJECT_THRESHOLD %q exceeded = %v, want %v", tt.rejected, tt.rows+tt.rejected, tt.threshold, got, tt.want)
+		}
+		if warned := len(p.Warnings()) == 1; warned != tt.want {
+			t.Errorf("REJECT_THRESHOLD %q warned = %v, want %v", tt.threshold, warned, tt.want)
+		}
+	}
+}
+
+// TestParseRejectsRedacted keeps the redacted columns redacted in the
+// REJECT_FILE.
+func TestParseRejectsRedacted(t *testing.T) {
+	p, path := rejectProject(t)
+	p.config.NumberMode = cells.NumberModeString
+	p.config.Columns = "1-3"
+	p.config.MaxCellBytes = 8
+	p.config.MaxCellPolicy = cellPolicyFail
+	p.redactions = []redaction{{Column: "Name", Strategy: redactMask}, {Column: "SSN", Strategy: redactDrop}}
+	parser, err := p.newRowParser(Pipeline{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.reset(p, 1)
+	rows := [][]interface{}{{"Name", "SSN", "Notes"}, {"Ann", "123-45-6789", "too long to keep"}}
+	if err := parser.Parse(fetchedBatch{Batch: Batch{1, 2}, Rows: rows}, &countingEmitter{}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got := readRejects(t, p, path)
+	if len(got) != 1 || !strings.Contains(got[0], `"cells":["A*n",null,"too long to keep"]`) || strings.Contains(got[0], "123-45-6789") {
+		t.Errorf("REJECT_FILE = %q, want the Name masked and the SSN left out", got)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// remoteConfigTimeout bounds fetching the `CONFIG_URL` config at startup.
+const remoteConfigTimeout = 10 * time.Second
+
+// defaultConfigCacheDir is where the last fetched `CONFIG_URL` config is
+// cached when `CONFIG_CACHE_DIR` isn't set.
+const defaultConfigCacheDir = ".cache"
+
+// remoteConfigKeys are the config keys `CONFIG_URL` can set: the ones shaping
+// what's read and how it's output. Whoever controls `CONFIG_URL` mustn't be
+// able to run commands (POST_COMMAND), redirect requests and tokens (e.g.
+// SHEETS_ENDPOINT, OAUTH_TOKEN_URL, RUN_LOG_SPREADSHEET_ID), choose the files
+// read or written (e.g. CREDENTIALS_FILE_NAME, DEBUG_DUMP_DIR, LOG_FILE), set
+// secrets, or loosen the guards (e.g. READ_ONLY, EXPECTED_ACCOUNT).
+var remoteConfigKeys = map[string]bool{
+	"MODE": true, "BATCH_COUNT": true, "SPREADSHEET_ID": true, "SPREADSHEET_IDS": true,
+	"SHEET_NAME": true, "COLUMNS": true, "COLUMN_PROMPT_TIMEOUT": true,
+	"SORT_BY": true, "SORT_CASE_INSENSITIVE": true, "SORT_MEMORY_MB": true, "MEMORY_BUDGET_MB": true,
+	"PROVENANCE": true, "PROVENANCE_PREFIX": true, "PROVENANCE_MODIFIED_AT": true,
+	"SKIP_STRIKETHROUGH": true, "SKIP_BACKGROUND_COLOR": true, "SOFT_DELETE_COLUMN": true,
+	"INCLUDE_VALIDATION": true, "INCLUDE_COLUMN_METADATA": true, "METADATA_TTL": true,
+	"HASH_COLUMN": true, "HASH_COLUMNS": true, "PREFLIGHT": true,
+	"READ_RANGES": true, "COLS_PER_REQUEST": true, "TILE_BATCH_GET": true, "MAX_COLUMNS": true,
+	"DECIMAL_COMMA": true, "LOCALE": true, "TRUE_LITERALS": true, "FALSE_LITERALS": true,
+	"REVISION_ID": true, "AS_OF": true, "EMPTY_SHEET": true, "HEADER_ROW": true, "HEADERS": true,
+	"FIELDS_MASK": true, "MAX_RETRIES": true, "RETRY_BUDGET": true,
+	"CIRCUIT_BREAKER_THRESHOLD": true, "QUOTA_PRESSURE_WARN_PCT": true, "REDACT_COLUMNS": true,
+	"GROUP_BY": true, "AGGREGATES": true, "AGGREGATE_FORMAT": true,
+	"CSV_DELIMITER": true, "CSV_BOM": true, "CSV_CRLF": true, "STRICT": true, "REJECT_THRESHOLD": true,
+	"SLOW_RANGE_THRESHOLD": true, "SLOWEST_BATCHES": true, "EXCLUDE_ROWS": true, "METADATA_SKIP": true,
+	"MAX_CELL_BYTES": true, "MAX_CELL_POLICY": true, "TRIM_CELLS": true, "EMPTY_AS_NULL": true,
+	"COLUMN_OPTIONS": true, "NUMBER_MODE": true, "RAW_SIDE_CHANNEL": true, "RAW_COLUMNS": true,
+	"SANITIZE_FORMULAS": true, "SANITIZE_FORMULAS_ALLOW": true, "MAX_RUN_DURATION": true,
+	"JOIN_SHEET": true, "JOIN_KEY": true, "JOIN_FOREIGN_KEY": true, "JOIN_COLUMNS": true,
+	"JOIN_PREFIX": true, "JOIN_WARN_ROWS": true,
+}
+
+// remoteConfigClient fetches `CONFIG_URL`, replaced by tests.
+var remoteConfigClient = http.DefaultClient
+
+// loadRemoteConfig fetches the env-format config at `CONFIG_URL` (https:// or
+// gs://) and sets the variables that aren't already set, so the local `.env`
+// and environment override it; the variables set are returned. Only the
+// `remoteConfigKeys` are set, the other keys are ignored with a warning. The
+// config is
+// cached in `CONFIG_CACHE_DIR`, and the cached copy used (with a warning) when
+// it can't be fetched.
+func loadRemoteConfig() ([]string, error) {
+	configURL := os.Getenv("CONFIG_URL")
+	if configURL == "" {
+		return nil, nil
+	}
+	cacheDir := os.Getenv("CONFIG_CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = defaultConfigCacheDir
+	}
+	sum := sha256.Sum256([]byte(configURL))
+	cacheFile := filepath.Join(cacheDir, "config-"+hex.EncodeToString(sum[:])[:16]+".env")
+
+	data, err := fetchRemoteConfig(configURL)
+	if err != nil {
+		cached, cacheErr := os.ReadFile(cacheFile)
+		if cacheErr != nil {
+			return nil, fmt.Errorf("unable to fetch %s (and no cached copy): %w", redactSecrets(configURL), err)
+		}
+		log.Printf("WARNING: unable to fetch CONFIG_URL, using the cached copy %s: %v", cacheFile, err)
+		data = cached
+	} else {
+		if err := os.MkdirAll(cacheDir, 0700); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(cacheFile, data, 0600); err != nil {
+			return nil, err
+		}
+	}
+	values, err := godotenv.Unmarshal(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", redactSecrets(configURL), err)
+	}
+	var set []string
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !remoteConfigKeys[key] {
+			log.Printf("WARNING: CONFIG_URL sets %s, which can only be set locally; ignored", key)
+			continue
+		}
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, values[key]); err != nil {
+			return nil, err
+		}
+		set = append(set, key)
+	}
+	return set, nil
+}
+
+// fetchRemoteConfig returns the content at `configURL`; gs:// URLs are read
+// through the Cloud Storage XML API without credentials, so the object must
+// be readable by the run (e.g. public, or a signed https:// URL used instead).
+func fetchRemoteConfig(configURL string) ([]byte, error) {
+	u, err := url.Parse(configURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "https":
+	case "gs":
+		u = &url.URL{Scheme: "https", Host: "storage.googleapis.com", Path: "/" + u.Host + u.Path}
+	default:
+		return nil, fmt.Errorf("unsupported CONFIG_URL scheme %q (want https or gs)", u.Scheme)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), remoteConfigTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := remoteConfigClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
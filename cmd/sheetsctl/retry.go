@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var errCircuitOpen = errors.New("circuit breaker open, not issuing more API calls")
+
+// exitCircuitOpen is the exit code of runs stopped by the circuit breaker.
+const exitCircuitOpen = 4
+
+// maxRetryDelay caps the wait before a retry, including a `Retry-After` asked
+// by the server.
+const maxRetryDelay = 30 * time.Second
+
+// retryTransport retries API calls that fail transiently (network errors, 429
+// and 5xx responses) with exponential backoff, up to `maxRetries` times per
+// call but only as long as the run-wide `budget` of retries lasts, so an
+// outage can't turn into retrying every batch over and over.
+//
+// After `threshold` consecutive failures of the same class (e.g. "503"), the
+// circuit breaker opens and every further call fails with `errCircuitOpen`.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	threshold  int
+	summary    *runSummary
+	clock      clock
+
+	mu          sync.Mutex
+	budget      int
+	lastClass   string
+	consecutive int
+	open        bool
+}
+
+func newRetryTransport(base http.RoundTripper, maxRetries, budget, threshold int, summary *runSummary, clock clock) *retryTransport {
+	return &retryTransport{base: base, maxRetries: maxRetries, budget: budget, threshold: threshold, summary: summary, clock: clock}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		t.mu.Lock()
+		open := t.open
+		t.mu.Unlock()
+		if open {
+			return nil, errCircuitOpen
+		}
+		resp, err := t.base.RoundTrip(req)
+		class := failureClass(resp, err)
+		if class == "" {
+			t.mu.Lock()
+			t.lastClass, t.consecutive = "", 0
+			t.mu.Unlock()
+			return resp, err
+		}
+		// a request with a body can only be retried if the body can be replayed
+		retryable := req.Body == nil || req.GetBody != nil
+		if !t.recordFailure(class) || !retryable || attempt >= t.maxRetries || !t.takeRetry() {
+			return resp, err
+		}
+		delay := retryDelay(resp, attempt)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		t.mu.Lock()
+		left := t.budget
+		t.mu.Unlock()
+		log.Printf("Retrying %s %s after %s in %s (retry %d, %d left in the budget)", req.Method, redactURL(req.URL), class, delay, attempt+1, left)
+		elapsed, stop := t.clock.Timer(delay)
+		select {
+		case <-elapsed:
+		case <-req.Context().Done():
+			stop()
+			return nil, req.Context().Err()
+		}
+		t.mu.Lock()
+		t.summary.BackoffSeconds += delay.Seconds()
+		t.mu.Unlock()
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// recordFailure counts a failure of the `class`, opening the circuit breaker
+// once there's been `threshold` in a row; false is returned when it's open.
+func (t *retryTransport) recordFailure(class string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if class == t.lastClass {
+		t.consecutive++
+	} else {
+		t.lastClass, t.consecutive = class, 1
+	}
+	if t.threshold > 0 && t.consecutive >= t.threshold && !t.open {
+		t.open = true
+		t.summary.CircuitOpen = true
+		log.Printf("Circuit breaker opened after %d consecutive %s failures", t.consecutive, class)
+	}
+	return !t.open
+}
+
+// takeRetry consumes a retry from the budget, if there's any left.
+func (t *retryTransport) takeRetry() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.budget <= 0 {
+		return false
+	}
+	t.budget--
+	t.summary.Retries++
+	return true
+}
+
+// failureClass returns the class of a transient failure, e.g. "503" or
+// "network error"; an empty string is returned when the call succeeded or
+// failed for good (e.g. a 404).
+func failureClass(resp *http.Response, err error) string {
+	if err != nil {
+		return "network error"
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return strconv.Itoa(resp.StatusCode)
+	}
+	return ""
+}
+
+// retryDelay returns how long to wait before the next attempt: the response's
+// `Retry-After` (in seconds) if set, else exponential backoff from 500ms; both
+// up to `maxRetryDelay`.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			if seconds > int(maxRetryDelay/time.Second) {
+				return maxRetryDelay
+			}
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	delay := 500 * time.Millisecond << uint(attempt)
+	if delay > maxRetryDelay || delay <= 0 {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// exitIfCircuitOpen stops the run when `err` is due to the circuit breaker,
+// emitting the summary so far (with `--porcelain`) before exiting with
+// `exitCircuitOpen`.
+func (p Project) exitIfCircuitOpen(err error) {
+	if !errors.Is(err, errCircuitOpen) {
+		return
+	}
+	if p.porcelain != nil {
+		p.porcelain.Emit(porcelainEvent{Type: eventSummary, Summary: p.summary})
+	}
+	fmt.Fprintf(os.Stderr, "Stopping: %v (%d retries used)\n", err, p.summary.Retries)
+	exit(exitCircuitOpen)
+}
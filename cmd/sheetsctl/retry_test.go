@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedTransport answers the requests with the `statuses` in turn (the last
+// one over and over), with the `retryAfter` header when set.
+type scriptedTransport struct {
+	statuses   []int
+	retryAfter string
+	calls      int
+}
+
+func (t *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := t.statuses[len(t.statuses)-1]
+	if t.calls < len(t.statuses) {
+		status = t.statuses[t.calls]
+	}
+	t.calls++
+	header := http.Header{}
+	if t.retryAfter != "" {
+		header.Set("Retry-After", t.retryAfter)
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func getThrough(t *testing.T, transport http.RoundTripper) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "https://sheets.googleapis.com/v4/spreadsheets/id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transport.RoundTrip(req)
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		maxRetries  int
+		budget      int
+		wantStatus  int
+		wantCalls   int
+		wantRetries int
+	}{
+		{"429 then 5xx then ok", []int{429, 503, 200}, 5, 50, 200, 3, 2},
+		{"not transient", []int{404}, 5, 50, 404, 1, 0},
+		{"max retries", []int{500}, 2, 50, 500, 3, 2},
+		{"budget", []int{503}, 5, 1, 503, 2, 1},
+		{"no budget", []int{503}, 5, 0, 503, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &scriptedTransport{statuses: tt.statuses}
+			summary := &runSummary{}
+			resp, err := getThrough(t, newRetryTransport(base, tt.maxRetries, tt.budget, 0, summary, newFakeClock()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus || base.calls != tt.wantCalls || summary.Retries != tt.wantRetries {
+				t.Errorf("status, calls, retries = %d, %d, %d, want %d, %d, %d", resp.StatusCode, base.calls, summary.Retries, tt.wantStatus, tt.wantCalls, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestRetryTransportCircuitBreaker(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{503}}
+	summary := &runSummary{}
+	transport := newRetryTransport(base, 10, 50, 3, summary, newFakeClock())
+
+	resp, err := getThrough(t, transport)
+	if err != nil || resp.StatusCode != 503 {
+		t.Fatalf("first call = %v, %v, want the 503 opening the breaker", resp, err)
+	}
+	if base.calls != 3 || !summary.CircuitOpen {
+		t.Errorf("calls, open = %d, %v, want 3, true", base.calls, summary.CircuitOpen)
+	}
+	if _, err := getThrough(t, transport); !errors.Is(err, errCircuitOpen) {
+		t.Errorf("call with the breaker open error = %v, want errCircuitOpen", err)
+	}
+	if base.calls != 3 {
+		t.Errorf("calls = %d with the breaker open, want no more", base.calls)
+	}
+}
+
+func TestRetryTransportContext(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{429}, retryAfter: "10"}
+	transport := newRetryTransport(base, 5, 50, 0, &runSummary{}, realClock{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://sheets.googleapis.com/v4/spreadsheets/id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := time.Now()
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RoundTrip error = %v, want the context's deadline", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("RoundTrip took %s, want it to stop waiting with the context", elapsed)
+	}
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	clock := newFakeClock()
+	base := &scriptedTransport{statuses: []int{503, 500, 429, 503, 200}}
+	summary := &runSummary{}
+	resp, err := getThrough(t, newRetryTransport(base, 5, 50, 0, summary, clock))
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("RoundTrip = %v, %v, want the 200 after the retries", resp, err)
+	}
+	want := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second}
+	if !reflect.DeepEqual(clock.waits, want) {
+		t.Errorf("waits = %v, want %v", clock.waits, want)
+	}
+	if summary.BackoffSeconds != 7.5 {
+		t.Errorf("BackoffSeconds = %v, want 7.5", summary.BackoffSeconds)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		attempt    int
+		want       time.Duration
+	}{
+		{"", 0, 500 * time.Millisecond},
+		{"", 3, 4 * time.Second},
+		{"", 10, maxRetryDelay},
+		{"", 100, maxRetryDelay},
+		{"2", 0, 2 * time.Second},
+		{"0", 5, 0},
+		{"86400", 0, maxRetryDelay},
+		{strconv.Itoa(1 << 62), 0, maxRetryDelay},
+		{"-1", 1, time.Second},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.retryAfter != "" {
+			resp.Header.Set("Retry-After", tt.retryAfter)
+		}
+		if got := retryDelay(resp, tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(Retry-After %q, %d) = %s, want %s", tt.retryAfter, tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+var errNoRevision = errors.New("no revision found")
+
+// revisionSheet is the configured sheet as of a past Drive revision of the
+// spreadsheet, read from its .xlsx export.
+type revisionSheet struct {
+	Id           string
+	ModifiedTime string
+	rows         [][]interface{}
+}
+
+// RowCount returns the number of rows of the sheet.
+func (s *revisionSheet) RowCount() int {
+	return len(s.rows)
+}
+
+// Rows returns the rows `start-end` of the `ranges`, stitched together like
+// rows read from the Values API (see `zipRows`).
+func (s *revisionSheet) Rows(ranges []a1.Range, start, end int) [][]interface{} {
+	segments := make([][][]interface{}, len(ranges))
+	for i, r := range ranges {
+		values := [][]interface{}{}
+		for n := start; n <= end && n <= len(s.rows); n++ {
+			row := s.rows[n-1]
+			if r.StartColumn >= len(row) {
+				values = append(values, []interface{}{})
+				continue
+			}
+			last := r.EndColumn + 1
+			if last > len(row) {
+				last = len(row)
+			}
+			values = append(values, trimTrailingBlanks(row[r.StartColumn:last]))
+		}
+		// the Values API leaves out trailing blank rows
+		for len(values) > 0 && len(values[len(values)-1]) == 0 {
+			values = values[:len(values)-1]
+		}
+		segments[i] = values
+	}
+	return zipRows(ranges, segments)
+}
+
+// trimTrailingBlanks returns `row` without its trailing empty cells.
+func trimTrailingBlanks(row []interface{}) []interface{} {
+	for len(row) > 0 && row[len(row)-1] == "" {
+		row = row[:len(row)-1]
+	}
+	return row
+}
+
+// checkRevisionConfig exits if a feature that needs the live spreadsheet is
+// enabled along with `REVISION_ID`/`AS_OF`.
+func (p Project) checkRevisionConfig() {
+	if !hasDriveScope(p.config.Scopes) {
+		fatalf("REVISION_ID/AS_OF require a Drive scope in SCOPES (e.g. drive.readonly)")
+	}
+	live := []struct {
+		name    string
+		enabled bool
+	}{
+		{"SKIP_STRIKETHROUGH", p.config.SkipStrikethrough},
+		{"SKIP_BACKGROUND_COLOR", p.config.SkipBackgroundColor != ""},
+		{"INCLUDE_VALIDATION", p.config.IncludeValidation},
+		{"INCLUDE_COLUMN_METADATA", p.config.IncludeColumnMetadata},
+		{"CACHE", p.config.Cache != cacheOff},
+	}
+	for _, feature := range live {
+		if feature.enabled {
+			fatalf("%s reads the live spreadsheet and can't be used with REVISION_ID/AS_OF", feature.name)
+		}
+	}
+}
+
+// loadRevision downloads the `REVISION_ID` revision of the spreadsheet (or the
+// latest one made at or before `AS_OF`) as an .xlsx file, and reads the
+// configured sheet from it.
+func (p Project) loadRevision() (*revisionSheet, error) {
+	driveService, err := drive.NewService(context.Background(), option.WithHTTPClient(p.client))
+	if err != nil {
+		return nil, err
+	}
+	revisionId := p.config.RevisionId
+	if revisionId == "" {
+		revisionId, err = latestRevisionBefore(driveService, p.config.SpreadsheetId, p.config.AsOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	revision, err := driveService.Revisions.Get(p.config.SpreadsheetId, revisionId).Fields("id,modifiedTime,exportLinks").Do()
+	if err != nil {
+		return nil, err
+	}
+	link, ok := revision.ExportLinks[xlsxMimeType]
+	if !ok {
+		return nil, fmt.Errorf("revision %s can't be exported as .xlsx", revision.Id)
+	}
+	resp, err := p.client.Get(link)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to export revision %s: %s", revision.Id, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := readXLSXSheet(data, p.config.SheetName)
+	if err != nil {
+		return nil, err
+	}
+	return &revisionSheet{Id: revision.Id, ModifiedTime: revision.ModifiedTime, rows: rows}, nil
+}
+
+// latestRevisionBefore returns the id of the latest revision of the file
+// modified at or before `asOf`; else an `errNoRevision` error.
+func latestRevisionBefore(driveService *drive.Service, fileId string, asOf time.Time) (string, error) {
+	var latest *drive.Revision
+	var latestTime time.Time
+	err := driveService.Revisions.List(fileId).
+		Fields("nextPageToken,revisions(id,modifiedTime)").
+		Pages(context.Background(), func(list *drive.RevisionList) error {
+			for _, revision := range list.Revisions {
+				t, err := time.Parse(time.RFC3339, revision.ModifiedTime)
+				if err != nil {
+					return err
+				}
+				if !t.After(asOf) && (latest == nil || t.After(latestTime)) {
+					latest, latestTime = revision, t
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return "", err
+	}
+	if latest == nil {
+		return "", fmt.Errorf("%w at or before %s", errNoRevision, asOf.Format(time.RFC3339))
+	}
+	return latest.Id, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+func TestRevisionSheetRows(t *testing.T) {
+	s := &revisionSheet{rows: [][]interface{}{
+		{"Name", "Age", "Major", "Notes"},
+		{"Ann", "", "Math", ""},
+		{},
+		{"", "", "", "late"},
+		{},
+	}}
+	if got := s.RowCount(); got != 5 {
+		t.Errorf("RowCount = %d, want 5", got)
+	}
+	tests := []struct {
+		name       string
+		ranges     []a1.Range
+		start, end int
+		want       [][]interface{}
+	}{
+		{"all columns", []a1.Range{{StartColumn: 0, EndColumn: 3}}, 1, 5, [][]interface{}{
+			{"Name", "Age", "Major", "Notes"},
+			{"Ann", "", "Math"},
+			{},
+			{"", "", "", "late"},
+		}},
+		{"several ranges", []a1.Range{{StartColumn: 0, EndColumn: 0}, {StartColumn: 2, EndColumn: 3}}, 2, 4, [][]interface{}{
+			{"Ann", "Math"},
+			{},
+			{"", "", "late"},
+		}},
+		{"past the last row", []a1.Range{{StartColumn: 0, EndColumn: 1}}, 4, 10, [][]interface{}{}},
+		{"past the last column", []a1.Range{{StartColumn: 5, EndColumn: 7}}, 1, 5, [][]interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Rows(tt.ranges, tt.start, tt.end); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rows(%d-%d) = %q, want %q", tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestRevisionBefore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// the revisions are listed in two pages, out of order
+		if r.URL.Query().Get("pageToken") == "" {
+			w.Write([]byte(`{"nextPageToken": "2", "revisions": [{"id": "3", "modifiedTime": "2024-03-01T00:00:00Z"}, {"id": "1", "modifiedTime": "2024-01-01T00:00:00Z"}]}`))
+			return
+		}
+		w.Write([]byte(`{"revisions": [{"id": "2", "modifiedTime": "2024-02-01T00:00:00Z"}]}`))
+	}))
+	defer server.Close()
+	driveService, err := drive.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		asOf string
+		want string
+	}{
+		{"2024-02-15T00:00:00Z", "2"},
+		{"2024-02-01T00:00:00Z", "2"},
+		{"2024-01-31T23:59:59Z", "1"},
+		{"2025-01-01T00:00:00Z", "3"},
+	}
+	for _, tt := range tests {
+		asOf, err := time.Parse(time.RFC3339, tt.asOf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := latestRevisionBefore(driveService, "spreadsheet-id", asOf); err != nil || got != tt.want {
+			t.Errorf("latestRevisionBefore(%s) = %q, %v, want %q", tt.asOf, got, err, tt.want)
+		}
+	}
+	if _, err := latestRevisionBefore(driveService, "spreadsheet-id", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, errNoRevision) {
+		t.Errorf("latestRevisionBefore the first revision error = %v, want errNoRevision", err)
+	}
+}
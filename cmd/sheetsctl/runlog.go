@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// runLogSchemaVersion is the version of the `runLogHeader` columns, to be
+// bumped whenever they change so consumers can tell the rows apart.
+const runLogSchemaVersion = 1
+
+// runLogHeader is the header of the run log sheet; columns are only ever
+// appended to it.
+var runLogHeader = []interface{}{"schema_version", "timestamp", "spreadsheet_id", "sheet_name", "rows_read", "rows_written", "duration_ms", "status", "exit_code", "error"}
+
+// runLogScope is added to `SCOPES` by `RUN_LOG_SPREADSHEET_ID`, to be able to
+// append to the run log.
+const runLogScope = sheets.SpreadsheetsScope
+
+// runLogger appends a row describing the run to the `RUN_LOG_SHEET` sheet of
+// the `RUN_LOG_SPREADSHEET_ID` spreadsheet, creating the sheet (with its
+// header) if needed. Failing to do so is logged, but doesn't change the run's
+// exit code.
+type runLogger struct {
+	service       *sheets.Service
+	spreadsheetId string
+	sheet         string
+	started       time.Time
+
+	mu       sync.Mutex
+	err      string
+	recorded bool
+}
+
+// runLog is set by `RUN_LOG_SPREADSHEET_ID`; its methods are no-ops when nil.
+var runLog *runLogger
+
+func newRunLogger(service *sheets.Service, spreadsheetId, sheet string, started time.Time) *runLogger {
+	return &runLogger{service: service, spreadsheetId: spreadsheetId, sheet: sheet, started: started}
+}
+
+// SetError sets the error the run failed with.
+func (l *runLogger) SetError(message string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.err = message
+	l.mu.Unlock()
+}
+
+// Record appends the run's row to the run log, with its exit `code`; only the
+// first call does.
+func (l *runLogger) Record(code int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.recorded {
+		return
+	}
+	l.recorded = true
+	if err := l.append(l.row(code)); err != nil {
+		log.Printf("Unable to write the run log: %v", err)
+	}
+}
+
+// row returns the run log row of the run, which exited with `code`.
+func (l *runLogger) row(code int) []interface{} {
+	status := "ok"
+	if code != 0 {
+		status = "failed"
+	}
+	return []interface{}{
+		runLogSchemaVersion,
+		l.started.UTC().Format(time.RFC3339),
+		strings.Join(project.spreadsheetIds(), ","),
+		project.config.SheetName,
+		project.summary.FetchedRows,
+		project.summary.Rows,
+		time.Since(l.started).Milliseconds(),
+		status,
+		code,
+		redactSecrets(l.err),
+	}
+}
+
+// append appends the `row` to the run log sheet, adding the sheet first if
+// it's missing.
+func (l *runLogger) append(row []interface{}) error {
+	if err := l.ensureSheet(); err != nil {
+		return err
+	}
+	readRange := a1.QuoteSheetName(l.sheet) + "!A1"
+	_, err := l.service.Spreadsheets.Values.Append(l.spreadsheetId, readRange, &sheets.ValueRange{Values: [][]interface{}{row}}).
+		ValueInputOption("RAW").
+		InsertDataOption("INSERT_ROWS").
+		Do()
+	return err
+}
+
+// ensureSheet adds the run log sheet, with its header, if it's missing.
+func (l *runLogger) ensureSheet() error {
+	resp, err := l.service.Spreadsheets.Get(l.spreadsheetId).Fields("sheets.properties.title").Do()
+	if err != nil {
+		return err
+	}
+	for _, sheet := range resp.Sheets {
+		if sheet.Properties.Title == l.sheet {
+			return nil
+		}
+	}
+	add := &sheets.Request{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: l.sheet}}}
+	if _, err := l.service.Spreadsheets.BatchUpdate(l.spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: []*sheets.Request{add}}).Do(); err != nil {
+		return fmt.Errorf("unable to add sheet '%s': %w", l.sheet, err)
+	}
+	headerRange := a1.QuoteSheetName(l.sheet) + "!A1"
+	_, err = l.service.Spreadsheets.Values.Update(l.spreadsheetId, headerRange, &sheets.ValueRange{Values: [][]interface{}{runLogHeader}}).
+		ValueInputOption("RAW").
+		Do()
+	return err
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+// setRunLogProject sets the global `project` the run log rows describe, for
+// the duration of the test.
+func setRunLogProject(t *testing.T) {
+	t.Helper()
+	saved := project
+	t.Cleanup(func() { project = saved })
+	project = Project{summary: &runSummary{FetchedRows: 31, Rows: 30}}
+	project.config.SpreadsheetId = "spreadsheet-id"
+	project.config.SheetName = "Class Data"
+}
+
+func TestRunLogRow(t *testing.T) {
+	setRunLogProject(t)
+	started := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	l := newRunLogger(nil, "log-id", "Runs", started)
+
+	row := l.row(0)
+	if len(row) != len(runLogHeader) {
+		t.Fatalf("row has %d columns, want the %d of the header", len(row), len(runLogHeader))
+	}
+	want := []interface{}{runLogSchemaVersion, "2024-03-01T11:00:00Z", "spreadsheet-id", "Class Data", 31, 30, row[6], "ok", 0, ""}
+	if !reflect.DeepEqual(row, want) {
+		t.Errorf("row(0) = %v, want %v", row, want)
+	}
+
+	l.SetError("Unable to read: Authorization: Bearer ya29.secret")
+	row = l.row(3)
+	if row[7] != "failed" || row[8] != 3 {
+		t.Errorf("row(3) status, exit code = %v, %v, want failed, 3", row[7], row[8])
+	}
+	if message := row[9].(string); strings.Contains(message, "ya29.secret") || !strings.HasPrefix(message, "Unable to read") {
+		t.Errorf("row(3) error = %q, want it with the token redacted", message)
+	}
+}
+
+func TestRunLogRecord(t *testing.T) {
+	setRunLogProject(t)
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("log-id", "Runs", [][]interface{}{runLogHeader})
+	service := fakeSheetsProject(t, server, "Runs").sheetsService
+
+	l := newRunLogger(service, "log-id", "Runs", time.Now())
+	l.Record(0)
+	// only the first call appends a row, e.g. once `exit` already recorded it
+	l.Record(1)
+	values := server.Values("log-id", "Runs")
+	if len(values) != 2 {
+		t.Fatalf("run log = %v, want the header and a row", values)
+	}
+	if got := values[1][7]; got != "ok" {
+		t.Errorf("run log status = %v, want ok", got)
+	}
+
+	// a nil logger, without RUN_LOG_SPREADSHEET_ID, does nothing
+	var none *runLogger
+	none.SetError("failed")
+	none.Record(1)
+}
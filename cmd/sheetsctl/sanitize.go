@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// formulaPrefixes are the first characters making spreadsheet apps interpret
+// a cell as a formula (tab and carriage return included, since some apps strip
+// them before looking).
+const formulaPrefixes = "=+-@\t\r"
+
+// sanitizeFormula prefixes the value `s` with a single quote when it would be
+// interpreted as a formula, so it's read back as text, which is also the case
+// after leading whitespace (e.g. " =1+1"); numbers (e.g. "-5") are left as is.
+func sanitizeFormula(s string) string {
+	trimmed := strings.TrimLeftFunc(s, unicode.IsSpace)
+	if !startsFormula(s) && !startsFormula(trimmed) {
+		return s
+	}
+	if _, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return s
+	}
+	return "'" + s
+}
+
+// startsFormula reports whether `s` starts with one of the `formulaPrefixes`.
+func startsFormula(s string) bool {
+	return s != "" && strings.ContainsRune(formulaPrefixes, rune(s[0]))
+}
+
+// sanitizeRows sanitizes the `rows` values with `sanitizeFormula`, except for
+// the `columns` in `SANITIZE_FORMULAS_ALLOW`.
+func (p Project) sanitizeRows(columns []string, rows [][]string) [][]string {
+	allowed := map[string]bool{}
+	for _, column := range p.config.SanitizeFormulasAllow {
+		allowed[strings.TrimSpace(column)] = true
+	}
+	sanitized := make([][]string, len(rows))
+	for i, row := range rows {
+		sanitized[i] = make([]string, len(row))
+		for j, value := range row {
+			if !allowed[columns[j]] {
+				value = sanitizeFormula(value)
+			}
+			sanitized[i][j] = value
+		}
+	}
+	return sanitized
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeFormula(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"", ""},
+		{"Ann", "Ann"},
+		{"a=b", "a=b"},
+		{"=1+1", "'=1+1"},
+		{"+1+1", "'+1+1"},
+		{"-1+1", "'-1+1"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		{"\tAnn", "'\tAnn"},
+		// leading whitespace before the formula
+		{" =1+1", "' =1+1"},
+		{"   +1+1", "'   +1+1"},
+		{"\n-1+1", "'\n-1+1"},
+		// a non-breaking space
+		{"\u00a0@SUM(A1:A2)", "'\u00a0@SUM(A1:A2)"},
+		{" Ann", " Ann"},
+		{"   ", "   "},
+		// numbers
+		{"-5", "-5"},
+		{"+3.5", "+3.5"},
+		{"-1e3", "-1e3"},
+		{" -5", " -5"},
+		{"-", "'-"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFormula(tt.s); got != tt.want {
+			t.Errorf("sanitizeFormula(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeRows(t *testing.T) {
+	p := Project{}
+	p.config.SanitizeFormulasAllow = []string{" Formula "}
+	columns := []string{"Name", "Formula"}
+	rows := [][]string{{"=cmd", "=SUM(A1:A2)"}, {" -2+3", " =1"}}
+	want := [][]string{{"'=cmd", "=SUM(A1:A2)"}, {"' -2+3", " =1"}}
+	if got := p.sanitizeRows(columns, rows); !reflect.DeepEqual(got, want) {
+		t.Errorf("sanitizeRows = %q, want %q", got, want)
+	}
+	if rows[0][0] != "=cmd" {
+		t.Errorf("sanitizeRows changed the rows to %q", rows)
+	}
+}
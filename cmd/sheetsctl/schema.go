@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/spreadsheet"
+)
+
+// The sample spreadsheet's rows are printed as `ExampleStudent` structs; other
+// programs embedding the command's packages register their own schemas with
+// `spreadsheet.RegisterSchema`, matched ahead of this one.
+func init() {
+	spreadsheet.RegisterSchema(studentSchema{})
+}
+
+// studentColumns are the sample spreadsheet's columns mapped to the
+// `ExampleStudent` fields.
+var studentColumns = []string{"Student Name", "Gender", "Class Level", "Home State", "Major", "Extracurricular Activity"}
+
+// studentSchema maps the rows of the sample spreadsheet to `ExampleStudent`;
+// only the sample's full header matches, in order.
+type studentSchema struct{}
+
+func (studentSchema) Name() string {
+	return "ExampleStudent"
+}
+
+func (studentSchema) Match(columns []string) bool {
+	if len(columns) != len(studentColumns) {
+		return false
+	}
+	for i, column := range columns {
+		if column != studentColumns[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (studentSchema) Record(fields map[string]interface{}) interface{} {
+	value := func(column string) string {
+		switch v := fields[column].(type) {
+		case string:
+			return v
+		case nil:
+			return ""
+		default:
+			// a number with `NUMBER_MODE`
+			return fmt.Sprint(v)
+		}
+	}
+	student := ExampleStudent{
+		StudentName:             value("Student Name"),
+		Gender:                  value("Gender"),
+		ClassLevel:              value("Class Level"),
+		HomeState:               value("Home State"),
+		Major:                   value("Major"),
+		ExtracurricularActivity: value("Extracurricular Activity"),
+	}
+	if student == (ExampleStudent{}) {
+		return nil
+	}
+	return student
+}
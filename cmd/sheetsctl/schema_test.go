@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/spreadsheet"
+)
+
+// The sample spreadsheet's rows are printed as `ExampleStudent` structs, see
+// TestSampleRun and testdata/golden/sample.txt.
+
+func TestStudentSchemaMatch(t *testing.T) {
+	reordered := append([]string{studentColumns[1], studentColumns[0]}, studentColumns[2:]...)
+	tests := []struct {
+		columns []string
+		want    bool
+	}{
+		{studentColumns, true},
+		// only the full header, in order, matches
+		{[]string{"Student Name", "Major"}, false},
+		{reordered, false},
+		{append(append([]string{}, studentColumns...), "GPA"), false},
+		{[]string{"Name", "Score", "Notes"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := (studentSchema{}).Match(tt.columns); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.columns, got, tt.want)
+		}
+	}
+	if got := spreadsheet.MatchSchema(studentColumns); got != (studentSchema{}) {
+		t.Errorf("MatchSchema(studentColumns) = %v, want the registered student schema", got)
+	}
+}
+
+func TestStudentSchemaRecord(t *testing.T) {
+	tests := []struct {
+		fields map[string]interface{}
+		want   interface{}
+	}{
+		{
+			map[string]interface{}{"Student Name": "Ann", "Major": "Art", "Home State": nil, "Extra": "ignored"},
+			ExampleStudent{StudentName: "Ann", Major: "Art"},
+		},
+		// a number with NUMBER_MODE
+		{map[string]interface{}{"Class Level": int64(4)}, ExampleStudent{ClassLevel: "4"}},
+		{map[string]interface{}{"Extra": "value"}, nil},
+	}
+	for _, tt := range tests {
+		if got := (studentSchema{}).Record(tt.fields); got != tt.want {
+			t.Errorf("Record(%v) = %#v, want %#v", tt.fields, got, tt.want)
+		}
+	}
+}
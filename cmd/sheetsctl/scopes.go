@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// readonlySuffix is the suffix of the read-only variant of a scope, e.g.
+// ".../auth/spreadsheets.readonly" for ".../auth/spreadsheets".
+const readonlySuffix = ".readonly"
+
+// impliedScopes lists, for the scopes implied by others besides their own
+// full variant, the broader scopes implying them.
+var impliedScopes = map[string][]string{
+	"https://www.googleapis.com/auth/drive.metadata.readonly": {
+		"https://www.googleapis.com/auth/drive.readonly",
+		"https://www.googleapis.com/auth/drive",
+	},
+}
+
+// normalizeScopes returns the `scopes` trimmed, deduplicated, and sorted,
+// without the read-only scopes implied by broader ones (e.g.
+// "spreadsheets.readonly" when there's "spreadsheets"); the dropped scopes
+// are returned too.
+func normalizeScopes(scopes []string) (normalized, dropped []string) {
+	set := map[string]bool{}
+	for _, scope := range scopes {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			set[scope] = true
+		}
+	}
+	for scope := range set {
+		if impliedScope(scope, set) {
+			dropped = append(dropped, scope)
+			continue
+		}
+		normalized = append(normalized, scope)
+	}
+	sort.Strings(normalized)
+	sort.Strings(dropped)
+	return normalized, dropped
+}
+
+// impliedScope reports whether the `scope` is implied by another of the
+// `scopes`.
+func impliedScope(scope string, scopes map[string]bool) bool {
+	if strings.HasSuffix(scope, readonlySuffix) && scopes[strings.TrimSuffix(scope, readonlySuffix)] {
+		return true
+	}
+	for _, broader := range impliedScopes[scope] {
+		if scopes[broader] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeScopes(t *testing.T) {
+	const (
+		sheets         = "https://www.googleapis.com/auth/spreadsheets"
+		sheetsReadonly = "https://www.googleapis.com/auth/spreadsheets.readonly"
+		drive          = "https://www.googleapis.com/auth/drive"
+		driveReadonly  = "https://www.googleapis.com/auth/drive.readonly"
+		driveMetadata  = "https://www.googleapis.com/auth/drive.metadata.readonly"
+		email          = "https://www.googleapis.com/auth/userinfo.email"
+	)
+	tests := []struct {
+		scopes              []string
+		normalized, dropped []string
+	}{
+		{[]string{sheetsReadonly}, []string{sheetsReadonly}, nil},
+		{[]string{sheetsReadonly, sheets}, []string{sheets}, []string{sheetsReadonly}},
+		{[]string{" " + sheets, sheets, "", "openid"}, []string{sheets, "openid"}, nil},
+		{[]string{driveMetadata, driveReadonly, email}, []string{driveReadonly, email}, []string{driveMetadata}},
+		{[]string{drive, driveMetadata, driveReadonly}, []string{drive}, []string{driveMetadata, driveReadonly}},
+		// unrelated read-only scopes are kept
+		{[]string{sheetsReadonly, drive}, []string{drive, sheetsReadonly}, nil},
+		{nil, nil, nil},
+	}
+	for _, tt := range tests {
+		normalized, dropped := normalizeScopes(tt.scopes)
+		if !reflect.DeepEqual(normalized, tt.normalized) || !reflect.DeepEqual(dropped, tt.dropped) {
+			t.Errorf("normalizeScopes(%q) = %q, %q, want %q, %q", tt.scopes, normalized, dropped, tt.normalized, tt.dropped)
+		}
+	}
+}
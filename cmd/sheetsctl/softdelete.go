@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// softDeleteFields is the narrow fields mask used when fetching the formatting
+// needed to detect soft-deleted rows, since grid data is otherwise bulky.
+const softDeleteFields = "sheets(data(startRow,rowData(values(effectiveFormat(textFormat(strikethrough),backgroundColor)))))"
+
+// rgbColor is a color with 0-255 components.
+type rgbColor struct {
+	Red, Green, Blue int
+}
+
+// parseHexColor parses a "#rrggbb" color.
+func parseHexColor(s string) (rgbColor, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return rgbColor{}, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgbColor{}, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+	}
+	return rgbColor{Red: int(n >> 16 & 0xff), Green: int(n >> 8 & 0xff), Blue: int(n & 0xff)}, nil
+}
+
+// matches reports whether the Sheets API `color` (0-1 components, with zero
+// components omitted) is the same color.
+func (c rgbColor) matches(color *sheets.Color) bool {
+	if color == nil {
+		return false
+	}
+	component := func(v float64) int { return int(math.Round(v * 255)) }
+	return component(color.Red) == c.Red && component(color.Green) == c.Green && component(color.Blue) == c.Blue
+}
+
+// softDeletedRows returns the absolute row numbers within the `batch` that are
+// "deleted" by being struck through (`SKIP_STRIKETHROUGH`) or colored with
+// `SKIP_BACKGROUND_COLOR`; only `SOFT_DELETE_COLUMN` is checked when set, else
+// a row matches if ANY of its cells match.
+func (p Project) softDeletedRows(batch Batch, background *rgbColor) (map[int]bool, error) {
+	// span every column of the `READ_RANGES`
+	first, last := p.readRanges[0].StartColumn, p.readRanges[0].EndColumn
+	for _, r := range p.readRanges {
+		if r.StartColumn < first {
+			first = r.StartColumn
+		}
+		if r.EndColumn > last {
+			last = r.EndColumn
+		}
+	}
+	startColumn, endColumn := a1.ColumnLetter(first), a1.ColumnLetter(last)
+	if p.config.SoftDeleteColumn != "" {
+		startColumn, endColumn = p.config.SoftDeleteColumn, p.config.SoftDeleteColumn
+	}
+	readRange := fmt.Sprintf("%s!%s%d:%s%d", a1.QuoteSheetName(p.config.SheetName), startColumn, batch.Start, endColumn, batch.End)
+	resp, err := p.sheetsService.Spreadsheets.Get(p.config.SpreadsheetId).
+		Ranges(readRange).
+		IncludeGridData(true).
+		Fields(softDeleteFields).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	deleted := map[int]bool{}
+	for _, sheet := range resp.Sheets {
+		for _, data := range sheet.Data {
+			for i, rowData := range data.RowData {
+				// `StartRow` is 0-based
+				rowNumber := int(data.StartRow) + i + 1
+				for _, cell := range rowData.Values {
+					format := cell.EffectiveFormat
+					if format == nil {
+						continue
+					}
+					if p.config.SkipStrikethrough && format.TextFormat != nil && format.TextFormat.Strikethrough {
+						deleted[rowNumber] = true
+						break
+					}
+					if background != nil && background.matches(format.BackgroundColor) {
+						deleted[rowNumber] = true
+						break
+					}
+				}
+			}
+		}
+	}
+	return deleted, nil
+}
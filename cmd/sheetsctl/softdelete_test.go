@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		s    string
+		want rgbColor
+	}{
+		{"#ff0000", rgbColor{255, 0, 0}},
+		{" #00FF7f ", rgbColor{0, 255, 127}},
+		{"0000ff", rgbColor{0, 0, 255}},
+	}
+	for _, tt := range tests {
+		got, err := parseHexColor(tt.s)
+		if err != nil || got != tt.want {
+			t.Errorf("parseHexColor(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+	for _, s := range []string{"", "#fff", "#ff00000", "#gg0000"} {
+		if _, err := parseHexColor(s); err == nil {
+			t.Errorf("parseHexColor(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestRGBColorMatches(t *testing.T) {
+	red := rgbColor{255, 0, 0}
+	tests := []struct {
+		color *sheets.Color
+		want  bool
+	}{
+		{nil, false},
+		// the API leaves out the zero components
+		{&sheets.Color{Red: 1}, true},
+		{&sheets.Color{Red: 0.999}, true},
+		{&sheets.Color{Red: 1, Green: 0.001}, true},
+		{&sheets.Color{Red: 1, Green: 0.1}, false},
+		{&sheets.Color{}, false},
+	}
+	for _, tt := range tests {
+		if got := red.matches(tt.color); got != tt.want {
+			t.Errorf("matches(%+v) = %v, want %v", tt.color, got, tt.want)
+		}
+	}
+}
+
+// softDeleteSheet is the grid data of rows 2-6: row 3 is struck through in its
+// second column, row 5 is red, and row 6 is struck through but not in A.
+const softDeleteSheet = `{"sheets": [{"data": [{"startRow": 1, "rowData": [
+	{"values": [{}, {"effectiveFormat": {"textFormat": {}}}]},
+	{"values": [{}, {"effectiveFormat": {"textFormat": {"strikethrough": true}}}]},
+	{},
+	{"values": [{"effectiveFormat": {"backgroundColor": {"red": 1}}}]},
+	{"values": [{}, {}, {"effectiveFormat": {"textFormat": {"strikethrough": true}}}]}
+]}]}]}`
+
+func TestSoftDeletedRows(t *testing.T) {
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.URL.Query()["ranges"]...)
+		if fields := r.URL.Query().Get("fields"); fields != softDeleteFields {
+			t.Errorf("fields = %q, want %q", fields, softDeleteFields)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(softDeleteSheet))
+	}))
+	defer server.Close()
+	service, err := sheets.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	red := rgbColor{255, 0, 0}
+	tests := []struct {
+		name          string
+		strikethrough bool
+		background    *rgbColor
+		column        string
+		wantRange     string
+		want          map[int]bool
+	}{
+		{"strikethrough", true, nil, "", "'Class Data'!B2:F6", map[int]bool{3: true, 6: true}},
+		{"background color", false, &red, "", "'Class Data'!B2:F6", map[int]bool{5: true}},
+		{"both", true, &red, "", "'Class Data'!B2:F6", map[int]bool{3: true, 5: true, 6: true}},
+		{"neither", false, nil, "", "'Class Data'!B2:F6", map[int]bool{}},
+		{"SOFT_DELETE_COLUMN", true, nil, "D", "'Class Data'!D2:D6", map[int]bool{3: true, 6: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges = nil
+			p := Project{sheetsService: service, readRanges: []a1.Range{{StartColumn: 3, EndColumn: 5}, {StartColumn: 1, EndColumn: 2}}}
+			p.config.SpreadsheetId = "spreadsheet-id"
+			p.config.SheetName = "Class Data"
+			p.config.SkipStrikethrough = tt.strikethrough
+			p.config.SoftDeleteColumn = tt.column
+			got, err := p.softDeletedRows(Batch{2, 6}, tt.background)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("softDeletedRows = %v, want %v", got, tt.want)
+			}
+			if len(ranges) != 1 || ranges[0] != tt.wantRange {
+				t.Errorf("ranges = %q, want %q", ranges, tt.wantRange)
+			}
+		})
+	}
+}
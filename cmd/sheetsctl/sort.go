@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+var errInvalidSortBy = errors.New("invalid SORT_BY")
+
+const (
+	// minSortRunRows is the fewest rows spilled at once, so that the sort
+	// doesn't spill every row when the budget is taken by the other stages.
+	minSortRunRows = 1000
+	// maxSortFanIn is the most runs merged at once, i.e. the most files open;
+	// more runs are merged in several passes.
+	maxSortFanIn = 64
+)
+
+func init() {
+	// the cell values that aren't gob's basic types, so they're spilled
+	// without changing type
+	gob.Register(time.Time{})
+}
+
+// sortKey is a single `SORT_BY` column, e.g. "Home State asc".
+type sortKey struct {
+	Column string
+	Desc   bool
+}
+
+// parseSortBy parses a comma-separated list of columns, each optionally
+// followed by "asc" (the default) or "desc", e.g.
+// "Home State asc,Student Name desc".
+func parseSortBy(s string) ([]sortKey, error) {
+	keys := []sortKey{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key := sortKey{Column: part}
+		if i := strings.LastIndex(part, " "); i != -1 {
+			switch strings.ToLower(part[i+1:]) {
+			case "asc":
+				key.Column = strings.TrimSpace(part[:i])
+			case "desc":
+				key.Column = strings.TrimSpace(part[:i])
+				key.Desc = true
+			}
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no columns in %q", errInvalidSortBy, s)
+	}
+	return keys, nil
+}
+
+// sortedRow is a parsed row buffered by the `rowSorter`; `Seq` is the row's
+// position in the sheet so rows with equal sort keys keep their sheet order.
+type sortedRow struct {
+	Seq    int
+	Fields map[string]interface{}
+}
+
+// spilledRow is a `sortedRow` as spilled to disk: gob keeps the types of the
+// values (e.g. int64 and time.Time, which a JSON round-trip changes), but
+// can't encode the nil values of a map, while nil struct fields are just
+// left out.
+type spilledRow struct {
+	Seq    int
+	Fields []spilledField
+}
+
+type spilledField struct {
+	Key   string
+	Value interface{}
+}
+
+// rowSorter buffers rows and emits them ordered by its `keys`. Once the
+// buffered rows exceed `memoryLimit` bytes (estimated), or the `budget` shared
+// with the other stages is exceeded, they're sorted and spilled to a temporary
+// file (once it holds at least `minRunRows`), and the spilled runs are merged
+// when emitting, `fanIn` at a time.
+type rowSorter struct {
+	keys            []sortKey
+	caseInsensitive bool
+	format          cells.Format
+	memoryLimit     int64
+	budget          *memoryBudget
+	minRunRows      int
+	fanIn           int
+
+	seq         int
+	buffer      []sortedRow
+	bufferBytes int64
+	runs        []string
+}
+
+func newRowSorter(keys []sortKey, caseInsensitive bool, format cells.Format, memoryLimit int64, budget *memoryBudget) *rowSorter {
+	return &rowSorter{keys: keys, caseInsensitive: caseInsensitive, format: format, memoryLimit: memoryLimit, budget: budget, minRunRows: minSortRunRows, fanIn: maxSortFanIn}
+}
+
+// Add buffers a row, spilling the buffer to disk if it's grown past the
+// memory limit or the budget (and holds enough rows to be worth a run).
+func (s *rowSorter) Add(fields map[string]interface{}) error {
+	s.seq++
+	s.buffer = append(s.buffer, sortedRow{Seq: s.seq, Fields: fields})
+	rowBytes := estimateRowBytes(fields)
+	s.bufferBytes += rowBytes
+	overBudget := s.budget.Reserve(rowBytes)
+	if ((s.memoryLimit > 0 && s.bufferBytes > s.memoryLimit) || overBudget) && len(s.buffer) >= s.minRunRows {
+		return s.spill()
+	}
+	return nil
+}
+
+// Spilled reports whether any rows had to be written to disk.
+func (s *rowSorter) Spilled() bool {
+	return len(s.runs) > 0
+}
+
+// Runs returns the number of sorted runs spilled to disk.
+func (s *rowSorter) Runs() int {
+	return len(s.runs)
+}
+
+// Len returns the number of rows added to the sorter.
+func (s *rowSorter) Len() int {
+	return s.seq
+}
+
+// spill sorts the buffered rows and writes them to a temporary file.
+func (s *rowSorter) spill() error {
+	s.sortBuffer()
+	name, err := writeSortRun(func(emit func(row sortedRow) error) error {
+		for _, row := range s.buffer {
+			if err := emit(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, name)
+	s.buffer = nil
+	s.budget.Release(s.bufferBytes)
+	s.budget.Spilled()
+	s.bufferBytes = 0
+	return nil
+}
+
+// writeSortRun writes the rows passed by `write` to `emit` to a new temporary
+// file, whose name is returned.
+func writeSortRun(write func(emit func(row sortedRow) error) error) (string, error) {
+	f, err := os.CreateTemp("", "sheet-sort-*.gob")
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	enc := gob.NewEncoder(w)
+	err = write(func(row sortedRow) error {
+		return enc.Encode(newSpilledRow(row))
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func newSpilledRow(row sortedRow) spilledRow {
+	spilled := spilledRow{Seq: row.Seq, Fields: make([]spilledField, 0, len(row.Fields))}
+	for key, value := range row.Fields {
+		spilled.Fields = append(spilled.Fields, spilledField{Key: key, Value: value})
+	}
+	return spilled
+}
+
+func (s *rowSorter) sortBuffer() {
+	sort.Slice(s.buffer, func(i, j int) bool {
+		return s.less(s.buffer[i], s.buffer[j])
+	})
+}
+
+// less orders rows by the sort keys, falling back to the sheet order.
+func (s *rowSorter) less(a, b sortedRow) bool {
+	for _, key := range s.keys {
+		c := compareValues(fmt.Sprint(valueOrEmpty(a.Fields, key.Column)), fmt.Sprint(valueOrEmpty(b.Fields, key.Column)), s.caseInsensitive, s.format)
+		if c == 0 {
+			continue
+		}
+		if key.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return a.Seq < b.Seq
+}
+
+// Emit calls `fn` for every row in sorted order, merging any spilled runs with
+// the rows still in memory, and removes the temporary files; it stops at the
+// first error returned by `fn`.
+//
+// At most `fanIn` runs are merged at once: with more, the first ones are
+// merged into a new run until few enough are left.
+func (s *rowSorter) Emit(fn func(fields map[string]interface{}) error) error {
+	defer func() {
+		for _, name := range s.runs {
+			os.Remove(name)
+		}
+		s.budget.Release(s.bufferBytes)
+	}()
+	for len(s.runs) > s.fanIn {
+		if err := s.mergePass(); err != nil {
+			return err
+		}
+	}
+	s.sortBuffer()
+	runs := make([]*sortRun, 0, len(s.runs)+1)
+	if len(s.buffer) > 0 {
+		runs = append(runs, &sortRun{rows: s.buffer})
+	}
+	for _, name := range s.runs {
+		run, err := openSortRun(name)
+		if err != nil {
+			closeSortRuns(runs)
+			return err
+		}
+		runs = append(runs, run)
+	}
+	defer closeSortRuns(runs)
+	return s.merge(runs, func(row sortedRow) error { return fn(row.Fields) })
+}
+
+// mergePass merges the first `fanIn` spilled runs into a new one, removing
+// them.
+func (s *rowSorter) mergePass() error {
+	names := s.runs[:s.fanIn]
+	runs := make([]*sortRun, 0, len(names))
+	for _, name := range names {
+		run, err := openSortRun(name)
+		if err != nil {
+			closeSortRuns(runs)
+			return err
+		}
+		runs = append(runs, run)
+	}
+	defer closeSortRuns(runs)
+
+	name, err := writeSortRun(func(emit func(row sortedRow) error) error {
+		return s.merge(runs, emit)
+	})
+	if err != nil {
+		return err
+	}
+	for _, merged := range names {
+		os.Remove(merged)
+	}
+	s.runs = append(s.runs[s.fanIn:], name)
+	return nil
+}
+
+// merge calls `fn` for the rows of the sorted `runs` in sorted order, using a
+// k-way merge; each run is closed as soon as it's exhausted.
+func (s *rowSorter) merge(runs []*sortRun, fn func(row sortedRow) error) error {
+	h := &runHeap{less: s.less}
+	// prime each run with its first row
+	for _, run := range runs {
+		ok, err := run.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.runs = append(h.runs, run)
+		}
+	}
+	heap.Init(h)
+	for h.Len() > 0 {
+		run := h.runs[0]
+		if err := fn(run.head); err != nil {
+			return err
+		}
+		ok, err := run.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
+
+// sortRun is a sorted sequence of rows, either in memory or spilled to disk.
+type sortRun struct {
+	head sortedRow
+	rows []sortedRow
+	file *os.File
+	dec  *gob.Decoder
+}
+
+// openSortRun opens the run spilled to the file `name`.
+func openSortRun(name string) (*sortRun, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sortRun{file: f, dec: gob.NewDecoder(bufio.NewReader(f))}, nil
+}
+
+// next advances `head` to the next row of the run; false is returned once the
+// run is exhausted, and its file closed.
+func (r *sortRun) next() (bool, error) {
+	if r.dec == nil {
+		if len(r.rows) == 0 {
+			return false, nil
+		}
+		r.head, r.rows = r.rows[0], r.rows[1:]
+		return true, nil
+	}
+	var row spilledRow
+	if err := r.dec.Decode(&row); err != nil {
+		r.close()
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	r.head = sortedRow{Seq: row.Seq, Fields: make(map[string]interface{}, len(row.Fields))}
+	for _, field := range row.Fields {
+		r.head.Fields[field.Key] = field.Value
+	}
+	return true, nil
+}
+
+// close closes the run's file, if it's spilled and still open.
+func (r *sortRun) close() {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+func closeSortRuns(runs []*sortRun) {
+	for _, run := range runs {
+		run.close()
+	}
+}
+
+// runHeap is a min-heap of runs ordered by their current `head` row, used for
+// the k-way merge.
+type runHeap struct {
+	runs []*sortRun
+	less func(a, b sortedRow) bool
+}
+
+func (h runHeap) Len() int            { return len(h.runs) }
+func (h runHeap) Less(i, j int) bool  { return h.less(h.runs[i].head, h.runs[j].head) }
+func (h runHeap) Swap(i, j int)       { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *runHeap) Push(x interface{}) { h.runs = append(h.runs, x.(*sortRun)) }
+func (h *runHeap) Pop() interface{} {
+	old := h.runs
+	run := old[len(old)-1]
+	h.runs = old[:len(old)-1]
+	return run
+}
+
+// compareValues compares two cell values, numerically if both are numbers,
+// chronologically if both are dates, and as strings otherwise. It returns -1,
+// 0, or 1.
+func compareValues(a, b string, caseInsensitive bool, format cells.Format) int {
+	if x, ok := format.ParseNumber(a); ok {
+		if y, ok := format.ParseNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := format.ParseTime(a); ok {
+		if y, ok := format.ParseTime(b); ok {
+			switch {
+			case x.Before(y):
+				return -1
+			case x.After(y):
+				return 1
+			}
+			return 0
+		}
+	}
+	if caseInsensitive {
+		a, b = strings.ToLower(a), strings.ToLower(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// valueOrEmpty returns the `fields` value for `key`, or an empty string if the
+// row doesn't have it (or it's null).
+func valueOrEmpty(fields map[string]interface{}, key string) interface{} {
+	if v, ok := fields[key]; ok && v != nil {
+		return v
+	}
+	return ""
+}
+
+// estimateRowBytes roughly estimates the memory held by a buffered row.
+func estimateRowBytes(fields map[string]interface{}) int64 {
+	size := int64(64)
+	for k, v := range fields {
+		size += int64(len(k)+len(fmt.Sprint(v))) + 32
+	}
+	return size
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// sortFixture returns `n` rows with every type of cell value the parser
+// outputs, with many equal sort keys.
+func sortFixture(n int) []map[string]interface{} {
+	rows := make([]map[string]interface{}, n)
+	for i := range rows {
+		fields := map[string]interface{}{
+			"id":    int64(i % 37),
+			"score": float64(i%11) / 4,
+			"name":  fmt.Sprintf("name %03d", (i*7)%101),
+			"when":  time.Date(2024, 1, 1+i%28, 0, 0, 0, 0, time.UTC),
+			"flag":  i%2 == 0,
+		}
+		switch i % 3 {
+		case 0:
+			fields["note"] = nil
+		case 1:
+			fields["note"] = ""
+		}
+		rows[i] = fields
+	}
+	return rows
+}
+
+// sortRows adds the `rows` to the `sorter` and returns them as emitted.
+func sortRows(t *testing.T, sorter *rowSorter, rows []map[string]interface{}) []map[string]interface{} {
+	t.Helper()
+	for _, fields := range rows {
+		if err := sorter.Add(fields); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	var sorted []map[string]interface{}
+	err := sorter.Emit(func(fields map[string]interface{}) error {
+		sorted = append(sorted, fields)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+	return sorted
+}
+
+func TestRowSorterSpilledMatchesInMemory(t *testing.T) {
+	keys := []sortKey{{Column: "id"}, {Column: "name", Desc: true}}
+	rows := sortFixture(500)
+	want := sortRows(t, newRowSorter(keys, false, cells.Format{}, 0, nil), rows)
+
+	// a tiny MEMORY_BUDGET spills every run, and merges them in several
+	// passes
+	spilling := newRowSorter(keys, false, cells.Format{}, 0, newMemoryBudget(1))
+	spilling.minRunRows, spilling.fanIn = 10, 4
+	for _, fields := range sortFixture(500) {
+		if err := spilling.Add(fields); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if spilling.Runs() <= spilling.fanIn {
+		t.Fatalf("Runs() = %d, want more than the fan-in of %d", spilling.Runs(), spilling.fanIn)
+	}
+	runs := append([]string(nil), spilling.runs...)
+	got := sortRows(t, spilling, nil)
+
+	if len(got) != len(want) {
+		t.Fatalf("spilled sort emitted %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("row %d = %#v, want %#v", i, got[i], want[i])
+		}
+	}
+	for _, name := range append(runs, spilling.runs...) {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("run %s wasn't removed: %v", name, err)
+		}
+	}
+}
+
+func TestRowSorterMinRunRows(t *testing.T) {
+	sorter := newRowSorter([]sortKey{{Column: "id"}}, false, cells.Format{}, 0, newMemoryBudget(1))
+	sortRows(t, sorter, sortFixture(minSortRunRows-1))
+	if sorter.Spilled() {
+		t.Errorf("spilled %d runs of fewer than %d rows", sorter.Runs(), minSortRunRows)
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	comma := cells.Format{DecimalComma: true}
+	tests := []struct {
+		a, b            string
+		caseInsensitive bool
+		format          cells.Format
+		want            int
+	}{
+		{"9", "10", false, cells.Format{}, -1},
+		{"2.5", "2.50", false, cells.Format{}, 0},
+		{"2024-03-01", "2024-02-29", false, cells.Format{}, 1},
+		{"b", "a", false, cells.Format{}, 1},
+		{"B", "a", false, cells.Format{}, -1},
+		{"B", "a", true, cells.Format{}, 1},
+		// a number and a string compare as strings
+		{"10", "n/a", false, cells.Format{}, -1},
+		// DECIMAL_COMMA
+		{"1.234,5", "999,9", false, comma, 1},
+		{"1.234,5", "999,9", false, cells.Format{}, -1},
+		{"0,5", "0,50", false, comma, 0},
+	}
+	for _, tt := range tests {
+		if got := compareValues(tt.a, tt.b, tt.caseInsensitive, tt.format); got != tt.want {
+			t.Errorf("compareValues(%q, %q, %v, DecimalComma %v) = %d, want %d", tt.a, tt.b, tt.caseInsensitive, tt.format.DecimalComma, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var errInvalidSpreadsheetRef = errors.New("invalid spreadsheet reference")
+
+// spreadsheetIdPattern matches the characters of a spreadsheet ID.
+var spreadsheetIdPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// spreadsheetRef is a spreadsheet ID, and the gid (sheet ID) of one of its
+// tabs when the reference was a URL to it.
+type spreadsheetRef struct {
+	Id     string
+	Gid    int64
+	HasGid bool
+}
+
+// ParseSpreadsheetRef parses a spreadsheet ID, or a spreadsheet URL as copied
+// from the browser or the share dialog, e.g.:
+//   - 1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms
+//   - https://docs.google.com/spreadsheets/d/<id>/edit#gid=1234
+//   - https://docs.google.com/spreadsheets/u/0/d/<id>/edit?usp=sharing
+//   - docs.google.com/spreadsheets/d/<id>/htmlview?gid=1234
+//   - https://drive.google.com/open?id=<id>
+//
+// NOTE: published URLs (`/d/e/<id>/pubhtml`) hold a different ID, which the
+// API doesn't accept.
+func ParseSpreadsheetRef(s string) (spreadsheetRef, error) {
+	s = strings.TrimSpace(s)
+	if spreadsheetIdPattern.MatchString(s) {
+		return spreadsheetRef{Id: s}, nil
+	}
+	raw := s
+	if !strings.Contains(s, "://") {
+		raw = "https://" + s
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return spreadsheetRef{}, fmt.Errorf("%w: %q is neither an ID nor a URL", errInvalidSpreadsheetRef, s)
+	}
+	ref := spreadsheetRef{}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment == "d" && i+1 < len(segments) {
+			ref.Id = segments[i+1]
+			if ref.Id == "e" {
+				return spreadsheetRef{}, fmt.Errorf("%w: %q is a published (/d/e/) URL, use the URL of the spreadsheet itself", errInvalidSpreadsheetRef, s)
+			}
+			break
+		}
+	}
+	if ref.Id == "" {
+		ref.Id = u.Query().Get("id")
+	}
+	if !spreadsheetIdPattern.MatchString(ref.Id) {
+		return spreadsheetRef{}, fmt.Errorf("%w: no spreadsheet ID in %q (host %q, path %q, parsed ID %q)", errInvalidSpreadsheetRef, s, u.Host, u.Path, ref.Id)
+	}
+	// the gid is in the fragment when viewing a tab (e.g. "#gid=0&range=A1"),
+	// or in the query of some shared URLs
+	gid := u.Query().Get("gid")
+	if fragment, err := url.ParseQuery(u.Fragment); err == nil && fragment.Get("gid") != "" {
+		gid = fragment.Get("gid")
+	}
+	if gid != "" {
+		ref.Gid, err = strconv.ParseInt(gid, 10, 64)
+		if err != nil {
+			return spreadsheetRef{}, fmt.Errorf("%w: invalid gid %q in %q (parsed ID %q)", errInvalidSpreadsheetRef, gid, s, ref.Id)
+		}
+		ref.HasGid = true
+	}
+	return ref, nil
+}
+
+// sheetTitleByGid returns the title of the sheet of the configured spreadsheet
+// whose ID is `gid`, or an `errSheetNotFound` error.
+func (p Project) sheetTitleByGid(gid int64) (string, error) {
+	resp, err := p.metadata.Spreadsheet(p.config.SpreadsheetId)
+	if err != nil {
+		return "", err
+	}
+	for _, sheet := range resp.Sheets {
+		if sheet.Properties.SheetId == gid {
+			return sheet.Properties.Title, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no sheet with gid %d", errSheetNotFound, gid)
+}
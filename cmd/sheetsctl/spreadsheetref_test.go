@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestParseSpreadsheetRef(t *testing.T) {
+	const id = "1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms"
+	tests := []struct {
+		s    string
+		want spreadsheetRef
+		err  string
+	}{
+		{id, spreadsheetRef{Id: id}, ""},
+		{"  " + id + "\n", spreadsheetRef{Id: id}, ""},
+		{"https://docs.google.com/spreadsheets/d/" + id + "/edit", spreadsheetRef{Id: id}, ""},
+		{"https://docs.google.com/spreadsheets/d/" + id + "/edit#gid=1234", spreadsheetRef{Id: id, Gid: 1234, HasGid: true}, ""},
+		{"https://docs.google.com/spreadsheets/d/" + id + "/edit#gid=0&range=A1", spreadsheetRef{Id: id, Gid: 0, HasGid: true}, ""},
+		{"https://docs.google.com/spreadsheets/u/0/d/" + id + "/edit?usp=sharing", spreadsheetRef{Id: id}, ""},
+		{"docs.google.com/spreadsheets/d/" + id + "/htmlview?gid=42", spreadsheetRef{Id: id, Gid: 42, HasGid: true}, ""},
+		// the fragment's gid is the tab being viewed
+		{"https://docs.google.com/spreadsheets/d/" + id + "/edit?gid=1#gid=2", spreadsheetRef{Id: id, Gid: 2, HasGid: true}, ""},
+		{"https://drive.google.com/open?id=" + id, spreadsheetRef{Id: id}, ""},
+		{"https://docs.google.com/spreadsheets/d/e/2PACX-1vQ/pubhtml", spreadsheetRef{}, "is a published (/d/e/) URL"},
+		{"https://docs.google.com/spreadsheets/d/" + id + "/edit#gid=abc", spreadsheetRef{}, `invalid gid "abc"`},
+		{"https://docs.google.com/document/", spreadsheetRef{}, "no spreadsheet ID"},
+		{"not an id!", spreadsheetRef{}, "is neither an ID nor a URL"},
+		{"", spreadsheetRef{}, "is neither an ID nor a URL"},
+	}
+	for _, tt := range tests {
+		got, err := ParseSpreadsheetRef(tt.s)
+		if tt.err != "" {
+			if !errors.Is(err, errInvalidSpreadsheetRef) || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseSpreadsheetRef(%q) error = %v, want %q", tt.s, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSpreadsheetRef(%q) = %+v, %v, want %+v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestSheetTitleByGid(t *testing.T) {
+	p := Project{metadata: newMetadataCache(time.Hour, func(spreadsheetId string) (*sheets.Spreadsheet, error) {
+		return &sheets.Spreadsheet{Sheets: []*sheets.Sheet{
+			{Properties: &sheets.SheetProperties{SheetId: 0, Title: "Class Data"}},
+			{Properties: &sheets.SheetProperties{SheetId: 1234, Title: "Notes"}},
+		}}, nil
+	})}
+	tests := []struct {
+		gid   int64
+		want  string
+		found bool
+	}{
+		{0, "Class Data", true},
+		{1234, "Notes", true},
+		{99, "", false},
+	}
+	for _, tt := range tests {
+		got, err := p.sheetTitleByGid(tt.gid)
+		if got != tt.want || (err == nil) != tt.found || (err != nil && !errors.Is(err, errSheetNotFound)) {
+			t.Errorf("sheetTitleByGid(%d) = %q, %v, want %q", tt.gid, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+// runSummary collects the counts reported at the end of a run.
+type runSummary struct {
+	Rows        int  `json:"rows"`
+	Batches     int  `json:"batches"`
+	BlankRows   int  `json:"blank_rows"`
+	SoftDeleted int  `json:"soft_deleted"`
+	CacheHits   int  `json:"cache_hits"`
+	CacheMisses int  `json:"cache_misses"`
+	SortSpilled bool `json:"sort_spilled"`
+	// Retries is how much of the `RETRY_BUDGET` was used, and CircuitOpen
+	// whether the circuit breaker stopped the run
+	Retries     int  `json:"retries"`
+	CircuitOpen bool `json:"circuit_open"`
+	// BackoffSeconds is the time spent waiting before retries, and BackoffPct
+	// its share of the run's wall time (see `QUOTA_PRESSURE_WARN_PCT`)
+	BackoffSeconds float64 `json:"backoff_seconds"`
+	BackoffPct     float64 `json:"backoff_pct"`
+	// SlowBatches is the number of batches slower than `SLOW_RANGE_THRESHOLD`,
+	// and SlowestBatches the `SLOWEST_BATCHES` slowest ones
+	SlowBatches    int           `json:"slow_batches"`
+	SlowestBatches []batchTiming `json:"slowest_batches,omitempty"`
+	// Redacted is the strategy of each of the `REDACT_COLUMNS`
+	Redacted map[string]string `json:"redacted,omitempty"`
+	// Sources is the number of rows read from each of the `SPREADSHEET_IDS`
+	Sources map[string]int `json:"sources,omitempty"`
+	// Warnings are the run's warnings, identical ones counted once
+	Warnings []Warning `json:"warnings,omitempty"`
+	// Account is the authorized account, with `SHOW_ACCOUNT`/`EXPECTED_ACCOUNT`
+	Account string `json:"account,omitempty"`
+	// ExcludedRows is the number of rows left out by `EXCLUDE_ROWS`, including
+	// those of the batches that weren't fetched
+	ExcludedRows int `json:"excluded_rows"`
+	// LargestCell is the largest cell read, and OversizedCells the number of
+	// cells larger than `MAX_CELL_BYTES`
+	LargestCell    *cellSize `json:"largest_cell,omitempty"`
+	OversizedCells int       `json:"oversized_cells"`
+	// FetchedRows is the number of rows fetched, header and blank rows
+	// included
+	FetchedRows int `json:"fetched_rows"`
+	// SourceFiles is how fresh each spreadsheet read is, from Drive
+	SourceFiles map[string]*sourceFile `json:"source_files,omitempty"`
+	// Partial is set when `MAX_RUN_DURATION` (or a `stop` through the
+	// `CONTROL_SOCKET`) stopped the run, NextBatch being
+	// the first batch left unread
+	Partial   bool   `json:"partial"`
+	NextBatch string `json:"next_batch,omitempty"`
+	// PeakMemoryBytes is the peak (estimated) memory held by the stages
+	// buffering data, and Spills how many times they spilled to disk, with
+	// `MEMORY_BUDGET_MB`
+	PeakMemoryBytes int64 `json:"peak_memory_bytes,omitempty"`
+	Spills          int   `json:"spills,omitempty"`
+	// Rejected is the number of rows written to the `REJECT_FILE`
+	Rejected int `json:"rejected,omitempty"`
+	// Build is the build that produced the run
+	Build buildInfo `json:"build"`
+}
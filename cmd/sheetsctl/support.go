@@ -0,0 +1,287 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits of what's kept for the support bundle, so a long run doesn't grow it
+// (or the memory it needs) without bound.
+const (
+	supportLogLines  = 200
+	supportMaxCalls  = 500
+	supportBodyBytes = 64 << 10
+)
+
+// secretConfigFields are the `Config` fields masked in the support bundle.
+var secretConfigFields = map[string]bool{
+	"RedactSalt": true,
+}
+
+// secretPatterns match secrets that might show up in logs, URLs, or response
+// bodies: OAuth access and refresh tokens, client secrets, API keys, and
+// credentials in URLs.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`ya29\.[A-Za-z0-9._-]+`),
+	regexp.MustCompile(`1//[A-Za-z0-9._-]+`),
+	regexp.MustCompile(`GOCSPX-[A-Za-z0-9_-]+`),
+	regexp.MustCompile(`AIza[A-Za-z0-9_-]{35}`),
+	regexp.MustCompile(`(?i)((?:^|[^a-z0-9_])"?(?:access_token|refresh_token|client_secret|id_token|key|code|code_verifier|password)"?\s*[:=]\s*"?)[^"&\s,}]+`),
+	regexp.MustCompile(`(?i)(bearer\s+)[^\s"]+`),
+	regexp.MustCompile(`(://)[^/@\s]+(@)`),
+}
+
+// redactSecrets masks everything in `s` matching the `secretPatterns`, but
+// their first and second groups (e.g. the JSON key); every string written to
+// the support bundle goes through it.
+func redactSecrets(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+			groups := append(pattern.FindStringSubmatch(match), "", "")
+			return groups[1] + redactedValue + groups[2]
+		})
+	}
+	return s
+}
+
+// supportBundle collects what's needed to look into a run's behavior, and
+// writes it as a zip when the run ends (successfully or not, see `exit`):
+//   - config.json: the config, with the `secretConfigFields` masked
+//   - plan.txt: the batches planned for each spreadsheet
+//   - calls.jsonl: the method, URL, status, and duration of the API calls
+//     (and the response bodies with `DEBUG_BODIES`)
+//   - summary.json: the run summary
+//   - log.txt: the last log lines
+//   - environment.json: the OS, Go version, and binary version
+type supportBundle struct {
+	path    string
+	config  *Config
+	summary *runSummary
+	started time.Time
+
+	mu    sync.Mutex
+	plan  []string
+	calls []supportCall
+	logs  []string
+	// partial holds the log output since the last complete line
+	partial []byte
+}
+
+// supportCall is an API call, as written to calls.jsonl.
+type supportCall struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	Status     int       `json:"status,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Body       string    `json:"body,omitempty"`
+}
+
+// bundle is set by `--support-bundle`; its methods are no-ops when nil.
+var bundle *supportBundle
+
+func newSupportBundle(path string, summary *runSummary) *supportBundle {
+	return &supportBundle{path: path, summary: summary, started: time.Now()}
+}
+
+// Write collects a log line (see `log.SetOutput`).
+func (b *supportBundle) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.partial = append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i == -1 {
+			break
+		}
+		b.logs = append(b.logs, redactSecrets(string(b.partial[:i])))
+		b.partial = b.partial[i+1:]
+	}
+	if len(b.logs) > supportLogLines {
+		b.logs = b.logs[len(b.logs)-supportLogLines:]
+	}
+	return len(p), nil
+}
+
+// SetConfig sets the config the run uses, as it's when the bundle is written.
+func (b *supportBundle) SetConfig(config *Config) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.config = config
+	b.mu.Unlock()
+}
+
+// AddPlan records the batches planned for a spreadsheet.
+func (b *supportBundle) AddPlan(spreadsheetId string, batches []Batch) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.plan = append(b.plan, fmt.Sprintf("spreadsheetId: %s", spreadsheetId))
+	for _, batch := range batches {
+		b.plan = append(b.plan, fmt.Sprintf("\trows %d-%d", batch.Start, batch.End))
+	}
+}
+
+// Transport returns a transport recording the calls made through `base`.
+func (b *supportBundle) Transport(base http.RoundTripper) http.RoundTripper {
+	if b == nil {
+		return base
+	}
+	return supportTransport{base: base, bundle: b}
+}
+
+type supportTransport struct {
+	base   http.RoundTripper
+	bundle *supportBundle
+}
+
+func (t supportTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	call := supportCall{Time: start, Method: req.Method, URL: redactSecrets(redactURL(req.URL)), DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		call.Error = redactSecrets(err.Error())
+	} else {
+		call.Status = resp.StatusCode
+		if t.bundle.config != nil && t.bundle.config.DebugBodies {
+			// the body is read up to the limit, and put back for the caller
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, supportBodyBytes))
+			resp.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+			call.Body = redactSecrets(string(body))
+		}
+	}
+	t.bundle.mu.Lock()
+	t.bundle.calls = append(t.bundle.calls, call)
+	if len(t.bundle.calls) > supportMaxCalls {
+		t.bundle.calls = t.bundle.calls[len(t.bundle.calls)-supportMaxCalls:]
+	}
+	t.bundle.mu.Unlock()
+	return resp, err
+}
+
+// Close writes the bundle, for a run exiting with `code`.
+func (b *supportBundle) Close(code int) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	add := func(name string, content []byte) error {
+		f, err := w.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(content)
+		return err
+	}
+	addJSON := func(name string, v interface{}) error {
+		content, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		return add(name, content)
+	}
+	var calls bytes.Buffer
+	encoder := json.NewEncoder(&calls)
+	for _, call := range b.calls {
+		if err := encoder.Encode(call); err != nil {
+			return err
+		}
+	}
+	logs := b.logs
+	if len(b.partial) > 0 {
+		logs = append(logs, redactSecrets(string(b.partial)))
+	}
+	config := Config{}
+	if b.config != nil {
+		config = *b.config
+	}
+	for _, err := range []error{
+		addJSON("config.json", redactedConfig(config)),
+		add("plan.txt", []byte(strings.Join(b.plan, "\n")+"\n")),
+		add("calls.jsonl", calls.Bytes()),
+		addJSON("summary.json", b.summary),
+		add("log.txt", []byte(strings.Join(logs, "\n")+"\n")),
+		addJSON("environment.json", map[string]interface{}{
+			"os":         runtime.GOOS,
+			"arch":       runtime.GOARCH,
+			"go_version": runtime.Version(),
+			"version":    buildVersion(),
+			"args":       redactSecrets(strings.Join(os.Args, " ")),
+			"started":    b.started,
+			"duration":   time.Since(b.started).String(),
+			"exit_code":  code,
+		}),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(b.path, buf.Bytes(), 0600)
+}
+
+// redactedConfig returns the `config` fields by name, with the
+// `secretConfigFields` masked and the others passed through `redactSecrets`.
+func redactedConfig(config Config) map[string]interface{} {
+	fields := map[string]interface{}{}
+	v := reflect.ValueOf(config)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		value := v.Field(i).Interface()
+		switch {
+		case secretConfigFields[name]:
+			if !v.Field(i).IsZero() {
+				value = redactedValue
+			}
+		case v.Field(i).Kind() == reflect.String:
+			value = redactSecrets(v.Field(i).String())
+		default:
+			b, _ := json.Marshal(value)
+			value = json.RawMessage(redactSecrets(string(b)))
+		}
+		fields[name] = value
+	}
+	return fields
+}
+
+// fatalf is `log.Fatalf`, writing the support bundle before exiting.
+func fatalf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+	runLog.SetError(fmt.Sprintf(format, args...))
+	exit(1)
+}
+
+// exit exits with `code`, recording the run log and writing the support bundle
+// first since deferred calls don't run on `os.Exit`.
+func exit(code int) {
+	runLog.Record(code)
+	if err := bundle.Close(code); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write the support bundle: %v\n", err)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,13 @@
+//go:build linux
+// +build linux
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// isTerminal reports whether the file descriptor `fd` is a terminal, as
+// golang.org/x/term's IsTerminal does.
+func isTerminal(fd int) bool {
+	_, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	return err == nil
+}
@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package main
+
+import "golang.org/x/sys/windows"
+
+// isTerminal reports whether the file descriptor `fd` is a console, as
+// golang.org/x/term's IsTerminal does.
+func isTerminal(fd int) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(fd), &mode) == nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// exitTimeLimit is the exit code of runs stopped by `MAX_RUN_DURATION` (or
+// through the `CONTROL_SOCKET`): the rows read so far were output, but the run
+// is partial.
+const exitTimeLimit = 6
+
+// timeLimitReached reports whether the `MAX_RUN_DURATION` of the run is up,
+// in which case the `batch` about to be read is recorded as the first one left
+// unread and the run marked as partial.
+func (p Project) timeLimitReached(batch Batch) bool {
+	if p.deadline.IsZero() || p.clock.Now().Before(p.deadline) {
+		return false
+	}
+	p.stopBefore(batch, fmt.Sprintf("MAX_RUN_DURATION %s reached", p.config.MaxRunDuration))
+	return true
+}
+
+// stopBefore marks the run as partial, the `batch` being the first one left
+// unread, logging the `reason` once.
+func (p Project) stopBefore(batch Batch, reason string) {
+	if !p.summary.Partial {
+		p.summary.Partial = true
+		p.summary.NextBatch = p.config.SpreadsheetId + " " + p.batchRanges(batch)
+		log.Printf("%s, stopping before %s", reason, p.summary.NextBatch)
+	}
+}
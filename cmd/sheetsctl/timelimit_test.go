@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+func TestTimeLimitReached(t *testing.T) {
+	clock := newFakeClock()
+	p := Project{clock: clock, summary: &runSummary{}, readRanges: []a1.Range{{EndColumn: 2}}}
+	p.config.SpreadsheetId = "spreadsheet-id"
+	p.config.SheetName = "Class Data"
+	p.config.MaxRunDuration = time.Minute
+	if p.timeLimitReached(Batch{1, 10}) {
+		t.Errorf("timeLimitReached without MAX_RUN_DURATION = true, want false")
+	}
+
+	p.deadline = clock.Now().Add(time.Minute)
+	clock.Advance(59 * time.Second)
+	if p.timeLimitReached(Batch{1, 10}) || p.summary.Partial {
+		t.Errorf("timeLimitReached before the deadline = true, want false")
+	}
+	clock.Advance(time.Second)
+	if !p.timeLimitReached(Batch{11, 20}) {
+		t.Fatalf("timeLimitReached at the deadline = false, want true")
+	}
+	if want := "spreadsheet-id 'Class Data'!A11:C20"; !p.summary.Partial || p.summary.NextBatch != want {
+		t.Errorf("summary = partial %v, next batch %q, want partial, %q", p.summary.Partial, p.summary.NextBatch, want)
+	}
+	// the first batch left unread is kept
+	p.timeLimitReached(Batch{21, 30})
+	if want := "spreadsheet-id 'Class Data'!A11:C20"; p.summary.NextBatch != want {
+		t.Errorf("next batch = %q, want %q", p.summary.NextBatch, want)
+	}
+}
+
+// TestTimeLimitRun stops a run whose first batch outlasts MAX_RUN_DURATION,
+// having output its rows.
+func TestTimeLimitRun(t *testing.T) {
+	run := newCommandRun(t)
+	var slow sync.Once
+	run.sheets.Fail = func(r *http.Request, ranges []string) (int, string) {
+		if len(ranges) > 0 {
+			slow.Do(func() { time.Sleep(1500 * time.Millisecond) })
+		}
+		return 0, ""
+	}
+	run.Setenv("BATCH_COUNT", "10")
+	run.Setenv("MAX_RUN_DURATION", "1s")
+	stdout, stderr, code := run.RunStderr("-porcelain")
+	if code != exitTimeLimit {
+		t.Fatalf("exit code %d, want %d", code, exitTimeLimit)
+	}
+	checkGolden(t, "time_limit.jsonl", stdout)
+	if want := "MAX_RUN_DURATION 1s reached, stopping before spreadsheet-id 'Class Data'!A11:Z20"; !strings.Contains(stderr, want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr, want)
+	}
+}
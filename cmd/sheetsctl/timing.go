@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiMeter counts the API calls made through its `Transport`, and the bytes of
+// their (decompressed) response bodies.
+type apiMeter struct {
+	mu    sync.Mutex
+	calls int
+	bytes int64
+}
+
+// Bytes returns the response bytes read so far.
+func (m *apiMeter) Bytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bytes
+}
+
+// Transport returns a transport metering the calls made through `base`.
+func (m *apiMeter) Transport(base http.RoundTripper) http.RoundTripper {
+	return meteredTransport{base: base, meter: m}
+}
+
+type meteredTransport struct {
+	base  http.RoundTripper
+	meter *apiMeter
+}
+
+func (t meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	t.meter.mu.Lock()
+	t.meter.calls++
+	t.meter.mu.Unlock()
+	if err == nil {
+		resp.Body = meteredBody{ReadCloser: resp.Body, meter: t.meter}
+	}
+	return resp, err
+}
+
+type meteredBody struct {
+	io.ReadCloser
+	meter *apiMeter
+}
+
+func (b meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.meter.mu.Lock()
+	b.meter.bytes += int64(n)
+	b.meter.mu.Unlock()
+	return n, err
+}
+
+// batchTiming is how long a batch took to fetch, and how much was fetched.
+type batchTiming struct {
+	Ranges     string `json:"ranges"`
+	StartRow   int    `json:"start_row"`
+	EndRow     int    `json:"end_row"`
+	DurationMs int64  `json:"duration_ms"`
+	Bytes      int64  `json:"bytes"`
+}
+
+// batchRanges returns the A1 ranges requested for the `batch`, e.g.
+// "'Class Data'!A1:Z3".
+func (p Project) batchRanges(batch Batch) string {
+	ranges := p.requestRanges()
+	readRanges := make([]string, len(ranges))
+	for i, r := range ranges {
+		readRanges[i] = r.Rows(p.config.SheetName, batch.Start, batch.End)
+	}
+	return strings.Join(readRanges, ",")
+}
+
+// recordBatchTiming warns about the batch right away when it's slower than
+// `SLOW_RANGE_THRESHOLD`, and keeps it if it's one of the `SLOWEST_BATCHES`
+// of the run.
+func (p Project) recordBatchTiming(batch Batch, duration time.Duration, bytes int64) {
+	timing := batchTiming{Ranges: p.batchRanges(batch), StartRow: batch.Start, EndRow: batch.End, DurationMs: duration.Milliseconds(), Bytes: bytes}
+	if p.config.SlowRangeThreshold > 0 && duration >= p.config.SlowRangeThreshold {
+		p.summary.SlowBatches++
+		p.warnAt(Warning{Code: warnSlowRange, Row: batch.Start, Message: fmt.Sprintf("Slow range %s (rows %d-%d): fetched %d bytes in %s", timing.Ranges, batch.Start, batch.End, bytes, duration.Round(time.Millisecond))})
+	}
+	if p.config.SlowestBatches <= 0 {
+		return
+	}
+	slowest := append(p.summary.SlowestBatches, timing)
+	sort.SliceStable(slowest, func(i, j int) bool {
+		return slowest[i].DurationMs > slowest[j].DurationMs
+	})
+	if len(slowest) > p.config.SlowestBatches {
+		slowest = slowest[:p.config.SlowestBatches]
+	}
+	p.summary.SlowestBatches = slowest
+}
+
+// printSlowestBatches lists the `SLOWEST_BATCHES` when some batch was slower
+// than `SLOW_RANGE_THRESHOLD`.
+func (p Project) printSlowestBatches() {
+	if p.summary.SlowBatches == 0 {
+		return
+	}
+	fmt.Printf("\n%d batches were slower than %s, the slowest were:\n", p.summary.SlowBatches, p.config.SlowRangeThreshold)
+	for _, timing := range p.summary.SlowestBatches {
+		fmt.Printf("\t%s (rows %d-%d): %dms, %d bytes\n", timing.Ranges, timing.StartRow, timing.EndRow, timing.DurationMs, timing.Bytes)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAPIMeter(t *testing.T) {
+	var m apiMeter
+	client := &http.Client{Transport: m.Transport(cannedTransport{"/values": {http.StatusOK, `{"values": [["a"]]}`}})}
+	for _, path := range []string{"/values", "/values", "/missing"} {
+		resp, err := client.Get("https://sheets.googleapis.com" + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	// the 404 body counts too
+	want := int64(2*len(`{"values": [["a"]]}`) + len(`{"error": {"code": 404, "message": "Not Found"}}`))
+	if m.calls != 3 || m.Bytes() != want {
+		t.Errorf("calls, bytes = %d, %d, want 3, %d", m.calls, m.Bytes(), want)
+	}
+}
+
+func TestRecordBatchTiming(t *testing.T) {
+	ranges, err := parseReadRanges("A:C,K:M")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Project{summary: &runSummary{}, readRanges: ranges}
+	p.config.SheetName = "Class Data"
+	p.config.SlowRangeThreshold = time.Second
+	p.config.SlowestBatches = 2
+
+	durations := []time.Duration{500 * time.Millisecond, 3 * time.Second, time.Second, 2 * time.Second}
+	for i, duration := range durations {
+		p.recordBatchTiming(Batch{Start: 10*i + 2, End: 10*i + 11}, duration, int64(100*i))
+	}
+
+	// the batches at the threshold are slow too
+	if p.summary.SlowBatches != 3 {
+		t.Errorf("slow batches = %d, want 3", p.summary.SlowBatches)
+	}
+	if w := p.Warnings(); len(w) != 3 || w[0].Code != warnSlowRange || w[0].Message != "Slow range 'Class Data'!A12:C21,'Class Data'!K12:M21 (rows 12-21): fetched 100 bytes in 3s" {
+		t.Errorf("warnings = %v, want 3 %s warnings", w, warnSlowRange)
+	}
+	want := []batchTiming{
+		{Ranges: "'Class Data'!A12:C21,'Class Data'!K12:M21", StartRow: 12, EndRow: 21, DurationMs: 3000, Bytes: 100},
+		{Ranges: "'Class Data'!A32:C41,'Class Data'!K32:M41", StartRow: 32, EndRow: 41, DurationMs: 2000, Bytes: 300},
+	}
+	if !reflect.DeepEqual(p.summary.SlowestBatches, want) {
+		t.Errorf("slowest batches = %+v, want %+v", p.summary.SlowestBatches, want)
+	}
+
+	// without SLOWEST_BATCHES, only the warnings are kept
+	p = Project{summary: &runSummary{}, readRanges: ranges}
+	p.config.SlowRangeThreshold = time.Second
+	p.recordBatchTiming(Batch{Start: 2, End: 11}, 2*time.Second, 0)
+	if p.summary.SlowBatches != 1 || p.summary.SlowestBatches != nil {
+		t.Errorf("slow batches, slowest = %d, %v, want 1, none", p.summary.SlowBatches, p.summary.SlowestBatches)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var errLocked = errors.New("locked by another process")
+
+// tokenLockTimeout is how long to wait for another process to release the
+// token's lock.
+const tokenLockTimeout = 30 * time.Second
+
+// lockFile takes an exclusive advisory lock on `path` (created if needed),
+// retrying with backoff while another process holds it, for up to `timeout`;
+// the returned function releases it.
+//
+// NOTE: the lock is taken on a separate file rather than on the file it
+// protects, since that's replaced when written (see `writeFileAtomic`).
+func lockFile(path string, timeout time.Duration) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	delay := 10 * time.Millisecond
+	for {
+		err := tryLockFile(f)
+		if err == nil {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("unable to lock %s: %w", path, err)
+		}
+		time.Sleep(delay)
+		if delay < time.Second {
+			delay *= 2
+		}
+	}
+}
+
+// tokenLockFile returns the lock file guarding the token file `path`, which
+// processes sharing it hold while reading, refreshing, and writing it.
+func tokenLockFile(path string) string {
+	return path + ".lock"
+}
+
+// readTokenFile reads the token file `path` under its lock.
+func readTokenFile(path string) (*oauth2.Token, error) {
+	unlock, err := lockFile(tokenLockFile(path), tokenLockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return tokenFromFile(path)
+}
+
+// writeTokenFile writes the `token` to the token file `path`; the caller must
+// hold its lock.
+func writeTokenFile(path string, token *oauth2.Token) error {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, b, 0600)
+}
+
+// fileTokenSource is a token source persisting refreshed tokens to the token
+// file `path`, for the processes sharing it.
+//
+// Refreshing is done under the file's lock, after re-reading the file: if
+// another process already refreshed the token, its token is used instead of
+// refreshing again.
+type fileTokenSource struct {
+	config *oauth2.Config
+	path   string
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+func newFileTokenSource(config *oauth2.Config, path string, token *oauth2.Token) *fileTokenSource {
+	return &fileTokenSource{config: config, path: path, token: token}
+}
+
+func (s *fileTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.Valid() {
+		return s.token, nil
+	}
+	token, _, err := s.refresh(0)
+	return token, err
+}
+
+// Refresh refreshes the token unless it's valid for at least `minValidity`,
+// whether the token was refreshed is also returned.
+func (s *fileTokenSource) Refresh(minValidity time.Duration) (*oauth2.Token, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.Valid() && time.Until(s.token.Expiry) >= minValidity {
+		return s.token, false, nil
+	}
+	return s.refresh(minValidity)
+}
+
+// refresh refreshes the token under the lock, unless the token file holds one
+// valid for at least `minValidity` (e.g. refreshed by another process); `mu`
+// must be held.
+func (s *fileTokenSource) refresh(minValidity time.Duration) (*oauth2.Token, bool, error) {
+	unlock, err := lockFile(tokenLockFile(s.path), tokenLockTimeout)
+	if err != nil {
+		return nil, false, err
+	}
+	defer unlock()
+	if saved, err := tokenFromFile(s.path); err == nil && saved.RefreshToken != "" {
+		s.token = saved
+		if saved.Valid() && time.Until(saved.Expiry) >= minValidity {
+			return s.token, false, nil
+		}
+	}
+	// the token is refreshed regardless of its expiry
+	expired := *s.token
+	expired.AccessToken = ""
+	expired.Expiry = time.Now().Add(-time.Minute)
+	token, err := s.config.TokenSource(context.Background(), &expired).Token()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := writeTokenFile(s.path, token); err != nil {
+		return nil, false, fmt.Errorf("unable to save the refreshed token: %w", err)
+	}
+	s.token = token
+	return s.token, true, nil
+}
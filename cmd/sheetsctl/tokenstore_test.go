@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/testsupport"
+)
+
+// TestFileTokenSourceConcurrentRefresh refreshes an expired token from
+// several token sources sharing the token file, as processes sharing it
+// would (the lock is taken on a file descriptor of each's own): only one
+// refreshes it, the others use the token it saved, and the file is left
+// whole.
+func TestFileTokenSourceConcurrentRefresh(t *testing.T) {
+	server := testsupport.NewTokenServer()
+	defer server.Close()
+	config := &oauth2.Config{
+		ClientID: "client-id",
+		Endpoint: oauth2.Endpoint{TokenURL: server.TokenURL(), AuthStyle: oauth2.AuthStyleInParams},
+	}
+	path := filepath.Join(t.TempDir(), "token.json")
+	expired := &oauth2.Token{AccessToken: "expired", TokenType: "Bearer", RefreshToken: server.NewRefreshToken(), Expiry: time.Now().Add(-time.Hour)}
+	if err := writeTokenFile(path, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	const sources = 8
+	tokens := make([]*oauth2.Token, sources)
+	errs := make([]error, sources)
+	var wg sync.WaitGroup
+	for i := 0; i < sources; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token := *expired
+			tokens[i], _, errs[i] = newFileTokenSource(config, path, &token).Refresh(time.Minute)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("source %d: Refresh: %v", i, err)
+		}
+		if tokens[i].AccessToken != tokens[0].AccessToken {
+			t.Errorf("source %d got the access token %q, want %q like source 0", i, tokens[i].AccessToken, tokens[0].AccessToken)
+		}
+	}
+	if got := server.Grants("refresh_token"); got != 1 {
+		t.Errorf("refreshes = %d, want 1", got)
+	}
+	saved, err := readTokenFile(path)
+	if err != nil {
+		t.Fatalf("readTokenFile: %v", err)
+	}
+	if saved.AccessToken != tokens[0].AccessToken || saved.RefreshToken != expired.RefreshToken {
+		t.Errorf("saved token = %+v, want the refreshed %q with the same refresh token", saved, tokens[0].AccessToken)
+	}
+}
+
+func TestLockFileTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json.lock")
+	unlock, err := lockFile(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lockFile(path, 50*time.Millisecond); !errors.Is(err, errLocked) {
+		t.Errorf("lockFile of a held lock error = %v, want errLocked", err)
+	}
+	unlock()
+	unlock, err = lockFile(path, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("lockFile of a released lock: %v", err)
+	}
+	unlock()
+}
+
+func TestFileTokenSourceRefresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		expiresIn   time.Duration
+		minValidity time.Duration
+		// saved is the expiry of the token in the file, e.g. refreshed by
+		// another process; 0 leaves the same token in the file
+		saved     time.Duration
+		refreshed bool
+	}{
+		{"valid long enough", 2 * time.Hour, 30 * time.Minute, 0, false},
+		{"near expiry", 10 * time.Minute, 30 * time.Minute, 0, true},
+		{"expired", -time.Minute, 0, 0, true},
+		{"refreshed by another process", 10 * time.Minute, 30 * time.Minute, 2 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		server := testsupport.NewTokenServer()
+		config := &oauth2.Config{
+			ClientID: "client-id",
+			Endpoint: oauth2.Endpoint{TokenURL: server.TokenURL(), AuthStyle: oauth2.AuthStyleInParams},
+		}
+		path := filepath.Join(t.TempDir(), "token.json")
+		cached := &oauth2.Token{AccessToken: "cached", TokenType: "Bearer", RefreshToken: server.NewRefreshToken(), Expiry: time.Now().Add(tt.expiresIn)}
+		inFile := *cached
+		if tt.saved != 0 {
+			inFile.AccessToken, inFile.Expiry = "saved", time.Now().Add(tt.saved)
+		}
+		if err := writeTokenFile(path, &inFile); err != nil {
+			t.Fatal(err)
+		}
+
+		token, refreshed, err := newFileTokenSource(config, path, cached).Refresh(tt.minValidity)
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: Refresh: %v", tt.name, err)
+			continue
+		}
+		wantRefreshes := 0
+		if tt.refreshed {
+			wantRefreshes = 1
+		}
+		if refreshed != tt.refreshed || server.Grants("refresh_token") != wantRefreshes {
+			t.Errorf("%s: refreshed = %v with %d refreshes, want %v", tt.name, refreshed, server.Grants("refresh_token"), tt.refreshed)
+		}
+		if time.Until(token.Expiry) < tt.minValidity {
+			t.Errorf("%s: token expires at %s, want it valid for %s", tt.name, token.Expiry, tt.minValidity)
+		}
+		if saved, err := readTokenFile(path); err != nil || saved.AccessToken != token.AccessToken {
+			t.Errorf("%s: saved token = %+v, %v, want %q", tt.name, saved, err, token.AccessToken)
+		}
+	}
+}
+
+func TestFileTokenSourceRefreshRevoked(t *testing.T) {
+	server := testsupport.NewTokenServer()
+	defer server.Close()
+	config := &oauth2.Config{
+		ClientID: "client-id",
+		Endpoint: oauth2.Endpoint{TokenURL: server.TokenURL(), AuthStyle: oauth2.AuthStyleInParams},
+	}
+	path := filepath.Join(t.TempDir(), "token.json")
+	cached := &oauth2.Token{AccessToken: "cached", RefreshToken: "revoked", Expiry: time.Now().Add(10 * time.Minute)}
+	if err := writeTokenFile(path, cached); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := newFileTokenSource(config, path, cached).Refresh(30 * time.Minute); err == nil {
+		t.Errorf("Refresh of a revoked refresh token succeeded, want the run to stop up front")
+	}
+	if saved, err := readTokenFile(path); err != nil || saved.AccessToken != "cached" {
+		t.Errorf("saved token = %+v, %v, want the cached one left as is", saved, err)
+	}
+}
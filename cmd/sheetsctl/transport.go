@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redactedValue replaces secrets in debug output.
+const redactedValue = "REDACTED"
+
+// sensitiveHeaders are the (canonical) request headers that carry
+// credentials, and are never written to debug output.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"X-Goog-Api-Key":      true,
+}
+
+// sensitiveQueryParams are the URL query parameters that carry credentials.
+var sensitiveQueryParams = []string{"access_token", "key"}
+
+// dumpTransport writes every request's method, URL, and headers along with
+// the raw response body to numbered files in `dir`, for debugging API
+// behavior; credentials are redacted.
+type dumpTransport struct {
+	base http.RoundTripper
+	dir  string
+
+	mu sync.Mutex
+	n  int
+}
+
+func newDumpTransport(base http.RoundTripper, dir string) (*dumpTransport, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &dumpTransport{base: base, dir: dir}, nil
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.n++
+	n := t.n
+	t.mu.Unlock()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s %s\n", req.Method, redactURL(req.URL))
+	writeHeaders(&b, req.Header)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(&b, "\nerror: %v\n", err)
+		t.write(n, b.Bytes())
+		return resp, err
+	}
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	fmt.Fprintf(&b, "\n%s %s\n", resp.Proto, resp.Status)
+	writeHeaders(&b, resp.Header)
+	b.WriteString("\n")
+	b.Write(body)
+	if readErr != nil {
+		fmt.Fprintf(&b, "\nerror reading body: %v\n", readErr)
+	}
+	t.write(n, b.Bytes())
+	return resp, nil
+}
+
+func (t *dumpTransport) write(n int, b []byte) {
+	path := filepath.Join(t.dir, fmt.Sprintf("%05d.txt", n))
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write debug dump %s: %v\n", path, err)
+	}
+}
+
+// writeHeaders writes the headers sorted by name, with credentials redacted.
+func writeHeaders(w io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			value = redactedValue
+		}
+		fmt.Fprintf(w, "%s: %s\n", name, value)
+	}
+}
+
+// redactURL returns the URL with any credentials in its query redacted.
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for _, param := range sensitiveQueryParams {
+		if query.Has(param) {
+			query.Set(param, redactedValue)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+	clone := *u
+	clone.RawQuery = query.Encode()
+	return clone.String()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// sourceSpreadsheetColumn is the column added to every row when reading the
+// union of `SPREADSHEET_IDS`, holding the id of the row's spreadsheet.
+const sourceSpreadsheetColumn = "_source_spreadsheet_id"
+
+// spreadsheetIds returns the spreadsheets to read: the `SPREADSHEET_IDS` if
+// set, else just the `SPREADSHEET_ID`.
+func (p Project) spreadsheetIds() []string {
+	if len(p.config.SpreadsheetIds) > 0 {
+		return p.config.SpreadsheetIds
+	}
+	return []string{p.config.SpreadsheetId}
+}
+
+// normalizeHeader returns the header name compared across spreadsheets, which
+// ignores case and extra whitespace.
+func normalizeHeader(header interface{}) string {
+	return strings.ToLower(strings.Join(strings.Fields(fmt.Sprint(header)), " "))
+}
+
+// headerDiff lists the columns where the `got` header differs from `want`
+// (after normalization), e.g. `column C: "Major" != "Minor"`.
+func headerDiff(want, got []interface{}) []string {
+	diff := []string{}
+	for i := 0; i < len(want) || i < len(got); i++ {
+		switch {
+		case i >= len(got):
+			diff = append(diff, fmt.Sprintf("column %s: missing %q", a1.ColumnLetter(i), want[i]))
+		case i >= len(want):
+			diff = append(diff, fmt.Sprintf("column %s: unexpected %q", a1.ColumnLetter(i), got[i]))
+		case normalizeHeader(want[i]) != normalizeHeader(got[i]):
+			diff = append(diff, fmt.Sprintf("column %s: %q != %q", a1.ColumnLetter(i), want[i], got[i]))
+		}
+	}
+	return diff
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHeaderDiff(t *testing.T) {
+	want := []interface{}{"Name", "Major", "Home State"}
+	tests := []struct {
+		name string
+		got  []interface{}
+		diff []string
+	}{
+		{"same", []interface{}{"Name", "Major", "Home State"}, []string{}},
+		{"case and whitespace", []interface{}{" name", "MAJOR ", "home  state"}, []string{}},
+		{"renamed", []interface{}{"Name", "Minor", "Home State"}, []string{`column B: "Major" != "Minor"`}},
+		{"missing", []interface{}{"Name"}, []string{`column B: missing "Major"`, `column C: missing "Home State"`}},
+		{"unexpected", []interface{}{"Name", "Major", "Home State", "Age"}, []string{`column D: unexpected "Age"`}},
+		{"reordered", []interface{}{"Major", "Name", "Home State"}, []string{`column A: "Name" != "Major"`, `column B: "Major" != "Name"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := headerDiff(want, tt.got); !reflect.DeepEqual(diff, tt.diff) {
+				t.Errorf("headerDiff = %q, want %q", diff, tt.diff)
+			}
+		})
+	}
+}
+
+func TestSpreadsheetIds(t *testing.T) {
+	var p Project
+	p.config.SpreadsheetId = "one"
+	if got := p.spreadsheetIds(); !reflect.DeepEqual(got, []string{"one"}) {
+		t.Errorf("spreadsheetIds = %q, want the SPREADSHEET_ID", got)
+	}
+	p.config.SpreadsheetIds = []string{"two", "three"}
+	if got := p.spreadsheetIds(); !reflect.DeepEqual(got, []string{"two", "three"}) {
+		t.Errorf("spreadsheetIds = %q, want the SPREADSHEET_IDS", got)
+	}
+}
+
+// TestUnionRun reads the union of two spreadsheets, whose headers only differ
+// in case, and then of one whose header differs.
+func TestUnionRun(t *testing.T) {
+	run := newCommandRun(t)
+	run.sheets.SetValues("first", "Class Data", [][]interface{}{{"Name", "Major"}, {"Ann", "Math"}, {"Bob", "Art"}})
+	run.sheets.SetValues("second", "Class Data", [][]interface{}{{"name", "MAJOR"}, {"Cy", "English"}})
+	run.sheets.SetValues("third", "Class Data", [][]interface{}{{"Name", "Minor"}, {"Dee", "Physics"}})
+	run.Setenv("SPREADSHEET_IDS", "first,second")
+	stdout, code := run.Run("-porcelain")
+	if code != 0 {
+		t.Fatalf("exit code %d, want 0", code)
+	}
+	for _, want := range []string{
+		`{"Major":"Math","Name":"Ann","` + sourceSpreadsheetColumn + `":"first"}`,
+		`{"Major":"Art","Name":"Bob","` + sourceSpreadsheetColumn + `":"first"}`,
+		// the rows keep the first spreadsheet's keys
+		`{"Major":"English","Name":"Cy","` + sourceSpreadsheetColumn + `":"second"}`,
+		`"sources":{"first":2,"second":1}`,
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout = %q, want it to contain %q", stdout, want)
+		}
+	}
+
+	run.Setenv("SPREADSHEET_IDS", "first,third")
+	_, stderr, code := run.RunStderr()
+	if code == 0 {
+		t.Errorf("exit code 0, want the differing header to fail the run")
+	}
+	if want := "the header of spreadsheet third differs from spreadsheet first's:\n\tcolumn B: \"Major\" != \"Minor\""; !strings.Contains(stderr, want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr, want)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// validationFields is the narrow fields mask used when fetching the data
+// validation (dropdown) rules of a row.
+const validationFields = "sheets(data(startColumn,rowData(values(dataValidation(condition(type,values(userEnteredValue)))))))"
+
+// columnValidation is the allowed values of a column constrained by an in-sheet
+// dropdown; `Unresolved` is the source range when its values couldn't be read,
+// e.g. because it refers to another spreadsheet.
+type columnValidation struct {
+	Allowed    []string
+	Unresolved string
+}
+
+// columnValidations returns the dropdown validation of the `READ_RANGES`
+// columns found on the sheet row `row`, keyed by the column's position in the
+// (stitched) rows. "ONE_OF_LIST" values are used as is, and "ONE_OF_RANGE"
+// values are read from the referenced range; other rule types are ignored.
+func (p Project) columnValidations(row int) (map[int]columnValidation, error) {
+	readRanges := make([]string, len(p.readRanges))
+	for i, r := range p.readRanges {
+		readRanges[i] = r.Rows(p.config.SheetName, row, row)
+	}
+	resp, err := p.sheetsService.Spreadsheets.Get(p.config.SpreadsheetId).
+		Ranges(readRanges...).
+		IncludeGridData(true).
+		Fields(validationFields).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	validations := map[int]columnValidation{}
+	for _, sheet := range resp.Sheets {
+		for _, data := range sheet.Data {
+			for _, rowData := range data.RowData {
+				for i, cell := range rowData.Values {
+					if cell.DataValidation == nil || cell.DataValidation.Condition == nil {
+						continue
+					}
+					position, ok := p.columnPosition(int(data.StartColumn) + i)
+					if !ok {
+						continue
+					}
+					condition := cell.DataValidation.Condition
+					values := make([]string, len(condition.Values))
+					for j, v := range condition.Values {
+						values[j] = v.UserEnteredValue
+					}
+					switch condition.Type {
+					case "ONE_OF_LIST":
+						validations[position] = columnValidation{Allowed: values}
+					case "ONE_OF_RANGE":
+						if len(values) == 0 {
+							continue
+						}
+						validations[position] = p.resolveValidationRange(values[0])
+					}
+				}
+			}
+		}
+	}
+	return validations, nil
+}
+
+// columnPosition returns the position of the 0-based sheet `column` in the
+// rows stitched from the `READ_RANGES`.
+func (p Project) columnPosition(column int) (int, bool) {
+	for i, r := range p.readRanges {
+		if column >= r.StartColumn && column <= r.EndColumn {
+			return offsetOf(p.readRanges, i) + column - r.StartColumn, true
+		}
+	}
+	return 0, false
+}
+
+// resolveValidationRange reads the non-blank values of a "ONE_OF_RANGE" source,
+// e.g. "=Lists!$A$2:$A$20"; ranges without a sheet name refer to the
+// configured sheet.
+func (p Project) resolveValidationRange(source string) columnValidation {
+	readRange := strings.ReplaceAll(strings.TrimPrefix(strings.TrimSpace(source), "="), "$", "")
+	if !strings.Contains(readRange, "!") {
+		readRange = a1.QuoteSheetName(p.config.SheetName) + "!" + readRange
+	}
+	resp, err := p.getValues(readRange)
+	if err != nil {
+		p.warn(warnValidationSrc, "Unable to resolve the dropdown values of %s: %v", source, err)
+		return columnValidation{Unresolved: source}
+	}
+	allowed := []string{}
+	for _, row := range resp.Values {
+		for _, v := range row {
+			if s := strings.TrimSpace(fmt.Sprint(v)); s != "" {
+				allowed = append(allowed, s)
+			}
+		}
+	}
+	return columnValidation{Allowed: allowed}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// `CACHE` modes.
+const (
+	cacheOff       = "off"
+	cacheRead      = "read"
+	cacheReadWrite = "readwrite"
+)
+
+// valueCache is an on-disk cache of fetched ranges so repeated runs against an
+// unchanged sheet don't use any read quota. Entries are keyed by the
+// spreadsheet's Drive `modifiedTime`, so any edit to the sheet invalidates
+// them.
+//
+// Entries are content-addressed JSON files (named by the SHA-256 of their key),
+// and the least recently used ones are evicted once the directory grows past
+// `maxBytes`.
+type valueCache struct {
+	dir      string
+	mode     string
+	maxBytes int64
+	// modifiedTime returns the Drive `modifiedTime` of a spreadsheet
+	modifiedTime  func(spreadsheetId string) (string, error)
+	modifiedTimes map[string]string
+
+	hits, misses int
+}
+
+func newValueCache(dir, mode string, maxBytes int64, modifiedTime func(spreadsheetId string) (string, error)) (*valueCache, error) {
+	switch mode {
+	case cacheRead, cacheReadWrite:
+	default:
+		return nil, fmt.Errorf("invalid CACHE %q, expected %q, %q, or %q", mode, cacheOff, cacheRead, cacheReadWrite)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &valueCache{
+		dir:           dir,
+		mode:          mode,
+		maxBytes:      maxBytes,
+		modifiedTime:  modifiedTime,
+		modifiedTimes: map[string]string{},
+	}, nil
+}
+
+// path returns the cache file for the range, fetching the spreadsheet's
+// `modifiedTime` the first time it's needed.
+func (c *valueCache) path(spreadsheetId, readRange, valueRenderOption string) (string, error) {
+	modified, ok := c.modifiedTimes[spreadsheetId]
+	if !ok {
+		var err error
+		modified, err = c.modifiedTime(spreadsheetId)
+		if err != nil {
+			return "", err
+		}
+		c.modifiedTimes[spreadsheetId] = modified
+	}
+	key := strings.Join([]string{spreadsheetId, readRange, modified, valueRenderOption}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// Get returns the cached values of the range if there are any.
+func (c *valueCache) Get(spreadsheetId, readRange, valueRenderOption string) (*sheets.ValueRange, bool) {
+	path, err := c.path(spreadsheetId, readRange, valueRenderOption)
+	if err != nil {
+		c.misses++
+		return nil, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		c.misses++
+		return nil, false
+	}
+	resp := &sheets.ValueRange{}
+	if err := json.Unmarshal(b, resp); err != nil {
+		c.misses++
+		return nil, false
+	}
+	// mark the entry as recently used for the LRU eviction
+	t := time.Now()
+	os.Chtimes(path, t, t)
+	c.hits++
+	return resp, true
+}
+
+// Put stores the values of the range (in "readwrite" mode), evicting the least
+// recently used entries if the cache is over its size limit.
+func (c *valueCache) Put(spreadsheetId, readRange, valueRenderOption string, resp *sheets.ValueRange) error {
+	if c.mode != cacheReadWrite {
+		return nil
+	}
+	path, err := c.path(spreadsheetId, readRange, valueRenderOption)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, b, 0600); err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// evict removes the least recently used entries until the cache fits in
+// `maxBytes`.
+func (c *valueCache) evict() error {
+	if c.maxBytes <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	files := []os.FileInfo{}
+	total := int64(0)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, info := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, info.Name())); err != nil {
+			return err
+		}
+		total -= info.Size()
+	}
+	return nil
+}
+
+// getValues returns the values of `readRange` from the configured spreadsheet,
+// served from the `CACHE_DIR` cache when possible.
+func (p Project) getValues(readRange string) (*sheets.ValueRange, error) {
+	if p.cache != nil {
+		if resp, ok := p.cache.Get(p.config.SpreadsheetId, readRange, valueRenderOption); ok {
+			return resp, nil
+		}
+	}
+	call := p.sheetsService.Spreadsheets.Values.Get(p.config.SpreadsheetId, readRange).ValueRenderOption(valueRenderOption)
+	if p.config.FieldsMask {
+		call.Fields(valuesFields)
+	}
+	resp, err := call.Do()
+	if err != nil {
+		return nil, err
+	}
+	if p.cache != nil {
+		if err := p.cache.Put(p.config.SpreadsheetId, readRange, valueRenderOption, resp); err != nil {
+			p.warn(warnCacheWrite, "Unable to cache values: %v", err)
+		}
+	}
+	return resp, nil
+}
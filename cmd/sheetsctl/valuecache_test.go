@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// newTestValueCache returns a cache in a temporary directory, of spreadsheets
+// last modified at `modified`.
+func newTestValueCache(t *testing.T, mode string, maxBytes int64, modified *string) *valueCache {
+	t.Helper()
+	c, err := newValueCache(t.TempDir(), mode, maxBytes, func(spreadsheetId string) (string, error) {
+		return *modified, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestValueCacheKey(t *testing.T) {
+	modified := "2024-03-01T10:00:00.000Z"
+	c := newTestValueCache(t, cacheReadWrite, 0, &modified)
+	resp := &sheets.ValueRange{Range: "Sheet1!A1:B2", Values: [][]interface{}{{"a1", "b1"}}}
+	if err := c.Put("id", "Sheet1!A1:B2", "FORMATTED_VALUE", resp); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	tests := []struct {
+		spreadsheetId, readRange, renderOption string
+		hit                                    bool
+	}{
+		{"id", "Sheet1!A1:B2", "FORMATTED_VALUE", true},
+		{"other", "Sheet1!A1:B2", "FORMATTED_VALUE", false},
+		{"id", "Sheet1!A1:B3", "FORMATTED_VALUE", false},
+		{"id", "Sheet1!A1:B2", "UNFORMATTED_VALUE", false},
+	}
+	for _, tt := range tests {
+		got, ok := c.Get(tt.spreadsheetId, tt.readRange, tt.renderOption)
+		if ok != tt.hit || (ok && !reflect.DeepEqual(got.Values, resp.Values)) {
+			t.Errorf("Get(%s, %s, %s) = %v, %v, want a hit %v", tt.spreadsheetId, tt.readRange, tt.renderOption, got, ok, tt.hit)
+		}
+	}
+	if c.hits != 1 || c.misses != 3 {
+		t.Errorf("hits, misses = %d, %d, want 1, 3", c.hits, c.misses)
+	}
+
+	// an edit of the spreadsheet changes its modifiedTime, in a later run
+	modified = "2024-03-02T09:00:00.000Z"
+	c.modifiedTimes = map[string]string{}
+	if _, ok := c.Get("id", "Sheet1!A1:B2", "FORMATTED_VALUE"); ok {
+		t.Errorf("Get after the spreadsheet was modified hit the cache")
+	}
+}
+
+func TestValueCacheReadMode(t *testing.T) {
+	modified := "2024-03-01T10:00:00.000Z"
+	c := newTestValueCache(t, cacheRead, 0, &modified)
+	if err := c.Put("id", "Sheet1!A1:B2", "FORMATTED_VALUE", &sheets.ValueRange{}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := c.Get("id", "Sheet1!A1:B2", "FORMATTED_VALUE"); ok {
+		t.Errorf("Get hit an entry Put in %q mode", cacheRead)
+	}
+	if _, err := newValueCache(t.TempDir(), cacheOff, 0, nil); err == nil {
+		t.Errorf("newValueCache in %q mode succeeded", cacheOff)
+	}
+}
+
+func TestValueCacheEviction(t *testing.T) {
+	modified := "2024-03-01T10:00:00.000Z"
+	// the entries are the same size, so the cache fits 3 of them
+	put := func(c *valueCache, readRange string) {
+		t.Helper()
+		if err := c.Put("id", readRange, "FORMATTED_VALUE", &sheets.ValueRange{Range: readRange}); err != nil {
+			t.Fatalf("Put(%s): %v", readRange, err)
+		}
+	}
+	probe := newTestValueCache(t, cacheReadWrite, 0, &modified)
+	put(probe, "Sheet1!A1")
+	path, _ := probe.path("id", "Sheet1!A1", "FORMATTED_VALUE")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		// used is read before the fourth entry is put
+		used    string
+		evicted string
+	}{
+		{"least recently put", "", "Sheet1!A1"},
+		{"least recently used", "Sheet1!A1", "Sheet1!A2"},
+		{"most recently used", "Sheet1!A3", "Sheet1!A1"},
+	}
+	for _, tt := range tests {
+		c := newTestValueCache(t, cacheReadWrite, 3*info.Size(), &modified)
+		for i, readRange := range []string{"Sheet1!A1", "Sheet1!A2", "Sheet1!A3"} {
+			put(c, readRange)
+			// spread the modification times past the file system's resolution
+			path, _ := c.path("id", readRange, "FORMATTED_VALUE")
+			at := time.Now().Add(time.Duration(i-3) * time.Hour)
+			if err := os.Chtimes(path, at, at); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if tt.used != "" {
+			if _, ok := c.Get("id", tt.used, "FORMATTED_VALUE"); !ok {
+				t.Fatalf("%s: Get(%s) missed", tt.name, tt.used)
+			}
+		}
+		put(c, "Sheet1!A4")
+
+		for _, readRange := range []string{"Sheet1!A1", "Sheet1!A2", "Sheet1!A3", "Sheet1!A4"} {
+			path, _ := c.path("id", readRange, "FORMATTED_VALUE")
+			_, err := os.Stat(path)
+			if kept := err == nil; kept == (readRange == tt.evicted) {
+				t.Errorf("%s: %s kept = %v, want %v", tt.name, readRange, kept, readRange != tt.evicted)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// The build's version, commit, and date, set with e.g.:
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%FT%TZ)" ./cmd/sheetsctl
+//
+// When they aren't, `buildVersion` falls back to the module and VCS info
+// embedded by the Go toolchain.
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// userAgentProduct is the product name the run identifies itself with to
+// Google in the User-Agent header.
+const userAgentProduct = "google_oauth_spreadsheet_example"
+
+// buildInfo identifies the build that produced a run.
+type buildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+}
+
+// String returns e.g. "v1.2.0 (commit 1a2b3c4, built 2026-10-16T00:00:00Z)".
+func (b buildInfo) String() string {
+	s := b.Version
+	if b.Commit != "" {
+		s += " (commit " + b.Commit
+		if b.Date != "" {
+			s += ", built " + b.Date
+		}
+		s += ")"
+	}
+	return s
+}
+
+// buildVersion returns the `version`, `commit`, and `date` set at build time,
+// falling back to the toolchain's build info for the unset ones.
+func buildVersion() buildInfo {
+	b := buildInfo{Version: version, Commit: commit, Date: date}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if b.Version == "" {
+			b.Version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && b.Commit == "":
+				b.Commit = setting.Value
+			case setting.Key == "vcs.time" && b.Date == "":
+				b.Date = setting.Value
+			}
+		}
+	}
+	if b.Version == "" {
+		b.Version = "(unknown)"
+	}
+	return b
+}
+
+// printVersion prints the build info, for the `version` subcommand and the
+// `--version` flag.
+func printVersion() {
+	fmt.Printf("%s %s\n", userAgentProduct, buildVersion())
+}
+
+// userAgentTransport prefixes the User-Agent of every request with the
+// product and version of the build, so the build is visible to Google.
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func newUserAgentTransport(base http.RoundTripper) *userAgentTransport {
+	return &userAgentTransport{base: base, userAgent: userAgentProduct + "/" + buildVersion().Version}
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// NOTE: a RoundTripper mustn't modify the request it's given.
+	req = req.Clone(req.Context())
+	userAgent := t.userAgent
+	if ua := req.Header.Get("User-Agent"); ua != "" {
+		userAgent += " " + ua
+	}
+	req.Header.Set("User-Agent", userAgent)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+func TestBuildInfoString(t *testing.T) {
+	tests := []struct {
+		info buildInfo
+		want string
+	}{
+		{buildInfo{Version: "v1.2.0", Commit: "1a2b3c4", Date: "2026-10-16T00:00:00Z"}, "v1.2.0 (commit 1a2b3c4, built 2026-10-16T00:00:00Z)"},
+		{buildInfo{Version: "v1.2.0", Commit: "1a2b3c4"}, "v1.2.0 (commit 1a2b3c4)"},
+		// the date alone isn't shown
+		{buildInfo{Version: "(devel)", Date: "2026-10-16T00:00:00Z"}, "(devel)"},
+	}
+	for _, tt := range tests {
+		if got := tt.info.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestBuildVersionLdflags(t *testing.T) {
+	defer func(v, c, d string) { version, commit, date = v, c, d }(version, commit, date)
+	version, commit, date = "v1.2.0", "1a2b3c4", "2026-10-16T00:00:00Z"
+	want := buildInfo{Version: "v1.2.0", Commit: "1a2b3c4", Date: "2026-10-16T00:00:00Z"}
+	if got := buildVersion(); got != want {
+		t.Errorf("buildVersion() = %+v, want %+v", got, want)
+	}
+	// the test binary's module version is "(devel)"
+	version = ""
+	if got := buildVersion(); got.Version == "" || got.Commit != "1a2b3c4" {
+		t.Errorf("buildVersion() without a version = %+v", got)
+	}
+}
+
+func TestUserAgentTransport(t *testing.T) {
+	defer func(v string) { version = v }(version)
+	version = "v1.2.0"
+	server := sheetstest.NewServer()
+	defer server.Close()
+	var userAgents []string
+	server.Fail = func(r *http.Request, ranges []string) (int, string) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		return 0, ""
+	}
+	client := &http.Client{Transport: newUserAgentTransport(server.Client().Transport)}
+
+	tests := []struct {
+		userAgent, want string
+	}{
+		{"", "google_oauth_spreadsheet_example/v1.2.0"},
+		{"google-api-go-client/0.5", "google_oauth_spreadsheet_example/v1.2.0 google-api-go-client/0.5"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, server.Endpoint()+"v4/spreadsheets/id", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.userAgent != "" {
+			req.Header.Set("User-Agent", tt.userAgent)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := userAgents[len(userAgents)-1]; got != tt.want {
+			t.Errorf("User-Agent %q sent as %q, want %q", tt.userAgent, got, tt.want)
+		}
+		// the caller's request is left as is
+		if got := req.Header.Get("User-Agent"); got != tt.userAgent {
+			t.Errorf("the request's User-Agent changed to %q", got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import "fmt"
+
+// Warning codes, stable for programs telling warnings apart.
+const (
+	warnHeaderCount     = "header_count"
+	warnEmptySheet      = "empty_sheet"
+	warnNonNumeric      = "non_numeric"
+	warnSlowRange       = "slow_range"
+	warnCacheWrite      = "cache_write"
+	warnValidationSrc   = "validation_source"
+	warnCellSize        = "cell_size"
+	warnGridShrunk      = "grid_shrunk"
+	warnSourceFile      = "source_file"
+	warnJoinSize        = "join_size"
+	warnJoinDuplicate   = "join_duplicate"
+	warnQuotaPressure   = "quota_pressure"
+	warnRejectThreshold = "reject_threshold"
+	warnRangeRead       = "range_read"
+)
+
+// Warning is something off about the run that didn't stop it. Identical
+// warnings (same code, column, and message) are reported once, with their
+// `Count`; `Row` is the first one's.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Row     int    `json:"row,omitempty"`
+	Column  string `json:"column,omitempty"`
+	Count   int    `json:"count"`
+}
+
+// warnAt prints the warning, emits it as a porcelain `warning` event when
+// `--porcelain` is enabled, and records it in the summary.
+func (p Project) warnAt(w Warning) {
+	fmt.Println(w.Message)
+	if p.porcelain != nil {
+		p.porcelain.Emit(porcelainEvent{Type: eventWarning, Message: w.Message, Code: w.Code, Row: w.Row, Column: w.Column})
+	}
+	for i, recorded := range p.summary.Warnings {
+		if recorded.Code == w.Code && recorded.Column == w.Column && recorded.Message == w.Message {
+			p.summary.Warnings[i].Count++
+			return
+		}
+	}
+	w.Count = 1
+	p.summary.Warnings = append(p.summary.Warnings, w)
+}
+
+// Warnings returns the warnings of the run so far.
+func (p Project) Warnings() []Warning {
+	return append([]Warning{}, p.summary.Warnings...)
+}
+
+// printWarnings lists the warnings of the run, with how many times each
+// occurred.
+func (p Project) printWarnings() {
+	if len(p.summary.Warnings) == 0 {
+		return
+	}
+	fmt.Printf("\n%d warnings:\n", len(p.summary.Warnings))
+	for _, w := range p.summary.Warnings {
+		fmt.Printf("\t[%s] %s (x%d)\n", w.Code, w.Message, w.Count)
+	}
+}
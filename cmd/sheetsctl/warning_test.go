@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWarnAt(t *testing.T) {
+	var b bytes.Buffer
+	p := Project{summary: &runSummary{}, porcelain: newPorcelainWriter(&b)}
+	warnings := []Warning{
+		{Code: warnHeaderCount, Row: 5, Message: "Row 5 has more cells than headers"},
+		{Code: warnNonNumeric, Row: 7, Column: "GPA", Message: `Skipping non-numeric value "n/a"`},
+		// identical to the second but for the row
+		{Code: warnNonNumeric, Row: 9, Column: "GPA", Message: `Skipping non-numeric value "n/a"`},
+		// same message, other column or code
+		{Code: warnNonNumeric, Row: 9, Column: "Credits", Message: `Skipping non-numeric value "n/a"`},
+		{Code: warnCellSize, Row: 9, Column: "GPA", Message: `Skipping non-numeric value "n/a"`},
+		{Code: warnNonNumeric, Row: 12, Column: "GPA", Message: `Skipping non-numeric value "n/a"`},
+	}
+	for _, w := range warnings {
+		p.warnAt(w)
+	}
+
+	want := []Warning{
+		{Code: warnHeaderCount, Row: 5, Message: "Row 5 has more cells than headers", Count: 1},
+		{Code: warnNonNumeric, Row: 7, Column: "GPA", Message: `Skipping non-numeric value "n/a"`, Count: 3},
+		{Code: warnNonNumeric, Row: 9, Column: "Credits", Message: `Skipping non-numeric value "n/a"`, Count: 1},
+		{Code: warnCellSize, Row: 9, Column: "GPA", Message: `Skipping non-numeric value "n/a"`, Count: 1},
+	}
+	if got := p.Warnings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Warnings() = %+v, want %+v", got, want)
+	}
+	// every occurrence is emitted
+	if events := strings.Count(b.String(), `"type":"warning"`); events != len(warnings) {
+		t.Errorf("%d warning events, want %d", events, len(warnings))
+	}
+
+	// the returned warnings are a copy
+	p.Warnings()[0].Count = 10
+	if p.summary.Warnings[0].Count != 1 {
+		t.Errorf("changing Warnings() changed the summary")
+	}
+}
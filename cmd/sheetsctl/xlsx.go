@@ -0,0 +1,190 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// xlsxMimeType is the MIME type Drive exports spreadsheets to as .xlsx files.
+const xlsxMimeType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// xlsxWorkbook is the part of `xl/workbook.xml` listing the sheets.
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		Id   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships is `xl/_rels/workbook.xml.rels`, mapping a sheet's
+// relationship id to its worksheet file.
+type xlsxRelationships struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a (possibly rich) string of the shared strings table or of an
+// inline string cell.
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+// xlsxWorksheet is the cell data of a worksheet file; `Ref` and `Number` are
+// optional, in which case cells/rows follow the previous one.
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string    `xml:"r,attr"`
+			Type   string    `xml:"t,attr"`
+			Value  string    `xml:"v"`
+			Inline *xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSXSheet returns the rows of the `sheetName` sheet of an .xlsx file, as
+// the Values API would: indexed from row 1, with trailing blank cells and rows
+// left out.
+//
+// NOTE: cells hold their stored values rather than the sheet's formatted
+// ones, e.g. dates are serial numbers and percentages are fractions.
+func readXLSXSheet(data []byte, sheetName string) ([][]interface{}, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var relationships xlsxRelationships
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+	worksheetName := ""
+	for _, sheet := range workbook.Sheets {
+		if sheet.Name != sheetName {
+			continue
+		}
+		for _, r := range relationships.Relationships {
+			if r.Id == sheet.Id {
+				worksheetName = r.Target
+			}
+		}
+	}
+	if worksheetName == "" {
+		return nil, errSheetNotFound
+	}
+	// targets are relative to the workbook unless absolute
+	if strings.HasPrefix(worksheetName, "/") {
+		worksheetName = strings.TrimPrefix(worksheetName, "/")
+	} else {
+		worksheetName = path.Join("xl", worksheetName)
+	}
+	var sharedStrings struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+	var worksheet xlsxWorksheet
+	if err := decodeXLSXPart(files, worksheetName, &worksheet); err != nil {
+		return nil, err
+	}
+
+	rows := [][]interface{}{}
+	rowNumber := 0
+	for _, xlsxRow := range worksheet.Rows {
+		rowNumber++
+		if xlsxRow.Number > 0 {
+			rowNumber = xlsxRow.Number
+		}
+		row := []interface{}{}
+		column := -1
+		for _, cell := range xlsxRow.Cells {
+			column++
+			if cell.Ref != "" {
+				if column, _, err = a1.ParseCell(cell.Ref); err != nil {
+					return nil, fmt.Errorf("invalid cell in %s: %w", worksheetName, err)
+				}
+			}
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(value)
+				if err != nil || i < 0 || i >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("invalid shared string %q in %s", value, worksheetName)
+				}
+				value = sharedStrings.Items[i].String()
+			case "inlineStr":
+				if cell.Inline != nil {
+					value = cell.Inline.String()
+				}
+			case "b":
+				value = strings.ToUpper(strconv.FormatBool(value == "1"))
+			}
+			if value == "" {
+				continue
+			}
+			for len(row) < column {
+				row = append(row, "")
+			}
+			row = append(row, value)
+		}
+		if len(row) == 0 {
+			continue
+		}
+		for len(rows) < rowNumber-1 {
+			rows = append(rows, []interface{}{})
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeXLSXPart decodes the XML file `name` of the .xlsx archive into `v`.
+func decodeXLSXPart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("invalid .xlsx file: missing %s", name)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := xml.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("invalid .xlsx file: %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// xlsxFile returns an .xlsx archive of the `parts`, named by their path.
+func xlsxFile(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range parts {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testWorkbook is a workbook whose "Class Data" sheet is the `worksheet`.
+func testWorkbook(worksheet string) map[string]string {
+	return map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Other" sheetId="1" r:id="rId1"/><sheet name="Class Data" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Name</t></si><si><r><t>Ma</t></r><r><t>jor</t></r></si><si><t>Ann</t></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1"><v>other</v></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": worksheet,
+	}
+}
+
+func TestReadXLSXSheet(t *testing.T) {
+	worksheet := `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"/><c r="C2" t="inlineStr"><is><t>Math</t></is></c><c r="D2" t="b"><v>1</v></c></row>
+<row r="5"><c r="B5"><v>45000</v></c><c t="b"><v>0</v></c></row>
+<row><c><v>x</v></c></row>
+</sheetData></worksheet>`
+	rows, err := readXLSXSheet(xlsxFile(t, testWorkbook(worksheet)), "Class Data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{
+		{"Name", "", "Major"},
+		{"Ann", "", "Math", "TRUE"},
+		{},
+		{},
+		// cells and rows without a reference follow the previous one
+		{"", "45000", "FALSE"},
+		{"x"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("readXLSXSheet = %q, want %q", rows, want)
+	}
+
+	rows, err = readXLSXSheet(xlsxFile(t, testWorkbook(worksheet)), "Other")
+	if err != nil || !reflect.DeepEqual(rows, [][]interface{}{{"other"}}) {
+		t.Errorf("readXLSXSheet(Other) = %q, %v, want the relative target read", rows, err)
+	}
+}
+
+func TestReadXLSXSheetInvalid(t *testing.T) {
+	valid := `<worksheet><sheetData/></worksheet>`
+	if _, err := readXLSXSheet(xlsxFile(t, testWorkbook(valid)), "Missing"); !errors.Is(err, errSheetNotFound) {
+		t.Errorf("readXLSXSheet of a missing sheet error = %v, want errSheetNotFound", err)
+	}
+	if _, err := readXLSXSheet([]byte("not a zip"), "Class Data"); err == nil {
+		t.Errorf("readXLSXSheet of a non-zip succeeded, want an error")
+	}
+	missing := testWorkbook(valid)
+	delete(missing, "xl/_rels/workbook.xml.rels")
+	if _, err := readXLSXSheet(xlsxFile(t, missing), "Class Data"); err == nil || !strings.Contains(err.Error(), "missing xl/_rels/workbook.xml.rels") {
+		t.Errorf("readXLSXSheet without relationships error = %v, want the part named", err)
+	}
+	for name, worksheet := range map[string]string{
+		"shared string out of range": `<worksheet><sheetData><row r="1"><c r="A1" t="s"><v>9</v></c></row></sheetData></worksheet>`,
+		"invalid cell reference":     `<worksheet><sheetData><row r="1"><c r="1A"><v>x</v></c></row></sheetData></worksheet>`,
+		"invalid XML":                `<worksheet><sheetData>`,
+	} {
+		if _, err := readXLSXSheet(xlsxFile(t, testWorkbook(worksheet)), "Class Data"); err == nil {
+			t.Errorf("%s: readXLSXSheet succeeded, want an error", name)
+		}
+	}
+}
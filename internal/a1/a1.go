@@ -0,0 +1,136 @@
+// Package a1 parses and formats A1 notation, the sheet ranges of the Sheets API
+// (e.g. "A:C", "A2:C100", or "A2:C").
+package a1
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRange is wrapped by the errors of invalid ranges.
+var ErrInvalidRange = errors.New("invalid A1 range")
+
+// ColumnLetter converts a 0-based column index to its A1 notation letters,
+// e.g. 0 is "A", 25 is "Z", and 26 is "AA".
+func ColumnLetter(index int) string {
+	letters := ""
+	for n := index + 1; n > 0; n = (n - 1) / 26 {
+		letters = string(rune('A'+(n-1)%26)) + letters
+	}
+	return letters
+}
+
+// ColumnIndex converts A1 notation column letters to a 0-based column index,
+// e.g. "A" is 0 and "AA" is 26.
+func ColumnIndex(letters string) (int, error) {
+	letters = strings.ToUpper(strings.TrimSpace(letters))
+	if letters == "" {
+		return 0, fmt.Errorf("%w: empty column", ErrInvalidRange)
+	}
+	n := 0
+	for _, r := range letters {
+		if r < 'A' || r > 'Z' {
+			return 0, fmt.Errorf("%w: invalid column %q", ErrInvalidRange, letters)
+		}
+		n = n*26 + int(r-'A'+1)
+		if n > 1<<20 {
+			return 0, fmt.Errorf("%w: column %q is out of range", ErrInvalidRange, letters)
+		}
+	}
+	return n - 1, nil
+}
+
+// Range is a rectangular A1 range of a sheet, e.g. "A:C", "A2:C100", or "A2:C";
+// `StartRow`/`EndRow` are 1-based, both 0 when the range spans all rows, and
+// `EndRow` alone is 0 when the range runs to the sheet's last row.
+type Range struct {
+	StartColumn int
+	EndColumn   int
+	StartRow    int
+	EndRow      int
+}
+
+// ParseRange parses a range without a sheet name, e.g. "A:C", "A2:C100", or
+// "A2:C" (from row 2 to the last row).
+func ParseRange(s string) (Range, error) {
+	if strings.Contains(s, "!") {
+		return Range{}, fmt.Errorf("%w: %q has a sheet name, expected e.g. \"A:C\" or \"A2:C100\"", ErrInvalidRange, s)
+	}
+	bounds := strings.Split(strings.TrimSpace(s), ":")
+	if len(bounds) != 2 {
+		return Range{}, fmt.Errorf("%w: %q, expected e.g. \"A:C\" or \"A2:C100\"", ErrInvalidRange, s)
+	}
+	startColumn, startRow, err := ParseCell(bounds[0])
+	if err != nil {
+		return Range{}, err
+	}
+	endColumn, endRow, err := ParseCell(bounds[1])
+	if err != nil {
+		return Range{}, err
+	}
+	r := Range{StartColumn: startColumn, EndColumn: endColumn, StartRow: startRow, EndRow: endRow}
+	if r.EndColumn < r.StartColumn || (r.EndRow != 0 && r.EndRow < r.StartRow) || (r.StartRow == 0 && r.EndRow != 0) {
+		return Range{}, fmt.Errorf("%w: %q", ErrInvalidRange, s)
+	}
+	return r, nil
+}
+
+// ParseCell parses a cell reference, e.g. "C", "C100", or "'Sheet'!C100" (the
+// sheet name is ignored), returning the 0-based column index and the 1-based
+// row (0 when there's none).
+func ParseCell(s string) (column, row int, err error) {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(s, "!"); i != -1 {
+		if i == 0 {
+			return 0, 0, fmt.Errorf("%w: no sheet name in %q", ErrInvalidRange, s)
+		}
+		s = s[i+1:]
+	}
+	i := strings.IndexFunc(s, func(r rune) bool { return r >= '0' && r <= '9' })
+	if i == -1 {
+		i = len(s)
+	}
+	column, err = ColumnIndex(s[:i])
+	if err != nil {
+		return 0, 0, err
+	}
+	if i < len(s) {
+		row, err = strconv.Atoi(s[i:])
+		if err != nil || row < 1 {
+			return 0, 0, fmt.Errorf("%w: invalid row in %q", ErrInvalidRange, s)
+		}
+	}
+	return column, row, nil
+}
+
+// Width returns the number of columns in the range.
+func (r Range) Width() int {
+	return r.EndColumn - r.StartColumn + 1
+}
+
+// String returns the range in A1 notation without a sheet name, e.g. "A:C",
+// "A2:C100", or "A2:C".
+func (r Range) String() string {
+	start, end := ColumnLetter(r.StartColumn), ColumnLetter(r.EndColumn)
+	if r.StartRow != 0 {
+		start += strconv.Itoa(r.StartRow)
+	}
+	if r.EndRow != 0 {
+		end += strconv.Itoa(r.EndRow)
+	}
+	return start + ":" + end
+}
+
+// Rows returns the A1 notation of the range's columns for the rows
+// `start-end`, e.g. "'Sheet Name'!A1:C10".
+func (r Range) Rows(sheetName string, start, end int) string {
+	return fmt.Sprintf("%s!%s%d:%s%d", QuoteSheetName(sheetName), ColumnLetter(r.StartColumn), start, ColumnLetter(r.EndColumn), end)
+}
+
+// QuoteSheetName quotes a sheet name for A1 notation, doubling the quotes in
+// it.
+func QuoteSheetName(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
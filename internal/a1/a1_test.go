@@ -0,0 +1,96 @@
+package a1
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestColumnLetter(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{1, "B"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := ColumnLetter(tt.index); got != tt.want {
+			t.Errorf("ColumnLetter(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+		// the letters round-trip
+		if index, err := ColumnIndex(tt.want); err != nil || index != tt.index {
+			t.Errorf("ColumnIndex(%q) = %d, %v, want %d", tt.want, index, err, tt.index)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Range
+	}{
+		{"A:C", Range{StartColumn: 0, EndColumn: 2}},
+		{"A2:C100", Range{StartColumn: 0, EndColumn: 2, StartRow: 2, EndRow: 100}},
+		// from row 2 to the last row
+		{"A2:Z", Range{StartColumn: 0, EndColumn: 25, StartRow: 2}},
+		{" b3:b3 ", Range{StartColumn: 1, EndColumn: 1, StartRow: 3, EndRow: 3}},
+		{"AA1:AB", Range{StartColumn: 26, EndColumn: 27, StartRow: 1}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRange(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRange(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+			continue
+		}
+		// the range round-trips, but for the case and spaces
+		if again, err := ParseRange(got.String()); err != nil || again != got {
+			t.Errorf("ParseRange(%q) = %+v, %v, want %+v", got.String(), again, err, got)
+		}
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	for _, in := range []string{
+		"", "A", "A1", "A:B:C", "C:A", "A10:B2",
+		// a row at the end only
+		"A:Z2",
+		"Sheet!A:C", "A0:B", "1:2", "A-1:B", "A1:B2x",
+	} {
+		if got, err := ParseRange(in); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("ParseRange(%q) = %+v, %v, want ErrInvalidRange", in, got, err)
+		}
+	}
+}
+
+func TestParseCell(t *testing.T) {
+	tests := []struct {
+		in     string
+		column int
+		row    int
+	}{
+		{"A", 0, 0},
+		{"C100", 2, 100},
+		{"Sheet!B3", 1, 3},
+		{"'My Sheet'!AA1", 26, 1},
+		// the sheet name can hold a "!"
+		{"'Hi!'!B", 1, 0},
+	}
+	for _, tt := range tests {
+		column, row, err := ParseCell(tt.in)
+		if err != nil || column != tt.column || row != tt.row {
+			t.Errorf("ParseCell(%q) = %d, %d, %v, want %d, %d", tt.in, column, row, err, tt.column, tt.row)
+		}
+	}
+	for _, in := range []string{"", "1", "A0", "A-1", "A1B", "!B3", "Sheet!", "\u00c91"} {
+		if column, row, err := ParseCell(in); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("ParseCell(%q) = %d, %d, %v, want ErrInvalidRange", in, column, row, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package cells reads the formatted values of sheet cells as typed values, and
+// gives typed access to a row's cells by header name (see `Row`).
+package cells
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Inferred column types, named after the Go type used for them.
+const (
+	TypeString = "string"
+	TypeInt    = "int64"
+	TypeFloat  = "float64"
+	TypeBool   = "bool"
+	TypeTime   = "time.Time"
+)
+
+// Format describes how formatted cell values are parsed into typed values,
+// for type inference and type-aware comparisons.
+type Format struct {
+	// DecimalComma swaps the thousand/decimal separators, e.g. "1.234,56"
+	DecimalComma bool
+	// Locale is the locale the booleans and dates below come from, e.g. "es_ES"
+	Locale string
+	// TrueLiterals/FalseLiterals are read as booleans besides "true"/"false"
+	TrueLiterals  []string
+	FalseLiterals []string
+	// DayFirst reads "01/02/2024" as the 1st of February; DateSeparator
+	// defaults to "/"
+	DayFirst      bool
+	DateSeparator string
+}
+
+// normalizeNumber converts a decimal-comma number to the "1234.56" form.
+func (f Format) normalizeNumber(s string) string {
+	s = strings.TrimSpace(s)
+	if !f.DecimalComma {
+		return s
+	}
+	return strings.Replace(strings.Replace(s, ".", "", -1), ",", ".", 1)
+}
+
+// ParseInt parses an integer cell value.
+func (f Format) ParseInt(s string) (int64, bool) {
+	n, err := strconv.ParseInt(f.normalizeNumber(s), 10, 64)
+	return n, err == nil
+}
+
+// ParseNumber parses a numeric cell value.
+func (f Format) ParseNumber(s string) (float64, bool) {
+	n, err := strconv.ParseFloat(f.normalizeNumber(s), 64)
+	return n, err == nil
+}
+
+// ParseBool parses a boolean cell value, case-insensitively.
+func (f Format) ParseBool(s string) (bool, bool) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "true") || containsFold(f.TrueLiterals, s) {
+		return true, true
+	}
+	if strings.EqualFold(s, "false") || containsFold(f.FalseLiterals, s) {
+		return false, true
+	}
+	return false, false
+}
+
+// containsFold reports whether `values` contains `s`, ignoring case.
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// dateLayouts returns the date formats recognized when parsing cell values:
+// ISO 8601 dates, and numeric dates in the locale's day/month order.
+func (f Format) dateLayouts() []string {
+	separator := f.DateSeparator
+	if separator == "" {
+		separator = "/"
+	}
+	date := "1" + separator + "2" + separator + "2006"
+	if f.DayFirst {
+		date = "2" + separator + "1" + separator + "2006"
+	}
+	return []string{
+		time.RFC3339,
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+		date + " 15:04:05",
+		date,
+	}
+}
+
+// ParseTime parses a date cell value using any of the `dateLayouts`.
+func (f Format) ParseTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range f.dateLayouts() {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// AmbiguousDate returns the first of `values` that's a different date when
+// read in the other day/month order, e.g. "01/02/2024"; an empty string is
+// returned when there's none.
+func (f Format) AmbiguousDate(values []string) string {
+	swapped := f
+	swapped.DayFirst = !f.DayFirst
+	for _, v := range values {
+		t, ok := f.ParseTime(v)
+		if !ok {
+			continue
+		}
+		if u, ok := swapped.ParseTime(v); ok && !t.Equal(u) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// InferColumnType returns the narrowest type that every non-empty value in
+// `values` can be parsed as, falling back to `TypeString`; a column without
+// any values is a string.
+func (f Format) InferColumnType(values []string) string {
+	candidates := []string{TypeInt, TypeFloat, TypeBool, TypeTime}
+	seen := false
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		seen = true
+		remaining := candidates[:0:0]
+		for _, candidate := range candidates {
+			if f.parsesAs(v, candidate) {
+				remaining = append(remaining, candidate)
+			}
+		}
+		candidates = remaining
+		if len(candidates) == 0 {
+			return TypeString
+		}
+	}
+	if !seen {
+		return TypeString
+	}
+	return candidates[0]
+}
+
+// parsesAs reports whether `v` can be parsed as the inferred type `t`.
+func (f Format) parsesAs(v, t string) bool {
+	var ok bool
+	switch t {
+	case TypeInt:
+		_, ok = f.ParseInt(v)
+	case TypeFloat:
+		_, ok = f.ParseNumber(v)
+	case TypeBool:
+		_, ok = f.ParseBool(v)
+	case TypeTime:
+		_, ok = f.ParseTime(v)
+	default:
+		ok = true
+	}
+	return ok
+}
+
+// SuggestsDecimalComma reports whether `values` only look numeric when read
+// with decimal commas, e.g. "1.234,56", so `DECIMAL_COMMA` should be enabled.
+func (f Format) SuggestsDecimalComma(values []string) bool {
+	if f.DecimalComma {
+		return false
+	}
+	comma := Format{DecimalComma: true}
+	hasComma := false
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		if _, ok := comma.ParseNumber(v); !ok {
+			return false
+		}
+		if strings.Contains(v, ",") {
+			hasComma = true
+		}
+	}
+	return hasComma && f.InferColumnType(values) == TypeString
+}
+
+// StripInvisiblePrefix removes a leading byte order mark and zero-width
+// characters, which sheets imported from CSV files sometimes carry in the
+// first cell.
+func StripInvisiblePrefix(s string) string {
+	return strings.TrimLeft(s, "\ufeff\u200b\u200c\u200d\u2060")
+}
+
+// DateOrder describes the day/month order dates are read in, for messages.
+func (f Format) DateOrder() string {
+	order := "month-first"
+	if f.DayFirst {
+		order = "day-first"
+	}
+	if f.Locale == "" {
+		return order
+	}
+	return fmt.Sprintf("%s (LOCALE %s)", order, f.Locale)
+}
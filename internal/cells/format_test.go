@@ -0,0 +1,102 @@
+package cells
+
+import "testing"
+
+func TestInferColumnType(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"1", "-2", " 30 "}, TypeInt},
+		{[]string{"1", "2.5"}, TypeFloat},
+		{[]string{"1e3", ".5"}, TypeFloat},
+		{[]string{"TRUE", "false", "True"}, TypeBool},
+		{[]string{"2024-03-01", "2024-03-01 10:00:00", "2024-03-01T10:00:00Z"}, TypeTime},
+		{[]string{"3/1/2024"}, TypeTime},
+		// blank cells don't count
+		{[]string{"", "1", " "}, TypeInt},
+		// a single value of another type makes the column a string
+		{[]string{"1", "n/a"}, TypeString},
+		{[]string{"TRUE", "1"}, TypeString},
+		{[]string{"2024-03-01", "yesterday"}, TypeString},
+		// no values
+		{nil, TypeString},
+		{[]string{"", ""}, TypeString},
+	}
+	for _, tt := range tests {
+		if got := (Format{}).InferColumnType(tt.values); got != tt.want {
+			t.Errorf("InferColumnType(%q) = %s, want %s", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDecimalComma(t *testing.T) {
+	comma := Format{DecimalComma: true}
+	tests := []struct {
+		format Format
+		s      string
+		want   float64
+		ok     bool
+	}{
+		{Format{}, "1234.56", 1234.56, true},
+		{Format{}, "1.234,56", 0, false},
+		{comma, "1.234,56", 1234.56, true},
+		{comma, "1.234.567", 1234567, true},
+		{comma, " -0,5 ", -0.5, true},
+		{comma, "12", 12, true},
+		{comma, "1,2,3", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := tt.format.ParseNumber(tt.s); got != tt.want || ok != tt.ok {
+			t.Errorf("ParseNumber(%q) with DecimalComma %v = %v, %v, want %v, %v", tt.s, tt.format.DecimalComma, got, ok, tt.want, tt.ok)
+		}
+	}
+	if n, ok := comma.ParseInt("1.234"); n != 1234 || !ok {
+		t.Errorf("ParseInt(%q) with DecimalComma = %d, %v, want 1234, true", "1.234", n, ok)
+	}
+	if got := comma.InferColumnType([]string{"1.234,5", "2,25"}); got != TypeFloat {
+		t.Errorf("InferColumnType of decimal-comma numbers = %s, want %s", got, TypeFloat)
+	}
+}
+
+func TestSuggestsDecimalComma(t *testing.T) {
+	tests := []struct {
+		format Format
+		values []string
+		want   bool
+	}{
+		{Format{}, []string{"1.234,56", "7,5", ""}, true},
+		// already numbers as they are
+		{Format{}, []string{"1234.56", "7.5"}, false},
+		{Format{}, []string{"1,5"}, true},
+		// without a comma, e.g. "1.234", they're numbers either way
+		{Format{}, []string{"1.234"}, false},
+		{Format{}, []string{"7,5", "n/a"}, false},
+		{Format{DecimalComma: true}, []string{"7,5"}, false},
+		{Format{}, nil, false},
+	}
+	for _, tt := range tests {
+		if got := tt.format.SuggestsDecimalComma(tt.values); got != tt.want {
+			t.Errorf("SuggestsDecimalComma(%q) with DecimalComma %v = %v, want %v", tt.values, tt.format.DecimalComma, got, tt.want)
+		}
+	}
+}
+
+func TestStripInvisiblePrefix(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"Name", "Name"},
+		{"\ufeffName", "Name"},
+		{"\u200b\u200dName", "Name"},
+		{"\ufeff\u2060", ""},
+		// only the prefix
+		{"Na\u200bme\ufeff", "Na\u200bme\ufeff"},
+		{" \ufeffName", " \ufeffName"},
+	}
+	for _, tt := range tests {
+		if got := StripInvisiblePrefix(tt.s); got != tt.want {
+			t.Errorf("StripInvisiblePrefix(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
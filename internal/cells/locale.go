@@ -0,0 +1,53 @@
+package cells
+
+import "strings"
+
+// LocaleFormat is how a spreadsheet locale writes booleans and dates.
+type LocaleFormat struct {
+	TrueLiterals  []string
+	FalseLiterals []string
+	DayFirst      bool
+	DateSeparator string
+}
+
+// localeFormats are the built-in locales, keyed by either a full locale (e.g.
+// "en_GB") or just its language (e.g. "es"); a full locale takes precedence.
+var localeFormats = map[string]LocaleFormat{
+	"en":    {DateSeparator: "/"},
+	"en_AU": {DayFirst: true, DateSeparator: "/"},
+	"en_GB": {DayFirst: true, DateSeparator: "/"},
+	"en_IE": {DayFirst: true, DateSeparator: "/"},
+	"en_IN": {DayFirst: true, DateSeparator: "/"},
+	"en_NZ": {DayFirst: true, DateSeparator: "/"},
+	"es":    {TrueLiterals: []string{"verdadero"}, FalseLiterals: []string{"falso"}, DayFirst: true, DateSeparator: "/"},
+	"de":    {TrueLiterals: []string{"wahr"}, FalseLiterals: []string{"falsch"}, DayFirst: true, DateSeparator: "."},
+	"fr":    {TrueLiterals: []string{"vrai"}, FalseLiterals: []string{"faux"}, DayFirst: true, DateSeparator: "/"},
+	"it":    {TrueLiterals: []string{"vero"}, FalseLiterals: []string{"falso"}, DayFirst: true, DateSeparator: "/"},
+	"pt":    {TrueLiterals: []string{"verdadeiro"}, FalseLiterals: []string{"falso"}, DayFirst: true, DateSeparator: "/"},
+	"nl":    {TrueLiterals: []string{"waar"}, FalseLiterals: []string{"onwaar"}, DayFirst: true, DateSeparator: "-"},
+}
+
+// LookupLocale returns the built-in format of `locale`, e.g. "es_ES" or
+// "de-DE", falling back to its language; false is returned for unknown
+// locales.
+func LookupLocale(locale string) (LocaleFormat, bool) {
+	locale = strings.Replace(strings.TrimSpace(locale), "-", "_", -1)
+	if format, ok := localeFormats[locale]; ok {
+		return format, true
+	}
+	language := strings.ToLower(strings.SplitN(locale, "_", 2)[0])
+	format, ok := localeFormats[language]
+	return format, ok
+}
+
+// WithLocale returns the format reading booleans and dates the way the built-in
+// `locale` writes them; false is returned for unknown locales, read as en_US.
+func (f Format) WithLocale(locale string) (Format, bool) {
+	format, ok := LookupLocale(locale)
+	f.Locale = locale
+	f.TrueLiterals = format.TrueLiterals
+	f.FalseLiterals = format.FalseLiterals
+	f.DayFirst = format.DayFirst
+	f.DateSeparator = format.DateSeparator
+	return f, ok
+}
@@ -0,0 +1,119 @@
+package cells
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookupLocale(t *testing.T) {
+	tests := []struct {
+		locale   string
+		dayFirst bool
+		trueLit  string
+		ok       bool
+	}{
+		{"en_US", false, "", true},
+		{"en_GB", true, "", true},
+		{"es_ES", true, "verdadero", true},
+		{"es_MX", true, "verdadero", true},
+		{"de-DE", true, "wahr", true},
+		{" fr_CA ", true, "vrai", true},
+		{"xx_XX", false, "", false},
+		{"", false, "", false},
+	}
+	for _, tt := range tests {
+		format, ok := LookupLocale(tt.locale)
+		trueLit := ""
+		if len(format.TrueLiterals) > 0 {
+			trueLit = format.TrueLiterals[0]
+		}
+		if ok != tt.ok || format.DayFirst != tt.dayFirst || trueLit != tt.trueLit {
+			t.Errorf("LookupLocale(%q) = %+v, %v, want DayFirst %v, true literal %q, %v", tt.locale, format, ok, tt.dayFirst, tt.trueLit, tt.ok)
+		}
+	}
+}
+
+func TestFormatWithLocale(t *testing.T) {
+	bools := []struct {
+		locale string
+		s      string
+		want   bool
+		ok     bool
+	}{
+		{"es_ES", "VERDADERO", true, true},
+		{"es_ES", "falso", false, true},
+		// the English literals are always read
+		{"de_DE", "TRUE", true, true},
+		{"de_DE", "Falsch", false, true},
+		{"en_US", "wahr", false, false},
+	}
+	for _, tt := range bools {
+		f, _ := Format{}.WithLocale(tt.locale)
+		if got, ok := f.ParseBool(tt.s); got != tt.want || ok != tt.ok {
+			t.Errorf("ParseBool(%q) with LOCALE %s = %v, %v, want %v, %v", tt.s, tt.locale, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	dates := []struct {
+		locale string
+		s      string
+		want   time.Time
+		ok     bool
+	}{
+		{"en_US", "03/02/2024", time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), true},
+		{"en_GB", "03/02/2024", time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC), true},
+		{"de_DE", "03.02.2024", time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC), true},
+		{"de_DE", "03/02/2024", time.Time{}, false},
+		{"nl_NL", "3-2-2024 10:30:00", time.Date(2024, 2, 3, 10, 30, 0, 0, time.UTC), true},
+		// ISO dates in every locale
+		{"fr_FR", "2024-02-03", time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC), true},
+		{"en_GB", "13/02/2024", time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC), true},
+		{"en_US", "13/02/2024", time.Time{}, false},
+	}
+	for _, tt := range dates {
+		f, _ := Format{}.WithLocale(tt.locale)
+		if got, ok := f.ParseTime(tt.s); !got.Equal(tt.want) || ok != tt.ok {
+			t.Errorf("ParseTime(%q) with LOCALE %s = %v, %v, want %v, %v", tt.s, tt.locale, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if f, ok := (Format{DecimalComma: true}).WithLocale("xx_XX"); ok || f.Locale != "xx_XX" || f.DayFirst || !f.DecimalComma {
+		t.Errorf("WithLocale of an unknown locale = %+v, %v, want en_US formats keeping DecimalComma", f, ok)
+	}
+}
+
+func TestAmbiguousDate(t *testing.T) {
+	tests := []struct {
+		dayFirst bool
+		values   []string
+		want     string
+	}{
+		{false, []string{"13/02/2024", " 03/02/2024 ", "04/02/2024"}, "03/02/2024"},
+		{true, []string{"03/02/2024"}, "03/02/2024"},
+		// the same date either way
+		{false, []string{"02/02/2024", "2024-03-02"}, ""},
+		{false, []string{"13/02/2024", "n/a"}, ""},
+		{false, nil, ""},
+	}
+	for _, tt := range tests {
+		if got := (Format{DayFirst: tt.dayFirst}).AmbiguousDate(tt.values); got != tt.want {
+			t.Errorf("AmbiguousDate(%q) with DayFirst %v = %q, want %q", tt.values, tt.dayFirst, got, tt.want)
+		}
+	}
+}
+
+func TestDateOrder(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{Format{}, "month-first"},
+		{Format{DayFirst: true}, "day-first"},
+		{Format{DayFirst: true, Locale: "en_GB"}, "day-first (LOCALE en_GB)"},
+	}
+	for _, tt := range tests {
+		if got := tt.format.DateOrder(); got != tt.want {
+			t.Errorf("DateOrder of %+v = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package cells
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// `NUMBER_MODE` values, how numeric cells are output in the rows' JSON objects.
+const (
+	// NumberModeString outputs them as the formatted strings (the default)
+	NumberModeString = "string"
+	// NumberModeFloat outputs them as JSON numbers, integers beyond 2^53
+	// losing precision
+	NumberModeFloat = "float"
+	// NumberModeAuto outputs them as JSON numbers, except for the integers
+	// beyond 2^53 (e.g. IDs), kept as strings since JSON decoders read numbers
+	// as float64
+	NumberModeAuto = "auto"
+)
+
+// maxExactInt is the largest integer a float64 holds exactly.
+const maxExactInt = 1 << 53
+
+// decimalNumber matches plain decimal numbers, leaving out what
+// `strconv.ParseFloat` parses besides (e.g. "0x1p3", "Inf", "1_000").
+var decimalNumber = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// NumberValue returns the numeric cell value `s` as a JSON number with the
+// `mode`; false is returned when it isn't a number, or is kept as a string.
+func (f Format) NumberValue(s, mode string) (interface{}, bool) {
+	if mode != NumberModeFloat && mode != NumberModeAuto {
+		return nil, false
+	}
+	n := f.normalizeNumber(s)
+	if !decimalNumber.MatchString(n) {
+		return nil, false
+	}
+	if mode == NumberModeAuto {
+		if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+			if i > maxExactInt || i < -maxExactInt {
+				return nil, false
+			}
+			return i, true
+		} else if err.(*strconv.NumError).Err == strconv.ErrRange {
+			return nil, false
+		}
+	}
+	v, err := strconv.ParseFloat(n, 64)
+	if err != nil || math.IsInf(v, 0) {
+		return nil, false
+	}
+	return v, true
+}
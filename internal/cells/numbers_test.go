@@ -0,0 +1,44 @@
+package cells
+
+import "testing"
+
+func TestNumberValue(t *testing.T) {
+	comma := Format{DecimalComma: true}
+	tests := []struct {
+		format Format
+		s      string
+		mode   string
+		want   interface{}
+		ok     bool
+	}{
+		{Format{}, "42", NumberModeString, nil, false},
+		{Format{}, "42", "", nil, false},
+		{Format{}, "42", NumberModeFloat, 42.0, true},
+		{Format{}, "42", NumberModeAuto, int64(42), true},
+		{Format{}, "-3.5", NumberModeAuto, -3.5, true},
+		{Format{}, " .5 ", NumberModeFloat, 0.5, true},
+		{Format{}, "1e3", NumberModeAuto, 1000.0, true},
+		{comma, "1.234,5", NumberModeFloat, 1234.5, true},
+		// 2^53 is exact, 2^53+1 isn't
+		{Format{}, "9007199254740992", NumberModeAuto, int64(9007199254740992), true},
+		{Format{}, "-9007199254740992", NumberModeAuto, int64(-9007199254740992), true},
+		{Format{}, "9007199254740993", NumberModeAuto, nil, false},
+		{Format{}, "-9007199254740993", NumberModeAuto, nil, false},
+		{Format{}, "9007199254740993", NumberModeFloat, 9007199254740992.0, true},
+		{Format{}, "99999999999999999999", NumberModeAuto, nil, false},
+		// not plain decimal numbers
+		{Format{}, "0x1p3", NumberModeFloat, nil, false},
+		{Format{}, "Inf", NumberModeFloat, nil, false},
+		{Format{}, "NaN", NumberModeFloat, nil, false},
+		{Format{}, "1_000", NumberModeFloat, nil, false},
+		{Format{}, "1e400", NumberModeFloat, nil, false},
+		{Format{}, "12 apples", NumberModeAuto, nil, false},
+		{Format{}, "", NumberModeAuto, nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.format.NumberValue(tt.s, tt.mode)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NumberValue(%q, %q) with DecimalComma %v = %#v, %v, want %#v, %v", tt.s, tt.mode, tt.format.DecimalComma, got, ok, tt.want, tt.ok)
+		}
+	}
+}
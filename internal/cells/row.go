@@ -0,0 +1,191 @@
+package cells
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Row is a sheet row along with its header, giving typed access to its cells
+// by header name, e.g.:
+//
+//	if age, ok := row.Int("Age"); ok {
+//		...
+//	}
+//
+// The accessors return false when the sheet has no such column, the cell is
+// blank (the API omits trailing empty cells), or the value can't be parsed as
+// the requested type; numbers, booleans, and dates are parsed as described by
+// the row's `Format`.
+type Row struct {
+	header *Header
+	cells  []interface{}
+	format Format
+}
+
+// NewRow returns the `cells` of a row of a sheet with the `headers` row; when
+// headers are duplicated, the first column with that name is used.
+func NewRow(headers, cells []interface{}, format Format) Row {
+	return NewHeader(headers).Row(cells, format)
+}
+
+// Header is the header of a sheet's rows as strings, and the column of each
+// header; it's meant to be shared by the rows of the sheet, rather than built
+// again for every row.
+type Header struct {
+	names []string
+	index map[string]int
+}
+
+// NewHeader returns the header of the `headers` row.
+func NewHeader(headers []interface{}) *Header {
+	h := &Header{names: make([]string, len(headers)), index: make(map[string]int, len(headers))}
+	for i, header := range headers {
+		h.names[i] = fmt.Sprint(header)
+		if _, ok := h.index[h.names[i]]; !ok && h.names[i] != "" {
+			h.index[h.names[i]] = i
+		}
+	}
+	return h
+}
+
+// Names returns the headers as strings, by column.
+func (h *Header) Names() []string {
+	return h.names
+}
+
+// Index returns the 0-based column of the header `name` (the first one when
+// it's duplicated).
+func (h *Header) Index(name string) (int, bool) {
+	i, ok := h.index[name]
+	return i, ok
+}
+
+// Row returns the `cells` of a row with the header.
+func (h *Header) Row(cells []interface{}, format Format) Row {
+	return Row{header: h, cells: cells, format: format}
+}
+
+// Names returns the row's headers, by column.
+func (r Row) Names() []string {
+	return r.header.names
+}
+
+// Cell returns the cell in the 0-based column `i` as returned by the API;
+// it's false past the end of the row, where the API omitted the cells.
+func (r Row) Cell(i int) (interface{}, bool) {
+	if i < 0 || i >= len(r.cells) {
+		return nil, false
+	}
+	return r.cells[i], true
+}
+
+// CellString returns the value of the cell in the 0-based column `i`.
+func (r Row) CellString(i int) (string, bool) {
+	cell, ok := r.Cell(i)
+	if !ok {
+		return "", false
+	}
+	s, ok := cell.(string)
+	if !ok {
+		s = fmt.Sprint(cell)
+	}
+	return s, s != ""
+}
+
+// String returns the value of the `col` cell.
+func (r Row) String(col string) (string, bool) {
+	i, ok := r.header.index[col]
+	if !ok {
+		return "", false
+	}
+	return r.CellString(i)
+}
+
+// MustString returns the value of the `col` cell, panicking if the sheet has
+// no such column; a blank cell is an empty string.
+func (r Row) MustString(col string) string {
+	if _, ok := r.header.index[col]; !ok {
+		panic(fmt.Sprintf("no %q column in %q", col, r.header.names))
+	}
+	s, _ := r.String(col)
+	return s
+}
+
+// Raw returns the `col` cell as returned by the API, before any conversion.
+func (r Row) Raw(col string) (interface{}, bool) {
+	i, ok := r.header.index[col]
+	if !ok {
+		return nil, false
+	}
+	return r.Cell(i)
+}
+
+// Int returns the `col` cell as an integer.
+func (r Row) Int(col string) (int64, bool) {
+	s, ok := r.String(col)
+	if !ok {
+		return 0, false
+	}
+	return r.format.ParseInt(s)
+}
+
+// Float returns the `col` cell as a number.
+func (r Row) Float(col string) (float64, bool) {
+	s, ok := r.String(col)
+	if !ok {
+		return 0, false
+	}
+	return r.format.ParseNumber(s)
+}
+
+// Bool returns the `col` cell as a boolean.
+func (r Row) Bool(col string) (bool, bool) {
+	s, ok := r.String(col)
+	if !ok {
+		return false, false
+	}
+	return r.format.ParseBool(s)
+}
+
+// Time returns the `col` cell as a time parsed with `layout`, or with any of
+// the recognized date formats when `layout` is empty.
+func (r Row) Time(col, layout string) (time.Time, bool) {
+	s, ok := r.String(col)
+	if !ok {
+		return time.Time{}, false
+	}
+	if layout == "" {
+		return r.format.ParseTime(s)
+	}
+	t, err := time.Parse(layout, strings.TrimSpace(s))
+	return t, err == nil
+}
+
+// AsMap returns the row's non-blank cells keyed by their header; like the
+// accessors, it has the first column of a duplicated header.
+func (r Row) AsMap() map[string]interface{} {
+	m := map[string]interface{}{}
+	for i, header := range r.header.names {
+		if r.header.index[header] != i {
+			continue
+		}
+		if s, ok := r.CellString(i); ok && header != "" {
+			m[header] = s
+		}
+	}
+	return m
+}
+
+// AsSlice returns the row's cells, padded with empty cells to the header's
+// width.
+func (r Row) AsSlice() []interface{} {
+	cells := make([]interface{}, len(r.header.names))
+	for i := range cells {
+		cells[i], _ = r.CellString(i)
+	}
+	if len(r.cells) > len(cells) {
+		cells = append(cells, r.cells[len(cells):]...)
+	}
+	return cells
+}
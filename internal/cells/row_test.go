@@ -0,0 +1,126 @@
+package cells
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var rowHeaders = []interface{}{"Name", "Age", "Score", "Member", "Joined", "Name", ""}
+
+func TestRowAccessors(t *testing.T) {
+	row := NewRow(rowHeaders, []interface{}{"Ann", "42", "1.234,5", "TRUE", "03/02/2024", "Duplicate", "untitled"}, Format{DecimalComma: true, DayFirst: true})
+	short := NewRow(rowHeaders, []interface{}{"Bob", "", "n/a"}, Format{})
+
+	strs := []struct {
+		row  Row
+		col  string
+		want string
+		ok   bool
+	}{
+		{row, "Name", "Ann", true},
+		{row, "Missing", "", false},
+		// blank and omitted cells
+		{short, "Age", "", false},
+		{short, "Member", "", false},
+	}
+	for _, tt := range strs {
+		if got, ok := tt.row.String(tt.col); got != tt.want || ok != tt.ok {
+			t.Errorf("String(%q) = %q, %v, want %q, %v", tt.col, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	ints := []struct {
+		row  Row
+		col  string
+		want int64
+		ok   bool
+	}{
+		{row, "Age", 42, true},
+		{row, "Score", 0, false},
+		{short, "Age", 0, false},
+	}
+	for _, tt := range ints {
+		if got, ok := tt.row.Int(tt.col); got != tt.want || ok != tt.ok {
+			t.Errorf("Int(%q) = %v, %v, want %v, %v", tt.col, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	floats := []struct {
+		row  Row
+		col  string
+		want float64
+		ok   bool
+	}{
+		{row, "Score", 1234.5, true},
+		{short, "Score", 0, false},
+	}
+	for _, tt := range floats {
+		if got, ok := tt.row.Float(tt.col); got != tt.want || ok != tt.ok {
+			t.Errorf("Float(%q) = %v, %v, want %v, %v", tt.col, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if got, ok := row.Bool("Member"); !got || !ok {
+		t.Errorf("Bool(Member) = %v, %v, want true, true", got, ok)
+	}
+	if _, ok := row.Bool("Name"); ok {
+		t.Errorf("Bool(Name) ok, want false")
+	}
+
+	want := time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)
+	if got, ok := row.Time("Joined", ""); !ok || !got.Equal(want) {
+		t.Errorf("Time(Joined) = %v, %v, want %v", got, ok, want)
+	}
+	want = time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	if got, ok := row.Time("Joined", "01/02/2006"); !ok || !got.Equal(want) {
+		t.Errorf("Time(Joined, layout) = %v, %v, want %v", got, ok, want)
+	}
+
+	if got, ok := row.Raw("Age"); got != "42" || !ok {
+		t.Errorf("Raw(Age) = %v, %v, want 42", got, ok)
+	}
+	if got, ok := short.Raw("Joined"); got != nil || ok {
+		t.Errorf("Raw of an omitted cell = %v, %v, want nil, false", got, ok)
+	}
+}
+
+func TestRowAsMapAsSlice(t *testing.T) {
+	row := NewRow(rowHeaders, []interface{}{"Ann", "", 7.0, "TRUE", "", "Duplicate", "untitled", "extra"}, Format{})
+	// the duplicated "Name" header is its first column, like with `String`
+	wantMap := map[string]interface{}{"Name": "Ann", "Score": "7", "Member": "TRUE"}
+	if got := row.AsMap(); !reflect.DeepEqual(got, wantMap) {
+		t.Errorf("AsMap = %v, want %v", got, wantMap)
+	}
+
+	short := NewRow(rowHeaders, []interface{}{"Bob"}, Format{})
+	wantSlice := []interface{}{"Bob", "", "", "", "", "", ""}
+	if got := short.AsSlice(); !reflect.DeepEqual(got, wantSlice) {
+		t.Errorf("AsSlice = %v, want %v", got, wantSlice)
+	}
+	if got := row.AsSlice(); len(got) != 8 || got[7] != "extra" {
+		t.Errorf("AsSlice = %v, want the cells past the header kept", got)
+	}
+}
+
+func TestRowMustString(t *testing.T) {
+	row := NewRow(rowHeaders, []interface{}{"Ann"}, Format{})
+	if got := row.MustString("Name"); got != "Ann" {
+		t.Errorf("MustString(Name) = %q, want Ann", got)
+	}
+	if got := row.MustString("Age"); got != "" {
+		t.Errorf("MustString of a blank cell = %q, want empty", got)
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("MustString of a missing column didn't panic")
+		}
+		if msg, ok := r.(string); !ok || !strings.Contains(msg, `"Missing"`) {
+			t.Errorf("panic = %v, want it to name the column", r)
+		}
+	}()
+	row.MustString("Missing")
+}
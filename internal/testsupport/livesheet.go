@@ -0,0 +1,90 @@
+//go:build integration
+// +build integration
+
+package testsupport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+)
+
+// LiveSheet is a temporary sheet of the TEST_SPREADSHEET_ID spreadsheet, for
+// the integration tests (run with `go test -tags integration ./...`) to
+// exercise the real Sheets API with.
+type LiveSheet struct {
+	Service       *sheets.Service
+	SpreadsheetID string
+	Title         string
+	SheetID       int64
+}
+
+// NewLiveSheet adds a sheet holding the `fixture` rows to the TEST_SPREADSHEET_ID
+// spreadsheet, using the service account key of GOOGLE_APPLICATION_CREDENTIALS,
+// and deletes it when the test and its subtests complete, whether or not they
+// failed. The test is skipped when either variable isn't set.
+//
+// The sheet's title is unique, so parallel runs against the same spreadsheet
+// don't collide, and has a space and a quote, so it has to be quoted in A1
+// notation.
+func NewLiveSheet(t *testing.T, fixture [][]interface{}) *LiveSheet {
+	t.Helper()
+	spreadsheetId := os.Getenv("TEST_SPREADSHEET_ID")
+	if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == "" || spreadsheetId == "" {
+		t.Skip("GOOGLE_APPLICATION_CREDENTIALS and TEST_SPREADSHEET_ID aren't set")
+	}
+	ctx := context.Background()
+	service, err := sheets.NewService(ctx, option.WithScopes(sheets.SpreadsheetsScope))
+	if err != nil {
+		t.Fatalf("Unable to create the Sheets service: %v", err)
+	}
+
+	title := uniqueTitle()
+	add := &sheets.Request{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: title}}}
+	resp, err := service.Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: []*sheets.Request{add}}).Context(ctx).Do()
+	if err != nil {
+		t.Fatalf("Unable to add sheet '%s': %v", title, err)
+	}
+	s := &LiveSheet{Service: service, SpreadsheetID: spreadsheetId, Title: title, SheetID: resp.Replies[0].AddSheet.Properties.SheetId}
+	t.Cleanup(func() {
+		remove := &sheets.Request{DeleteSheet: &sheets.DeleteSheetRequest{SheetId: s.SheetID}}
+		if _, err := service.Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: []*sheets.Request{remove}}).Do(); err != nil {
+			t.Errorf("Unable to delete sheet '%s': %v", title, err)
+		}
+	})
+
+	if len(fixture) > 0 {
+		_, err = service.Spreadsheets.Values.Update(spreadsheetId, s.Range("A1"), &sheets.ValueRange{Values: fixture}).
+			ValueInputOption("RAW").
+			Context(ctx).
+			Do()
+		if err != nil {
+			t.Fatalf("Unable to write the fixture: %v", err)
+		}
+	}
+	return s
+}
+
+// Range returns the A1 notation of the `bounds` of the sheet, e.g. "A1:C10".
+func (s *LiveSheet) Range(bounds string) string {
+	return a1.QuoteSheetName(s.Title) + "!" + bounds
+}
+
+// uniqueTitle returns a sheet title no other run uses, e.g.
+// "it's 20261016T120000 3f9a1c2e".
+func uniqueTitle() string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("it's %s %s", time.Now().UTC().Format("20060102T150405"), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,198 @@
+// Package testsupport provides a fake of Google's OAuth endpoints, so the
+// command's auth flows can be tested without network access or a Google
+// account (the fake Sheets API is the public sheetstest package), and the
+// temporary sheets of a real spreadsheet the integration tests run in.
+package testsupport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// deviceCodeGrant is the grant type of the OAuth device flow's token polls.
+const deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+
+// TokenServer is a fake OAuth 2.0 authorization server implementing the parts
+// of Google's the example uses: the authorization endpoint (which approves
+// every request), the device authorization endpoint, and the token endpoint
+// for the authorization code, refresh token, and device code grants.
+//
+// Its endpoints are at AuthURL, TokenURL, and DeviceCodeURL, laid out like
+// Google's (i.e. the device endpoint is "device/code" next to the token one).
+type TokenServer struct {
+	*httptest.Server
+
+	// PendingPolls is how many device flow polls are answered with
+	// "authorization_pending" before the token is issued.
+	PendingPolls int
+	// TokenLifetime is how long the issued access tokens are valid for, an
+	// hour when zero.
+	TokenLifetime time.Duration
+
+	mu            sync.Mutex
+	codes         map[string]bool
+	refreshTokens map[string]bool
+	polls         int
+	issued        int
+	grants        map[string]int
+}
+
+// NewTokenServer starts a TokenServer, the caller must Close it.
+func NewTokenServer() *TokenServer {
+	s := &TokenServer{
+		codes:         map[string]bool{},
+		refreshTokens: map[string]bool{},
+		grants:        map[string]int{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth", s.authorize)
+	mux.HandleFunc("/device/code", s.deviceCode)
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// AuthURL is the authorization endpoint.
+func (s *TokenServer) AuthURL() string { return s.URL + "/auth" }
+
+// TokenURL is the token endpoint.
+func (s *TokenServer) TokenURL() string { return s.URL + "/token" }
+
+// DeviceCodeURL is the device authorization endpoint.
+func (s *TokenServer) DeviceCodeURL() string { return s.URL + "/device/code" }
+
+// NewRefreshToken returns a new refresh token the token endpoint accepts, e.g.
+// to seed a cached token.
+func (s *TokenServer) NewRefreshToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token := fmt.Sprintf("seeded-refresh-%d", len(s.refreshTokens)+1)
+	s.refreshTokens[token] = true
+	return token
+}
+
+// Issued returns how many access tokens were issued.
+func (s *TokenServer) Issued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issued
+}
+
+// Grants returns how many tokens were issued for the `grantType`, e.g.
+// "refresh_token".
+func (s *TokenServer) Grants(grantType string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.grants[grantType]
+}
+
+// authorize approves the request right away: it redirects to the
+// `redirect_uri` with a new code and the request's state, or shows the code
+// when there's no redirect URI (i.e. it's to be pasted).
+func (s *TokenServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") == "" {
+		http.Error(w, "missing client_id", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	code := fmt.Sprintf("code-%d", len(s.codes)+1)
+	s.codes[code] = true
+	s.mu.Unlock()
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirect.Scheme != "http" {
+		fmt.Fprintln(w, code)
+		return
+	}
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *TokenServer) deviceCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.PostFormValue("client_id") == "" {
+		writeOAuthError(w, "invalid_request")
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"device_code":      "device-code",
+		"user_code":        "ABCD-EFGH",
+		"verification_url": s.URL + "/device",
+		"expires_in":       300,
+		"interval":         1,
+	})
+}
+
+func (s *TokenServer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeOAuthError(w, "invalid_request")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grantType := r.PostFormValue("grant_type")
+	switch grantType {
+	case "authorization_code":
+		code := r.PostFormValue("code")
+		if !s.codes[code] {
+			writeOAuthError(w, "invalid_grant")
+			return
+		}
+		// codes are single use
+		delete(s.codes, code)
+	case "refresh_token":
+		if !s.refreshTokens[r.PostFormValue("refresh_token")] {
+			writeOAuthError(w, "invalid_grant")
+			return
+		}
+	case deviceCodeGrant:
+		if r.PostFormValue("device_code") != "device-code" {
+			writeOAuthError(w, "invalid_grant")
+			return
+		}
+		s.polls++
+		if s.polls <= s.PendingPolls {
+			writeOAuthError(w, "authorization_pending")
+			return
+		}
+	default:
+		writeOAuthError(w, "unsupported_grant_type")
+		return
+	}
+	s.issued++
+	s.grants[grantType]++
+	refreshToken := r.PostFormValue("refresh_token")
+	if refreshToken == "" {
+		refreshToken = fmt.Sprintf("refresh-%d", s.issued)
+		s.refreshTokens[refreshToken] = true
+	}
+	lifetime := s.TokenLifetime
+	if lifetime == 0 {
+		lifetime = time.Hour
+	}
+	writeJSON(w, map[string]interface{}{
+		"access_token":  fmt.Sprintf("access-%d", s.issued),
+		"token_type":    "Bearer",
+		"refresh_token": refreshToken,
+		"expires_in":    int(lifetime / time.Second),
+	})
+}
+
+// writeOAuthError writes an OAuth error response with the `code`.
+func writeOAuthError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
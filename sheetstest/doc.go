@@ -0,0 +1,48 @@
+// Package sheetstest provides a fake Sheets API v4 for testing code that uses
+// the Sheets API (directly or through the spreadsheet package) without network
+// access or a Google account:
+//
+//	server := sheetstest.NewServer()
+//	defer server.Close()
+//	server.SetValues("spreadsheet-id", "Sheet1", [][]interface{}{{"Name", "Score"}, {"Ann", "10"}})
+//	service, err := sheets.NewService(ctx, option.WithHTTPClient(server.Client()), option.WithEndpoint(server.Endpoint()))
+//
+// # Behavior
+//
+// The fake serves spreadsheets.get, and values.get, batchGet, update, append,
+// and clear over in-memory sheets, answering like the API does:
+//
+//   - Reads return formatted values: trailing blank cells and rows are left
+//     out, and so is `values` when the range is blank. The echoed `range` is
+//     the range read, bounded by the sheet's grid.
+//   - Values written are stored as the API formats them: numbers in their
+//     shortest form (e.g. "10" or "1.5"), booleans as "TRUE"/"FALSE", and
+//     strings as is; formulas aren't evaluated, RAW and USER_ENTERED store
+//     the same. Values seeded with SetValues are served as given.
+//   - Writes require `valueInputOption`; an update must fit its range (a
+//     single cell range only sets where the values start) and the sheet's
+//     grid, and null cells leave the cells as they are. An append
+//     writes below the last row with values in the range's columns (for a
+//     single cell range, those of the table starting at the cell), starting
+//     at the range's first column, growing the grid if needed.
+//   - Errors are the API's JSON errors, with its messages for the cases
+//     above: "Unable to parse range: X" (400) for unknown sheets or invalid
+//     ranges, "exceeds grid limits" (400) past a sheet's grid, "Requested
+//     entity was not found." (404) for unknown spreadsheets, and the quota
+//     error (429) once ReadQuota/WriteQuota requests have been served.
+//
+// Spreadsheets have the locale en_US and the time zone America/New_York.
+// Value render options other than FORMATTED_VALUE, majorDimension COLUMNS on
+// reads, and the other spreadsheet methods (e.g. batchUpdate) aren't
+// implemented.
+//
+// # Versioning
+//
+// The behavior described here is part of the module's API: changing it is a
+// breaking change, and bumps BehaviorVersion, so tests depending on the fake's
+// behavior can check which one they get.
+package sheetstest
+
+// BehaviorVersion is the version of the fake's documented behavior, bumped
+// whenever it changes.
+const BehaviorVersion = 1
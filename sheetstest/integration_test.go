@@ -0,0 +1,103 @@
+//go:build integration
+// +build integration
+
+package sheetstest_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/testsupport"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+// conformanceFixture is the sheet both the live API and the fake start with.
+var conformanceFixture = [][]interface{}{
+	{"Name", "Score", "Note"},
+	{"Ann", "10", "first"},
+	{"Bob", "7.5"},
+}
+
+// conformanceScenario reads and writes the `title` sheet, which holds the
+// `conformanceFixture`, returning what the API answered with along the way.
+func conformanceScenario(t *testing.T, service *sheets.Service, spreadsheetId, title string) []interface{} {
+	t.Helper()
+	quoted := a1.QuoteSheetName(title)
+	var answers []interface{}
+	check := func(step string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+	}
+
+	batch, err := service.Spreadsheets.Values.BatchGet(spreadsheetId).Ranges(quoted+"!A1:A3", quoted+"!B2:C10").Do()
+	check("batchGet", err)
+	for _, resp := range batch.ValueRanges {
+		answers = append(answers, resp.Range, resp.Values)
+	}
+
+	appended, err := service.Spreadsheets.Values.Append(spreadsheetId, quoted+"!A1", &sheets.ValueRange{Values: [][]interface{}{{"Cy", 3, true}}}).
+		ValueInputOption("RAW").
+		InsertDataOption("INSERT_ROWS").
+		Do()
+	check("append", err)
+	answers = append(answers, appended.TableRange, appended.Updates.UpdatedRange, appended.Updates.UpdatedCells)
+
+	// an upsert by name: updating Bob's row, and appending Dee's
+	for _, row := range [][]interface{}{{"Bob", 8, "again"}, {"Dee", 1.25, nil}} {
+		names, err := service.Spreadsheets.Values.Get(spreadsheetId, quoted+"!A:A").Do()
+		check("get names", err)
+		found := 0
+		for i, name := range names.Values {
+			if len(name) > 0 && name[0] == row[0] {
+				found = i + 1
+			}
+		}
+		if found != 0 {
+			updated, err := service.Spreadsheets.Values.Update(spreadsheetId, fmt.Sprintf("%s!A%d", quoted, found), &sheets.ValueRange{Values: [][]interface{}{row}}).
+				ValueInputOption("RAW").
+				Do()
+			check("update", err)
+			answers = append(answers, updated.UpdatedRange, updated.UpdatedCells)
+			continue
+		}
+		appended, err := service.Spreadsheets.Values.Append(spreadsheetId, quoted+"!A:C", &sheets.ValueRange{Values: [][]interface{}{row}}).
+			ValueInputOption("USER_ENTERED").
+			Do()
+		check("append", err)
+		answers = append(answers, appended.Updates.UpdatedRange, appended.Updates.UpdatedCells)
+	}
+
+	cleared, err := service.Spreadsheets.Values.Clear(spreadsheetId, quoted+"!B2:C2", &sheets.ClearValuesRequest{}).Do()
+	check("clear", err)
+	answers = append(answers, cleared.ClearedRange)
+
+	resp, err := service.Spreadsheets.Values.Get(spreadsheetId, quoted).Do()
+	check("get", err)
+	return append(answers, resp.Range, resp.Values)
+}
+
+// TestLiveConformance checks the fake answers the same as the live API.
+func TestLiveConformance(t *testing.T) {
+	sheet := testsupport.NewLiveSheet(t, conformanceFixture)
+	live := conformanceScenario(t, sheet.Service, sheet.SpreadsheetID, sheet.Title)
+
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues(sheet.SpreadsheetID, sheet.Title, conformanceFixture)
+	fake := conformanceScenario(t, newService(t, server), sheet.SpreadsheetID, sheet.Title)
+
+	if len(live) != len(fake) {
+		t.Fatalf("the fake answered %v, the live API %v", fake, live)
+	}
+	for i := range live {
+		if !reflect.DeepEqual(live[i], fake[i]) {
+			t.Errorf("answer %d: the fake answered %v, the live API %v", i, fake[i], live[i])
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package sheetstest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake Sheets API v4 serving in-memory spreadsheets, see the
+// package documentation for its behavior.
+type Server struct {
+	*httptest.Server
+
+	// Fail, when set, is called with each request and the ranges it reads or
+	// writes (none for spreadsheets.get); a non-zero status is answered as an
+	// API error with the message instead of serving the request.
+	Fail func(r *http.Request, ranges []string) (status int, message string)
+	// Latency delays every response, e.g. to test timeouts.
+	Latency time.Duration
+	// ReadQuota/WriteQuota, when non-zero, are how many read (GET) and write
+	// requests are served before answering them with the API's quota error,
+	// until ResetQuota.
+	ReadQuota  int
+	WriteQuota int
+
+	mu           sync.Mutex
+	spreadsheets map[string][]*sheet
+	requests     []string
+	reads        int
+	writes       int
+}
+
+// sheet is a sheet of a fake spreadsheet, and its grid size.
+type sheet struct {
+	id      int
+	title   string
+	rows    int
+	columns int
+	values  [][]interface{}
+}
+
+// NewServer starts a Server without spreadsheets, the caller must Close it.
+func NewServer() *Server {
+	s := &Server{spreadsheets: map[string][]*sheet{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Endpoint is the base path of the API, for option.WithEndpoint.
+func (s *Server) Endpoint() string { return s.URL + "/" }
+
+// SetValues sets the values of the sheet `title` of the spreadsheet, adding
+// them if needed. The sheet's grid is 1000 rows by 26 columns, or as large as
+// the values.
+func (s *Server) SetValues(spreadsheetId, title string, values [][]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh := s.sheet(spreadsheetId, title)
+	if sh == nil {
+		sh = &sheet{id: len(s.spreadsheets[spreadsheetId]), title: title, rows: 1000, columns: 26}
+		s.spreadsheets[spreadsheetId] = append(s.spreadsheets[spreadsheetId], sh)
+	}
+	// copied, so writes don't change the caller's values
+	sh.values = make([][]interface{}, len(values))
+	for i, row := range values {
+		sh.values[i] = append([]interface{}{}, row...)
+		sh.fit(0, len(row))
+	}
+	sh.fit(len(values), 0)
+}
+
+// SetCSV sets the values of the sheet `title` of the spreadsheet from the CSV
+// read from `r`, like SetValues.
+func (s *Server) SetCSV(spreadsheetId, title string, r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	values := make([][]interface{}, len(records))
+	for i, record := range records {
+		values[i] = make([]interface{}, len(record))
+		for j, cell := range record {
+			values[i][j] = cell
+		}
+	}
+	s.SetValues(spreadsheetId, title, values)
+	return nil
+}
+
+// SetGrid sets the grid size of the sheet `title` of the spreadsheet, which
+// must have been added with SetValues.
+func (s *Server) SetGrid(spreadsheetId, title string, rows, columns int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh := s.sheet(spreadsheetId, title)
+	sh.rows, sh.columns = rows, columns
+}
+
+// Values returns the values of the sheet `title` of the spreadsheet, with the
+// writes applied, or nil when there's no such sheet.
+func (s *Server) Values(spreadsheetId, title string) [][]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh := s.sheet(spreadsheetId, title)
+	if sh == nil {
+		return nil
+	}
+	values := make([][]interface{}, len(sh.values))
+	for i, row := range sh.values {
+		values[i] = append([]interface{}{}, row...)
+	}
+	return values
+}
+
+// Requests returns the requests served so far, as "METHOD path?query".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// ResetQuota starts counting the requests against ReadQuota/WriteQuota over.
+func (s *Server) ResetQuota() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads, s.writes = 0, 0
+}
+
+// sheet returns the sheet `title` of the spreadsheet, nil when there's none.
+func (s *Server) sheet(spreadsheetId, title string) *sheet {
+	for _, sh := range s.spreadsheets[spreadsheetId] {
+		if sh.title == title {
+			return sh
+		}
+	}
+	return nil
+}
+
+// fit grows the sheet's grid to at least `rows` by `columns`.
+func (sh *sheet) fit(rows, columns int) {
+	if rows > sh.rows {
+		sh.rows = rows
+	}
+	if columns > sh.columns {
+		sh.columns = columns
+	}
+}
+
+// Methods of the API, by the suffix of their path after the spreadsheet ID.
+const (
+	methodGet      = "get"
+	methodBatchGet = "values.batchGet"
+	methodValues   = "values.get"
+	methodUpdate   = "values.update"
+	methodAppend   = "values.append"
+	methodClear    = "values.clear"
+)
+
+// route returns the method of the request for the `rest` of its path after the
+// spreadsheet ID, and the range in the path if any.
+func route(httpMethod, rest string) (method, readRange string) {
+	switch {
+	case rest == "" && httpMethod == http.MethodGet:
+		return methodGet, ""
+	case rest == "/values:batchGet" && httpMethod == http.MethodGet:
+		return methodBatchGet, ""
+	case !strings.HasPrefix(rest, "/values/"):
+		return "", ""
+	}
+	readRange = strings.TrimPrefix(rest, "/values/")
+	switch {
+	case httpMethod == http.MethodGet:
+		return methodValues, readRange
+	case httpMethod == http.MethodPut:
+		return methodUpdate, readRange
+	case httpMethod == http.MethodPost && strings.HasSuffix(readRange, ":append"):
+		return methodAppend, strings.TrimSuffix(readRange, ":append")
+	case httpMethod == http.MethodPost && strings.HasSuffix(readRange, ":clear"):
+		return methodClear, strings.TrimSuffix(readRange, ":clear")
+	}
+	return "", ""
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+	s.mu.Unlock()
+
+	if s.Latency > 0 {
+		select {
+		case <-time.After(s.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v4/spreadsheets/")
+	if path == r.URL.Path {
+		writeAPIError(w, http.StatusNotFound, "Requested entity was not found.")
+		return
+	}
+	spreadsheetId, rest := path, ""
+	if i := strings.IndexAny(path, "/:"); i != -1 {
+		spreadsheetId, rest = path[:i], path[i:]
+	}
+	method, readRange := route(r.Method, rest)
+	var ranges []string
+	switch method {
+	case "":
+		writeAPIError(w, http.StatusNotFound, "Requested entity was not found.")
+		return
+	case methodGet:
+	case methodBatchGet:
+		ranges = r.URL.Query()["ranges"]
+	default:
+		ranges = []string{readRange}
+	}
+	if s.Fail != nil {
+		if status, message := s.Fail(r, ranges); status != 0 {
+			writeAPIError(w, status, message)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status, message := s.countQuota(r.Method == http.MethodGet); status != 0 {
+		writeAPIError(w, status, message)
+		return
+	}
+	sheets, ok := s.spreadsheets[spreadsheetId]
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "Requested entity was not found.")
+		return
+	}
+	var resp interface{}
+	var status int
+	var message string
+	switch method {
+	case methodGet:
+		resp = s.spreadsheet(spreadsheetId, sheets)
+	case methodValues:
+		resp, status, message = s.get(spreadsheetId, readRange)
+	case methodBatchGet:
+		valueRanges := make([]interface{}, len(ranges))
+		for i, readRange := range ranges {
+			if valueRanges[i], status, message = s.get(spreadsheetId, readRange); status != 0 {
+				break
+			}
+		}
+		resp = map[string]interface{}{"spreadsheetId": spreadsheetId, "valueRanges": valueRanges}
+	case methodClear:
+		resp, status, message = s.clear(spreadsheetId, readRange)
+	case methodUpdate, methodAppend:
+		var values [][]interface{}
+		if values, status, message = readValues(r); status == 0 {
+			if method == methodUpdate {
+				resp, status, message = s.update(spreadsheetId, readRange, values)
+			} else {
+				resp, status, message = s.append(spreadsheetId, readRange, values)
+			}
+		}
+	}
+	if status != 0 {
+		writeAPIError(w, status, message)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// countQuota counts a read or write request against the quotas, returning the
+// status and message of the quota error once it's exceeded.
+func (s *Server) countQuota(read bool) (int, string) {
+	kind, count, quota := "Write", &s.writes, s.WriteQuota
+	if read {
+		kind, count, quota = "Read", &s.reads, s.ReadQuota
+	}
+	*count++
+	if quota == 0 || *count <= quota {
+		return 0, ""
+	}
+	return http.StatusTooManyRequests, fmt.Sprintf("Quota exceeded for quota metric '%s requests' and limit '%s requests per minute per user' of service 'sheets.googleapis.com' for consumer 'project_number:0'.", kind, kind)
+}
+
+// spreadsheet returns the Spreadsheet resource of spreadsheets.get, without
+// grid data.
+func (s *Server) spreadsheet(spreadsheetId string, sheets []*sheet) map[string]interface{} {
+	resources := make([]interface{}, len(sheets))
+	for i, sh := range sheets {
+		resources[i] = map[string]interface{}{
+			"properties": map[string]interface{}{
+				"sheetId":   sh.id,
+				"title":     sh.title,
+				"index":     i,
+				"sheetType": "GRID",
+				"gridProperties": map[string]interface{}{
+					"rowCount":    sh.rows,
+					"columnCount": sh.columns,
+				},
+			},
+		}
+	}
+	return map[string]interface{}{
+		"spreadsheetId": spreadsheetId,
+		"properties":    map[string]interface{}{"title": spreadsheetId, "locale": "en_US", "timeZone": "America/New_York"},
+		"sheets":        resources,
+	}
+}
+
+// readValues reads the ValueRange body of a write, returning its values as
+// rows, or the status and message of the error the API answers with.
+func readValues(r *http.Request) ([][]interface{}, int, string) {
+	switch option := r.URL.Query().Get("valueInputOption"); option {
+	case "RAW", "USER_ENTERED":
+	case "":
+		return nil, http.StatusBadRequest, "'valueInputOption' is required but not specified"
+	default:
+		return nil, http.StatusBadRequest, fmt.Sprintf("Invalid value at 'value_input_option' (%s)", option)
+	}
+	var body struct {
+		MajorDimension string          `json:"majorDimension"`
+		Values         [][]interface{} `json:"values"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, http.StatusBadRequest, fmt.Sprintf("Invalid JSON payload received. %v", err)
+	}
+	if body.MajorDimension != "COLUMNS" {
+		return body.Values, 0, ""
+	}
+	// the rows of the columns
+	rows := [][]interface{}{}
+	for column, cells := range body.Values {
+		for row, cell := range cells {
+			for len(rows) <= row {
+				rows = append(rows, []interface{}{})
+			}
+			for len(rows[row]) < column {
+				rows[row] = append(rows[row], nil)
+			}
+			rows[row] = append(rows[row], cell)
+		}
+	}
+	return rows, 0, ""
+}
+
+// writeJSON writes `v` as a JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// apiStatuses are the statuses of the API's errors, by HTTP status.
+var apiStatuses = map[int]string{
+	http.StatusBadRequest:          "INVALID_ARGUMENT",
+	http.StatusUnauthorized:        "UNAUTHENTICATED",
+	http.StatusForbidden:           "PERMISSION_DENIED",
+	http.StatusNotFound:            "NOT_FOUND",
+	http.StatusTooManyRequests:     "RESOURCE_EXHAUSTED",
+	http.StatusInternalServerError: "INTERNAL",
+	http.StatusServiceUnavailable:  "UNAVAILABLE",
+}
+
+// writeAPIError writes a Google API error response.
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	apiStatus, ok := apiStatuses[status]
+	if !ok {
+		apiStatus = "UNKNOWN"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{"code": status, "message": message, "status": apiStatus},
+	})
+}
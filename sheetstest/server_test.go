@@ -0,0 +1,251 @@
+package sheetstest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+)
+
+func newService(t *testing.T, server *sheetstest.Server) *sheets.Service {
+	t.Helper()
+	service, err := sheets.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.Endpoint()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+// apiCode returns the HTTP status of an API error, 0 for other errors.
+func apiCode(err error) int {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+func TestServerGet(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("id", "Sheet 1", [][]interface{}{
+		{"a1", "b1", "c1"},
+		{"a2", nil, "", ""},
+		{},
+		{"", ""},
+	})
+	service := newService(t, server)
+
+	tests := []struct {
+		readRange string
+		echoed    string
+		values    [][]interface{}
+	}{
+		{"'Sheet 1'", "'Sheet 1'!A1:Z1000", [][]interface{}{{"a1", "b1", "c1"}, {"a2"}}},
+		{"'Sheet 1'!B1:C2", "'Sheet 1'!B1:C2", [][]interface{}{{"b1", "c1"}}},
+		{"'Sheet 1'!A2:C", "'Sheet 1'!A2:C1000", [][]interface{}{{"a2"}}},
+		{"'Sheet 1'!A3:C4", "'Sheet 1'!A3:C4", nil},
+	}
+	for _, tt := range tests {
+		resp, err := service.Spreadsheets.Values.Get("id", tt.readRange).Do()
+		if err != nil {
+			t.Errorf("Get(%s): %v", tt.readRange, err)
+			continue
+		}
+		if resp.Range != tt.echoed || !reflect.DeepEqual(resp.Values, tt.values) {
+			t.Errorf("Get(%s) = %s %v, want %s %v", tt.readRange, resp.Range, resp.Values, tt.echoed, tt.values)
+		}
+	}
+
+	errorTests := []struct {
+		readRange string
+		code      int
+		message   string
+	}{
+		{"Missing!A1:B2", http.StatusBadRequest, "Unable to parse range: Missing!A1:B2"},
+		{"'Sheet 1'!A1:AA2", http.StatusBadRequest, "exceeds grid limits"},
+		{"'Sheet 1''!A1:B2", http.StatusBadRequest, "Unable to parse range"},
+	}
+	for _, tt := range errorTests {
+		_, err := service.Spreadsheets.Values.Get("id", tt.readRange).Do()
+		if apiCode(err) != tt.code || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("Get(%s) error = %v, want %d %q", tt.readRange, err, tt.code, tt.message)
+		}
+	}
+	if _, err := service.Spreadsheets.Get("missing").Do(); apiCode(err) != http.StatusNotFound {
+		t.Errorf("Get of a missing spreadsheet error = %v, want 404", err)
+	}
+}
+
+func TestServerWrites(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("id", "Sheet1", [][]interface{}{{"Name", "Score", "Passed"}})
+	service := newService(t, server)
+
+	// appended below the header, with numbers and booleans formatted
+	appended, err := service.Spreadsheets.Values.Append("id", "Sheet1!A:C", &sheets.ValueRange{Values: [][]interface{}{
+		{"Ann", 10, true},
+		{"Bob", 7.5, false},
+	}}).ValueInputOption("USER_ENTERED").Do()
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if appended.TableRange != "Sheet1!A1:C1" || appended.Updates.UpdatedRange != "Sheet1!A2:C3" || appended.Updates.UpdatedCells != 6 {
+		t.Errorf("Append = %s %s %d, want Sheet1!A1:C1 Sheet1!A2:C3 6", appended.TableRange, appended.Updates.UpdatedRange, appended.Updates.UpdatedCells)
+	}
+
+	// null cells are left as they are
+	updated, err := service.Spreadsheets.Values.Update("id", "Sheet1!B3:C3", &sheets.ValueRange{Values: [][]interface{}{{nil, "maybe"}}}).ValueInputOption("RAW").Do()
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.UpdatedRange != "Sheet1!B3:C3" || updated.UpdatedCells != 1 {
+		t.Errorf("Update = %s %d, want Sheet1!B3:C3 1", updated.UpdatedRange, updated.UpdatedCells)
+	}
+
+	cleared, err := service.Spreadsheets.Values.Clear("id", "Sheet1!A2:C2", &sheets.ClearValuesRequest{}).Do()
+	if err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if cleared.ClearedRange != "Sheet1!A2:C2" {
+		t.Errorf("Clear = %s, want Sheet1!A2:C2", cleared.ClearedRange)
+	}
+
+	want := [][]interface{}{{"Name", "Score", "Passed"}, {}, {"Bob", "7.5", "maybe"}}
+	if got := server.Values("id", "Sheet1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Values = %v, want %v", got, want)
+	}
+	resp, err := service.Spreadsheets.Values.Get("id", "Sheet1").Do()
+	if err != nil || !reflect.DeepEqual(resp.Values, want) {
+		t.Errorf("Get = %v, %v, want %v", resp.Values, err, want)
+	}
+}
+
+func TestServerWriteErrors(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("id", "Sheet1", [][]interface{}{{"a"}})
+	service := newService(t, server)
+	values := &sheets.ValueRange{Values: [][]interface{}{{1, 2}, {3, 4}}}
+
+	tests := []struct {
+		name    string
+		call    func() error
+		message string
+	}{
+		{"without valueInputOption", func() error {
+			_, err := service.Spreadsheets.Values.Update("id", "Sheet1!A1:B2", values).Do()
+			return err
+		}, "'valueInputOption' is required but not specified"},
+		{"past the range's rows", func() error {
+			_, err := service.Spreadsheets.Values.Update("id", "Sheet1!A1:B1", values).ValueInputOption("RAW").Do()
+			return err
+		}, "Requested writing within range [Sheet1!A1:B1], but tried writing to row [2]"},
+		{"past the range's columns", func() error {
+			_, err := service.Spreadsheets.Values.Update("id", "Sheet1!A1:A2", values).ValueInputOption("RAW").Do()
+			return err
+		}, "Requested writing within range [Sheet1!A1:A2], but tried writing to column [B]"},
+		{"past the grid", func() error {
+			_, err := service.Spreadsheets.Values.Update("id", "Sheet1!A1000:B1001", values).ValueInputOption("RAW").Do()
+			return err
+		}, "exceeds grid limits"},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); apiCode(err) != http.StatusBadRequest || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: error = %v, want 400 %q", tt.name, err, tt.message)
+		}
+	}
+	if got := server.Values("id", "Sheet1"); !reflect.DeepEqual(got, [][]interface{}{{"a"}}) {
+		t.Errorf("Values = %v, want them unchanged", got)
+	}
+
+	// a single cell range is where the values start
+	updated, err := service.Spreadsheets.Values.Update("id", "Sheet1!B2", values).ValueInputOption("RAW").Do()
+	if err != nil || updated.UpdatedRange != "Sheet1!B2:C3" {
+		t.Errorf("Update of a single cell range = %v, %v, want Sheet1!B2:C3", updated, err)
+	}
+	if _, err := service.Spreadsheets.Values.Update("id", "Sheet1!Z1", values).ValueInputOption("RAW").Do(); apiCode(err) != http.StatusBadRequest || !strings.Contains(err.Error(), "exceeds grid limits") {
+		t.Errorf("Update of a single cell range past the grid error = %v, want the grid limits error", err)
+	}
+
+	// the table of a single cell range spans the columns with values
+	server.SetValues("id", "Table", [][]interface{}{{"a", "b", "", "d"}, {"a"}})
+	if appended, err := service.Spreadsheets.Values.Append("id", "Table!A1", values).ValueInputOption("RAW").Do(); err != nil || appended.TableRange != "Table!A1:B2" || appended.Updates.UpdatedRange != "Table!A3:B4" {
+		t.Errorf("Append to a single cell range = %v, %v, want the table Table!A1:B2", appended, err)
+	}
+
+	// an append grows the grid
+	if _, err := service.Spreadsheets.Values.Append("id", "Sheet1!A999", values).ValueInputOption("RAW").Do(); err != nil {
+		t.Errorf("Append past the grid: %v", err)
+	}
+	if resp, err := service.Spreadsheets.Values.Get("id", "Sheet1!A1000:B1000").Do(); err != nil || !reflect.DeepEqual(resp.Values, [][]interface{}{{"3", "4"}}) {
+		t.Errorf("Get of the appended rows = %v, %v", resp, err)
+	}
+}
+
+func TestServerSetCSV(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	if err := server.SetCSV("id", "Sheet1", strings.NewReader("Name,Note\nAnn,\"first, and only\"\nBob\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{{"Name", "Note"}, {"Ann", "first, and only"}, {"Bob"}}
+	if got := server.Values("id", "Sheet1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Values = %v, want %v", got, want)
+	}
+	if err := server.SetCSV("id", "Sheet2", strings.NewReader("\"unterminated\n")); err == nil {
+		t.Errorf("SetCSV of an invalid CSV succeeded")
+	}
+}
+
+func TestServerHooks(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("id", "Sheet1", [][]interface{}{{"a"}})
+	service := newService(t, server)
+	get := func(ctx context.Context) error {
+		_, err := service.Spreadsheets.Values.Get("id", "Sheet1").Context(ctx).Do()
+		return err
+	}
+
+	server.ReadQuota = 1
+	if err := get(context.Background()); err != nil {
+		t.Fatalf("Get within the quota: %v", err)
+	}
+	if err := get(context.Background()); apiCode(err) != http.StatusTooManyRequests || !strings.Contains(err.Error(), "Quota exceeded for quota metric 'Read requests'") {
+		t.Errorf("Get past the quota error = %v, want the quota error", err)
+	}
+	server.ResetQuota()
+	if err := get(context.Background()); err != nil {
+		t.Errorf("Get after ResetQuota: %v", err)
+	}
+	server.ReadQuota = 0
+
+	server.Fail = func(r *http.Request, ranges []string) (int, string) {
+		if ranges[0] == "Sheet1" {
+			return http.StatusServiceUnavailable, "The service is currently unavailable."
+		}
+		return 0, ""
+	}
+	if err := get(context.Background()); apiCode(err) != http.StatusServiceUnavailable {
+		t.Errorf("Get error = %v, want the Fail status", err)
+	}
+	server.Fail = nil
+
+	server.Latency = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get with Latency error = %v, want the context's deadline exceeded", err)
+	}
+}
@@ -0,0 +1,363 @@
+package sheetstest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// cellRange is a range of a sheet, with 0-based columns and 1-based rows.
+type cellRange struct {
+	startColumn, endColumn int
+	startRow, endRow       int
+}
+
+// String returns the A1 notation of the range on the sheet `title`.
+func (r cellRange) String(title string) string {
+	return fmt.Sprintf("%s!%s%d:%s%d", quoteTitle(title), columnLetter(r.startColumn), r.startRow, columnLetter(r.endColumn), r.endRow)
+}
+
+// lookup returns the sheet and the range of `a1`, e.g. "'Sheet 1'!A1:C10", or
+// the status and message of the error the API answers with; the range isn't
+// checked against the sheet's grid.
+func (s *Server) lookup(spreadsheetId, a1 string) (*sheet, cellRange, int, string) {
+	title, bounds := a1, ""
+	if i := strings.LastIndex(a1, "!"); i != -1 {
+		title, bounds = a1[:i], a1[i+1:]
+	}
+	if strings.HasPrefix(title, "'") && strings.HasSuffix(title, "'") && len(title) > 1 {
+		quoted := title[1 : len(title)-1]
+		if strings.Contains(strings.ReplaceAll(quoted, "''", ""), "'") {
+			// the quotes of a quoted title have to be doubled
+			return nil, cellRange{}, http.StatusBadRequest, "Unable to parse range: " + a1
+		}
+		title = strings.ReplaceAll(quoted, "''", "'")
+	}
+	sh := s.sheet(spreadsheetId, title)
+	if sh == nil {
+		return nil, cellRange{}, http.StatusBadRequest, "Unable to parse range: " + a1
+	}
+	r, ok := parseRange(bounds, sh)
+	if !ok {
+		return nil, cellRange{}, http.StatusBadRequest, "Unable to parse range: " + a1
+	}
+	return sh, r, 0, ""
+}
+
+// checkGrid returns the status and message of the error the API answers with
+// for the range `r` of the sheet past its grid.
+func checkGrid(sh *sheet, r cellRange, bounds string) (int, string) {
+	if r.endRow > sh.rows || r.endColumn >= sh.columns {
+		return http.StatusBadRequest, fmt.Sprintf("Range (%s!%s) exceeds grid limits. Max rows: %d, max columns: %d", sh.title, bounds, sh.rows, sh.columns)
+	}
+	return 0, ""
+}
+
+// bounds returns the part of `a1` after the sheet title.
+func bounds(a1 string) string {
+	if i := strings.LastIndex(a1, "!"); i != -1 {
+		return a1[i+1:]
+	}
+	return ""
+}
+
+// get returns the ValueRange of values.get for `a1`, or the status and message
+// of the error the API answers with.
+func (s *Server) get(spreadsheetId, a1 string) (map[string]interface{}, int, string) {
+	sh, r, status, message := s.lookup(spreadsheetId, a1)
+	if status != 0 {
+		return nil, status, message
+	}
+	if status, message := checkGrid(sh, r, bounds(a1)); status != 0 {
+		return nil, status, message
+	}
+	values := [][]interface{}{}
+	for row := r.startRow; row <= r.endRow && row <= len(sh.values); row++ {
+		cells := []interface{}{}
+		source := sh.values[row-1]
+		for column := r.startColumn; column <= r.endColumn && column < len(source); column++ {
+			cell := source[column]
+			if cell == nil {
+				cell = ""
+			}
+			cells = append(cells, cell)
+		}
+		values = append(values, trimBlank(cells))
+	}
+	values = trimBlankRows(values)
+	resp := map[string]interface{}{"range": r.String(sh.title), "majorDimension": "ROWS"}
+	if len(values) > 0 {
+		resp["values"] = values
+	}
+	return resp, 0, ""
+}
+
+// update writes the `values` at the top-left of the range `a1`, returning the
+// UpdateValuesResponse, or the status and message of the error the API
+// answers with. A single cell range only sets where the values start.
+func (s *Server) update(spreadsheetId, a1 string, values [][]interface{}) (map[string]interface{}, int, string) {
+	sh, r, status, message := s.lookup(spreadsheetId, a1)
+	if status != 0 {
+		return nil, status, message
+	}
+	if cell := bounds(a1); cell != "" && !strings.Contains(cell, ":") {
+		r.endRow = r.startRow + len(values) - 1
+		for _, row := range values {
+			if r.startColumn+len(row)-1 > r.endColumn {
+				r.endColumn = r.startColumn + len(row) - 1
+			}
+		}
+	}
+	if status, message := checkGrid(sh, r, bounds(a1)); status != 0 {
+		return nil, status, message
+	}
+	for i, row := range values {
+		if r.startRow+i > r.endRow {
+			return nil, http.StatusBadRequest, fmt.Sprintf("Requested writing within range [%s], but tried writing to row [%d]", a1, r.startRow+i)
+		}
+		if r.startColumn+len(row)-1 > r.endColumn {
+			return nil, http.StatusBadRequest, fmt.Sprintf("Requested writing within range [%s], but tried writing to column [%s]", a1, columnLetter(r.startColumn+len(row)-1))
+		}
+	}
+	return sh.write(spreadsheetId, r.startRow, r.startColumn, values), 0, ""
+}
+
+// write writes the `values` with their top-left cell at the 1-based `row` and
+// 0-based `column` of the sheet, leaving the cells of null values as they are,
+// and returns the update counts of the response.
+func (sh *sheet) write(spreadsheetId string, row, column int, values [][]interface{}) map[string]interface{} {
+	columns, cells := 0, 0
+	for i, cellValues := range values {
+		for len(sh.values) < row+i {
+			sh.values = append(sh.values, []interface{}{})
+		}
+		target := sh.values[row+i-1]
+		for j, value := range cellValues {
+			if value == nil {
+				continue
+			}
+			for len(target) <= column+j {
+				target = append(target, "")
+			}
+			target[column+j] = formatValue(value)
+			cells++
+		}
+		sh.values[row+i-1] = target
+		if len(cellValues) > columns {
+			columns = len(cellValues)
+		}
+	}
+	written := cellRange{startColumn: column, endColumn: column + columns - 1, startRow: row, endRow: row + len(values) - 1}
+	if columns == 0 {
+		written.endColumn = column
+	}
+	return map[string]interface{}{
+		"spreadsheetId":  spreadsheetId,
+		"updatedRange":   written.String(sh.title),
+		"updatedRows":    len(values),
+		"updatedColumns": columns,
+		"updatedCells":   cells,
+	}
+}
+
+// append writes the `values` below the last row with values in the columns of
+// the range `a1`, returning the AppendValuesResponse, or the status and
+// message of the error the API answers with. The columns of a single cell
+// range are those of the table starting at the cell, i.e. up to the first
+// column without values.
+func (s *Server) append(spreadsheetId, a1 string, values [][]interface{}) (map[string]interface{}, int, string) {
+	sh, r, status, message := s.lookup(spreadsheetId, a1)
+	if status != 0 {
+		return nil, status, message
+	}
+	if cell := bounds(a1); cell != "" && !strings.Contains(cell, ":") {
+		r.endRow = sh.rows
+		for r.endColumn+1 < sh.columns && sh.hasValues(r.endColumn+1, r.startRow) {
+			r.endColumn++
+		}
+	}
+	last := 0
+	for i, row := range sh.values {
+		for column := r.startColumn; column <= r.endColumn && column < len(row); column++ {
+			if blankNil(row[column]) != nil {
+				last = i + 1
+				break
+			}
+		}
+	}
+	start := last + 1
+	if start < r.startRow {
+		start = r.startRow
+	}
+	resp := map[string]interface{}{"spreadsheetId": spreadsheetId}
+	if last >= r.startRow {
+		resp["tableRange"] = cellRange{startColumn: r.startColumn, endColumn: r.endColumn, startRow: r.startRow, endRow: last}.String(sh.title)
+	}
+	width := 0
+	for _, row := range values {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	sh.fit(start+len(values)-1, r.startColumn+width)
+	resp["updates"] = sh.write(spreadsheetId, start, r.startColumn, values)
+	return resp, 0, ""
+}
+
+// hasValues returns whether the `column` has values from the `row` down.
+func (sh *sheet) hasValues(column, row int) bool {
+	for i := row - 1; i < len(sh.values); i++ {
+		if column < len(sh.values[i]) && blankNil(sh.values[i][column]) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// clear blanks the cells of the range `a1`, returning the
+// ClearValuesResponse, or the status and message of the error the API answers
+// with.
+func (s *Server) clear(spreadsheetId, a1 string) (map[string]interface{}, int, string) {
+	sh, r, status, message := s.lookup(spreadsheetId, a1)
+	if status != 0 {
+		return nil, status, message
+	}
+	if status, message := checkGrid(sh, r, bounds(a1)); status != 0 {
+		return nil, status, message
+	}
+	for row := r.startRow; row <= r.endRow && row <= len(sh.values); row++ {
+		cells := sh.values[row-1]
+		for column := r.startColumn; column <= r.endColumn && column < len(cells); column++ {
+			cells[column] = ""
+		}
+		sh.values[row-1] = trimBlank(cells)
+	}
+	sh.values = trimBlankRows(sh.values)
+	return map[string]interface{}{"spreadsheetId": spreadsheetId, "clearedRange": r.String(sh.title)}, 0, ""
+}
+
+// formatValue returns a written value as the API formats it.
+func formatValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return value
+}
+
+// parseRange parses the A1 `bounds` of a range of the sheet, e.g. "A2:C10",
+// "A:C", "2:10", or "B3"; the whole sheet when empty.
+func parseRange(bounds string, sh *sheet) (cellRange, bool) {
+	r := cellRange{startColumn: 0, endColumn: sh.columns - 1, startRow: 1, endRow: sh.rows}
+	if bounds == "" {
+		return r, true
+	}
+	parts := strings.Split(bounds, ":")
+	if len(parts) > 2 {
+		return r, false
+	}
+	startColumn, startRow, ok := parseCell(parts[0])
+	if !ok {
+		return r, false
+	}
+	endColumn, endRow := startColumn, startRow
+	if len(parts) == 2 {
+		if endColumn, endRow, ok = parseCell(parts[1]); !ok {
+			return r, false
+		}
+	}
+	if startColumn != -1 {
+		r.startColumn = startColumn
+	}
+	if endColumn != -1 {
+		r.endColumn = endColumn
+	}
+	if startRow != 0 {
+		r.startRow = startRow
+	}
+	if endRow != 0 {
+		r.endRow = endRow
+	}
+	return r, r.startColumn <= r.endColumn && r.startRow <= r.endRow
+}
+
+// parseCell parses a cell reference, e.g. "C", "10", or "C10", returning its
+// 0-based column (-1 without one) and 1-based row (0 without one).
+func parseCell(s string) (column, row int, ok bool) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r >= '0' && r <= '9' })
+	if i == -1 {
+		i = len(s)
+	}
+	letters, digits := strings.ToUpper(s[:i]), s[i:]
+	if letters == "" && digits == "" {
+		return 0, 0, false
+	}
+	column = -1
+	if letters != "" {
+		column = 0
+		for _, r := range letters {
+			if r < 'A' || r > 'Z' {
+				return 0, 0, false
+			}
+			column = column*26 + int(r-'A'+1)
+		}
+		column--
+	}
+	if digits != "" {
+		n, err := strconv.Atoi(digits)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		row = n
+	}
+	return column, row, true
+}
+
+// columnLetter converts a 0-based column index to its A1 letters.
+func columnLetter(index int) string {
+	letters := ""
+	for n := index + 1; n > 0; n = (n - 1) / 26 {
+		letters = string(rune('A'+(n-1)%26)) + letters
+	}
+	return letters
+}
+
+// quoteTitle quotes a sheet title for A1 notation when it needs it.
+func quoteTitle(title string) string {
+	if strings.IndexFunc(title, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_')
+	}) == -1 {
+		return title
+	}
+	return "'" + strings.ReplaceAll(title, "'", "''") + "'"
+}
+
+// blankNil returns nil for a blank cell, and the cell otherwise.
+func blankNil(cell interface{}) interface{} {
+	if cell == "" {
+		return nil
+	}
+	return cell
+}
+
+// trimBlank trims the trailing blank cells of a row, as the API does.
+func trimBlank(cells []interface{}) []interface{} {
+	for len(cells) > 0 && blankNil(cells[len(cells)-1]) == nil {
+		cells = cells[:len(cells)-1]
+	}
+	return cells
+}
+
+// trimBlankRows trims the trailing blank rows, as the API does.
+func trimBlankRows(rows [][]interface{}) [][]interface{} {
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
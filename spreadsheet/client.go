@@ -0,0 +1,145 @@
+package spreadsheet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/a1"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/cells"
+)
+
+// ErrInvalidConfig is wrapped by the errors of an invalid `Config`.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Row is a sheet row along with its header, giving typed access to its cells
+// by header name, e.g.:
+//
+//	if age, ok := row.Int("Age"); ok {
+//		...
+//	}
+//
+// The accessors return false when the sheet has no such column, the cell is
+// blank, or the value can't be parsed as the requested type; numbers,
+// booleans, and dates are read in the spreadsheet's locale (see
+// `Config.Locale`).
+type Row = cells.Row
+
+// Config is the sheet to read, and how to read its values.
+type Config struct {
+	// SpreadsheetID is the ID of the spreadsheet, as in its URL
+	SpreadsheetID string
+	// SheetName is the sheet (tab) read, the first one when empty
+	SheetName string
+	// Range limits the columns (and rows) read, e.g. "A:F", "A2:F100", or
+	// "A2:F"; its first row is the header. The whole sheet is read when empty.
+	Range string
+	// Locale is the locale booleans and dates are read in, e.g. "es_ES";
+	// the spreadsheet's own locale when empty
+	Locale string
+	// DecimalComma reads numbers with swapped thousand/decimal separators,
+	// e.g. "1.234,56"
+	DecimalComma bool
+	// OnWarning, when set, is called at the end of a read with each of its
+	// warnings (see `Warning`), e.g. to log them
+	OnWarning func(Warning)
+}
+
+// Client reads sheets with the Sheets API.
+type Client struct {
+	service *sheets.Service
+}
+
+// NewClient returns a client authorized and configured by the `opts`, e.g.
+// option.WithCredentialsFile with a service account key, or
+// option.WithHTTPClient with an OAuth client.
+func NewClient(ctx context.Context, opts ...option.ClientOption) (*Client, error) {
+	service, err := sheets.NewService(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{service: service}, nil
+}
+
+// Rows returns the rows of the sheet below its header, leaving out the blank
+// ones; an empty sheet has no rows.
+func (c *Client) Rows(ctx context.Context, config Config) ([]Row, error) {
+	w := &warnings{}
+	defer w.report(config.OnWarning)
+	rows, _, err := c.rows(ctx, config, w)
+	return rows, err
+}
+
+// rows returns the rows of the sheet below its header, and their 1-based row
+// numbers in the sheet, recording the read's warnings in `w`.
+func (c *Client) rows(ctx context.Context, config Config, w *warnings) ([]Row, []int, error) {
+	if config.SpreadsheetID == "" {
+		return nil, nil, fmt.Errorf("%w: no SpreadsheetID", ErrInvalidConfig)
+	}
+	headerRow := 1
+	if config.Range != "" {
+		r, err := a1.ParseRange(config.Range)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: Range: %v", ErrInvalidConfig, err)
+		}
+		if r.StartRow != 0 {
+			headerRow = r.StartRow
+		}
+	}
+	sheetName, locale := config.SheetName, config.Locale
+	if sheetName == "" || locale == "" {
+		resp, err := c.service.Spreadsheets.Get(config.SpreadsheetID).Fields("properties.locale", "sheets.properties.title").Context(ctx).Do()
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to get spreadsheet %s: %w", config.SpreadsheetID, err)
+		}
+		if locale == "" && resp.Properties != nil {
+			locale = resp.Properties.Locale
+		}
+		if sheetName == "" {
+			if len(resp.Sheets) == 0 || resp.Sheets[0].Properties == nil {
+				return nil, nil, fmt.Errorf("spreadsheet %s has no sheets", config.SpreadsheetID)
+			}
+			sheetName = resp.Sheets[0].Properties.Title
+		}
+	}
+	format := cells.Format{DecimalComma: config.DecimalComma}
+	if locale != "" {
+		var known bool
+		if format, known = format.WithLocale(locale); !known {
+			w.add(WarnUnknownLocale, 0, "", "Unknown locale %q, reading booleans and dates as en_US", locale)
+		}
+	}
+
+	readRange := a1.QuoteSheetName(sheetName)
+	if config.Range != "" {
+		readRange += "!" + strings.TrimSpace(config.Range)
+	}
+	resp, err := c.service.Spreadsheets.Values.Get(config.SpreadsheetID, readRange).Context(ctx).Do()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read %s: %w", readRange, err)
+	}
+	if len(resp.Values) == 0 {
+		w.add(WarnEmptySheet, 0, "", "%s is empty, it has no header", readRange)
+		return nil, nil, nil
+	}
+	header := cells.NewHeader(resp.Values[0])
+	rows := make([]Row, 0, len(resp.Values)-1)
+	numbers := make([]int, 0, len(resp.Values)-1)
+	for i, values := range resp.Values[1:] {
+		number := headerRow + 1 + i
+		if len(values) == 0 {
+			w.add(WarnBlankRow, number, "", "Leaving out the blank rows")
+			continue
+		}
+		if len(values) > len(resp.Values[0]) {
+			w.add(WarnHeaderCount, number, "", "Rows have more cells than the %d header columns, the extra cells can't be read by name", len(resp.Values[0]))
+		}
+		rows = append(rows, header.Row(values, format))
+		numbers = append(numbers, number)
+	}
+	return rows, numbers, nil
+}
@@ -0,0 +1,171 @@
+package spreadsheet_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/spreadsheet"
+)
+
+// newFakeClient returns a client of a fake Sheets API serving the spreadsheet
+// "spreadsheet-id" with a "Scores" sheet (its first) and a "Notes" sheet.
+func newFakeClient(t *testing.T) *spreadsheet.Client {
+	t.Helper()
+	server := sheetstest.NewServer()
+	t.Cleanup(server.Close)
+	server.SetValues("spreadsheet-id", "Scores", [][]interface{}{
+		{"Name", "Score", "Passed", "Date", "Ratio"},
+		{"Ann", "10", "TRUE", "2024-03-01", "0.5"},
+		{},
+		{"Bob", "7", "FALSE", "", "1,5"},
+		{"Cy"},
+	})
+	server.SetValues("spreadsheet-id", "Notes", [][]interface{}{
+		{"Name", "Note"},
+		{"Ann", "first"},
+	})
+	client, err := spreadsheet.NewClient(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.Endpoint()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestClientRows(t *testing.T) {
+	client := newFakeClient(t)
+	tests := []struct {
+		config spreadsheet.Config
+		want   [][]interface{}
+	}{
+		// the first sheet, leaving out the blank row
+		{spreadsheet.Config{SpreadsheetID: "spreadsheet-id"}, [][]interface{}{
+			{"Ann", "10", "TRUE", "2024-03-01", "0.5"},
+			{"Bob", "7", "FALSE", "", "1,5"},
+			{"Cy", "", "", "", ""},
+		}},
+		{spreadsheet.Config{SpreadsheetID: "spreadsheet-id", SheetName: "Notes"}, [][]interface{}{{"Ann", "first"}}},
+		{spreadsheet.Config{SpreadsheetID: "spreadsheet-id", SheetName: "Scores", Range: "A1:B2"}, [][]interface{}{{"Ann", "10"}}},
+		// the header is the range's first row
+		{spreadsheet.Config{SpreadsheetID: "spreadsheet-id", Range: "A2:B4"}, [][]interface{}{{"Bob", "7"}}},
+		// to the last row
+		{spreadsheet.Config{SpreadsheetID: "spreadsheet-id", Range: "A2:B"}, [][]interface{}{{"Bob", "7"}, {"Cy", ""}}},
+	}
+	for _, tt := range tests {
+		rows, err := client.Rows(context.Background(), tt.config)
+		if err != nil {
+			t.Errorf("Rows(%+v): %v", tt.config, err)
+			continue
+		}
+		got := make([][]interface{}, len(rows))
+		for i, row := range rows {
+			got[i] = row.AsSlice()
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Rows(%+v) = %v, want %v", tt.config, got, tt.want)
+		}
+	}
+}
+
+func TestClientRowsInvalid(t *testing.T) {
+	client := newFakeClient(t)
+	for _, config := range []spreadsheet.Config{
+		{},
+		{SpreadsheetID: "spreadsheet-id", Range: "A1"},
+	} {
+		if _, err := client.Rows(context.Background(), config); !errors.Is(err, spreadsheet.ErrInvalidConfig) {
+			t.Errorf("Rows(%+v) error = %v, want ErrInvalidConfig", config, err)
+		}
+	}
+	if _, err := client.Rows(context.Background(), spreadsheet.Config{SpreadsheetID: "spreadsheet-id", SheetName: "Missing"}); err == nil {
+		t.Errorf("Rows of a missing sheet succeeded")
+	}
+}
+
+type score struct {
+	Name   string
+	Points int  `sheet:"Score"`
+	Passed bool `sheet:"Passed"`
+	Date   time.Time
+	Ratio  float64 `sheet:"-"`
+}
+
+func TestClientReadInto(t *testing.T) {
+	client := newFakeClient(t)
+	config := spreadsheet.Config{SpreadsheetID: "spreadsheet-id"}
+	var scores []score
+	if err := client.ReadInto(context.Background(), config, &scores); err != nil {
+		t.Fatalf("ReadInto: %v", err)
+	}
+	want := []score{
+		{Name: "Ann", Points: 10, Passed: true, Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "Bob", Points: 7},
+		{Name: "Cy"},
+	}
+	if !reflect.DeepEqual(scores, want) {
+		t.Errorf("ReadInto = %+v, want %+v", scores, want)
+	}
+
+	var pointers []*score
+	if err := client.ReadInto(context.Background(), config, &pointers); err != nil || len(pointers) != 3 || pointers[0].Name != "Ann" {
+		t.Errorf("ReadInto pointers = %v, %v, want the 3 rows", pointers, err)
+	}
+
+	// "1,5" isn't a number without DecimalComma
+	var ratios []struct{ Ratio float64 }
+	if err := client.ReadInto(context.Background(), config, &ratios); !errors.Is(err, spreadsheet.ErrCellType) {
+		t.Errorf("ReadInto error = %v, want ErrCellType", err)
+	} else if want := `row 4: cell doesn't parse as the field's type: column "Ratio": "1,5" isn't a float64`; err.Error() != want {
+		t.Errorf("ReadInto error = %q, want %q", err, want)
+	}
+	config.DecimalComma = true
+	if err := client.ReadInto(context.Background(), config, &ratios); err != nil || ratios[1].Ratio != 1.5 {
+		t.Errorf("ReadInto with DecimalComma = %v, %v, want 1.5 for Bob", ratios, err)
+	}
+}
+
+func TestClientReadIntoInvalid(t *testing.T) {
+	client := newFakeClient(t)
+	config := spreadsheet.Config{SpreadsheetID: "spreadsheet-id"}
+	for _, dst := range []interface{}{
+		[]score{},
+		&score{},
+		&[]string{},
+		&[]struct{ Name []byte }{},
+	} {
+		if err := client.ReadInto(context.Background(), config, dst); err == nil {
+			t.Errorf("ReadInto(%T) succeeded", dst)
+		}
+	}
+}
+
+func TestClientLocale(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Daten", [][]interface{}{
+		{"Datum", "Aktiv", "Betrag"},
+		{"01.02.2024", "WAHR", "1.234,5"},
+	})
+	client, err := spreadsheet.NewClient(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.Endpoint()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := client.Rows(context.Background(), spreadsheet.Config{SpreadsheetID: "spreadsheet-id", Locale: "de_DE", DecimalComma: true})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("Rows = %v, %v, want one row", rows, err)
+	}
+	if date, ok := rows[0].Time("Datum", ""); !ok || !date.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time(Datum) = %v, %v, want 2024-02-01", date, ok)
+	}
+	if active, ok := rows[0].Bool("Aktiv"); !active || !ok {
+		t.Errorf("Bool(Aktiv) = %v, %v, want true", active, ok)
+	}
+	if amount, ok := rows[0].Float("Betrag"); amount != 1234.5 || !ok {
+		t.Errorf("Float(Betrag) = %v, %v, want 1234.5", amount, ok)
+	}
+}
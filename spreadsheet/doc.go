@@ -0,0 +1,18 @@
+// Package spreadsheet reads the rows of Google Sheets, the way the sheetsctl
+// command does, for Go programs embedding it:
+//
+//	client, err := spreadsheet.NewClient(ctx, option.WithCredentialsFile("service-account.json"))
+//	...
+//	var students []Student
+//	err = client.ReadInto(ctx, spreadsheet.Config{SpreadsheetID: id, SheetName: "Class Data"}, &students)
+//
+// The first row of the sheet (or of `Config.Range`) is the header; rows are
+// read by header name, either as a `Row` or into structs. What's off about a
+// read without failing it (e.g. blank rows left out) is reported to
+// `Config.OnWarning` as a `Warning`.
+//
+// This package, with the sheetstest fake for its users' tests, is the module's
+// stable API: its exported identifiers follow the module's semantic versioning,
+// and only change in a backwards-compatible way within a major version. The
+// cmd/ and internal/ packages aren't covered.
+package spreadsheet
@@ -0,0 +1,70 @@
+package spreadsheet_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/api/option"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/spreadsheet"
+)
+
+// newExampleClient returns a client of a fake Sheets API serving a few rows of
+// the Google sample spreadsheet; use e.g. option.WithCredentialsFile instead to
+// read a real one.
+func newExampleClient() (*spreadsheet.Client, func()) {
+	server := sheetstest.NewServer()
+	server.SetValues("1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms", "Class Data", [][]interface{}{
+		{"Student Name", "Gender", "Class Level", "Home State", "Major", "Extracurricular Activity"},
+		{"Alexandra", "Female", "4. Senior", "CA", "English", "Drama Club"},
+		{"Andrew", "Male", "1. Freshman", "SD", "Math", "Lacrosse"},
+	})
+	client, err := spreadsheet.NewClient(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.Endpoint()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client, server.Close
+}
+
+func ExampleClient_Rows() {
+	client, done := newExampleClient()
+	defer done()
+
+	rows, err := client.Rows(context.Background(), spreadsheet.Config{
+		SpreadsheetID: "1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms",
+		SheetName:     "Class Data",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, row := range rows {
+		fmt.Printf("%s, %s\n", row.MustString("Student Name"), row.MustString("Major"))
+	}
+	// Output:
+	// Alexandra, English
+	// Andrew, Math
+}
+
+func ExampleClient_ReadInto() {
+	client, done := newExampleClient()
+	defer done()
+
+	type student struct {
+		Name  string `sheet:"Student Name"`
+		State string `sheet:"Home State"`
+		Major string
+	}
+	var students []student
+	err := client.ReadInto(context.Background(), spreadsheet.Config{
+		SpreadsheetID: "1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms",
+		Range:         "A:E",
+	}, &students)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", students)
+	// Output:
+	// [{Name:Alexandra State:CA Major:English} {Name:Andrew State:SD Major:Math}]
+}
@@ -0,0 +1,76 @@
+//go:build integration
+// +build integration
+
+package spreadsheet_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/internal/testsupport"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/spreadsheet"
+)
+
+func TestLiveClient(t *testing.T) {
+	sheet := testsupport.NewLiveSheet(t, [][]interface{}{
+		{"Name", "Score", "Passed", "Date"},
+		{"Ann", 10, true, "2024-03-01"},
+		{},
+		{"Bob", 7.5, false},
+	})
+	client, err := spreadsheet.NewClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Rows", func(t *testing.T) {
+		tests := []struct {
+			config spreadsheet.Config
+			want   [][]interface{}
+		}{
+			{spreadsheet.Config{SpreadsheetID: sheet.SpreadsheetID, SheetName: sheet.Title}, [][]interface{}{
+				{"Ann", "10", "TRUE", "2024-03-01"},
+				{"Bob", "7.5", "FALSE", ""},
+			}},
+			{spreadsheet.Config{SpreadsheetID: sheet.SpreadsheetID, SheetName: sheet.Title, Range: "A1:B2"}, [][]interface{}{{"Ann", "10"}}},
+			{spreadsheet.Config{SpreadsheetID: sheet.SpreadsheetID, SheetName: sheet.Title, Range: "A:B"}, [][]interface{}{{"Ann", "10"}, {"Bob", "7.5"}}},
+		}
+		for _, tt := range tests {
+			rows, err := client.Rows(context.Background(), tt.config)
+			if err != nil {
+				t.Errorf("Rows(%+v): %v", tt.config, err)
+				continue
+			}
+			got := make([][]interface{}, len(rows))
+			for i, row := range rows {
+				got[i] = row.AsSlice()
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rows(%+v) = %v, want %v", tt.config, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("ReadInto", func(t *testing.T) {
+		type score struct {
+			Name   string
+			Score  float64
+			Passed bool
+			Date   time.Time
+		}
+		var scores []score
+		err := client.ReadInto(context.Background(), spreadsheet.Config{SpreadsheetID: sheet.SpreadsheetID, SheetName: sheet.Title}, &scores)
+		if err != nil {
+			t.Fatalf("ReadInto: %v", err)
+		}
+		want := []score{
+			{Name: "Ann", Score: 10, Passed: true, Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+			{Name: "Bob", Score: 7.5},
+		}
+		if !reflect.DeepEqual(scores, want) {
+			t.Errorf("ReadInto = %+v, want %+v", scores, want)
+		}
+	})
+}
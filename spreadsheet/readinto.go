@@ -0,0 +1,169 @@
+package spreadsheet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrCellType is wrapped by the errors of cells that can't be read as their
+// struct field's type.
+var ErrCellType = errors.New("cell doesn't parse as the field's type")
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// ReadInto reads the rows of the sheet into `dst`, a pointer to a slice of
+// structs (or of pointers to structs), e.g. as generated by sheetsctl with
+// `MODE=gen-struct`. Each exported field is set from the column named by its
+// `sheet` tag, or by the field's name without one; a `sheet:"-"` tag skips the
+// field, as do columns missing from the sheet and blank cells.
+//
+// Fields can be strings, booleans, integers, floats, and `time.Time` (read in
+// the date formats of the locale); a cell that doesn't parse as its field's
+// type fails the read with an error wrapping `ErrCellType`.
+func (c *Client) ReadInto(ctx context.Context, config Config, dst interface{}) error {
+	slice := reflect.ValueOf(dst)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("ReadInto: dst must be a pointer to a slice, not %T", dst)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("ReadInto: dst must be a pointer to a slice of structs, not %T", dst)
+	}
+	fields, err := recordFields(structType)
+	if err != nil {
+		return err
+	}
+
+	w := &warnings{}
+	defer w.report(config.OnWarning)
+	rows, numbers, err := c.rows(ctx, config, w)
+	if err != nil {
+		return err
+	}
+	if len(rows) > 0 {
+		columns := map[string]bool{}
+		for _, name := range rows[0].Names() {
+			columns[name] = true
+		}
+		for _, field := range fields {
+			if !columns[field.column] {
+				w.add(WarnMissingColumn, 0, field.column, "Column %q of field %s isn't in the sheet, leaving it unset", field.column, structType.Field(field.index).Name)
+			}
+		}
+	}
+	records := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for i, row := range rows {
+		record := reflect.New(structType)
+		if err := setFields(record.Elem(), fields, row); err != nil {
+			return fmt.Errorf("row %d: %w", numbers[i], err)
+		}
+		if elemType.Kind() == reflect.Ptr {
+			records = reflect.Append(records, record)
+		} else {
+			records = reflect.Append(records, record.Elem())
+		}
+	}
+	slice.Set(records)
+	return nil
+}
+
+// recordField is a struct field read from the `column`.
+type recordField struct {
+	index  int
+	column string
+}
+
+// recordFields returns the fields of the struct `t` read from a column, or an
+// error for a field of an unsupported type.
+func recordFields(t reflect.Type) ([]recordField, error) {
+	fields := []recordField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		column := field.Name
+		if tag, ok := field.Tag.Lookup("sheet"); ok {
+			if tag == "-" {
+				continue
+			}
+			column = tag
+		}
+		if !supportedField(field.Type) {
+			return nil, fmt.Errorf("ReadInto: field %s has unsupported type %s", field.Name, field.Type)
+		}
+		fields = append(fields, recordField{index: i, column: column})
+	}
+	return fields, nil
+}
+
+// supportedField reports whether a field of type `t` can be read from a cell.
+func supportedField(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// setFields sets the `fields` of the struct `v` from the `row` cells.
+func setFields(v reflect.Value, fields []recordField, row Row) error {
+	for _, field := range fields {
+		s, ok := row.String(field.column)
+		if !ok {
+			continue
+		}
+		value := v.Field(field.index)
+		if value.Type() == timeType {
+			t, ok := row.Time(field.column, "")
+			if !ok {
+				return fmt.Errorf("%w: column %q: %q isn't a date", ErrCellType, field.column, s)
+			}
+			value.Set(reflect.ValueOf(t))
+			continue
+		}
+		switch value.Kind() {
+		case reflect.String:
+			value.SetString(s)
+		case reflect.Bool:
+			b, ok := row.Bool(field.column)
+			if !ok {
+				return fmt.Errorf("%w: column %q: %q isn't a boolean", ErrCellType, field.column, s)
+			}
+			value.SetBool(b)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, ok := row.Int(field.column)
+			if !ok || value.OverflowInt(n) {
+				return fmt.Errorf("%w: column %q: %q isn't a %s", ErrCellType, field.column, s, value.Type())
+			}
+			value.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, ok := row.Int(field.column)
+			if !ok || n < 0 || value.OverflowUint(uint64(n)) {
+				return fmt.Errorf("%w: column %q: %q isn't a %s", ErrCellType, field.column, s, value.Type())
+			}
+			value.SetUint(uint64(n))
+		case reflect.Float32, reflect.Float64:
+			f, ok := row.Float(field.column)
+			if !ok || value.OverflowFloat(f) {
+				return fmt.Errorf("%w: column %q: %q isn't a %s", ErrCellType, field.column, s, value.Type())
+			}
+			value.SetFloat(f)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package spreadsheet
+
+import "sync"
+
+// SchemaMatcher is a known sheet structure whose rows are mapped to a Go
+// struct, e.g. by sheetsctl to print them as the struct rather than as JSON
+// objects. Schemas are registered with `RegisterSchema`, and the first one
+// matching the header is used.
+type SchemaMatcher interface {
+	// Name names the schema's struct, e.g. "ExampleStudent".
+	Name() string
+	// Match reports whether rows with the (output) `columns`, in order, have
+	// the schema's structure; a schema usually matches its full header only,
+	// so a sheet sharing a column with it isn't taken for it.
+	Match(columns []string) bool
+	// Record maps the `fields` of a row to the schema's struct, or returns nil
+	// if the row has none of its values.
+	Record(fields map[string]interface{}) interface{}
+}
+
+var (
+	schemasMu sync.Mutex
+	// schemas are the registered schemas, in matching order
+	schemas []SchemaMatcher
+)
+
+// RegisterSchema registers the `schema`, ahead of the schemas already
+// registered; it's meant to be called from an init function.
+func RegisterSchema(schema SchemaMatcher) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	schemas = append([]SchemaMatcher{schema}, schemas...)
+}
+
+// MatchSchema returns the first registered schema matching the `columns`, or
+// nil if there's none.
+func MatchSchema(columns []string) SchemaMatcher {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	for _, schema := range schemas {
+		if schema.Match(columns) {
+			return schema
+		}
+	}
+	return nil
+}
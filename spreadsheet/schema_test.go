@@ -0,0 +1,49 @@
+package spreadsheet_test
+
+import (
+	"testing"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/spreadsheet"
+)
+
+// headerSchema is a schema matching a full header, in order.
+type headerSchema struct {
+	name   string
+	header [2]string
+}
+
+func (s headerSchema) Name() string { return s.name }
+
+func (s headerSchema) Match(columns []string) bool {
+	return len(columns) == 2 && columns[0] == s.header[0] && columns[1] == s.header[1]
+}
+
+func (s headerSchema) Record(fields map[string]interface{}) interface{} {
+	return fields
+}
+
+func TestRegisterSchema(t *testing.T) {
+	first := headerSchema{"first", [2]string{"Item", "Price"}}
+	second := headerSchema{"second", [2]string{"Item", "Price"}}
+	other := headerSchema{"other", [2]string{"Item", "Stock"}}
+	spreadsheet.RegisterSchema(first)
+	spreadsheet.RegisterSchema(other)
+	spreadsheet.RegisterSchema(second)
+
+	tests := []struct {
+		columns []string
+		want    spreadsheet.SchemaMatcher
+	}{
+		// the last registered schema is matched first
+		{[]string{"Item", "Price"}, second},
+		{[]string{"Item", "Stock"}, other},
+		{[]string{"Price", "Item"}, nil},
+		{[]string{"Item"}, nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := spreadsheet.MatchSchema(tt.columns); got != tt.want {
+			t.Errorf("MatchSchema(%q) = %v, want %v", tt.columns, got, tt.want)
+		}
+	}
+}
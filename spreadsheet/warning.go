@@ -0,0 +1,59 @@
+package spreadsheet
+
+import "fmt"
+
+// Warning codes, stable for programs telling warnings apart.
+const (
+	// WarnEmptySheet is a sheet (or `Config.Range`) without even a header
+	WarnEmptySheet = "empty_sheet"
+	// WarnBlankRow is a blank row, left out of the rows
+	WarnBlankRow = "blank_row"
+	// WarnHeaderCount is a row with more cells than the header has columns,
+	// the extra cells being unreadable by name
+	WarnHeaderCount = "header_count"
+	// WarnUnknownLocale is a locale without built-in formats, read as en_US
+	WarnUnknownLocale = "unknown_locale"
+	// WarnMissingColumn is a `ReadInto` field whose column isn't in the sheet,
+	// left unset
+	WarnMissingColumn = "missing_column"
+)
+
+// Warning is something off about a read that didn't fail it. Identical
+// warnings (same code, column, and message) are reported once, with their
+// `Count`; `Row` is the first one's 1-based row number in the sheet, when the
+// warning is about a row.
+type Warning struct {
+	Code    string
+	Message string
+	Row     int
+	Column  string
+	Count   int
+}
+
+// warnings collects the warnings of a read, for `Config.OnWarning`.
+type warnings struct {
+	list []Warning
+}
+
+// add records a warning, counting the identical ones.
+func (w *warnings) add(code string, row int, column string, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	for i, recorded := range w.list {
+		if recorded.Code == code && recorded.Column == column && recorded.Message == message {
+			w.list[i].Count++
+			return
+		}
+	}
+	w.list = append(w.list, Warning{Code: code, Message: message, Row: row, Column: column, Count: 1})
+}
+
+// report calls the `handler`, if any, with each warning in the order they
+// first occurred.
+func (w *warnings) report(handler func(Warning)) {
+	if handler == nil {
+		return
+	}
+	for _, warning := range w.list {
+		handler(warning)
+	}
+}
@@ -0,0 +1,85 @@
+package spreadsheet_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/option"
+
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/sheetstest"
+	"github.com/skplunkerin/google_oauth_spreadsheet_example--golang/spreadsheet"
+)
+
+func TestClientWarnings(t *testing.T) {
+	server := sheetstest.NewServer()
+	defer server.Close()
+	server.SetValues("spreadsheet-id", "Scores", [][]interface{}{
+		{"Name", "Score"},
+		{"Ann", "10"},
+		{},
+		{"Bob", "7", "extra"},
+		{},
+		{"Cy", "4", "more", "cells"},
+	})
+	server.SetValues("spreadsheet-id", "Empty", nil)
+	client, err := spreadsheet.NewClient(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.Endpoint()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type warning struct {
+		code   string
+		row    int
+		column string
+		count  int
+	}
+	var got []warning
+	handler := func(w spreadsheet.Warning) {
+		if w.Message == "" {
+			t.Errorf("warning %s has no message", w.Code)
+		}
+		got = append(got, warning{w.Code, w.Row, w.Column, w.Count})
+	}
+
+	config := spreadsheet.Config{SpreadsheetID: "spreadsheet-id", SheetName: "Scores", Locale: "xx_XX", OnWarning: handler}
+	if _, err := client.Rows(context.Background(), config); err != nil {
+		t.Fatalf("Rows: %v", err)
+	}
+	want := []warning{
+		{spreadsheet.WarnUnknownLocale, 0, "", 1},
+		// identical warnings are counted, with the first one's row
+		{spreadsheet.WarnBlankRow, 3, "", 2},
+		{spreadsheet.WarnHeaderCount, 4, "", 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Rows warnings = %+v, want %+v", got, want)
+	}
+
+	got = nil
+	var scores []struct {
+		Name  string
+		Score int
+		Grade string
+	}
+	config.Locale = "en_US"
+	if err := client.ReadInto(context.Background(), config, &scores); err != nil {
+		t.Fatalf("ReadInto: %v", err)
+	}
+	want = []warning{
+		{spreadsheet.WarnBlankRow, 3, "", 2},
+		{spreadsheet.WarnHeaderCount, 4, "", 2},
+		{spreadsheet.WarnMissingColumn, 0, "Grade", 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadInto warnings = %+v, want %+v", got, want)
+	}
+
+	got = nil
+	config.SheetName = "Empty"
+	if _, err := client.Rows(context.Background(), config); err != nil {
+		t.Fatalf("Rows of the empty sheet: %v", err)
+	}
+	if want := []warning{{spreadsheet.WarnEmptySheet, 0, "", 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("empty sheet warnings = %+v, want %+v", got, want)
+	}
+}